@@ -0,0 +1,144 @@
+package bridge
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// logFormat selects how the access log is written.
+type logFormat string
+
+const (
+	// logText is chi's colored, human-readable request log.
+	logText logFormat = "text"
+	// logCLF is the Common Log Format used by Apache and NGINX.
+	logCLF logFormat = "clf"
+	// logCombined is the Combined Log Format: CLF plus referer and user
+	// agent.
+	logCombined logFormat = "combined"
+)
+
+func parseLogFormat(s string) (logFormat, error) {
+	switch f := logFormat(s); f {
+	case "":
+		return logText, nil
+	case logText, logCLF, logCombined:
+		return f, nil
+	default:
+		return "", fmt.Errorf("invalid log format %q (want text, clf or combined)", s)
+	}
+}
+
+// accessLog returns the access log middleware for format, writing to out.
+// With sizes set lines also carry the request and response body sizes
+// that measurePayloadSizes records, for the requests it samples.
+func accessLog(out io.Writer, format logFormat, sizes bool) func(http.Handler) http.Handler {
+	var logger func(http.Handler) http.Handler
+	if format == logText {
+		f, ok := out.(*os.File)
+		logger = textLog(out, !ok || !isTerminal(f))
+	} else {
+		logger = commonLog(out, format == logCombined)
+	}
+	if !sizes {
+		return logger
+	}
+	return func(next http.Handler) http.Handler {
+		next = logger(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(withPayloadSizes(r.Context())))
+		})
+	}
+}
+
+// textLog writes chi's request log to out, followed by the payload sizes
+// of requests that have them, in color unless noColor is set.
+func textLog(out io.Writer, noColor bool) func(http.Handler) http.Handler {
+	return middleware.RequestLogger(sizesFormatter{logger: log.New(out, "", log.LstdFlags), noColor: noColor})
+}
+
+// sizesFormatter is chi's DefaultLogFormatter with each line's payload
+// sizes appended.
+type sizesFormatter struct {
+	logger  *log.Logger
+	noColor bool
+}
+
+func (f sizesFormatter) NewLogEntry(r *http.Request) middleware.LogEntry {
+	sizes, _ := r.Context().Value(payloadSizesKey{}).(*payloadSizes)
+	entry := &middleware.DefaultLogFormatter{Logger: sizesLogger{f.logger, sizes}, NoColor: f.noColor}
+	return entry.NewLogEntry(r)
+}
+
+// sizesLogger prints a request's log line with its payload sizes, read
+// once the request has been served.
+type sizesLogger struct {
+	*log.Logger
+	sizes *payloadSizes
+}
+
+func (l sizesLogger) Print(v ...interface{}) {
+	l.Logger.Print(strings.TrimSuffix(fmt.Sprint(v...), "\n") + l.sizes.field())
+}
+
+// isTerminal reports whether f is a terminal, for colored output, as chi
+// decides for its own logger.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// commonLog writes one Common Log Format line per request to out, or a
+// Combined Log Format line if combined is set.
+func commonLog(out io.Writer, combined bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r)
+
+			host, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				host = r.RemoteAddr
+			}
+			user, _, _ := r.BasicAuth()
+			code := ww.Status()
+			if code == 0 {
+				code = http.StatusOK
+			}
+			size := "-"
+			if n := ww.BytesWritten(); n > 0 {
+				size = strconv.Itoa(n)
+			}
+
+			line := fmt.Sprintf("%s - %s [%s] %q %d %s",
+				host, clfField(user), start.Format("02/Jan/2006:15:04:05 -0700"),
+				r.Method+" "+r.URL.RequestURI()+" "+r.Proto, code, size)
+			if combined {
+				line += fmt.Sprintf(" %q %q", clfField(r.Referer()), clfField(r.UserAgent()))
+			}
+			if sizes, ok := r.Context().Value(payloadSizesKey{}).(*payloadSizes); ok {
+				line += sizes.field()
+			}
+			io.WriteString(out, line+"\n")
+		})
+	}
+}
+
+// clfField returns s, or "-" for an absent value.
+func clfField(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
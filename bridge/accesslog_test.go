@@ -0,0 +1,36 @@
+package bridge
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestCommonLogLine(t *testing.T) {
+	for _, tt := range []struct {
+		combined bool
+		suffix   string
+	}{
+		{false, ``},
+		{true, ` "https://app.example/" "curl/8.0"`},
+	} {
+		var out bytes.Buffer
+		h := commonLog(&out, tt.combined)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("hello"))
+		}))
+		req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo?x=1", nil)
+		req.RemoteAddr = "203.0.113.7:51234"
+		req.SetBasicAuth("alice", "secret")
+		req.Header.Set("Referer", "https://app.example/")
+		req.Header.Set("User-Agent", "curl/8.0")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+
+		re := `^203\.0\.113\.7 - alice \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "POST /test\.v1\.Echo/Echo\?x=1 HTTP/1\.1" 201 5` + regexp.QuoteMeta(tt.suffix) + "\n$"
+		if !regexp.MustCompile(re).MatchString(out.String()) {
+			t.Errorf("combined %v: logged %q, want a line matching %s", tt.combined, out.String(), re)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// AuditConfig records every call to a method, typically one that mutates
+// state, in the audit log.
+type AuditConfig struct {
+	// Payload includes the request body in each entry.
+	Payload bool `json:"payload"`
+	// Redact lists request fields, as dotted JSON paths like
+	// "card.number", whose values are replaced with "[REDACTED]" in the
+	// logged payload. Paths pass through repeated fields.
+	Redact []string `json:"redact"`
+}
+
+// auditEntry is one line of the audit log.
+type auditEntry struct {
+	Time       time.Time       `json:"time"`
+	Method     string          `json:"method"`
+	Principal  string          `json:"principal,omitempty"`
+	RequestID  string          `json:"request_id,omitempty"`
+	Status     int             `json:"status"`
+	Code       string          `json:"code,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	DurationMs float64         `json:"duration_ms"`
+	Request    json.RawMessage `json:"request,omitempty"`
+}
+
+// auditPayloadLimit bounds the request bytes kept for an entry; larger
+// payloads are left out.
+const auditPayloadLimit = 64 << 10
+
+// auditLog writes audit entries as JSON lines, apart from the access and
+// bridge logs. Without a file it writes them to the bridge's log.
+type auditLog struct {
+	mu   sync.Mutex
+	out  io.Writer
+	file *os.File
+}
+
+// newAuditLog opens the audit log at path, with "-" meaning stderr.
+func newAuditLog(path string) (*auditLog, error) {
+	switch path {
+	case "":
+		return &auditLog{}, nil
+	case "-":
+		return &auditLog{out: os.Stderr}, nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &auditLog{out: f, file: f}, nil
+}
+
+func (a *auditLog) write(e auditEntry) {
+	line, err := json.Marshal(e)
+	if err != nil {
+		log.Printf("✗ Failed to encode audit entry: %v", err)
+		return
+	}
+	if a.out == nil {
+		log.Printf("✎ Audit: %s", line)
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.out.Write(append(line, '\n')); err != nil {
+		log.Printf("✗ Failed to write audit entry: %v", err)
+	}
+}
+
+func (a *auditLog) Close() error {
+	if a.file == nil {
+		return nil
+	}
+	return a.file.Close()
+}
+
+// audit wraps w and r to observe a call to an audited method, returning
+// a function that writes its entry once the call has been answered. For
+// methods without an audit policy it returns them unchanged.
+func (b *Bridge) audit(w http.ResponseWriter, r *http.Request, fullMethod string) (http.ResponseWriter, *http.Request, func()) {
+	policy, ok := b.audits[fullMethod[1:]]
+	if !ok {
+		return w, r, func() {}
+	}
+	start := time.Now()
+	var reqBody *cappedBuffer
+	if policy.Payload {
+		reqBody = &cappedBuffer{limit: auditPayloadLimit}
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.TeeReader(r.Body, reqBody), r.Body}
+	}
+	// Error bodies are small; only their code and message are kept
+	respBody := &cappedBuffer{limit: 4 << 10}
+	ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+	ww.Tee(respBody)
+
+	return ww, r, func() {
+		e := auditEntry{
+			Time:       start.UTC(),
+			Method:     fullMethod,
+			RequestID:  middleware.GetReqID(r.Context()),
+			Status:     ww.Status(),
+			DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+		}
+		e.Principal, _ = PrincipalFromContext(r.Context())
+		if e.Status == 0 {
+			e.Status = http.StatusOK
+		}
+		if e.Status < http.StatusBadRequest {
+			e.Code = "OK"
+		} else {
+			var body struct {
+				Code  string `json:"code"`
+				Error string `json:"error"`
+			}
+			if json.Unmarshal(respBody.Bytes(), &body) == nil {
+				e.Code, e.Error = body.Code, body.Error
+			} else {
+				e.Error = strings.TrimSpace(respBody.String())
+			}
+		}
+		if reqBody != nil && !reqBody.truncated && json.Valid(reqBody.Bytes()) {
+			e.Request = redactFields(reqBody.Bytes(), policy.Redact)
+		}
+		b.auditor.write(e)
+	}
+}
+
+// cappedBuffer keeps the first limit bytes written to it and drops the
+// rest, noting that it did.
+type cappedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (c *cappedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if room := c.limit - c.Len(); len(p) > room {
+		c.truncated = true
+		p = p[:max(room, 0)]
+	}
+	c.Buffer.Write(p)
+	return n, nil
+}
+
+// redactFields replaces the values at paths in the JSON document data
+// with "[REDACTED]".
+func redactFields(data []byte, paths []string) json.RawMessage {
+	if len(paths) == 0 {
+		return data
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil
+	}
+	for _, p := range paths {
+		redactPath(v, strings.Split(p, "."))
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+func redactPath(v interface{}, path []string) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		child, ok := v[path[0]]
+		if !ok {
+			return
+		}
+		if len(path) == 1 {
+			v[path[0]] = "[REDACTED]"
+			return
+		}
+		redactPath(child, path[1:])
+	case []interface{}:
+		for _, elem := range v {
+			redactPath(elem, path)
+		}
+	}
+}
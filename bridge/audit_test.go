@@ -0,0 +1,62 @@
+package bridge
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestAuditedMethodRecordsPrincipalAndOutcome(t *testing.T) {
+	be := startBackend(t)
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, AuditLog: path, Methods: map[string]MethodConfig{
+		"test.v1.Echo/Echo": {Audit: &AuditConfig{Payload: true, Redact: []string{"message"}}},
+	}})
+	h := b.Handler()
+
+	call := func(body string) {
+		req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		serve(h, withClientCert(req, "alice"))
+	}
+	call(`{"message": "secret", "count": 1}`)
+	be.onUnary(func(ctx context.Context, req *dynamicpb.Message) (proto.Message, error) {
+		return nil, status.Error(codes.PermissionDenied, "not yours")
+	})
+	call(`{"count": 2}`)
+	// Methods without an audit policy aren't recorded
+	post(h, "/test.v1.Echo/OldEcho", `{}`)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := ndjsonLines(t, string(data))
+	if len(entries) != 2 {
+		t.Fatalf("got %d audit entries, want 2:\n%s", len(entries), data)
+	}
+	for i, want := range []struct {
+		status float64
+		code   string
+	}{{200, "OK"}, {403, "PermissionDenied"}} {
+		e := entries[i]
+		if e["method"] != "/test.v1.Echo/Echo" || e["principal"] != "alice" || e["request_id"] == nil {
+			t.Errorf("entry %d: %v, want method, principal alice and request ID", i, e)
+		}
+		if e["status"] != want.status || e["code"] != want.code {
+			t.Errorf("entry %d: outcome %v %v, want %v %v", i, e["status"], e["code"], want.status, want.code)
+		}
+	}
+	if req, _ := entries[0]["request"].(map[string]interface{}); req["message"] != "[REDACTED]" || req["count"] == nil {
+		t.Fatalf("logged payload %v, want message redacted and count kept", entries[0]["request"])
+	}
+}
@@ -0,0 +1,254 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+)
+
+// backend is one gRPC upstream together with its reflection resolver.
+type backend struct {
+	addr     string
+	conn     *grpc.ClientConn
+	resolver *resolver
+	stats    *callStats
+
+	// checked is set for backends probed by the health checker, which is
+	// then responsible for clearing unhealthy again.
+	checked bool
+	// unhealthy is set when the last health probe or call found the
+	// backend unavailable.
+	unhealthy atomic.Bool
+}
+
+func newBackend(addr string, conn *grpc.ClientConn, stats *callStats) *backend {
+	return &backend{
+		addr:     addr,
+		conn:     conn,
+		resolver: newResolver(grpc_reflection_v1alpha.NewServerReflectionClient(conn)),
+		stats:    stats,
+	}
+}
+
+// awaitFirstAttempt waits for conn's first connection attempt to finish,
+// returning its error if it failed. Unlike a blocking dial, it doesn't
+// keep retrying until ctx expires, so a slow handshake is reported as one.
+func awaitFirstAttempt(ctx context.Context, conn *grpc.ClientConn) error {
+	conn.Connect()
+	for {
+		s := conn.GetState()
+		switch s {
+		case connectivity.Ready:
+			return nil
+		case connectivity.TransientFailure:
+			// A non-blocking call on a failed connection reports the
+			// error that failed it
+			_, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+			if err == nil || status.Code(err) != codes.Unavailable {
+				return nil
+			}
+			return errors.New(status.Convert(err).Message())
+		}
+		if !conn.WaitForStateChange(ctx, s) {
+			return ctx.Err()
+		}
+	}
+}
+
+// dialBackend connects to addr without blocking, so that an unreachable
+// backend doesn't prevent the bridge from starting.
+func dialBackend(addr string, opts []grpc.DialOption) (*backend, error) {
+	stats := &callStats{}
+	conn, err := grpc.Dial(dialTarget(addr), stats.dialOptions(opts)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial backend %s: %w", addr, err)
+	}
+	return newBackend(addr, conn, stats), nil
+}
+
+// dialTarget returns the gRPC target for addr. Plain host:port addresses
+// use the DNS resolver, which looks the name up again whenever a
+// connection is lost, so a backend that moves to new addresses is
+// followed instead of redialing the stale ones. Addresses with a scheme,
+// such as unix:/path or passthrough:///host:port, are used as they are.
+func dialTarget(addr string) string {
+	if strings.Contains(addr, "://") || strings.HasPrefix(addr, "unix:") {
+		return addr
+	}
+	return "dns:///" + addr
+}
+
+func (be *backend) Healthy() bool {
+	if be.unhealthy.Load() {
+		return false
+	}
+	switch be.conn.GetState() {
+	case connectivity.TransientFailure, connectivity.Shutdown:
+		return false
+	}
+	return true
+}
+
+// probe checks the backend with the standard gRPC health service. Backends
+// that don't implement it are considered healthy as long as they answer.
+func (be *backend) probe(ctx context.Context) bool {
+	resp, err := grpc_health_v1.NewHealthClient(be.conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		return status.Code(err) == codes.Unimplemented
+	}
+	return resp.GetStatus() == grpc_health_v1.HealthCheckResponse_SERVING
+}
+
+// observe marks a health-checked backend unhealthy when a call to it fails
+// because it is unavailable, so failover doesn't wait for the next probe.
+func (be *backend) observe(err error) {
+	if be.checked && status.Code(err) == codes.Unavailable {
+		be.unhealthy.Store(true)
+	}
+}
+
+// serviceRoute holds the backends configured for a service.
+type serviceRoute struct {
+	primary   *backend
+	secondary *backend
+	// split, if set, spreads requests over weighted backends instead of
+	// sending them all to primary, which is then the first of them.
+	split *weightedSelector
+	// versions holds the backends serving each API version prefix
+	versions map[string]*backend
+	// header, if set, is the request header whose value picks a backend
+	// from byHeader
+	header   string
+	byHeader map[string]*backend
+}
+
+// backendFor returns the backend that should serve service: its primary,
+// or one picked by weight, while healthy, otherwise its secondary if that
+// is healthy. Services without a route go to the default backend.
+func (b *Bridge) backendFor(service string) *backend {
+	route, ok := b.routes[service]
+	if !ok {
+		return b.backend
+	}
+	primary := route.primary
+	if route.split != nil {
+		primary = route.split.pick()
+	}
+	if route.secondary != nil && !primary.Healthy() && route.secondary.Healthy() {
+		return route.secondary
+	}
+	return primary
+}
+
+// backendsFor returns every backend that may serve service: the primary,
+// split and secondary backends of its route and those of its versions.
+func (b *Bridge) backendsFor(service string) []*backend {
+	route, ok := b.routes[service]
+	if !ok {
+		return []*backend{b.backend}
+	}
+	list := []*backend{route.primary}
+	if route.split != nil {
+		list = append(list, route.split.backends...)
+	}
+	if route.secondary != nil {
+		list = append(list, route.secondary)
+	}
+	for _, be := range route.versions {
+		list = append(list, be)
+	}
+	for _, be := range route.byHeader {
+		list = append(list, be)
+	}
+	seen := make(map[*backend]bool, len(list))
+	unique := list[:0]
+	for _, be := range list {
+		if be != nil && !seen[be] {
+			seen[be] = true
+			unique = append(unique, be)
+		}
+	}
+	return unique
+}
+
+// primaryFor returns the backend service is listed from in /services,
+// which is the same on every call even if requests are split.
+func (b *Bridge) primaryFor(service string) *backend {
+	if route, ok := b.routes[service]; ok {
+		return route.primary
+	}
+	return b.backend
+}
+
+// healthChecker periodically probes every backend that takes part in
+// failover and records the result.
+type healthChecker struct {
+	backends []*backend
+	interval time.Duration
+	probe    func(context.Context, *backend) bool
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+func startHealthChecker(backends []*backend, interval time.Duration, probe func(context.Context, *backend) bool) *healthChecker {
+	for _, be := range backends {
+		be.checked = true
+	}
+	hc := &healthChecker{
+		backends: backends,
+		interval: interval,
+		probe:    probe,
+		stop:     make(chan struct{}),
+	}
+	hc.wg.Add(1)
+	go hc.run()
+	return hc
+}
+
+func (hc *healthChecker) run() {
+	defer hc.wg.Done()
+
+	ticker := time.NewTicker(hc.interval)
+	defer ticker.Stop()
+	for {
+		hc.checkAll()
+		select {
+		case <-hc.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (hc *healthChecker) checkAll() {
+	for _, be := range hc.backends {
+		ctx, cancel := context.WithTimeout(context.Background(), hc.interval)
+		healthy := hc.probe(ctx, be)
+		cancel()
+
+		if was := !be.unhealthy.Swap(!healthy); was != healthy {
+			if healthy {
+				log.Printf("✓ Backend %s is healthy", be.addr)
+			} else {
+				log.Printf("✗ Backend %s is unhealthy", be.addr)
+			}
+		}
+	}
+}
+
+func (hc *healthChecker) Stop() {
+	close(hc.stop)
+	hc.wg.Wait()
+}
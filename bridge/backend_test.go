@@ -0,0 +1,109 @@
+package bridge
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mizrahidaniel/grpc-http-bridge/bridgetest"
+)
+
+func TestFailoverToSecondary(t *testing.T) {
+	primary, secondary := bridgetest.Start(t), bridgetest.Start(t)
+	b := newTestBridge(t, Config{
+		GRPCAddr:       primary.Addr,
+		HealthInterval: 20 * time.Millisecond,
+		Services: map[string]ServiceConfig{
+			bridgetest.Service: {Primary: primary.Addr, Secondary: secondary.Addr},
+		},
+	})
+	h := b.Handler()
+	if rec := post(h, "/bridgetest.v1.Greeter/SayHello", `{"name": "Ada"}`); rec.Code != 200 {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+	if primary.Calls() != 1 || secondary.Calls() != 0 {
+		t.Fatalf("healthy primary: calls %d/%d, want 1/0", primary.Calls(), secondary.Calls())
+	}
+
+	primary.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for secondary.Calls() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("requests never failed over to the secondary")
+		}
+		post(h, "/bridgetest.v1.Greeter/SayHello", `{"name": "Ada"}`)
+		time.Sleep(10 * time.Millisecond)
+	}
+	for i := 0; i < 3; i++ {
+		if rec := post(h, "/bridgetest.v1.Greeter/SayHello", `{"name": "Ada"}`); rec.Code != 200 {
+			t.Fatalf("after failover: got %d %s", rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestBackendsDialThroughDNSResolver(t *testing.T) {
+	tests := []struct{ addr, want string }{
+		{"users.internal:9090", "dns:///users.internal:9090"},
+		{"127.0.0.1:9090", "dns:///127.0.0.1:9090"},
+		{"unix:/run/users.sock", "unix:/run/users.sock"},
+		{"passthrough:///users:9090", "passthrough:///users:9090"},
+	}
+	for _, tt := range tests {
+		if got := dialTarget(tt.addr); got != tt.want {
+			t.Errorf("dialTarget(%q) = %q, want %q", tt.addr, got, tt.want)
+		}
+	}
+
+	be := bridgetest.Start(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.Addr})
+	if got, want := b.backend.conn.Target(), "dns:///"+be.Addr; got != want {
+		t.Fatalf("backend dialed %q, want %q", got, want)
+	}
+	if rec := post(b.Handler(), "/bridgetest.v1.Greeter/SayHello", `{"name": "Ada"}`); rec.Code != 200 {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestRequestsCantPickUnlistedBackends(t *testing.T) {
+	configured, eu, other := bridgetest.Start(t), bridgetest.Start(t), bridgetest.Start(t)
+	b := newTestBridge(t, Config{
+		GRPCAddr: configured.Addr,
+		Services: map[string]ServiceConfig{
+			bridgetest.Service: {RouteHeader: "X-Region", HeaderRoutes: map[string]string{"eu": eu.Addr}},
+		},
+	})
+	call := func(path string, header http.Header) int {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"name": "Ada"}`))
+		req.Header = header
+		if host := header.Get("Host"); host != "" {
+			req.Host = host
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return serve(b.Handler(), req).Code
+	}
+
+	// A Host naming another backend is ignored
+	if code := call("/bridgetest.v1.Greeter/SayHello", http.Header{"Host": {other.Addr}}); code != 200 {
+		t.Fatalf("plain call: got %d, want 200", code)
+	}
+	// The route header only picks among the listed backends
+	if code := call("/bridgetest.v1.Greeter/SayHello", http.Header{"X-Region": {other.Addr}}); code != 400 {
+		t.Fatalf("route header naming an address: got %d, want 400", code)
+	}
+	if code := call("/bridgetest.v1.Greeter/SayHello", http.Header{"X-Region": {"eu"}}); code != 200 {
+		t.Fatalf("listed route: got %d, want 200", code)
+	}
+	// Nor can the path name one
+	if code := call("/"+other.Addr+"/bridgetest.v1.Greeter/SayHello", http.Header{}); code < 400 || code > 499 {
+		t.Fatalf("address in the path: got %d, want a 4xx", code)
+	}
+
+	if configured.Calls() != 1 || eu.Calls() != 1 || other.Calls() != 0 {
+		t.Fatalf("calls: configured %d, eu %d, unlisted %d; want 1, 1, 0", configured.Calls(), eu.Calls(), other.Calls())
+	}
+	if len(b.backends) != 2 {
+		t.Fatalf("bridge holds %d backend connections, want 2", len(b.backends))
+	}
+}
@@ -0,0 +1,35 @@
+package bridge
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// addBasePath mounts service under base, so that {base}/{method} calls the
+// service's methods.
+func (b *Bridge) addBasePath(service, base string) error {
+	clean := path.Clean("/" + base)
+	if clean == "/" {
+		return fmt.Errorf("service %s: base path %q is empty", service, base)
+	}
+	if other, ok := b.mounts[clean]; ok {
+		return fmt.Errorf("service %s: base path %s is already used by %s", service, clean, other)
+	}
+	b.mounts[clean] = service
+	return nil
+}
+
+// splitBasePath returns the service mounted at the longest base path
+// prefixing p, and the method named by the rest of p.
+func (b *Bridge) splitBasePath(p string) (service, method string, ok bool) {
+	var matched string
+	for base, s := range b.mounts {
+		rest, found := strings.CutPrefix(p, base+"/")
+		if !found || rest == "" || strings.Contains(rest, "/") || len(base) <= len(matched) {
+			continue
+		}
+		matched, service, method = base, s, rest
+	}
+	return service, method, matched != ""
+}
@@ -0,0 +1,43 @@
+package bridge
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mizrahidaniel/grpc-http-bridge/bridgetest"
+)
+
+func TestBasePathRoutesToService(t *testing.T) {
+	be := bridgetest.Start(t)
+	b := newTestBridge(t, Config{
+		GRPCAddr: be.Addr,
+		Services: map[string]ServiceConfig{bridgetest.Service: {BasePath: "/greeter"}},
+	})
+	h := b.Handler()
+
+	rec := post(h, "/greeter/SayHello", `{"name": "Ada"}`)
+	var resp struct{ Message string }
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != 200 || be.Calls() != 1 {
+		t.Fatalf("got %d %s after %d backend calls", rec.Code, rec.Body, be.Calls())
+	}
+	if resp.Message == "" {
+		t.Fatalf("SayHello answered %s", rec.Body)
+	}
+	if rec := post(h, "/bridgetest.v1.Greeter/SayHello", `{"name": "Ada"}`); rec.Code != 200 {
+		t.Fatalf("full path: got %d %s", rec.Code, rec.Body)
+	}
+	if rec := post(h, "/greeter/Nope", `{}`); rec.Code != 404 {
+		t.Fatalf("unknown method under the base path: got %d, want 404", rec.Code)
+	}
+
+	_, err := NewBridge(Config{
+		GRPCAddr: be.Addr,
+		Services: map[string]ServiceConfig{
+			bridgetest.Service: {BasePath: "/greeter"},
+			"other.v1.Greeter": {BasePath: "greeter/"},
+		},
+	})
+	if err == nil {
+		t.Fatal("two services were mounted at one base path")
+	}
+}
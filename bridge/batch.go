@@ -0,0 +1,141 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// batchCall is one call of a POST /batch request.
+type batchCall struct {
+	// Method is the method to call, as "{service}/{method}".
+	Method string `json:"method"`
+	// Request is the JSON request. Defaults to {}.
+	Request json.RawMessage `json:"request"`
+}
+
+// batchResult is the outcome of a batchCall, in the order of the calls.
+type batchResult struct {
+	Method string `json:"method"`
+	// Status is the HTTP status the call would have been answered with
+	// on its own.
+	Status int `json:"status"`
+	// Body is the call's response, or its error body. Bodies that aren't
+	// JSON, such as those of streaming methods, are given as a string.
+	Body json.RawMessage `json:"body"`
+}
+
+// handleBatch returns the handler of POST /batch, which makes each call of
+// a JSON array through h, one after another, as if it were a request of
+// its own with the batch's headers. It answers 200 if every call succeeded
+// and otherwise 207 Multi-Status, the status of each call being in its
+// result. Calls go through the whole handler, so that concurrency limits,
+// rate limits and the like count every call.
+func (b *Bridge) handleBatch(h http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var calls []batchCall
+		if err := json.NewDecoder(r.Body).Decode(&calls); err != nil {
+			b.writeError(w, status.Errorf(codes.InvalidArgument, "invalid batch: %v (want an array of {\"method\": \"{service}/{method}\", \"request\": {...}})", err))
+			return
+		}
+		if len(calls) == 0 || len(calls) > b.maxBatch {
+			b.writeError(w, status.Errorf(codes.InvalidArgument, "batch has %d calls (want 1 to %d)", len(calls), b.maxBatch))
+			return
+		}
+		for i, c := range calls {
+			service, method, ok := strings.Cut(strings.TrimPrefix(c.Method, "/"), "/")
+			if !ok || service == "" || method == "" || strings.Contains(method, "/") {
+				b.writeError(w, status.Errorf(codes.InvalidArgument, "call %d: invalid method %q (want {service}/{method})", i, c.Method))
+				return
+			}
+		}
+
+		results := make([]batchResult, 0, len(calls))
+		code := http.StatusOK
+		for _, c := range calls {
+			res := b.batchCall(h, r, c)
+			if res.Status < 200 || res.Status > 299 {
+				code = http.StatusMultiStatus
+			}
+			results = append(results, res)
+		}
+		b.encodeJSON(w, code, results)
+	}
+}
+
+func (b *Bridge) batchCall(h http.Handler, r *http.Request, c batchCall) batchResult {
+	path := "/" + strings.TrimPrefix(c.Method, "/")
+	service, method, _ := strings.Cut(path[1:], "/")
+	body := c.Request
+	if len(body) == 0 {
+		body = json.RawMessage("{}")
+	}
+	// Each call is routed afresh: chi reuses a route context it finds in
+	// the request's, and the batch's has its own path and method, which
+	// would route the call back to /batch under a parent router
+	ctx := context.WithValue(r.Context(), chi.RouteCtxKey, chi.NewRouteContext())
+	req, err := http.NewRequestWithContext(ctx, b.httpMethod(service, method), path, bytes.NewReader(body))
+	if err != nil {
+		code, resp := b.errorResponse(status.Errorf(codes.InvalidArgument, "invalid method %q: %v", c.Method, err))
+		return batchResult{Method: c.Method, Status: code, Body: resp}
+	}
+	req.Header = r.Header.Clone()
+	for _, k := range []string{"Content-Length", "Content-Encoding", "Accept-Encoding", "Prefer"} {
+		req.Header.Del(k)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.RemoteAddr = r.RemoteAddr
+	req.TLS = r.TLS
+
+	rec := &batchRecorder{header: make(http.Header)}
+	h.ServeHTTP(rec, req)
+	out := bytes.TrimSpace(rec.body.Bytes())
+	if !json.Valid(out) {
+		out, _ = json.Marshal(string(out))
+	}
+	return batchResult{Method: c.Method, Status: rec.status(), Body: out}
+}
+
+// batchRecorder is the response writer of a batch call, which keeps its
+// status and body for the call's result. Server streaming calls flush it,
+// which does nothing.
+type batchRecorder struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func (rec *batchRecorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *batchRecorder) WriteHeader(code int) {
+	if rec.code == 0 {
+		rec.code = code
+	}
+}
+
+func (rec *batchRecorder) Write(p []byte) (int, error) {
+	rec.WriteHeader(http.StatusOK)
+	return rec.body.Write(p)
+}
+
+func (rec *batchRecorder) Flush() {
+	rec.WriteHeader(http.StatusOK)
+}
+
+// status returns the status the call was answered with, 200 if it wrote
+// nothing.
+func (rec *batchRecorder) status() int {
+	if rec.code == 0 {
+		return http.StatusOK
+	}
+	return rec.code
+}
@@ -0,0 +1,84 @@
+package bridge
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mizrahidaniel/grpc-http-bridge/bridgetest"
+)
+
+const mixedBatch = `[
+	{"method": "bridgetest.v1.Greeter/SayHello", "request": {"name": "Ada"}},
+	{"method": "bridgetest.v1.Greeter/Fail", "request": {"code": 5, "message": "no such user"}},
+	{"method": "bridgetest.v1.Greeter/SayHello", "request": {"name": 7}},
+	{"method": "bridgetest.v1.Greeter/SayHello"}
+]`
+
+func TestBatchReportsPerCallStatuses(t *testing.T) {
+	be := bridgetest.Start(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.Addr, MaxBatchSize: 10})
+
+	mounted := chi.NewRouter()
+	mounted.Mount("/api", b.Handler())
+
+	for _, tt := range []struct {
+		name string
+		h    http.Handler
+		path string
+	}{
+		{"root", b.Handler(), "/batch"},
+		{"mounted", mounted, "/api/batch"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := post(tt.h, tt.path, mixedBatch)
+			if rec.Code != http.StatusMultiStatus {
+				t.Fatalf("got %d %s, want 207", rec.Code, rec.Body)
+			}
+			var results []batchResult
+			if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil {
+				t.Fatal(err)
+			}
+			want := []int{200, 404, 400, 200}
+			if len(results) != len(want) {
+				t.Fatalf("got %d results, want %d: %s", len(results), len(want), rec.Body)
+			}
+			for i, res := range results {
+				if res.Status != want[i] {
+					t.Errorf("call %d: status %d, want %d: %s", i, res.Status, want[i], res.Body)
+				}
+			}
+			var hello struct{ Message string }
+			if json.Unmarshal(results[0].Body, &hello); hello.Message != "Hello, Ada!" {
+				t.Errorf("call 0 body %s", results[0].Body)
+			}
+		})
+	}
+
+	rec := post(b.Handler(), "/batch", `[{"method": "bridgetest.v1.Greeter/SayHello", "request": {"name": "Ada"}}]`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("successful batch: got %d, want 200", rec.Code)
+	}
+}
+
+func TestBatchRecordsStreamingCalls(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, MaxBatchSize: 10})
+
+	rec := post(b.Handler(), "/batch", `[{"method": "test.v1.Echo/ServerStream", "request": {"message": "hi", "count": 2}}]`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d %s, want 200", rec.Code, rec.Body)
+	}
+	var results []batchResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil {
+		t.Fatal(err)
+	}
+	var body string
+	if len(results) != 1 || json.Unmarshal(results[0].Body, &body) != nil {
+		t.Fatalf("got results %s, want the stream's messages as a string", rec.Body)
+	}
+	if lines := ndjsonLines(t, body); len(lines) != 2 {
+		t.Fatalf("got %d messages in %q, want 2", len(lines), body)
+	}
+}
@@ -0,0 +1,47 @@
+package bridge
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+type bearerKey struct{}
+
+// WithBearerForwarding forwards the bearer token of each request's
+// Authorization header to backends as per-RPC call credentials. Unlike
+// plain metadata, call credentials are only sent over a secure transport,
+// so NewBridge fails unless backend connections use TLS.
+func WithBearerForwarding() Option {
+	return func(o *options) {
+		o.forwardBearer = true
+	}
+}
+
+// bearerMiddleware attaches the request's bearer token, if it has one, to
+// its context for bearerCredentials to send.
+func bearerMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+		if ok && strings.EqualFold(scheme, "Bearer") && token != "" {
+			r = r.WithContext(context.WithValue(r.Context(), bearerKey{}, token))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bearerCredentials sends the bearer token of the request a call is made
+// for as its authorization metadata.
+type bearerCredentials struct{}
+
+func (bearerCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	token, ok := ctx.Value(bearerKey{}).(string)
+	if !ok {
+		return nil, nil
+	}
+	return map[string]string{"authorization": "Bearer " + token}, nil
+}
+
+func (bearerCredentials) RequireTransportSecurity() bool {
+	return true
+}
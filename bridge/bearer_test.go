@@ -0,0 +1,41 @@
+package bridge
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+func TestBearerTokenForwardedAsCallCredentials(t *testing.T) {
+	certFile, keyFile := writeCert(t)
+	creds, err := credentials.NewServerTLSFromFile(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	be := startBackend(t, grpc.Creds(creds))
+	echoMetadata(be, "authorization")
+	b := newTestBridge(t, Config{GRPCAddr: be.addr}, WithTLS(&tls.Config{InsecureSkipVerify: true}), WithBearerForwarding())
+
+	req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec := serve(b.Handler(), req)
+	var resp struct{ Message string }
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != 200 {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+	if resp.Message != "Bearer s3cret" {
+		t.Fatalf("backend got authorization %q, want the bearer token", resp.Message)
+	}
+
+	if b, err := NewBridge(Config{GRPCAddr: be.addr}, WithBearerForwarding()); err == nil {
+		b.Close()
+		t.Fatal("bearer forwarding was allowed over an insecure connection")
+	}
+}
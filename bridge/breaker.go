@@ -0,0 +1,140 @@
+package bridge
+
+import (
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// circuitBreaker trips after threshold consecutive backend failures and
+// rejects calls until cooldown has passed, then lets a single probe call
+// through to decide whether to close again.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+func (cb *circuitBreaker) allow(now time.Time) bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case breakerOpen:
+		if now.Sub(cb.openedAt) < cb.cooldown {
+			return false
+		}
+		cb.state = breakerHalfOpen
+		return true
+	case breakerHalfOpen:
+		// A probe is already in flight
+		return false
+	}
+	return true
+}
+
+func (cb *circuitBreaker) record(err error, now time.Time) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch {
+	case err == nil:
+		cb.state = breakerClosed
+		cb.failures = 0
+	case isBackendFailure(err):
+		cb.failures++
+		if cb.state == breakerHalfOpen || cb.failures >= cb.threshold {
+			cb.state = breakerOpen
+			cb.openedAt = now
+		}
+	case cb.state == breakerHalfOpen:
+		// Other errors say nothing about a closed breaker's backend. A
+		// probe the backend answered, say with NotFound, closes the
+		// breaker; one it never saw, like a malformed request or a client
+		// that went away, lets the next call probe instead.
+		if backendAnswered(err) {
+			cb.state = breakerClosed
+			cb.failures = 0
+		} else {
+			cb.state = breakerOpen
+		}
+	}
+}
+
+// isBackendFailure reports whether err indicates the backend, rather than
+// the request, is at fault.
+func isBackendFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.Unknown:
+		return true
+	}
+	return false
+}
+
+// backendAnswered reports whether err, which isn't a backend failure,
+// came from the backend rather than from the bridge or the client before
+// the backend could answer.
+func backendAnswered(err error) bool {
+	return status.Code(err) != codes.Canceled && errorCodeOf(err) != errorInvalidPayload
+}
+
+// breakerSet holds one circuit breaker per method, so a failing method
+// doesn't reject calls to healthy methods on the same backend.
+type breakerSet struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+func newBreakerSet(threshold int, cooldown time.Duration) *breakerSet {
+	return &breakerSet{
+		threshold: threshold,
+		cooldown:  cooldown,
+		breakers:  make(map[string]*circuitBreaker),
+	}
+}
+
+func (s *breakerSet) get(fullMethod string) *circuitBreaker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cb, ok := s.breakers[fullMethod]
+	if !ok {
+		cb = &circuitBreaker{threshold: s.threshold, cooldown: s.cooldown}
+		s.breakers[fullMethod] = cb
+	}
+	return cb
+}
+
+// guard runs call under md's circuit breaker, failing fast with
+// Unavailable while the breaker is open.
+func (b *Bridge) guard(md protoreflect.MethodDescriptor, call func() error) error {
+	if b.breakers == nil {
+		return call()
+	}
+
+	fullMethod := methodPath(md)
+	cb := b.breakers.get(fullMethod)
+	if !cb.allow(time.Now()) {
+		return status.Errorf(codes.Unavailable, "circuit breaker open for %s", fullMethod)
+	}
+	err := call()
+	cb.record(err, time.Now())
+	return err
+}
@@ -0,0 +1,88 @@
+package bridge
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mizrahidaniel/grpc-http-bridge/bridgetest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBreakerOpensPerMethod(t *testing.T) {
+	be := bridgetest.Start(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.Addr, BreakerThreshold: 2, BreakerCooldown: time.Hour})
+	h := b.Handler()
+
+	unavailable := `{"code": 14, "message": "down"}`
+	for i := 0; i < 2; i++ {
+		if rec := post(h, "/bridgetest.v1.Greeter/Fail", unavailable); rec.Code != 503 {
+			t.Fatalf("failure %d: got %d, want 503", i+1, rec.Code)
+		}
+	}
+	calls := be.Calls()
+	if rec := post(h, "/bridgetest.v1.Greeter/Fail", unavailable); rec.Code != 503 || be.Calls() != calls {
+		t.Fatalf("open breaker: got %d with %d new backend calls, want 503 without any", rec.Code, be.Calls()-calls)
+	}
+
+	rec := post(h, "/bridgetest.v1.Greeter/SayHello", `{"name": "Ada"}`)
+	if rec.Code != 200 {
+		t.Fatalf("other method: got %d %s, want 200", rec.Code, rec.Body)
+	}
+}
+
+func TestBreakerIgnoresErrorsThatAreNotTheBackends(t *testing.T) {
+	be := bridgetest.Start(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.Addr, BreakerThreshold: 2, BreakerCooldown: time.Hour})
+	h := b.Handler()
+
+	post(h, "/bridgetest.v1.Greeter/Fail", `{"code": 14}`)
+	if rec := post(h, "/bridgetest.v1.Greeter/Fail", `{"code": "not a number"}`); rec.Code != 400 {
+		t.Fatalf("malformed body: got %d, want 400", rec.Code)
+	}
+	post(h, "/bridgetest.v1.Greeter/Fail", `{"code": 14}`)
+
+	calls := be.Calls()
+	post(h, "/bridgetest.v1.Greeter/Fail", `{"code": 14}`)
+	if be.Calls() != calls {
+		t.Fatal("a malformed body reset the failure streak")
+	}
+}
+
+func TestBreakerProbe(t *testing.T) {
+	failure := status.Error(codes.Unavailable, "down")
+	canceled := status.FromContextError(context.Canceled).Err()
+	malformed := invalidPayload(status.Error(codes.InvalidArgument, "invalid request body"))
+	notFound := status.Error(codes.NotFound, "no such user")
+
+	tests := []struct {
+		name  string
+		probe error
+		want  breakerState
+	}{
+		{"success", nil, breakerClosed},
+		{"failure", failure, breakerOpen},
+		{"backend answer", notFound, breakerClosed},
+		{"client went away", canceled, breakerOpen},
+		{"malformed request", malformed, breakerOpen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			cb := &circuitBreaker{threshold: 1, cooldown: time.Minute}
+			cb.record(failure, now)
+			now = now.Add(time.Minute)
+			if !cb.allow(now) {
+				t.Fatal("breaker didn't let a probe through after the cooldown")
+			}
+			cb.record(tt.probe, now)
+			if cb.state != tt.want {
+				t.Fatalf("state after probe = %d, want %d", cb.state, tt.want)
+			}
+			if tt.probe != nil && tt.probe != failure && tt.want == breakerOpen && !cb.allow(now) {
+				t.Fatal("a probe the backend never saw didn't let the next call probe")
+			}
+		})
+	}
+}
@@ -0,0 +1,978 @@
+// Package bridge exposes gRPC services as JSON over HTTP, resolving method
+// schemas at runtime via gRPC server reflection.
+package bridge
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+type Bridge struct {
+	grpcAddr string
+	httpPort int
+	dialOpts []grpc.DialOption
+	backend  *backend
+	backends map[string]*backend
+	routes   map[string]serviceRoute
+	mounts   map[string]string
+	versions map[string]bool
+	health   *healthChecker
+	breakers *breakerSet
+	retries  *retryPolicy
+	limiter  *rateLimiter
+	inflight *concurrencyLimiter
+	recorder *recorder
+	replayer *replayer
+	auditor  *auditLog
+	compress *responseCompressor
+	nullMode nullMode
+	dupKeys  duplicateMode
+
+	omitUnpopulated   bool
+	omitEmptyRepeated bool
+	deterministic     bool
+	defaultFormat     responseFormat
+	bufferResponses   bool
+	jsonNewline       bool
+	problemErrors     bool
+	logPayloadSizes   bool
+	payloadSampling   payloadSampling
+	requestSizes      *histogram
+	responseSizes     *histogram
+	slowRequests      time.Duration
+	logFormat         logFormat
+	logOut            io.Writer // where the access log is written
+	requestIDs        requestIDScheme
+	callIDs           bool
+	debugHeaders      bool
+	forwardBearer     bool
+	tracing           bool
+
+	interceptors  []UnaryInterceptor
+	transforms    map[string]*methodTransforms
+	methodTags    map[string][]string
+	httpMethods   map[string]string
+	methodFormats map[string]responseFormat
+	removed       map[string]string
+	caches        map[string]*responseCache
+	methodLimits  map[string]*concurrencyLimiter
+	audits        map[string]AuditConfig
+
+	streamObservers []StreamObserver
+	sources         []DescriptorSource
+
+	callTimeoutDefault time.Duration
+	serviceTimeouts    map[string]time.Duration
+	methodTimeouts     map[string]time.Duration
+	deadlineMargin     time.Duration
+	refusedMessage     string
+	exhaustedStatus    int
+	timeoutOption      protoreflect.FullName
+	optionTimeouts     sync.Map
+	deprecatedTypes    sync.Map
+	methodDocs         sync.Map
+
+	flushPolicies map[string]flushPolicy
+	streamArrays  map[string]string
+	unaryStreams  map[string]bool
+	streamResumes int
+	streamEnds    bool
+	streamIdle    time.Duration
+	emptyStream   emptyStream
+	emptyStreams  map[string]emptyStream
+
+	emptyResponse  emptyResponse
+	emptyResponses map[string]emptyResponse
+
+	requiredHeaders map[string][]string
+	contentTypes    map[string]*contentTypes
+	requestSchemas  map[string]*jsonSchema
+	headerFields    map[string]map[string]string
+
+	cors []corsPolicy
+
+	healthProbe      *healthProbe
+	maxDescriptorAge time.Duration
+	fanIns           map[string][]fanInSource
+
+	defaultService string
+	exposeServices []string
+	devMode        bool
+	foldCase       bool
+	rootIndex      bool
+	formPayload    string
+	formRepeated   formRepeatedMode
+
+	tlsConfig         *tls.Config
+	tlsCertFile       string
+	tlsKeyFile        string
+	principalMetadata string
+	publicMethods     map[string]bool
+	metadataLimit     int
+	metadataPolicy    string
+
+	http2PingInterval time.Duration
+	http2PingTimeout  time.Duration
+
+	startupAttempts int
+	startupInterval time.Duration
+
+	reusePort    bool
+	tcpKeepAlive time.Duration
+	maxConns     int
+	maxPath      int
+	connRejects  atomic.Uint64
+
+	exemplars bool
+
+	adminToken  string
+	maintenance maintenance
+	recent      *recentRequests
+	operations  *operationStore
+	maxBatch    int
+
+	// server is the http.Server Serve runs, which Shutdown stops
+	serverMu           sync.Mutex
+	server             *http.Server
+	drain              drainer
+	shutdownTimeout    time.Duration
+	streamDrainTimeout time.Duration
+	watchdog           *watchdog
+
+	// types resolves google.protobuf.Any types; descriptorFiles holds the
+	// schema loaded with Config.DescriptorSet
+	types           *typeResolver
+	descriptorFiles *protoregistry.Files
+
+	registeredMu sync.RWMutex
+	registered   map[string]protoreflect.MethodDescriptor
+}
+
+// NewBridge creates a Bridge for cfg. Unless cfg.ReplayFile is set, it
+// blocks until the backend at cfg.GRPCAddr is reachable, for up to 5
+// seconds or a single attempt if WithConnectTimeout is given.
+func NewBridge(cfg Config, opts ...Option) (*Bridge, error) {
+	nm, err := parseNullMode(cfg.NullFields)
+	if err != nil {
+		return nil, err
+	}
+	dm, err := parseDuplicateMode(cfg.DuplicateKeys)
+	if err != nil {
+		return nil, err
+	}
+	lf, err := parseLogFormat(cfg.LogFormat)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := parseRequestIDScheme(cfg.RequestIDFormat)
+	if err != nil {
+		return nil, err
+	}
+	es, err := parseEmptyStream(cfg.EmptyStream)
+	if err != nil {
+		return nil, err
+	}
+	er, err := parseEmptyResponse(cfg.EmptyResponse)
+	if err != nil {
+		return nil, err
+	}
+	frm, err := parseFormRepeatedMode(cfg.FormRepeatedFields)
+	if err != nil {
+		return nil, err
+	}
+	df := formatJSON
+	if cfg.DefaultFormat != "" {
+		var ok bool
+		if df, ok = parseFormat(cfg.DefaultFormat); !ok {
+			return nil, fmt.Errorf("invalid default format %q (want json, proto or yaml)", cfg.DefaultFormat)
+		}
+	}
+	if cfg.PayloadLogSampleRate < 0 || cfg.PayloadLogSampleRate > 1 {
+		return nil, fmt.Errorf("invalid payload log sample rate %g (want 0 to 1)", cfg.PayloadLogSampleRate)
+	}
+	if s := cfg.ResourceExhaustedStatus; s != 0 && (s < 400 || s > 599) {
+		return nil, fmt.Errorf("invalid resource exhausted status %d (want a 4xx or 5xx status)", s)
+	}
+	switch cfg.ErrorFormat {
+	case "", "json", "problem":
+	default:
+		return nil, fmt.Errorf("invalid error format %q (want json or problem)", cfg.ErrorFormat)
+	}
+	transforms, err := compileTransforms(cfg.Methods)
+	if err != nil {
+		return nil, err
+	}
+	cors, err := compileCORS(cfg.CORS)
+	if err != nil {
+		return nil, err
+	}
+	for _, pattern := range cfg.ExposeServices {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid exposed service pattern %q: %w", pattern, err)
+		}
+	}
+	hp, err := compileHealthProbe(cfg.HealthProbe)
+	if err != nil {
+		return nil, err
+	}
+	fanIns, err := compileFanIns(cfg.FanIns)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if cfg.CallIDs {
+		// First, so that the caller's interceptors see the metadata
+		o.unaryInterceptors = append([]grpc.UnaryClientInterceptor{callIDUnary}, o.unaryInterceptors...)
+		o.streamInterceptors = append([]grpc.StreamClientInterceptor{callIDStream}, o.streamInterceptors...)
+	}
+
+	b := &Bridge{
+		grpcAddr: cfg.GRPCAddr,
+		httpPort: cfg.HTTPPort,
+		dialOpts: o.dialOptions(),
+		backends: make(map[string]*backend),
+		routes:   make(map[string]serviceRoute),
+		mounts:   make(map[string]string),
+		versions: make(map[string]bool),
+		nullMode: nm,
+		dupKeys:  dm,
+
+		omitUnpopulated:   cfg.OmitUnpopulated,
+		omitEmptyRepeated: cfg.OmitEmptyRepeated,
+		deterministic:     cfg.DeterministicJSON,
+		defaultFormat:     df,
+		bufferResponses:   cfg.BufferResponses,
+		jsonNewline:       cfg.JSONTrailingNewline,
+		problemErrors:     cfg.ErrorFormat == "problem",
+		logPayloadSizes:   cfg.LogPayloadSizes,
+		slowRequests:      cfg.SlowRequestThreshold,
+		logFormat:         lf,
+		logOut:            os.Stdout,
+		requestIDs:        ids,
+		callIDs:           cfg.CallIDs,
+		debugHeaders:      cfg.DebugHeaders,
+
+		interceptors:  o.bridgeInterceptors,
+		transforms:    transforms,
+		methodTags:    make(map[string][]string),
+		httpMethods:   make(map[string]string),
+		methodFormats: make(map[string]responseFormat),
+		removed:       make(map[string]string),
+		caches:        make(map[string]*responseCache),
+		methodLimits:  make(map[string]*concurrencyLimiter),
+		audits:        make(map[string]AuditConfig),
+
+		streamObservers: o.streamObservers,
+		sources:         o.sources,
+		forwardBearer:   o.forwardBearer,
+		tracing:         o.tracing,
+
+		callTimeoutDefault: cfg.CallTimeout,
+		serviceTimeouts:    make(map[string]time.Duration),
+		methodTimeouts:     make(map[string]time.Duration),
+		deadlineMargin:     cfg.DeadlineMargin,
+		refusedMessage:     cfg.RefusedMessage,
+		exhaustedStatus:    cfg.ResourceExhaustedStatus,
+		timeoutOption:      protoreflect.FullName(cfg.TimeoutOption),
+
+		flushPolicies: make(map[string]flushPolicy),
+		streamArrays:  make(map[string]string),
+		unaryStreams:  make(map[string]bool),
+		streamResumes: cfg.StreamResumes,
+		streamEnds:    cfg.StreamEndFrames,
+		streamIdle:    cfg.StreamMessageTimeout,
+		emptyStream:   es,
+		emptyStreams:  make(map[string]emptyStream),
+
+		emptyResponse:  er,
+		emptyResponses: make(map[string]emptyResponse),
+
+		requiredHeaders: make(map[string][]string),
+		contentTypes:    make(map[string]*contentTypes),
+		requestSchemas:  make(map[string]*jsonSchema),
+		headerFields:    make(map[string]map[string]string),
+
+		cors: cors,
+
+		healthProbe:      hp,
+		maxDescriptorAge: cfg.MaxDescriptorAge,
+		fanIns:           fanIns,
+
+		defaultService: cfg.DefaultService,
+		exposeServices: cfg.ExposeServices,
+		devMode:        cfg.DevMode,
+		foldCase:       cfg.CaseInsensitive,
+		rootIndex:      !cfg.DisableRootIndex,
+		formPayload:    cfg.FormPayloadField,
+		formRepeated:   frm,
+
+		tlsCertFile:       cfg.TLSCertFile,
+		tlsKeyFile:        cfg.TLSKeyFile,
+		principalMetadata: strings.ToLower(cfg.PrincipalMetadata),
+		metadataLimit:     cfg.MaxForwardedMetadata,
+		metadataPolicy:    cfg.ForwardedMetadataPolicy,
+
+		http2PingInterval: cfg.HTTP2PingInterval,
+		http2PingTimeout:  cfg.HTTP2PingTimeout,
+
+		startupAttempts: cfg.StartupProbeAttempts,
+		startupInterval: cfg.StartupProbeInterval,
+
+		reusePort:    cfg.ReusePort,
+		tcpKeepAlive: cfg.TCPKeepAlive,
+		maxConns:     cfg.MaxConnections,
+		maxPath:      cfg.MaxPathLength,
+
+		exemplars: cfg.MetricsExemplars,
+
+		adminToken: cfg.AdminToken,
+
+		shutdownTimeout:    cfg.ShutdownTimeout,
+		streamDrainTimeout: cfg.StreamDrainTimeout,
+	}
+	b.types = &typeResolver{b: b}
+	if cfg.TLSCertFile != "" {
+		b.tlsConfig, err = serverTLSConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(cfg.PublicMethods) > 0 {
+		if cfg.TLSClientCAFile == "" {
+			return nil, fmt.Errorf("public methods require client certificates (TLSClientCAFile)")
+		}
+		if cfg.TLSClientAuth == "" || cfg.TLSClientAuth == "require" {
+			b.publicMethods = make(map[string]bool, len(cfg.PublicMethods))
+			for _, name := range cfg.PublicMethods {
+				b.publicMethods[strings.TrimPrefix(name, "/")] = true
+			}
+		}
+	}
+	switch b.metadataPolicy {
+	case "":
+		b.metadataPolicy = "reject"
+	case "reject", "drop":
+	default:
+		return nil, fmt.Errorf("invalid forwarded metadata policy %q (want reject or drop)", cfg.ForwardedMetadataPolicy)
+	}
+	if cfg.TimeoutOption != "" && !b.timeoutOption.IsValid() {
+		return nil, fmt.Errorf("invalid timeout option %q (want a full name like myapp.v1.timeout)", cfg.TimeoutOption)
+	}
+	if cfg.BreakerThreshold > 0 {
+		cooldown := cfg.BreakerCooldown
+		if cooldown <= 0 {
+			cooldown = 30 * time.Second
+		}
+		b.breakers = newBreakerSet(cfg.BreakerThreshold, cooldown)
+	}
+	if cfg.RateLimit > 0 {
+		window := cfg.RateLimitWindow
+		if window <= 0 {
+			window = time.Minute
+		}
+		b.limiter = newRateLimiter(cfg.RateLimit, window)
+	}
+	if len(cfg.Compression) > 0 {
+		if b.compress, err = newResponseCompressor(cfg.Compression, o.encoders); err != nil {
+			return nil, err
+		}
+	}
+	if b.formPayload == "" {
+		b.formPayload = defaultFormPayload
+	}
+	if b.refusedMessage == "" {
+		b.refusedMessage = defaultRefusedMessage
+	}
+	if cfg.MaxConcurrentRequests > 0 {
+		wait := cfg.QueueWait
+		if wait <= 0 {
+			wait = time.Second
+		}
+		b.inflight = newConcurrencyLimiter(cfg.MaxConcurrentRequests, cfg.QueueDepth, wait)
+	}
+	if cfg.Maintenance != nil {
+		b.maintenance.window = *cfg.Maintenance
+		b.drain.window = cfg.Maintenance
+	}
+	// Only the admin endpoints can read recent requests, so there's no
+	// point keeping them without a token
+	if cfg.RecentRequests > 0 && cfg.AdminToken != "" {
+		b.recent = newRecentRequests(cfg.RecentRequests)
+	}
+	if cfg.AsyncOperations > 0 {
+		b.operations = newOperationStore(cfg.AsyncOperations, cfg.AsyncOperationTTL)
+	}
+	b.maxBatch = cfg.MaxBatchSize
+	b.payloadSampling = payloadSampling{
+		threshold: cfg.PayloadLogThreshold,
+		rate:      cfg.PayloadLogSampleRate,
+		slow:      cfg.SlowRequestThreshold,
+	}
+	if cfg.LogPayloadSizes {
+		b.requestSizes = &histogram{bounds: sizeBuckets}
+		b.responseSizes = &histogram{bounds: sizeBuckets}
+	}
+	if cfg.WatchdogInterval > 0 {
+		b.watchdog = newWatchdog(cfg.WatchdogInterval, cfg.WatchdogTimeout)
+	}
+	if cfg.RetryMax > 0 {
+		jitter, err := parseJitter(cfg.RetryJitter)
+		if err != nil {
+			return nil, err
+		}
+		b.retries = newRetryPolicy(cfg.RetryMax, cfg.RetryBackoff, cfg.RetryMaxBackoff, jitter)
+		for _, m := range cfg.RetryMessages {
+			if strings.TrimSpace(m) == "" {
+				return nil, fmt.Errorf("retry messages can't be blank")
+			}
+			b.retries.messages = append(b.retries.messages, strings.TrimSpace(m))
+		}
+	} else if len(cfg.RetryMessages) > 0 {
+		return nil, fmt.Errorf("retry messages need RetryMax")
+	}
+	var reflectionRetries *retryPolicy
+	if cfg.ReflectionRetries > 0 {
+		reflectionRetries = newRetryPolicy(cfg.ReflectionRetries, cfg.ReflectionRetryBackoff, cfg.RetryMaxBackoff, jitterFull)
+	}
+	for name, mc := range cfg.Methods {
+		if len(mc.Tags) > 0 {
+			b.methodTags[name] = mc.Tags
+		}
+		if mc.HTTPMethod != "" {
+			verb := strings.ToUpper(mc.HTTPMethod)
+			if verb != http.MethodPost && !overridableMethods[verb] {
+				return nil, fmt.Errorf("method %s: unsupported http_method %q", name, mc.HTTPMethod)
+			}
+			b.httpMethods[name] = verb
+		}
+		if mc.Cache != nil {
+			c, err := newResponseCache(mc.Cache)
+			if err != nil {
+				return nil, fmt.Errorf("method %s: %w", name, err)
+			}
+			b.caches[name] = c
+		}
+		if mc.MaxConcurrent < 0 {
+			return nil, fmt.Errorf("method %s: max_concurrent must not be negative", name)
+		} else if mc.MaxConcurrent > 0 {
+			b.methodLimits[name] = newConcurrencyLimiter(mc.MaxConcurrent, 0, 0)
+		}
+		if mc.Audit != nil {
+			b.audits[name] = *mc.Audit
+		}
+		if mc.Removed {
+			b.removed[name] = mc.Replacement
+		} else if mc.Replacement != "" {
+			return nil, fmt.Errorf("method %s: replacement is only used with removed", name)
+		}
+		if mc.ResponseFormat != "" {
+			f, ok := parseFormat(mc.ResponseFormat)
+			if !ok {
+				return nil, fmt.Errorf("method %s: invalid response_format %q (want json, proto or yaml)", name, mc.ResponseFormat)
+			}
+			b.methodFormats[name] = f
+		}
+		if mc.Timeout > 0 {
+			b.methodTimeouts[name] = time.Duration(mc.Timeout)
+		}
+		if mc.FlushMessages > 1 || mc.FlushInterval > 0 {
+			b.flushPolicies[name] = flushPolicy{messages: mc.FlushMessages, interval: time.Duration(mc.FlushInterval)}
+		}
+		sm, err := parseStreamingMode(mc.Streaming)
+		if err != nil {
+			return nil, fmt.Errorf("method %s: %w", name, err)
+		}
+		switch {
+		case mc.BufferStream && sm == streamingStreamed:
+			return nil, fmt.Errorf("method %s: buffer_stream contradicts streaming %q", name, sm)
+		case mc.BufferStream || sm == streamingBuffered:
+			b.streamArrays[name] = mc.StreamField
+		case mc.StreamField != "":
+			return nil, fmt.Errorf("method %s: stream_field is only used with buffered streams", name)
+		}
+		if sm == streamingStreamed {
+			b.unaryStreams[name] = true
+		}
+		if mc.EmptyStream != "" {
+			if b.emptyStreams[name], err = parseEmptyStream(mc.EmptyStream); err != nil {
+				return nil, fmt.Errorf("method %s: %w", name, err)
+			}
+		}
+		if mc.EmptyResponse != "" {
+			if b.emptyResponses[name], err = parseEmptyResponse(mc.EmptyResponse); err != nil {
+				return nil, fmt.Errorf("method %s: %w", name, err)
+			}
+		}
+		ct, err := compileContentTypes(mc)
+		if err != nil {
+			return nil, fmt.Errorf("method %s: %w", name, err)
+		}
+		if ct != nil {
+			b.contentTypes[name] = ct
+		}
+		for _, h := range mc.RequiredHeaders {
+			b.requiredHeaders[name] = append(b.requiredHeaders[name], http.CanonicalHeaderKey(h))
+		}
+		if len(mc.RequestSchema) > 0 {
+			s, err := compileJSONSchema(mc.RequestSchema)
+			if err != nil {
+				return nil, fmt.Errorf("invalid request_schema for %s: %w", name, err)
+			}
+			b.requestSchemas[name] = s
+		}
+		if len(mc.HeaderFields) > 0 {
+			b.headerFields[name] = mc.HeaderFields
+		}
+	}
+	for name, sc := range cfg.Services {
+		if sc.Timeout > 0 {
+			b.serviceTimeouts[name] = time.Duration(sc.Timeout)
+		}
+	}
+	if cfg.DescriptorSet != "" {
+		files, err := loadDescriptorFile(cfg.DescriptorSet)
+		if err != nil {
+			return nil, err
+		}
+		b.registerFiles(files)
+	}
+
+	// Replay mode serves recorded responses and never dials a backend
+	if cfg.ReplayFile != "" {
+		rp, err := loadReplayer(cfg.ReplayFile)
+		if err != nil {
+			return nil, err
+		}
+		b.replayer = rp
+	} else {
+		stats := &callStats{}
+		var conn *grpc.ClientConn
+		if b.startupAttempts > 0 {
+			// Serve's startup probe waits for the backend instead
+			conn, err = grpc.Dial(dialTarget(cfg.GRPCAddr), stats.dialOptions(b.dialOpts)...)
+		} else {
+			conn, err = dialPrimary(cfg.GRPCAddr, stats.dialOptions(b.dialOpts), o.connectTimeout)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to gRPC backend: %w", err)
+		}
+
+		b.backend = newBackend(cfg.GRPCAddr, conn, stats)
+		b.backends[cfg.GRPCAddr] = b.backend
+
+		if err := b.setupRoutes(cfg); err != nil {
+			b.Close()
+			return nil, err
+		}
+		for _, be := range b.backends {
+			be.resolver.retries = reflectionRetries
+		}
+	}
+
+	if cfg.RecordFile != "" {
+		rec, err := newRecorder(cfg.RecordFile)
+		if err != nil {
+			b.Close()
+			return nil, err
+		}
+		b.recorder = rec
+	}
+	if len(b.audits) > 0 {
+		auditor, err := newAuditLog(cfg.AuditLog)
+		if err != nil {
+			b.Close()
+			return nil, err
+		}
+		b.auditor = auditor
+	}
+
+	return b, nil
+}
+
+// dialPrimary connects to the default backend. With a connect timeout, it
+// fails as soon as the first attempt does; otherwise it keeps retrying for
+// up to 5 seconds.
+func dialPrimary(addr string, opts []grpc.DialOption, connectTimeout time.Duration) (*grpc.ClientConn, error) {
+	if connectTimeout <= 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return grpc.DialContext(ctx, dialTarget(addr), append(opts, grpc.WithBlock())...)
+	}
+
+	conn, err := grpc.Dial(dialTarget(addr), opts...)
+	if err != nil {
+		return nil, err
+	}
+	// The attempt itself is bounded by connectTimeout; the context only
+	// guards against it never being scheduled
+	ctx, cancel := context.WithTimeout(context.Background(), 2*connectTimeout)
+	defer cancel()
+	if err := awaitFirstAttempt(ctx, conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// setupRoutes dials the backends named in cfg.Services and starts health
+// checking for services that can fail over.
+func (b *Bridge) setupRoutes(cfg Config) error {
+	var checked []*backend
+	for service, sc := range cfg.Services {
+		route := serviceRoute{primary: b.backend}
+		if sc.Primary != "" {
+			be, err := b.backendAt(sc.Primary)
+			if err != nil {
+				return err
+			}
+			route.primary = be
+		}
+		if len(sc.Backends) > 0 {
+			if sc.Primary != "" {
+				return fmt.Errorf("service %s: primary and backends are mutually exclusive", service)
+			}
+			var backends []*backend
+			var weights []int
+			for _, wb := range sc.Backends {
+				if wb.Weight <= 0 {
+					return fmt.Errorf("service %s: backend %s needs a positive weight", service, wb.Address)
+				}
+				be, err := b.backendAt(wb.Address)
+				if err != nil {
+					return err
+				}
+				backends = append(backends, be)
+				weights = append(weights, wb.Weight)
+			}
+			route.primary = backends[0]
+			route.split = newWeightedSelector(backends, weights)
+		}
+		for version, addr := range sc.Versions {
+			be, err := b.backendAt(addr)
+			if err != nil {
+				return err
+			}
+			if route.versions == nil {
+				route.versions = make(map[string]*backend)
+			}
+			route.versions[version] = be
+			b.versions[version] = true
+		}
+		if (sc.RouteHeader == "") != (len(sc.HeaderRoutes) == 0) {
+			return fmt.Errorf("service %s: route_header and header_routes must be set together", service)
+		}
+		for value, addr := range sc.HeaderRoutes {
+			be, err := b.backendAt(addr)
+			if err != nil {
+				return err
+			}
+			if route.byHeader == nil {
+				route.header = http.CanonicalHeaderKey(sc.RouteHeader)
+				route.byHeader = make(map[string]*backend)
+			}
+			route.byHeader[value] = be
+		}
+		if sc.Secondary != "" {
+			be, err := b.backendAt(sc.Secondary)
+			if err != nil {
+				return err
+			}
+			route.secondary = be
+			checked = append(checked, route.secondary)
+			if route.split != nil {
+				checked = append(checked, route.split.backends...)
+			} else {
+				checked = append(checked, route.primary)
+			}
+		}
+		b.routes[service] = route
+		if sc.BasePath != "" {
+			if err := b.addBasePath(service, sc.BasePath); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(checked) > 0 {
+		interval := cfg.HealthInterval
+		if interval <= 0 {
+			interval = 5 * time.Second
+		}
+		b.health = startHealthChecker(uniqueBackends(checked), interval, b.probe)
+	}
+	return nil
+}
+
+// backendAt returns the backend for addr, dialing it on first use.
+func (b *Bridge) backendAt(addr string) (*backend, error) {
+	if be, ok := b.backends[addr]; ok {
+		return be, nil
+	}
+	be, err := dialBackend(addr, b.dialOpts)
+	if err != nil {
+		return nil, err
+	}
+	b.backends[addr] = be
+	return be, nil
+}
+
+func uniqueBackends(list []*backend) []*backend {
+	seen := make(map[*backend]bool)
+	var out []*backend
+	for _, be := range list {
+		if !seen[be] {
+			seen[be] = true
+			out = append(out, be)
+		}
+	}
+	return out
+}
+
+func (b *Bridge) Close() {
+	if b.health != nil {
+		b.health.Stop()
+	}
+	for _, be := range b.backends {
+		be.conn.Close()
+	}
+	if b.recorder != nil {
+		b.recorder.Close()
+	}
+	if b.auditor != nil {
+		b.auditor.Close()
+	}
+}
+
+// Serve listens on the configured HTTP port and serves Handler, over HTTPS
+// if a TLS certificate is configured. With a startup probe, it first waits
+// for the backends to become ready.
+func (b *Bridge) Serve() error {
+	if b.startupAttempts > 0 {
+		interval := b.startupInterval
+		if interval <= 0 {
+			interval = 2 * time.Second
+		}
+		if err := b.awaitStartup(b.startupAttempts, interval); err != nil {
+			return err
+		}
+	}
+	addr := fmt.Sprintf(":%d", b.httpPort)
+	scheme := "http"
+	if b.tlsConfig != nil {
+		scheme = "https"
+	}
+	log.Printf("✓ Bridge ready - listening on %s", addr)
+	log.Printf("  Example: curl %s://localhost:%d/health", scheme, b.httpPort)
+	log.Printf("  RPC format: curl %s://localhost:%d/{service}/{method} -d '{...}'", scheme, b.httpPort)
+
+	srv, err := b.httpServer(addr)
+	if err != nil {
+		return err
+	}
+	ln, err := b.listen(addr)
+	if err != nil {
+		return err
+	}
+	b.serverMu.Lock()
+	b.server = srv
+	b.serverMu.Unlock()
+	if b.watchdog != nil {
+		b.watchdog.ping = httpPing(ln.Addr(), b.tlsConfig != nil)
+		defer b.watchdog.start()()
+	}
+	if b.tlsConfig == nil {
+		return srv.Serve(ln)
+	}
+	return srv.ServeTLS(ln, b.tlsCertFile, b.tlsKeyFile)
+}
+
+// httpServer returns the server Serve runs on addr. HTTP/2 is only
+// negotiated over TLS, so the ping settings only affect HTTPS.
+func (b *Bridge) httpServer(addr string) (*http.Server, error) {
+	srv := &http.Server{Addr: addr, Handler: b.Handler(), TLSConfig: b.tlsConfig}
+	if b.tlsConfig == nil || b.http2PingInterval <= 0 {
+		return srv, nil
+	}
+	timeout := b.http2PingTimeout
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	err := http2.ConfigureServer(srv, &http2.Server{
+		ReadIdleTimeout: b.http2PingInterval,
+		PingTimeout:     timeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure HTTP/2: %w", err)
+	}
+	return srv, nil
+}
+
+// Handler returns the bridge's HTTP handler, for mounting in an existing
+// server or testing without binding a port.
+func (b *Bridge) Handler() http.Handler {
+	r := chi.NewRouter()
+	r.Use(methodOverride)
+	r.Use(accessLog(b.logOut, b.logFormat, b.logPayloadSizes))
+	r.Use(middleware.Recoverer)
+	if b.compress != nil {
+		r.Use(b.compress.middleware)
+	}
+	r.Use(requestID(b.requestIDs))
+	if b.problemErrors {
+		r.Use(problemDetails)
+	}
+	if b.jsonNewline {
+		r.Use(markJSONNewline)
+	}
+	if b.maxPath > 0 {
+		r.Use(limitPathLength(b.maxPath))
+	}
+	if b.debugHeaders {
+		r.Use(forceDescriptorSource)
+	}
+	r.Use(middleware.Timeout(60 * time.Second))
+	r.Use(principalMiddleware(b.principalMetadata))
+	if b.publicMethods != nil {
+		r.Use(b.requireClientCert)
+	}
+	if b.forwardBearer {
+		r.Use(bearerMiddleware)
+	}
+	if b.callIDs {
+		r.Use(assignCallIDs)
+	}
+	if b.metadataLimit > 0 {
+		r.Use(b.limitMetadata)
+	}
+	if b.logPayloadSizes {
+		r.Use(b.measurePayloadSizes)
+	}
+	if b.slowRequests > 0 {
+		r.Use(logSlowRequests(b.slowRequests))
+	}
+	if b.exemplars {
+		r.Use(traceContext)
+	}
+	if b.tracing {
+		r.Use(extractTraceContext)
+	}
+
+	// Health check
+	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		backends := make(map[string]bool, len(b.backends))
+		for addr, be := range b.backends {
+			backends[addr] = be.Healthy()
+		}
+
+		resp := map[string]interface{}{
+			"status":     "ok",
+			"grpc_addr":  b.grpcAddr,
+			"reflection": b.replayer == nil,
+			"replay":     b.replayer != nil,
+			"backends":   backends,
+			"timestamp":  time.Now().Unix(),
+		}
+		if b.watchdog != nil {
+			if stuck, err := b.watchdog.stuck(); stuck {
+				resp["status"] = "deadlocked"
+				resp["watchdog_error"] = err.Error()
+				b.encodeJSON(w, http.StatusServiceUnavailable, resp)
+				return
+			}
+		}
+		b.encodeJSON(w, http.StatusOK, resp)
+	})
+
+	r.Get("/ready", b.handleReady)
+	r.Get("/version", b.handleVersion)
+	r.Get("/capabilities", b.handleCapabilities)
+	r.Get("/metrics", b.handleMetrics)
+	// Browsers ask for a favicon on every page; answer before it can be
+	// taken for a call to a method of the default service
+	r.Get("/favicon.ico", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	if b.rootIndex {
+		r.Get("/", b.handleIndex)
+	} else {
+		r.Get("/", http.NotFound)
+	}
+
+	// Service listing, optionally filtered: GET /services?filter=myapp.
+	r.Get("/services", b.handleServices)
+	r.Get("/services/{service}/{method}", b.handleMethodSchema)
+	r.Get("/services/{service}/{method}/example", b.handleMethodExample)
+	r.Get("/{service}/{method}/types", b.handleMethodTypes)
+	r.Get("/reflection/{symbol}", b.handleReflection)
+	if b.operations != nil {
+		r.Get("/operations/{id}", b.handleOperation)
+	}
+	// Batched calls are made through r, outside the RPC group, so that a
+	// batch doesn't hold a concurrency slot its calls are waiting for
+	if b.maxBatch > 0 {
+		r.Post("/batch", b.handleBatch(r))
+	}
+
+	// Admin endpoints, only served with an admin token configured
+	if b.adminToken != "" {
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(b.adminAuth)
+			r.Get("/maintenance", b.handleMaintenance)
+			r.Post("/maintenance", b.handleMaintenance)
+			r.Post("/descriptors/refresh", b.handleRefreshDescriptors)
+			r.Post("/warmup", b.handleWarmup)
+		})
+		if b.recent != nil {
+			r.With(b.adminAuth).Get("/debug/requests", b.handleRecentRequests)
+		}
+	}
+
+	// Main RPC handler: POST /{service}/{method}, or a WebSocket upgrade for
+	// bidirectional streams
+	r.Group(func(r chi.Router) {
+		if b.recent != nil {
+			r.Use(b.recent.record)
+		}
+		r.Use(b.drain.track)
+		r.Use(b.maintenance.gate)
+		if b.limiter != nil {
+			r.Use(b.limiter.middleware)
+		}
+		if b.inflight != nil {
+			r.Use(b.inflight.middleware)
+		}
+		r.Use(clientDeadline)
+		if len(b.fanIns) > 0 {
+			r.Get("/fan-in/{name}", b.handleFanIn)
+		}
+		r.Get("/*", b.handleRPC)
+		r.Post("/*", b.handleRPC)
+		r.Put("/*", b.handleRPC)
+		r.Patch("/*", b.handleRPC)
+		r.Delete("/*", b.handleRPC)
+	})
+	r.Options("/*", b.handlePreflight)
+
+	return r
+}
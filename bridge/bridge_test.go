@@ -0,0 +1,102 @@
+package bridge
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+func TestHandlerServesHealthOverHTTP(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr})
+	srv := httptest.NewServer(b.Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var health struct {
+		Status   string          `json:"status"`
+		GRPCAddr string          `json:"grpc_addr"`
+		Backends map[string]bool `json:"backends"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 200 || health.Status != "ok" || health.GRPCAddr != be.addr {
+		t.Fatalf("got %d %+v", resp.StatusCode, health)
+	}
+	if _, ok := health.Backends[be.addr]; !ok {
+		t.Fatalf("backends %v don't list %s", health.Backends, be.addr)
+	}
+
+	// RPCs are served through the same handler
+	resp, err = http.Post(srv.URL+"/test.v1.Echo/Echo", "application/json", strings.NewReader(`{"message": "hi"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 {
+		t.Fatalf("RPC through the mounted handler: got %d", resp.StatusCode)
+	}
+}
+
+func TestServerPingsIdleHTTP2Clients(t *testing.T) {
+	be := startBackend(t)
+	certFile, keyFile := writeCert(t)
+	b := newTestBridge(t, Config{
+		GRPCAddr:          be.addr,
+		TLSCertFile:       certFile,
+		TLSKeyFile:        keyFile,
+		HTTP2PingInterval: 50 * time.Millisecond,
+		HTTP2PingTimeout:  time.Second,
+	})
+	srv, err := b.httpServer("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.ServeTLS(ln, certFile, keyFile)
+	defer srv.Close()
+
+	conn, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{InsecureSkipVerify: true, NextProtos: []string{"h2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if p := conn.ConnectionState().NegotiatedProtocol; p != "h2" {
+		t.Fatalf("negotiated %q, want h2", p)
+	}
+	if _, err := io.WriteString(conn, http2.ClientPreface); err != nil {
+		t.Fatal(err)
+	}
+	fr := http2.NewFramer(conn, conn)
+	if err := fr.WriteSettings(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The client then stays idle
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		f, err := fr.ReadFrame()
+		if err != nil {
+			t.Fatalf("no ping from the server: %v", err)
+		}
+		if ping, ok := f.(*http2.PingFrame); ok && !ping.IsAck() {
+			return
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package bridge
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultCacheEntries is how many responses a method's cache holds unless
+// max_entries says otherwise.
+const defaultCacheEntries = 1000
+
+// responseCache holds one method's successful unary responses for ttl.
+// Requests share an entry when they have the same path, body and JSON
+// options and the same values of the vary headers.
+type responseCache struct {
+	ttl  time.Duration
+	vary []string
+	max  int
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	// order holds keys oldest first, for eviction
+	order []string
+}
+
+type cacheEntry struct {
+	body    []byte
+	expires time.Time
+}
+
+func newResponseCache(cc *CacheConfig) (*responseCache, error) {
+	if cc.TTL <= 0 {
+		return nil, fmt.Errorf("cache needs a positive ttl")
+	}
+	c := &responseCache{
+		ttl:     time.Duration(cc.TTL),
+		max:     cc.MaxEntries,
+		entries: make(map[string]cacheEntry),
+	}
+	if c.max <= 0 {
+		c.max = defaultCacheEntries
+	}
+	for _, h := range cc.Vary {
+		c.vary = append(c.vary, http.CanonicalHeaderKey(h))
+	}
+	return c, nil
+}
+
+// key identifies the response to r, whose body after header fields and
+// transforms is body.
+func (c *responseCache) key(r *http.Request, enc jsonEncoding, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%+v\x00", r.URL.Path, enc)
+	h.Write(body)
+	for _, name := range c.vary {
+		// Separate repeated headers with a byte header values can't hold,
+		// so that they don't key like one comma-joined header
+		values := r.Header.Values(name)
+		fmt.Fprintf(h, "\x00%s=%d", name, len(values))
+		for _, v := range values {
+			fmt.Fprintf(h, "\x00%s", v)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// lookup returns the cached response for key, if there is a fresh one, and
+// reports the outcome in X-Cache. The vary headers are listed in Vary so
+// that HTTP caches in front of the bridge key on them too.
+func (c *responseCache) lookup(h http.Header, key string) ([]byte, bool) {
+	for _, name := range c.vary {
+		h.Add("Vary", name)
+	}
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	c.mu.Unlock()
+	if !ok || time.Now().After(e.expires) {
+		h.Set("X-Cache", "MISS")
+		return nil, false
+	}
+	h.Set("X-Cache", "HIT")
+	return e.body, true
+}
+
+// store caches body under key, making room by dropping expired entries and
+// then the oldest ones.
+func (c *responseCache) store(key string, body []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.max {
+		kept := c.order[:0]
+		for _, k := range c.order {
+			if now.After(c.entries[k].expires) {
+				delete(c.entries, k)
+				continue
+			}
+			kept = append(kept, k)
+		}
+		c.order = kept
+		for len(c.entries) >= c.max {
+			delete(c.entries, c.order[0])
+			c.order = c.order[1:]
+		}
+	}
+	if _, ok := c.entries[key]; !ok {
+		c.order = append(c.order, key)
+	}
+	c.entries[key] = cacheEntry{body: body, expires: now.Add(c.ttl)}
+}
@@ -0,0 +1,53 @@
+package bridge
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCacheKeysOnVaryHeaders(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{
+		GRPCAddr: be.addr,
+		Methods: map[string]MethodConfig{
+			"test.v1.Echo/Echo": {Cache: &CacheConfig{TTL: Duration(time.Minute), Vary: []string{"accept-language"}}},
+		},
+	})
+	call := func(languages ...string) string {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", strings.NewReader(`{"message": "hi"}`))
+		req.Header.Set("Content-Type", "application/json")
+		for _, l := range languages {
+			req.Header.Add("Accept-Language", l)
+		}
+		rec := serve(b.Handler(), req)
+		if rec.Code != 200 {
+			t.Fatalf("got %d %s", rec.Code, rec.Body)
+		}
+		return rec.Header().Get("X-Cache")
+	}
+
+	if got := call("en"); got != "MISS" {
+		t.Fatalf("first en request: X-Cache %s, want MISS", got)
+	}
+	if got := call("fr"); got != "MISS" {
+		t.Fatalf("fr request: X-Cache %s, want MISS", got)
+	}
+	if got := call("en"); got != "HIT" {
+		t.Fatalf("second en request: X-Cache %s, want HIT", got)
+	}
+	if n := be.Calls(); n != 2 {
+		t.Fatalf("backend answered %d calls, want 2", n)
+	}
+
+	// Two headers aren't the same key as one header holding both values
+	if got := call("en,fr"); got != "MISS" {
+		t.Fatalf("joined header: X-Cache %s, want MISS", got)
+	}
+	if got := call("en", "fr"); got != "MISS" {
+		t.Fatalf("repeated header: X-Cache %s, want MISS", got)
+	}
+}
@@ -0,0 +1,73 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// callIDHeader returns a request's gRPC call ID to the client.
+	callIDHeader = "X-Grpc-Call-Id"
+	// callIDMetadata and requestIDMetadata carry a call's ID and its
+	// request's ID to the backend.
+	callIDMetadata    = "x-call-id"
+	requestIDMetadata = "x-request-id"
+)
+
+type callIDKey struct{}
+
+// callIDSeq numbers the gRPC calls made for one request. The first call's
+// ID is base; later ones, such as retries, a call made again after its
+// method was re-resolved or the sources of a fan-in, are base.2, base.3
+// and so on.
+type callIDSeq struct {
+	base  string
+	calls atomic.Int32
+}
+
+func (s *callIDSeq) next() string {
+	if n := s.calls.Add(1); n > 1 {
+		return fmt.Sprintf("%s.%d", s.base, n)
+	}
+	return s.base
+}
+
+// assignCallIDs gives each request a gRPC call ID, which the bridge
+// generates since request IDs may come from clients and needn't be
+// unique, and returns it in the X-Grpc-Call-Id response header.
+func assignCallIDs(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seq := &callIDSeq{base: newRequestID(requestIDShort)}
+		w.Header().Set(callIDHeader, seq.base)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), callIDKey{}, seq)))
+	})
+}
+
+// withCallID sends the next call ID of the request ctx belongs to, along
+// with the request's ID, as metadata of the call to method, and logs the
+// two together. Reflection lookups aren't numbered.
+func withCallID(ctx context.Context, method string) context.Context {
+	seq, ok := ctx.Value(callIDKey{}).(*callIDSeq)
+	if !ok || strings.HasPrefix(method, "/grpc.reflection.") {
+		return ctx
+	}
+	id, reqID := seq.next(), middleware.GetReqID(ctx)
+	log.Printf("→ Call %s for request %s: %s", id, reqID, method)
+	return metadata.AppendToOutgoingContext(ctx, callIDMetadata, id, requestIDMetadata, reqID)
+}
+
+func callIDUnary(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(withCallID(ctx, method), method, req, reply, cc, opts...)
+}
+
+func callIDStream(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(withCallID(ctx, method), desc, cc, method, opts...)
+}
@@ -0,0 +1,61 @@
+package bridge
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mizrahidaniel/grpc-http-bridge/bridgetest"
+)
+
+func TestCallIDCorrelatesWithRequestID(t *testing.T) {
+	be := startBackend(t)
+	echoMetadata(be, callIDMetadata)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, CallIDs: true})
+
+	logged := captureLog(t)
+	req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-Id", "req-1")
+	rec := serve(b.Handler(), req)
+	if rec.Code != 200 {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+	callID := rec.Header().Get(callIDHeader)
+	if callID == "" {
+		t.Fatalf("no %s header", callIDHeader)
+	}
+	var resp struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Message != callID {
+		t.Fatalf("backend got call ID %q, want the header's %q", resp.Message, callID)
+	}
+	if want := "→ Call " + callID + " for request req-1: /test.v1.Echo/Echo"; !strings.Contains(logged(), want) {
+		t.Fatalf("log doesn't contain %q", want)
+	}
+}
+
+func TestRetriesGetNumberedCallIDs(t *testing.T) {
+	be := bridgetest.Start(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.Addr, CallIDs: true, RetryMax: 1, RetryBackoff: time.Millisecond})
+
+	logged := captureLog(t)
+	rec := post(b.Handler(), "/bridgetest.v1.Greeter/Fail", `{"code": 14, "message": "down"}`)
+	callID := rec.Header().Get(callIDHeader)
+	out := logged()
+	for _, id := range []string{callID, callID + ".2"} {
+		if !strings.Contains(out, "→ Call "+id+" for request ") {
+			t.Errorf("no call %s logged", id)
+		}
+	}
+	if strings.Contains(out, "→ Call "+callID+".3") {
+		t.Error("a single retry was logged as two")
+	}
+}
@@ -0,0 +1,68 @@
+package bridge
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+)
+
+// callStats counts the calls made over a backend connection and those
+// that failed, for /metrics. Reflection and health checks are the
+// bridge's own traffic and aren't counted.
+type callStats struct {
+	requests atomic.Uint64
+	errors   atomic.Uint64
+}
+
+// dialOptions returns opts with the interceptors that count calls into s.
+func (s *callStats) dialOptions(opts []grpc.DialOption) []grpc.DialOption {
+	return append(opts[:len(opts):len(opts)],
+		grpc.WithChainUnaryInterceptor(s.unary),
+		grpc.WithChainStreamInterceptor(s.stream))
+}
+
+func internalMethod(method string) bool {
+	return strings.HasPrefix(method, "/grpc.reflection.") || strings.HasPrefix(method, "/grpc.health.")
+}
+
+func (s *callStats) unary(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	if !internalMethod(method) {
+		s.requests.Add(1)
+		if err != nil {
+			s.errors.Add(1)
+		}
+	}
+	return err
+}
+
+func (s *callStats) stream(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	if internalMethod(method) {
+		return stream, err
+	}
+	s.requests.Add(1)
+	if err != nil {
+		s.errors.Add(1)
+		return nil, err
+	}
+	return &countedStream{ClientStream: stream, stats: s}, nil
+}
+
+// countedStream counts its stream as failed if it ends with an error.
+type countedStream struct {
+	grpc.ClientStream
+	stats  *callStats
+	failed atomic.Bool
+}
+
+func (s *countedStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil && err != io.EOF && s.failed.CompareAndSwap(false, true) {
+		s.stats.errors.Add(1)
+	}
+	return err
+}
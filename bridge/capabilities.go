@@ -0,0 +1,125 @@
+package bridge
+
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+// capabilities is the GET /capabilities response, what the bridge's
+// configuration enables, for clients and tooling that adapt to it.
+type capabilities struct {
+	Version     string                `json:"version"`
+	Streaming   streamingCapabilities `json:"streaming"`
+	Auth        authCapabilities      `json:"auth"`
+	Compression []string              `json:"compression"`
+	// Codecs are the request and response encodings by media type
+	Codecs      codecCapabilities      `json:"codecs"`
+	CORS        bool                   `json:"cors"`
+	Descriptors descriptorCapabilities `json:"descriptors"`
+	// ErrorFormat is json or problem, as set with Config.ErrorFormat
+	ErrorFormat string `json:"error_format"`
+	// Batch is the largest POST /batch, or 0 when it is disabled
+	Batch      int  `json:"batch"`
+	Operations bool `json:"operations"`
+	Admin      bool `json:"admin"`
+}
+
+type streamingCapabilities struct {
+	// Server lists the encodings server streams can be read in
+	Server []string `json:"server"`
+	Client bool     `json:"client"`
+	// Bidi lists the transports bidirectional streams are served over
+	Bidi    []string `json:"bidi"`
+	GRPCWeb bool     `json:"grpc_web"`
+	// Resumes is how many times a server stream the backend drops is
+	// resumed, or 0
+	Resumes   int    `json:"resumes"`
+	EndFrames bool   `json:"end_frames"`
+	Empty     string `json:"empty"`
+}
+
+type authCapabilities struct {
+	// ClientCertificates is required, optional or none
+	ClientCertificates string `json:"client_certificates"`
+	BearerForwarding   bool   `json:"bearer_forwarding"`
+	PrincipalMetadata  string `json:"principal_metadata,omitempty"`
+}
+
+type codecCapabilities struct {
+	Request  []string `json:"request"`
+	Response []string `json:"response"`
+}
+
+type descriptorCapabilities struct {
+	// Reflection is whether descriptors are fetched from backends with
+	// server reflection
+	Reflection bool `json:"reflection"`
+	// Static is whether a descriptor set is loaded
+	Static bool `json:"static"`
+	Replay bool `json:"replay"`
+}
+
+// handleCapabilities serves GET /capabilities. The document is derived from
+// the bridge's configuration, so it describes what any method may use;
+// per-method settings such as allowed content types can narrow it.
+func (b *Bridge) handleCapabilities(w http.ResponseWriter, r *http.Request) {
+	c := capabilities{
+		Version: Version,
+		Streaming: streamingCapabilities{
+			Server:    []string{"ndjson", "array"},
+			Client:    true,
+			Bidi:      []string{"websocket"},
+			GRPCWeb:   true,
+			Resumes:   b.streamResumes,
+			EndFrames: b.streamEnds,
+			Empty:     string(b.emptyStream),
+		},
+		Auth: authCapabilities{
+			ClientCertificates: "none",
+			BearerForwarding:   b.forwardBearer,
+			PrincipalMetadata:  b.principalMetadata,
+		},
+		Compression: []string{},
+		Codecs: codecCapabilities{
+			Request: []string{
+				"application/json",
+				"application/x-protobuf",
+				"application/x-www-form-urlencoded",
+				"multipart/form-data",
+				"application/grpc-web",
+				"application/grpc-web-text",
+			},
+			Response: []string{
+				formatJSON.mediaType(),
+				formatProto.mediaType(),
+				formatYAML.mediaType(),
+			},
+		},
+		CORS: len(b.cors) > 0,
+		Descriptors: descriptorCapabilities{
+			Reflection: b.replayer == nil,
+			Static:     b.descriptorFiles != nil,
+			Replay:     b.replayer != nil,
+		},
+		ErrorFormat: "json",
+		Batch:       b.maxBatch,
+		Operations:  b.operations != nil,
+		Admin:       b.adminToken != "",
+	}
+	if c.Streaming.Empty == "" {
+		c.Streaming.Empty = "default"
+	}
+	if b.problemErrors {
+		c.ErrorFormat = "problem"
+	}
+	if b.compress != nil {
+		c.Compression = b.compress.preference
+	}
+	if b.tlsConfig != nil && b.tlsConfig.ClientCAs != nil {
+		c.Auth.ClientCertificates = "optional"
+		if b.tlsConfig.ClientAuth == tls.RequireAndVerifyClientCert || b.publicMethods != nil {
+			c.Auth.ClientCertificates = "required"
+		}
+	}
+	b.encodeJSON(w, http.StatusOK, c)
+}
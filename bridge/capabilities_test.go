@@ -0,0 +1,53 @@
+package bridge
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestCapabilitiesFollowConfig(t *testing.T) {
+	be := startBackend(t)
+	get := func(cfg Config) capabilities {
+		t.Helper()
+		cfg.GRPCAddr = be.addr
+		b := newTestBridge(t, cfg)
+		rec := serve(b.Handler(), httptest.NewRequest(http.MethodGet, "/capabilities", nil))
+		if rec.Code != 200 {
+			t.Fatalf("got %d %s", rec.Code, rec.Body)
+		}
+		var c capabilities
+		if err := json.Unmarshal(rec.Body.Bytes(), &c); err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	base := get(Config{})
+	if base.Admin || base.Batch != 0 || base.Streaming.EndFrames || base.ErrorFormat != "json" || len(base.Compression) != 0 || !base.Descriptors.Reflection {
+		t.Fatalf("default capabilities %+v", base)
+	}
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want func(c *capabilities)
+	}{
+		{"admin", Config{AdminToken: "secret"}, func(c *capabilities) { c.Admin = true }},
+		{"batch", Config{MaxBatchSize: 10}, func(c *capabilities) { c.Batch = 10 }},
+		{"end frames", Config{StreamEndFrames: true}, func(c *capabilities) { c.Streaming.EndFrames = true }},
+		{"empty stream", Config{EmptyStream: "no-content"}, func(c *capabilities) { c.Streaming.Empty = "no-content" }},
+		{"problem errors", Config{ErrorFormat: "problem"}, func(c *capabilities) { c.ErrorFormat = "problem" }},
+		{"compression", Config{Compression: []string{"gzip"}}, func(c *capabilities) { c.Compression = []string{"gzip"} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := base
+			tt.want(&want)
+			if got := get(tt.cfg); !reflect.DeepEqual(got, want) {
+				t.Fatalf("got %+v, want %+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package bridge
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// canonicalMethod maps service and method, named in any case, to the names
+// they are declared under, so routing and per-method config keyed by the
+// declared names apply. Names that can't be resolved are returned as they
+// are and fail later with the usual NotFound.
+func (b *Bridge) canonicalMethod(ctx context.Context, version, service, method string) (string, string) {
+	if _, ok := b.routes[service]; !ok {
+		for name := range b.routes {
+			if strings.EqualFold(name, service) {
+				service = name
+				break
+			}
+		}
+	}
+
+	if _, ok := b.registeredMethod(service, method); ok {
+		return service, method
+	}
+	for _, md := range b.registeredMethods() {
+		if strings.EqualFold(string(md.Parent().FullName()), service) && strings.EqualFold(string(md.Name()), method) {
+			return string(md.Parent().FullName()), string(md.Name())
+		}
+	}
+
+	be, err := b.backendForVersion(service, version)
+	if err != nil {
+		return service, method
+	}
+	sd, err := be.resolver.findServiceFold(ctx, service)
+	if err != nil {
+		return service, method
+	}
+	service = string(sd.FullName())
+	if sd.Methods().ByName(protoreflect.Name(method)) != nil {
+		return service, method
+	}
+	methods := sd.Methods()
+	for i := 0; i < methods.Len(); i++ {
+		if name := string(methods.Get(i).Name()); strings.EqualFold(name, method) {
+			return service, name
+		}
+	}
+	return service, method
+}
+
+// findServiceFold is FindService, falling back to a case-insensitive match
+// against the services the backend lists.
+func (r *resolver) findServiceFold(ctx context.Context, service string) (protoreflect.ServiceDescriptor, error) {
+	sd, err := r.FindService(ctx, service)
+	if status.Code(err) != codes.NotFound {
+		return sd, err
+	}
+
+	r.mu.RLock()
+	canonical, ok := r.folded[strings.ToLower(service)]
+	r.mu.RUnlock()
+	if !ok {
+		names, listErr := r.ListServices(ctx)
+		if listErr != nil {
+			return nil, listErr
+		}
+		for _, name := range names {
+			if strings.EqualFold(name, service) {
+				canonical, ok = name, true
+				break
+			}
+		}
+		if !ok {
+			return nil, err
+		}
+		r.mu.Lock()
+		r.folded[strings.ToLower(service)] = canonical
+		r.mu.Unlock()
+	}
+	return r.FindService(ctx, canonical)
+}
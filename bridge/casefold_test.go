@@ -0,0 +1,32 @@
+package bridge
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestCaseInsensitiveResolution(t *testing.T) {
+	be := startBackend(t)
+	var called string
+	be.onUnary(func(ctx context.Context, req *dynamicpb.Message) (proto.Message, error) {
+		called, _ = grpc.Method(ctx)
+		return req, nil
+	})
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, CaseInsensitive: true})
+	rec := post(b.Handler(), "/test.v1.echo/ECHO", `{"message": "hi"}`)
+	if rec.Code != 200 || be.Calls() != 1 {
+		t.Fatalf("got %d %s with %d backend calls, want 200 from the backend", rec.Code, rec.Body, be.Calls())
+	}
+	if called != "/test.v1.Echo/Echo" {
+		t.Fatalf("backend was called as %q, want /test.v1.Echo/Echo", called)
+	}
+
+	exact := newTestBridge(t, Config{GRPCAddr: be.addr})
+	if rec := post(exact.Handler(), "/test.v1.echo/ECHO", `{"message": "hi"}`); rec.Code != 404 {
+		t.Fatalf("without CaseInsensitive: got %d, want 404", rec.Code)
+	}
+}
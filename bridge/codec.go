@@ -0,0 +1,93 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// jsonEncoding controls which unpopulated fields responses include.
+type jsonEncoding struct {
+	// emitUnpopulated includes fields with default values
+	emitUnpopulated bool
+	// omitEmptyRepeated leaves out empty repeated and map fields even when
+	// emitUnpopulated is set
+	omitEmptyRepeated bool
+	// deterministic lays responses out with fixed whitespace. protojson
+	// randomly varies its whitespace between builds so that nobody relies
+	// on it; fields are already in field number order and map keys sorted.
+	deterministic bool
+	// compact renders unary responses on one line instead of indented
+	compact bool
+}
+
+// setHeader reports the options responses are marshaled with in
+// X-Json-Options. Field names are always JSON names and enums always
+// names, which it states too so the header is self-explanatory.
+func (e jsonEncoding) setHeader(h http.Header) {
+	h.Set("X-Json-Options", fmt.Sprintf(
+		"emit-unpopulated=%t, omit-empty-repeated=%t, use-proto-names=false, enum-numbers=false, deterministic=%t",
+		e.emitUnpopulated, e.emitUnpopulated && e.omitEmptyRepeated, e.deterministic))
+}
+
+// Helper: convert protobuf Message to indented JSON
+func messageToJSON(msg proto.Message, enc jsonEncoding, types *typeResolver) ([]byte, error) {
+	if enc.compact {
+		return enc.marshal(msg, "", types)
+	}
+	return enc.marshal(msg, "  ", types)
+}
+
+// marshal renders msg as JSON, indented by indent if it is non-empty.
+func (e jsonEncoding) marshal(msg proto.Message, indent string, types *typeResolver) ([]byte, error) {
+	prune := e.emitUnpopulated && e.omitEmptyRepeated
+	reformat := prune || e.deterministic
+	marshaler := protojson.MarshalOptions{
+		EmitUnpopulated: e.emitUnpopulated,
+		Resolver:        types,
+	}
+	if !reformat {
+		marshaler.Indent = indent
+	}
+	out, err := marshaler.Marshal(msg)
+	if err != nil {
+		return nil, types.marshalError(msg.ProtoReflect(), err)
+	}
+	if !reformat {
+		return out, nil
+	}
+
+	if prune {
+		out, err = dropEmptyRepeated(out, msg.ProtoReflect().Descriptor())
+		if err != nil {
+			return nil, withCause(status.Errorf(codes.Internal, "failed to omit empty repeated fields: %v", err), err)
+		}
+	}
+	var buf bytes.Buffer
+	if indent != "" {
+		err = json.Indent(&buf, out, "", indent)
+	} else {
+		err = json.Compact(&buf, out)
+	}
+	if err != nil {
+		return nil, withCause(status.Errorf(codes.Internal, "failed to format response: %v", err), err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Helper: convert JSON to protobuf Message
+func jsonToMessage(data []byte, msgDesc protoreflect.MessageDescriptor, types *typeResolver) (*dynamicpb.Message, error) {
+	msg := dynamicpb.NewMessage(msgDesc)
+	if err := (protojson.UnmarshalOptions{Resolver: types}).Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
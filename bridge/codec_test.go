@@ -0,0 +1,82 @@
+package bridge
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEmptyRepeatedFieldRendering(t *testing.T) {
+	be := startBackend(t)
+	for _, tt := range []struct {
+		name      string
+		cfg       Config
+		wantTags  bool
+		wantCount bool
+	}{
+		{"default", Config{}, true, true},
+		{"omit empty repeated", Config{OmitEmptyRepeated: true}, false, true},
+		{"omit unpopulated", Config{OmitUnpopulated: true}, false, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.GRPCAddr = be.addr
+			rec := post(newTestBridge(t, cfg).Handler(), "/test.v1.Echo/Echo", `{"message": "hi"}`)
+			var resp map[string]json.RawMessage
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); rec.Code != 200 || err != nil {
+				t.Fatalf("got %d %s", rec.Code, rec.Body)
+			}
+			tags, hasTags := resp["tags"]
+			if hasTags != tt.wantTags || hasTags && string(tags) != "[]" {
+				t.Fatalf("tags rendered as %q (present %v), want present %v as []", tags, hasTags, tt.wantTags)
+			}
+			if _, hasCount := resp["count"]; hasCount != tt.wantCount {
+				t.Fatalf("count present %v, want %v", hasCount, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestDeterministicJSONIsByteStable(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, DeterministicJSON: true, OmitUnpopulated: true})
+	h := b.Handler()
+
+	// protojson varies its whitespace from build to build, so only fixed
+	// formatting matches these byte for byte
+	body := `{"data": {"b": 1, "a": {"z": true, "y": null}}, "tags": ["x"], "message": "hi"}`
+	want := "{\n  \"message\": \"hi\",\n  \"tags\": [\n    \"x\"\n  ],\n  \"data\": {\n    \"a\": {\n      \"y\": null,\n      \"z\": true\n    },\n    \"b\": 1\n  }\n}"
+	for i := 0; i < 3; i++ {
+		if rec := post(h, "/test.v1.Echo/Echo", body); rec.Code != 200 || rec.Body.String() != want {
+			t.Fatalf("got %d %q, want %q", rec.Code, rec.Body, want)
+		}
+	}
+
+	rec := post(h, "/test.v1.Echo/ServerStream", `{"message": "hi", "count": 1, "data": {"b": 1, "a": 2}}`)
+	if want := `{"message":"hi","count":1,"data":{"a":2,"b":1}}` + "\n"; rec.Body.String() != want {
+		t.Fatalf("stream line %q, want %q", rec.Body, want)
+	}
+}
+
+func TestJSONOptionsHeader(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, DebugHeaders: true, OmitEmptyRepeated: true})
+	h := b.Handler()
+	for _, tt := range []struct {
+		path string
+		want string
+	}{
+		{"/test.v1.Echo/Echo", "emit-unpopulated=true, omit-empty-repeated=true, use-proto-names=false, enum-numbers=false, deterministic=false"},
+		{"/test.v1.Echo/Echo?emit_unpopulated=false", "emit-unpopulated=false, omit-empty-repeated=false, use-proto-names=false, enum-numbers=false, deterministic=false"},
+		{"/test.v1.Echo/ServerStream", "emit-unpopulated=true, omit-empty-repeated=true, use-proto-names=false, enum-numbers=false, deterministic=false"},
+	} {
+		rec := post(h, tt.path, `{"count": 1}`)
+		if got := rec.Header().Get("X-Json-Options"); rec.Code != 200 || got != tt.want {
+			t.Errorf("%s: got %d with X-Json-Options %q, want %q", tt.path, rec.Code, got, tt.want)
+		}
+	}
+
+	plain := newTestBridge(t, Config{GRPCAddr: be.addr})
+	if got := post(plain.Handler(), "/test.v1.Echo/Echo", `{}`).Header().Get("X-Json-Options"); got != "" {
+		t.Fatalf("without debug headers: X-Json-Options %q, want none", got)
+	}
+}
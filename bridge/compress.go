@@ -0,0 +1,148 @@
+package bridge
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/klauspost/compress/zstd"
+)
+
+// compressionLevel is the level encoders are created with, a balance of
+// speed and ratio for API responses.
+const compressionLevel = 5
+
+// compressibleTypes are the response content types that are compressed.
+var compressibleTypes = []string{
+	"application/json",
+	"application/x-ndjson",
+	"application/yaml",
+	"application/x-protobuf",
+	"application/typescript",
+	"text/plain",
+}
+
+// builtinEncoders are the encodings available without
+// WithCompressionEncoder.
+var builtinEncoders = map[string]func(w io.Writer, level int) io.Writer{
+	"gzip": func(w io.Writer, level int) io.Writer {
+		gw, err := gzip.NewWriterLevel(w, level)
+		if err != nil {
+			return nil
+		}
+		return gw
+	},
+	"deflate": func(w io.Writer, level int) io.Writer {
+		fw, err := flate.NewWriter(w, level)
+		if err != nil {
+			return nil
+		}
+		return fw
+	},
+	"br": func(w io.Writer, level int) io.Writer {
+		return brotli.NewWriterLevel(w, level)
+	},
+	"zstd": func(w io.Writer, level int) io.Writer {
+		// One goroutine per response: responses are compressed as they
+		// stream, not in parallel blocks
+		zw, err := zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)), zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			return nil
+		}
+		return zw
+	},
+}
+
+// WithCompressionEncoder makes encoding available to Config.Compression,
+// or replaces the built-in gzip, deflate, br or zstd encoder, with fn
+// wrapping a response writer in a streaming encoder at the given level.
+// fn may return nil on failure.
+func WithCompressionEncoder(encoding string, fn func(w io.Writer, level int) io.Writer) Option {
+	return func(o *options) {
+		if o.encoders == nil {
+			o.encoders = make(map[string]func(io.Writer, int) io.Writer)
+		}
+		o.encoders[strings.ToLower(encoding)] = fn
+	}
+}
+
+// responseCompressor compresses responses with the first encoding in
+// preference that the client accepts.
+type responseCompressor struct {
+	preference []string
+	compressor *middleware.Compressor
+}
+
+func newResponseCompressor(preference []string, encoders map[string]func(io.Writer, int) io.Writer) (*responseCompressor, error) {
+	c := &responseCompressor{compressor: middleware.NewCompressor(compressionLevel, compressibleTypes...)}
+	for _, name := range preference {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := encoders[name]; !ok && builtinEncoders[name] == nil {
+			return nil, fmt.Errorf("no encoder for compression %q (gzip, deflate, br and zstd are built in; others need WithCompressionEncoder)", name)
+		}
+		c.preference = append(c.preference, name)
+	}
+	// The compressor prefers the encoding set last
+	for i := len(c.preference) - 1; i >= 0; i-- {
+		name := c.preference[i]
+		fn, ok := encoders[name]
+		if !ok {
+			fn = builtinEncoders[name]
+		}
+		c.compressor.SetEncoder(name, middleware.EncoderFunc(fn))
+	}
+	return c, nil
+}
+
+// middleware compresses responses. The compressor picks encodings in its
+// own order and ignores q-values, so Accept-Encoding is first narrowed to
+// the configured encodings the client accepts.
+func (c *responseCompressor) middleware(next http.Handler) http.Handler {
+	compress := c.compressor.Handler(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := encodingQualities(r.Header.Values("Accept-Encoding"))
+		var allowed []string
+		for _, name := range c.preference {
+			if v, ok := q[name]; ok && v > 0 || !ok && q["*"] > 0 {
+				allowed = append(allowed, name)
+			}
+		}
+		if len(allowed) == 0 {
+			r.Header.Del("Accept-Encoding")
+			w.Header().Add("Vary", "Accept-Encoding")
+			next.ServeHTTP(w, r)
+			return
+		}
+		r.Header.Set("Accept-Encoding", strings.Join(allowed, ", "))
+		compress.ServeHTTP(w, r)
+	})
+}
+
+// encodingQualities parses Accept-Encoding headers into the q-value of
+// each encoding named, "*" included.
+func encodingQualities(headers []string) map[string]float64 {
+	q := make(map[string]float64)
+	for _, h := range headers {
+		for _, part := range strings.Split(h, ",") {
+			name, params, err := mime.ParseMediaType("x/" + strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+			v := 1.0
+			if s, ok := params["q"]; ok {
+				if v, err = strconv.ParseFloat(s, 64); err != nil {
+					continue
+				}
+			}
+			q[strings.TrimPrefix(name, "x/")] = v
+		}
+	}
+	return q
+}
@@ -0,0 +1,70 @@
+package bridge
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestCompressionNegotiatesPreferredEncoding(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, Compression: []string{"br", "zstd", "gzip"}})
+	h := b.Handler()
+
+	tests := []struct{ accept, want string }{
+		{"gzip, br", "br"},
+		{"br", "br"},
+		{"zstd, gzip", "zstd"},
+		{"gzip", "gzip"},
+		{"br;q=0, gzip", "gzip"},
+		{"*", "br"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", strings.NewReader(`{"message": "`+strings.Repeat("compress me ", 50)+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Encoding", tt.accept)
+		rec := serve(h, req)
+		if got := rec.Header().Get("Content-Encoding"); rec.Code != 200 || got != tt.want {
+			t.Fatalf("Accept-Encoding %q: got %d with Content-Encoding %q, want 200 with %q", tt.accept, rec.Code, got, tt.want)
+		}
+		var body io.Reader = rec.Body
+		switch tt.want {
+		case "br":
+			body = brotli.NewReader(body)
+		case "zstd":
+			zr, err := zstd.NewReader(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer zr.Close()
+			body = zr
+		case "gzip":
+			gr, err := gzip.NewReader(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body = gr
+		}
+		var resp struct{ Message string }
+		if err := json.NewDecoder(body).Decode(&resp); err != nil || !strings.HasPrefix(resp.Message, "compress me") {
+			t.Fatalf("Accept-Encoding %q: body didn't decode to the response: %v", tt.accept, err)
+		}
+	}
+}
+
+func TestCompressionRejectsUnknownEncoding(t *testing.T) {
+	be := startBackend(t)
+	if _, err := NewBridge(Config{GRPCAddr: be.addr, Compression: []string{"lz4"}}); err == nil {
+		t.Fatal("an encoding without an encoder was accepted")
+	}
+	newTestBridge(t, Config{GRPCAddr: be.addr, Compression: []string{"lz4"}},
+		WithCompressionEncoder("lz4", func(w io.Writer, level int) io.Writer { return w }))
+}
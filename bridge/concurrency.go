@@ -0,0 +1,75 @@
+package bridge
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// concurrencyLimiter bounds how many RPC requests are served at once.
+// Requests over the limit wait in a queue of up to depth requests for at
+// most wait, and are shed once the queue is full or their wait is over.
+type concurrencyLimiter struct {
+	slots chan struct{}
+	queue chan struct{}
+	wait  time.Duration
+}
+
+func newConcurrencyLimiter(limit, depth int, wait time.Duration) *concurrencyLimiter {
+	return &concurrencyLimiter{
+		slots: make(chan struct{}, limit),
+		queue: make(chan struct{}, depth),
+		wait:  wait,
+	}
+}
+
+// acquire takes a slot, queueing for one if they are all in use. It fails
+// with Unavailable if the request can't be served in time.
+func (l *concurrencyLimiter) acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if cap(l.queue) == 0 {
+		return withErrorCode(status.Errorf(codes.Unavailable, "too many requests in flight (%d)", cap(l.slots)), errorBridgeOverloaded)
+	}
+	select {
+	case l.queue <- struct{}{}:
+		defer func() { <-l.queue }()
+	default:
+		return withErrorCode(status.Errorf(codes.Unavailable, "too many requests in flight (%d) and queued (%d)", cap(l.slots), cap(l.queue)), errorBridgeOverloaded)
+	}
+	timer := time.NewTimer(l.wait)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return withErrorCode(status.Errorf(codes.Unavailable, "no capacity to serve the request within %s", l.wait), errorBridgeOverloaded)
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+func (l *concurrencyLimiter) release() {
+	<-l.slots
+}
+
+// middleware holds a slot for the duration of each request, shedding the
+// requests acquire rejects with 503 and Retry-After.
+func (l *concurrencyLimiter) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := l.acquire(r.Context()); err != nil {
+			w.Header().Set("Retry-After", "1")
+			writeError(w, r, err)
+			return
+		}
+		defer l.release()
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,115 @@
+package bridge
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestConcurrencyLimitQueuesThenSheds(t *testing.T) {
+	be := startBackend(t)
+	entered, release := make(chan struct{}), make(chan struct{})
+	be.onUnary(func(ctx context.Context, req *dynamicpb.Message) (proto.Message, error) {
+		entered <- struct{}{}
+		<-release
+		return req, nil
+	})
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, MaxConcurrentRequests: 1, QueueDepth: 1, QueueWait: 5 * time.Second})
+	h := b.Handler()
+
+	results := make(chan *httptest.ResponseRecorder, 2)
+	go func() { results <- post(h, "/test.v1.Echo/Echo", `{}`) }()
+	<-entered
+	go func() { results <- post(h, "/test.v1.Echo/Echo", `{}`) }()
+	for len(b.inflight.queue) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	rec := post(h, "/test.v1.Echo/Echo", `{}`)
+	if rec.Code != 503 || rec.Header().Get("Retry-After") != "1" {
+		t.Fatalf("beyond the queue: got %d with Retry-After %q, want 503 with 1", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	select {
+	case <-entered:
+		t.Fatal("the queued request reached the backend while the limit was in use")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// Finishing the first request lets the queued one in
+	release <- struct{}{}
+	<-entered
+	release <- struct{}{}
+	for i := 0; i < 2; i++ {
+		if rec := <-results; rec.Code != 200 {
+			t.Fatalf("got %d %s, want both requests served", rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestConcurrencyLimitShedsAfterQueueWait(t *testing.T) {
+	be := startBackend(t)
+	release := make(chan struct{})
+	be.onUnary(func(ctx context.Context, req *dynamicpb.Message) (proto.Message, error) {
+		<-release
+		return req, nil
+	})
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, MaxConcurrentRequests: 1, QueueDepth: 1, QueueWait: 50 * time.Millisecond})
+	h := b.Handler()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		post(h, "/test.v1.Echo/Echo", `{}`)
+	}()
+	for be.Calls() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	start := time.Now()
+	if rec := post(h, "/test.v1.Echo/Echo", `{}`); rec.Code != 503 || time.Since(start) < 50*time.Millisecond {
+		t.Fatalf("got %d after %v, want 503 after the 50ms wait", rec.Code, time.Since(start))
+	}
+	close(release)
+	<-done
+}
+
+func TestMethodConcurrencyCapShedsOnlyThatMethod(t *testing.T) {
+	be := startBackend(t)
+	entered, release := make(chan struct{}), make(chan struct{})
+	be.onUnary(func(ctx context.Context, req *dynamicpb.Message) (proto.Message, error) {
+		if m, _ := grpc.Method(ctx); m == "/test.v1.Echo/Echo" {
+			entered <- struct{}{}
+			<-release
+		}
+		return req, nil
+	})
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, Methods: map[string]MethodConfig{
+		"test.v1.Echo/Echo": {MaxConcurrent: 2},
+	}})
+	h := b.Handler()
+
+	results := make(chan *httptest.ResponseRecorder, 2)
+	for i := 0; i < 2; i++ {
+		go func() { results <- post(h, "/test.v1.Echo/Echo", `{}`) }()
+		<-entered
+	}
+
+	rec := post(h, "/test.v1.Echo/Echo", `{}`)
+	if rec.Code != 503 || rec.Header().Get("Retry-After") != "1" {
+		t.Fatalf("over the cap: got %d with Retry-After %q, want 503 with 1", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if rec := post(h, "/test.v1.Echo/OldEcho", `{}`); rec.Code != 200 {
+		t.Fatalf("other method: got %d %s, want 200", rec.Code, rec.Body)
+	}
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		if rec := <-results; rec.Code != 200 {
+			t.Fatalf("got %d %s, want both capped calls served", rec.Code, rec.Body)
+		}
+	}
+}
@@ -0,0 +1,629 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Config holds the settings used to construct a Bridge.
+type Config struct {
+	GRPCAddr string
+	HTTPPort int
+
+	// RecordFile, if set, is a JSONL file every unary interaction is
+	// appended to.
+	RecordFile string
+	// ReplayFile, if set, is a JSONL file of recorded interactions to serve
+	// instead of dialing a backend.
+	ReplayFile string
+	// AuditLog is the JSONL file that calls to methods with an audit
+	// policy are recorded in, or "-" for stderr. If unset, they are
+	// written to the bridge's log.
+	AuditLog string
+
+	// DescriptorSet, if set, is a FileDescriptorSet or buf image whose
+	// services are resolved from it instead of via reflection.
+	DescriptorSet string
+
+	// OmitUnpopulated leaves fields with default values out of JSON
+	// responses. Requests can override it with ?emit_unpopulated=.
+	OmitUnpopulated bool
+	// OmitEmptyRepeated leaves empty repeated and map fields out of JSON
+	// responses even when other unpopulated fields are emitted, instead of
+	// rendering them as [] and {}.
+	OmitEmptyRepeated bool
+	// DeterministicJSON renders responses with fixed whitespace, so the
+	// same message always produces byte-identical JSON, even across
+	// builds of the bridge.
+	DeterministicJSON bool
+
+	// DefaultFormat is the response format for requests without an
+	// Accept header or accepting */*: "json" (default), "proto" or
+	// "yaml".
+	DefaultFormat string
+	// BufferResponses sends unary responses with a Content-Length header
+	// instead of chunked, so clients can keep the connection alive and
+	// show progress. Responses are already held in memory whole; this only
+	// stops large ones from being streamed out in chunks.
+	BufferResponses bool
+	// JSONTrailingNewline ends every JSON response body, success or
+	// error, with a newline, as command-line tools expect. By default no
+	// JSON body has one.
+	JSONTrailingNewline bool
+	// ErrorFormat is how error responses are rendered: "json" (the
+	// default), {"error": ..., "code": ...}, or "problem", an RFC 7807
+	// application/problem+json document that keeps code and details as
+	// extension members.
+	ErrorFormat string
+	// Compression lists the encodings responses may be compressed with,
+	// most preferred first, e.g. ["br", "gzip"]. Each response uses the
+	// first one the client's Accept-Encoding allows. gzip, deflate, br and
+	// zstd are built in; other encodings need WithCompressionEncoder.
+	// Empty leaves responses uncompressed.
+	Compression []string
+
+	// LogPayloadSizes adds the request and response body sizes of every
+	// request to its access log line, and serves histograms of them on
+	// /metrics.
+	LogPayloadSizes bool
+	// PayloadLogThreshold, if set, samples the access log lines
+	// LogPayloadSizes adds sizes to: requests whose request or response
+	// body has at least this many bytes, or that take longer than
+	// SlowRequestThreshold, always have them, and the others at
+	// PayloadLogSampleRate, from 0 to 1. The histograms count every
+	// request.
+	PayloadLogThreshold  int64
+	PayloadLogSampleRate float64
+
+	// SlowRequestThreshold, if set, logs a warning for every request that
+	// takes longer, with the time spent in the backend and the bridge.
+	SlowRequestThreshold time.Duration
+
+	// DebugHeaders adds X-Backend-Peer, the address of the backend that
+	// served the call, to unary and client streaming responses, and
+	// X-Json-Options, the JSON options responses are rendered with, to
+	// those and server streaming responses. Every call also reports its
+	// resolved message types in X-Grpc-Input-Type and X-Grpc-Output-Type,
+	// and where they came from in X-Descriptor-Source. Requests may send
+	// X-Descriptor-Source: reflection or static to force that source.
+	DebugHeaders bool
+
+	// LogFormat selects the access log format: "text" (default), "clf"
+	// for the Common Log Format or "combined" for the Combined Log Format.
+	LogFormat string
+
+	// RequestIDFormat selects how IDs are generated for requests without
+	// an X-Request-Id header: "sequential" (default, host/prefix-counter),
+	// "uuid" for random UUIDs, "ksuid" or "short" for 11 random base62
+	// characters. The ID is returned in the X-Request-Id response header.
+	RequestIDFormat string
+	// CallIDs gives each request a gRPC call ID, returned in the
+	// X-Grpc-Call-Id response header and sent to backends as x-call-id
+	// metadata together with the request ID as x-request-id. Each call is
+	// logged with both IDs; a request's later calls, like retries, get the
+	// ID with ".2", ".3" and so on appended.
+	CallIDs bool
+
+	// NullFields selects how explicit JSON nulls in request bodies are
+	// handled: "clear" (default), "ignore" or "error".
+	NullFields string
+
+	// DuplicateKeys selects how request bodies with a key repeated in one
+	// object are handled: "last" (default) keeps the last value and
+	// "error" rejects them.
+	DuplicateKeys string
+
+	// DefaultService, if set, is the service that single-segment paths
+	// such as /GetUser resolve against.
+	DefaultService string
+	// FormPayloadField is the field of form-encoded request bodies that
+	// holds the request as JSON. Defaults to "payload".
+	FormPayloadField string
+	// FormRepeatedFields is how form fields setting a repeated field
+	// combine with the values the payload has for it: "replace" (default)
+	// sends only the form's, "append" sends the payload's followed by the
+	// form's.
+	FormRepeatedFields string
+
+	// ExposeServices, if set, limits the services the bridge serves and
+	// lists to those named, or matching one of the globs, e.g.
+	// "myapp.v1.*". Other services the backend reflects answer 404.
+	ExposeServices []string
+
+	// CaseInsensitive resolves services and methods named in any case,
+	// e.g. /api.v1.userservice/getuser, to the names the backend declares.
+	CaseInsensitive bool
+
+	// DevMode accepts // and /* */ comments and trailing commas in request
+	// JSON, which is convenient for hand-written test requests, and adds
+	// the chain of wrapped errors to error responses. Leave it off in
+	// production.
+	DevMode bool
+
+	// DisableRootIndex makes GET / return 404 instead of a JSON index of
+	// the bridge's endpoints.
+	DisableRootIndex bool
+
+	// Services holds per-service settings, keyed by fully-qualified
+	// service name. It is normally loaded with LoadConfigFile.
+	Services map[string]ServiceConfig
+	// Methods holds per-method settings, keyed by "{service}/{method}".
+	// It is normally loaded with LoadConfigFile.
+	Methods map[string]MethodConfig
+	// CORS holds the cross-origin policies for RPC routes. The first
+	// policy whose pattern matches a method applies; methods matching none
+	// are same-origin only.
+	CORS []CORSPolicy
+	// CallTimeout is the default deadline for backend calls, unless a
+	// service or method sets its own. Zero leaves calls bounded only by
+	// the request.
+	CallTimeout time.Duration
+	// DeadlineMargin is how long before the request's deadline, set by
+	// a Grpc-Timeout header or the 60s handler timeout, backend calls are
+	// cut off, leaving time to write the response.
+	DeadlineMargin time.Duration
+	// TimeoutOption is the full name of a custom method option, e.g.
+	// "myapp.v1.timeout", holding a method's expected duration as a
+	// google.protobuf.Duration or a string like "5s". Annotated methods
+	// default to that timeout, ahead of CallTimeout but behind service
+	// and method timeouts and a client's Grpc-Timeout.
+	TimeoutOption string
+	// HealthInterval is how often backends with a secondary are probed.
+	HealthInterval time.Duration
+	// HealthProbe, if set, is the backend method used to probe backends,
+	// both for failover and for GET /ready, instead of the standard gRPC
+	// health service. It is normally loaded with LoadConfigFile.
+	HealthProbe *HealthProbe
+	// MaxDescriptorAge, if set, makes GET /ready fail once a backend's
+	// cached descriptors were fetched longer ago than this, so that schema
+	// drift after a backend redeploy is noticed; POST
+	// /admin/descriptors/refresh drops the cache.
+	MaxDescriptorAge time.Duration
+	// FanIns, keyed by name, are served at GET /fan-in/{name}, merging
+	// several server streams into one. They are normally loaded with
+	// LoadConfigFile.
+	FanIns map[string]FanIn
+
+	// BreakerThreshold is the number of consecutive backend failures after
+	// which a method's circuit breaker opens. Zero disables breakers.
+	BreakerThreshold int
+	// BreakerCooldown is how long an open breaker rejects calls before
+	// letting a probe through.
+	BreakerCooldown time.Duration
+
+	// RateLimit, if set, is how many RPC requests each client may make per
+	// RateLimitWindow, which defaults to a minute. Clients are identified
+	// by certificate principal or IP address.
+	RateLimit       int
+	RateLimitWindow time.Duration
+
+	// MaxConcurrentRequests, if set, is how many RPC requests are served at
+	// once. Up to QueueDepth more wait up to QueueWait (default 1s) for
+	// one to finish; the rest are shed with 503.
+	MaxConcurrentRequests int
+	QueueDepth            int
+	QueueWait             time.Duration
+
+	// RetryMax is how many times a unary call that fails with Unavailable
+	// is retried. Zero disables bridge-level retries.
+	RetryMax int
+	// RetryBackoff is the base delay between retries, doubled after each
+	// attempt up to RetryMaxBackoff.
+	RetryBackoff    time.Duration
+	RetryMaxBackoff time.Duration
+	// RetryJitter spreads retry delays: "none", "full" (default), "equal"
+	// or "decorrelated".
+	RetryJitter string
+	// RetryMessages makes unary calls whose error message contains one of
+	// these phrases retriable whatever their code, for backends that only
+	// signal transient failures in the message. Phrases match whole words,
+	// ignoring case, and are retried within RetryMax; cancelled calls and
+	// exceeded deadlines never are.
+	RetryMessages []string
+	// ReflectionRetries is how many times a reflection lookup that fails
+	// with Unavailable is retried before the call it resolves fails,
+	// independently of RetryMax. Retries wait ReflectionRetryBackoff,
+	// doubled after each attempt up to RetryMaxBackoff, with full jitter.
+	ReflectionRetries      int
+	ReflectionRetryBackoff time.Duration
+	// RefusedMessage is the error message for calls whose backend refused
+	// the connection, which fail with 503 without being retried. {addr}
+	// stands for the backend's address. Defaults to "backend connection
+	// refused at {addr}".
+	RefusedMessage string
+	// ResourceExhaustedStatus is the HTTP status of calls failing with
+	// ResourceExhausted, usually a backend's rate limit or quota.
+	// Defaults to 429; 503 suits backends that use the code for overload.
+	// Either way the backend's pushback, if any, is passed on in
+	// Retry-After.
+	ResourceExhaustedStatus int
+	// StreamResumes is how many times a server stream that fails with
+	// Unavailable is restarted by re-sending its request. Zero disables
+	// resuming.
+	StreamResumes int
+	// StreamEndFrames makes NDJSON server streams that complete end with
+	// a {"_control": "end", "request_id": ...} line, so that clients can
+	// tell a finished stream from a cut one and correlate it with the
+	// bridge's logs. Error lines carry the request ID regardless.
+	StreamEndFrames bool
+	// EmptyStream is how a server stream that ends without a message is
+	// answered: "array" for 200 with [], "ndjson" for 200 with an empty
+	// NDJSON body, or "no-content" for 204. By default it is answered in
+	// the stream's own format, [] with buffer_stream and an empty NDJSON
+	// body otherwise. MethodConfig.EmptyStream overrides it per method.
+	EmptyStream string
+	// EmptyResponse is how a successful unary response with no populated
+	// fields is answered, whatever its type: "object" for 200 with {},
+	// "empty" for 200 with an empty body, or "no-content" for 204. By
+	// default it is rendered like any other response.
+	// MethodConfig.EmptyResponse overrides it per method.
+	EmptyResponse string
+	// StreamMessageTimeout, if set, is how long a client or bidirectional
+	// stream may wait for the client's next message. A client that stops
+	// sending without ending its stream has the call cancelled after it,
+	// instead of holding the backend stream open indefinitely.
+	StreamMessageTimeout time.Duration
+
+	// TLSCertFile and TLSKeyFile, if set, make Serve listen over HTTPS.
+	TLSCertFile string
+	TLSKeyFile  string
+	// TLSClientCAFile, if set, is a PEM file of CAs that client
+	// certificates are verified against.
+	TLSClientCAFile string
+	// TLSClientAuth is "require" (default) to reject clients without a
+	// certificate, or "optional" to accept them unauthenticated.
+	TLSClientAuth string
+	// PublicMethods lists methods, as "{service}/{method}", that clients
+	// may call without a certificate when they are required. The TLS
+	// handshake then lets such clients through, and the bridge answers
+	// all their other requests with 401.
+	PublicMethods []string
+	// PrincipalMetadata, if set, is the metadata key the client
+	// certificate principal is forwarded to backends under.
+	PrincipalMetadata string
+	// MaxForwardedMetadata, if set, caps the size in bytes of the metadata
+	// forwarded to backends for a request, the principal and bearer
+	// token, counted as gRPC counts its header list: name plus value plus
+	// 32 bytes per entry. Requests over it are handled according to
+	// ForwardedMetadataPolicy.
+	MaxForwardedMetadata int
+	// ForwardedMetadataPolicy is "reject" (default) to answer requests
+	// over MaxForwardedMetadata with 400, or "drop" to leave out the
+	// largest entries until the rest fit.
+	ForwardedMetadataPolicy string
+
+	// StartupProbeAttempts, if set, makes Serve probe the backends before
+	// binding the HTTP port, up to this many times StartupProbeInterval
+	// (default 2s) apart, and fail if they don't become ready. A probe
+	// connects and calls the health probe method, or reflection if none is
+	// configured. NewBridge then doesn't wait for the default backend.
+	StartupProbeAttempts int
+	StartupProbeInterval time.Duration
+
+	// HTTP2PingInterval, if set, makes Serve ping HTTP/2 clients after a
+	// connection has been idle this long, keeping streams alive through
+	// intermediaries and closing connections whose peer has gone away.
+	HTTP2PingInterval time.Duration
+	// HTTP2PingTimeout is how long to wait for a ping ack before closing
+	// the connection. Defaults to 15 seconds.
+	HTTP2PingTimeout time.Duration
+
+	// ReusePort sets SO_REUSEPORT on the listener Serve opens, so several
+	// bridge processes can share the port for zero-downtime restarts.
+	ReusePort bool
+	// TCPKeepAlive is the keepalive period of accepted connections. Zero
+	// keeps Go's default and a negative value disables keepalives.
+	TCPKeepAlive time.Duration
+	// MaxConnections, if set, bounds how many HTTP connections are open at
+	// once. Connections accepted beyond it are closed straight away.
+	MaxConnections int
+	// MaxPathLength, if set, bounds the length in bytes of request paths,
+	// as sent. Longer ones are answered with 414 URI Too Long.
+	MaxPathLength int
+
+	// MetricsExemplars links reflection latency observations to the trace
+	// of the request they were made for, taken from a sampled W3C
+	// traceparent header, as OpenMetrics exemplars on GET /metrics.
+	MetricsExemplars bool
+
+	// ShutdownTimeout is how long Shutdown lets unary calls in flight
+	// finish before cancelling them. Defaults to 10 seconds.
+	ShutdownTimeout time.Duration
+	// StreamDrainTimeout is how long Shutdown lets streams in flight run,
+	// if longer than ShutdownTimeout.
+	StreamDrainTimeout time.Duration
+	// WatchdogInterval, if set, makes Serve ping its own server's /health
+	// this often and report the bridge deadlocked in /health, with 503,
+	// once three pings in a row have failed or taken longer than
+	// WatchdogTimeout (default 5s).
+	WatchdogInterval time.Duration
+	WatchdogTimeout  time.Duration
+
+	// AdminToken, if set, enables the /admin endpoints for clients sending
+	// it as a bearer token.
+	AdminToken string
+	// Maintenance, if set, describes planned maintenance to clients turned
+	// away with 503 in maintenance mode or while the bridge drains. It is
+	// normally loaded with LoadConfigFile.
+	Maintenance *MaintenanceWindow
+	// RecentRequests, if positive, keeps the last RecentRequests RPC
+	// requests for GET /debug/requests, which needs the admin token.
+	// Without an AdminToken no requests are kept.
+	RecentRequests int
+
+	// AsyncOperations, if positive, lets unary requests sent with Prefer:
+	// respond-async be answered 202 Accepted at once and polled for their
+	// result at GET /operations/{id}. At most AsyncOperations are kept.
+	AsyncOperations int
+	// AsyncOperationTTL is how long an operation may run and, once done,
+	// how long its result is kept. Defaults to 10 minutes.
+	AsyncOperationTTL time.Duration
+
+	// MaxBatchSize, if positive, enables POST /batch, which makes up to
+	// MaxBatchSize unary calls in one request and reports the status of
+	// each.
+	MaxBatchSize int
+}
+
+// ServiceConfig holds the settings for one service.
+type ServiceConfig struct {
+	// Primary is the backend serving the service. Defaults to GRPCAddr.
+	Primary string `json:"primary"`
+	// Secondary, if set, is a backend offering the same service that
+	// receives new requests while the primary is unhealthy.
+	Secondary string `json:"secondary"`
+	// Backends, if set instead of Primary, spreads the service's requests
+	// over several backends in proportion to their weights, for example
+	// to send a share of traffic to a canary.
+	Backends []WeightedBackend `json:"backends"`
+	// Versions maps API versions to the backends serving them, so that
+	// /{version}/{service}/{method} reaches that version's backend, for
+	// blue/green deployments. Unprefixed paths use the routing above.
+	Versions map[string]string `json:"versions"`
+	// RouteHeader and HeaderRoutes pick the backend from a request header:
+	// with RouteHeader "X-Region", HeaderRoutes {"eu": "users-eu:50051"}
+	// sends requests with X-Region: eu to users-eu:50051. Requests with a
+	// value not listed are rejected, and those without the header use the
+	// routing above.
+	RouteHeader  string            `json:"route_header"`
+	HeaderRoutes map[string]string `json:"header_routes"`
+	// Timeout, if set, overrides CallTimeout for the service's methods.
+	Timeout Duration `json:"timeout"`
+	// BasePath, if set, also serves the service's methods under it, as
+	// {base_path}/{method}: with "/users", /users/GetUser calls GetUser.
+	BasePath string `json:"base_path"`
+}
+
+// MaintenanceWindow is the maintenance reported in 503 responses, under
+// "maintenance", so clients can show why and plan when to retry.
+type MaintenanceWindow struct {
+	// Reason is shown to clients, e.g. "database upgrade". It is also the
+	// default maintenance mode message.
+	Reason string `json:"reason"`
+	// EstimatedEnd is when the maintenance is expected to be over.
+	EstimatedEnd time.Time `json:"estimated_end"`
+}
+
+// WeightedBackend is one of a service's Backends.
+type WeightedBackend struct {
+	Address string `json:"address"`
+	// Weight is the backend's share of requests relative to the others.
+	Weight int `json:"weight"`
+}
+
+// MethodConfig holds the settings for one method.
+type MethodConfig struct {
+	// RequestTransform, if set, is a jq expression applied to the request
+	// body before it is parsed into the input message.
+	RequestTransform string `json:"request_transform"`
+	// ResponseTransform, if set, is a jq expression applied to successful
+	// response bodies before they are returned.
+	ResponseTransform string `json:"response_transform"`
+	// Tags group the method in listings such as /services.
+	Tags []string `json:"tags"`
+	// ResponseFormat, if set, is the format responses are returned in
+	// whatever the Accept header says: "json", "proto" or "yaml". The
+	// format query parameter still overrides it.
+	ResponseFormat string `json:"response_format"`
+	// HTTPMethod is the verb the method is served under: POST (default),
+	// PUT, PATCH or DELETE.
+	HTTPMethod string `json:"http_method"`
+	// Removed answers calls to the method with 410 Gone, whether or not
+	// the backend still serves it. Replacement, if set, is the
+	// "{service}/{method}" callers should use instead.
+	Removed     bool   `json:"removed"`
+	Replacement string `json:"replacement"`
+	// Timeout, if set, overrides the service and global timeouts.
+	Timeout Duration `json:"timeout"`
+	// FlushMessages and FlushInterval batch a server stream's messages,
+	// flushing after every FlushMessages messages or FlushInterval after
+	// the first unflushed one. By default every message is flushed.
+	FlushMessages int      `json:"flush_messages"`
+	FlushInterval Duration `json:"flush_interval"`
+	// BufferStream returns a server stream's messages as one JSON array
+	// once the stream has ended, instead of NDJSON as they arrive.
+	// StreamField, if set, wraps the array in an object under that field,
+	// e.g. {"results": [...]}.
+	BufferStream bool   `json:"buffer_stream"`
+	StreamField  string `json:"stream_field"`
+	// Streaming decides how responses are delivered whatever the method
+	// declares: "buffered" is BufferStream, and "streamed" also answers a
+	// unary method's successful JSON responses as NDJSON, a stream of one
+	// message, for clients that read every method as a stream. By default
+	// server streams are streamed and unary responses buffered.
+	Streaming string `json:"streaming"`
+	// EmptyStream overrides Config.EmptyStream for the method.
+	EmptyStream string `json:"empty_stream"`
+	// EmptyResponse overrides Config.EmptyResponse for the method.
+	EmptyResponse string `json:"empty_response"`
+	// RequestTypes, if set, lists the media types request bodies may be
+	// sent as, e.g. ["application/x-protobuf"] to hold clients of a
+	// binary-heavy method to the compact encoding. Others are rejected
+	// with 415; requests without a Content-Type count as JSON.
+	// ResponseTypes likewise restricts the formats unary responses are
+	// sent in, rejecting calls that would get another with 406.
+	RequestTypes  []string `json:"request_types"`
+	ResponseTypes []string `json:"response_types"`
+	// RequiredHeaders lists headers, such as a tenant header, that requests
+	// must carry with a non-empty value. Requests missing one are rejected
+	// with 400 before the backend is called.
+	RequiredHeaders []string `json:"required_headers"`
+	// RequestSchema, if set, is a JSON Schema request bodies must match
+	// before they are parsed, for rules the proto can't express such as
+	// fields that depend on each other.
+	RequestSchema json.RawMessage `json:"request_schema"`
+	// HeaderFields fills request fields from headers, mapping a field's
+	// proto or JSON name to the header it is taken from, e.g.
+	// {"tenant_id": "X-Tenant"}. The header value is converted to the
+	// field's type and replaces any value the body has.
+	HeaderFields map[string]string `json:"header_fields"`
+	// Envelope, if set, wraps successful responses of a list method in a
+	// common shape. It is applied before ResponseTransform.
+	Envelope *EnvelopeConfig `json:"envelope"`
+	// ErrorField, if set, turns successful responses that report a domain
+	// error in a field into HTTP errors. It is checked before Envelope.
+	ErrorField *ErrorFieldConfig `json:"error_field"`
+	// Cache, if set, serves repeated requests to a unary method from
+	// memory instead of calling the backend.
+	Cache *CacheConfig `json:"cache"`
+	// MaxConcurrent, if set, caps the calls to the method served at once.
+	// Calls over the cap are shed with 503 straight away, leaving the
+	// capacity of other methods alone.
+	MaxConcurrent int `json:"max_concurrent"`
+	// Audit, if set, records every call to the method in the audit log.
+	Audit *AuditConfig `json:"audit"`
+}
+
+// CacheConfig caches a unary method's successful responses. Requests with
+// the same path, body and JSON options share a response, unless they
+// differ in one of the Vary headers.
+type CacheConfig struct {
+	// TTL is how long a response is served from the cache.
+	TTL Duration `json:"ttl"`
+	// Vary lists request headers, such as Accept-Language, whose values
+	// are part of the cache key. Responses that depend on a header, like
+	// Authorization, must list it here or they are shared across clients.
+	Vary []string `json:"vary"`
+	// MaxEntries bounds the responses kept for the method. Defaults to
+	// 1000.
+	MaxEntries int `json:"max_entries"`
+}
+
+// ErrorFieldConfig answers successful responses whose Field holds an error,
+// as some backends report domain errors in the response message instead
+// of the gRPC status, with the bridge's usual error body: the message, the
+// code, and an ErrorInfo detail whose reason is the field's value. Fields
+// may be given by their proto or JSON names, with dots for nested ones.
+type ErrorFieldConfig struct {
+	// Field holds the error, e.g. error_code. Responses where it is unset,
+	// empty, zero or false, or one of OK, are passed through.
+	Field string `json:"field"`
+	// Message, if set, holds the error's message. Without it the message
+	// is "{field}: {value}".
+	Message string `json:"message"`
+	// OK lists further values that mean success, such as an enum's
+	// ERROR_CODE_UNSPECIFIED.
+	OK []string `json:"ok"`
+	// Code is the gRPC code reported, e.g. NOT_FOUND. Defaults to
+	// FAILED_PRECONDITION.
+	Code string `json:"code"`
+	// Status is the HTTP status answered, by default Code's. Statuses
+	// overrides it for particular values of the field, e.g.
+	// {"OUT_OF_STOCK": 409}.
+	Status   int            `json:"status"`
+	Statuses map[string]int `json:"statuses"`
+}
+
+// EnvelopeConfig wraps a list response as {"items": [...],
+// "next_page_token": "...", "total": N}, taking each from the response
+// field it names. Fields may be given by their proto or JSON names.
+type EnvelopeConfig struct {
+	// Items is the repeated field holding the page's results.
+	Items string `json:"items"`
+	// NextPageToken and Total, if set, are the fields holding the token of
+	// the next page and the total number of results.
+	NextPageToken string `json:"next_page_token"`
+	Total         string `json:"total"`
+	// Status adds the call's gRPC status, "code": "OK" and
+	// "grpc_status": 0, as error bodies carry theirs, so that clients can
+	// check every response the same way.
+	Status bool `json:"status"`
+}
+
+// CORSPolicy is the cross-origin policy for the methods matching Pattern.
+type CORSPolicy struct {
+	// Pattern matches "{service}/{method}" as a prefix or, if it contains
+	// *, ? or [, as a glob, like the /services filter.
+	Pattern string `json:"pattern"`
+	// AllowedOrigins lists the origins allowed to call the methods; "*"
+	// allows any. An empty list allows none.
+	AllowedOrigins []string `json:"allowed_origins"`
+	// AllowedHeaders lists the request headers allowed beyond
+	// Content-Type.
+	AllowedHeaders []string `json:"allowed_headers"`
+	// AllowCredentials lets browsers send cookies and client certificates.
+	AllowCredentials bool `json:"allow_credentials"`
+	// MaxAge, if set, is how long browsers may cache preflight responses.
+	MaxAge Duration `json:"max_age"`
+}
+
+// Duration is a time.Duration written in config files as a string such
+// as "1.5s".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"5s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// fileConfig is the layout of the JSON config file.
+type fileConfig struct {
+	Services map[string]ServiceConfig `json:"services"`
+	Methods  map[string]MethodConfig  `json:"methods"`
+	CORS     []CORSPolicy             `json:"cors"`
+
+	HealthProbe *HealthProbe       `json:"health_probe"`
+	FanIns      map[string]FanIn   `json:"fan_in"`
+	Maintenance *MaintenanceWindow `json:"maintenance"`
+}
+
+// LoadConfigFile reads the JSON config file at path into cfg.
+func LoadConfigFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var fc fileConfig
+	if err := strictUnmarshal(data, &fc); err != nil {
+		return fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	cfg.Services = fc.Services
+	cfg.Methods = fc.Methods
+	cfg.CORS = fc.CORS
+	cfg.HealthProbe = fc.HealthProbe
+	cfg.FanIns = fc.FanIns
+	cfg.Maintenance = fc.Maintenance
+	return nil
+}
+
+func strictUnmarshal(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
@@ -0,0 +1,131 @@
+package bridge
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// contentTypes are the media types a method's requests and responses are
+// restricted to. A nil set allows any.
+type contentTypes struct {
+	request  map[string]bool
+	response map[string]bool
+}
+
+// compileContentTypes returns the restrictions of mc, or nil if it has
+// none.
+func compileContentTypes(mc MethodConfig) (*contentTypes, error) {
+	if len(mc.RequestTypes) == 0 && len(mc.ResponseTypes) == 0 {
+		return nil, nil
+	}
+	ct := &contentTypes{}
+	var err error
+	if ct.request, err = mediaTypeSet(mc.RequestTypes); err != nil {
+		return nil, fmt.Errorf("invalid request_types: %w", err)
+	}
+	if ct.response, err = mediaTypeSet(mc.ResponseTypes); err != nil {
+		return nil, fmt.Errorf("invalid response_types: %w", err)
+	}
+	for mt := range ct.response {
+		if !isResponseMediaType(mt) {
+			return nil, fmt.Errorf("invalid response_types: %s isn't a response format (want application/json, application/x-protobuf or application/yaml)", mt)
+		}
+	}
+	return ct, nil
+}
+
+func mediaTypeSet(types []string) (map[string]bool, error) {
+	if len(types) == 0 {
+		return nil, nil
+	}
+	set := make(map[string]bool, len(types))
+	for _, t := range types {
+		mt, _, err := mime.ParseMediaType(t)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", t, err)
+		}
+		set[canonicalMediaType(mt)] = true
+	}
+	return set, nil
+}
+
+// canonicalMediaType folds the aliases of the protobuf and YAML media
+// types into the names the bridge answers with.
+func canonicalMediaType(mt string) string {
+	switch mt = strings.ToLower(mt); mt {
+	case "application/protobuf":
+		return "application/x-protobuf"
+	case "application/x-yaml", "text/yaml":
+		return "application/yaml"
+	}
+	return mt
+}
+
+func isResponseMediaType(mt string) bool {
+	return mt == "application/json" || mt == "application/x-protobuf" || mt == "application/yaml"
+}
+
+// mediaType returns the media type responses in f are sent as.
+func (f responseFormat) mediaType() string {
+	switch f {
+	case formatProto:
+		return "application/x-protobuf"
+	case formatYAML:
+		return "application/yaml"
+	}
+	return "application/json"
+}
+
+// checkRequestType rejects requests to method, as "{service}/{method}",
+// whose Content-Type it doesn't allow with 415. Requests without one are
+// taken for JSON.
+func (b *Bridge) checkRequestType(w http.ResponseWriter, r *http.Request, method string) bool {
+	ct := b.contentTypes[method]
+	if ct == nil || ct.request == nil {
+		return true
+	}
+	mt := "application/json"
+	if h := r.Header.Get("Content-Type"); h != "" {
+		parsed, _, err := mime.ParseMediaType(h)
+		if err != nil {
+			parsed = h
+		}
+		mt = canonicalMediaType(parsed)
+	}
+	if ct.request[mt] {
+		return true
+	}
+	allowed := sortedTypes(ct.request)
+	w.Header().Set("Accept", strings.Join(allowed, ", "))
+	_, body := b.errorResponse(status.Errorf(codes.InvalidArgument, "%s doesn't accept %s request bodies (want %s)", method, mt, strings.Join(allowed, " or ")))
+	b.writeJSON(w, http.StatusUnsupportedMediaType, body)
+	return false
+}
+
+// checkResponseType rejects calls to method whose response would be sent
+// in a format it doesn't allow with 406.
+func (b *Bridge) checkResponseType(w http.ResponseWriter, method string, f responseFormat) bool {
+	ct := b.contentTypes[method]
+	if ct == nil || ct.response == nil || ct.response[f.mediaType()] {
+		return true
+	}
+	allowed := sortedTypes(ct.response)
+	_, body := b.errorResponse(status.Errorf(codes.InvalidArgument, "%s doesn't answer in %s (want Accept: %s)", method, f.mediaType(), strings.Join(allowed, " or ")))
+	b.writeJSON(w, http.StatusNotAcceptable, body)
+	return false
+}
+
+func sortedTypes(set map[string]bool) []string {
+	types := make([]string, 0, len(set))
+	for mt := range set {
+		types = append(types, mt)
+	}
+	sort.Strings(types)
+	return types
+}
@@ -0,0 +1,82 @@
+package bridge
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestProtobufOnlyMethodRejectsJSON(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{
+		GRPCAddr: be.addr,
+		Methods: map[string]MethodConfig{
+			"test.v1.Echo/Echo": {RequestTypes: []string{"application/protobuf"}},
+		},
+	})
+	h := b.Handler()
+
+	for _, ct := range []string{"application/json", ""} {
+		req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", bytes.NewReader([]byte(`{"message": "hi"}`)))
+		if ct != "" {
+			req.Header.Set("Content-Type", ct)
+		}
+		rec := serve(h, req)
+		if rec.Code != http.StatusUnsupportedMediaType || rec.Header().Get("Accept") != "application/x-protobuf" {
+			t.Fatalf("Content-Type %q: got %d Accept %q, want 415 Accept application/x-protobuf", ct, rec.Code, rec.Header().Get("Accept"))
+		}
+	}
+	if be.Calls() != 0 {
+		t.Fatalf("backend answered %d rejected calls", be.Calls())
+	}
+
+	m := dynamicpb.NewMessage(echoRequest)
+	m.Set(echoRequest.Fields().ByName("message"), protoreflect.ValueOfString("hi"))
+	body, err := proto.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Accept", "application/x-protobuf")
+	if rec := serve(h, req); rec.Code != 200 {
+		t.Fatalf("protobuf request: got %d %s", rec.Code, rec.Body)
+	}
+	if rec := post(h, "/test.v1.Echo/OldEcho", `{"message": "hi"}`); rec.Code != 200 {
+		t.Fatalf("unrestricted method: got %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestResponseTypesRejectOtherFormats(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{
+		GRPCAddr: be.addr,
+		Methods: map[string]MethodConfig{
+			"test.v1.Echo/Echo": {ResponseTypes: []string{"application/x-protobuf"}},
+		},
+	})
+	h := b.Handler()
+
+	req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", bytes.NewReader([]byte(`{}`)))
+	req.Header.Set("Accept", "application/json")
+	if rec := serve(h, req); rec.Code != http.StatusNotAcceptable {
+		t.Fatalf("JSON response: got %d, want 406", rec.Code)
+	}
+	req = httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", bytes.NewReader([]byte(`{}`)))
+	req.Header.Set("Accept", "application/x-protobuf")
+	if rec := serve(h, req); rec.Code != 200 || rec.Header().Get("Content-Type") != "application/x-protobuf" {
+		t.Fatalf("protobuf response: got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	_, err := NewBridge(Config{GRPCAddr: be.addr, Methods: map[string]MethodConfig{
+		"test.v1.Echo/Echo": {ResponseTypes: []string{"text/csv"}},
+	}})
+	if err == nil {
+		t.Fatal("a response type the bridge can't answer in was accepted")
+	}
+}
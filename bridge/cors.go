@@ -0,0 +1,114 @@
+package bridge
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// corsPolicy is a compiled CORSPolicy.
+type corsPolicy struct {
+	match       func(string) bool
+	origins     []string
+	anyOrigin   bool
+	headers     string
+	credentials bool
+	maxAge      time.Duration
+}
+
+func compileCORS(policies []CORSPolicy) ([]corsPolicy, error) {
+	compiled := make([]corsPolicy, 0, len(policies))
+	for _, p := range policies {
+		match, err := serviceFilter(p.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CORS pattern %q: %w", p.Pattern, err)
+		}
+		cp := corsPolicy{
+			match:       match,
+			headers:     strings.Join(append([]string{"Content-Type"}, p.AllowedHeaders...), ", "),
+			credentials: p.AllowCredentials,
+			maxAge:      time.Duration(p.MaxAge),
+		}
+		for _, origin := range p.AllowedOrigins {
+			if origin == "*" {
+				cp.anyOrigin = true
+			} else {
+				cp.origins = append(cp.origins, origin)
+			}
+		}
+		compiled = append(compiled, cp)
+	}
+	return compiled, nil
+}
+
+func (p *corsPolicy) allows(origin string) bool {
+	if p.anyOrigin {
+		return true
+	}
+	for _, o := range p.origins {
+		if strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// corsPolicyFor returns the first policy matching route, or nil.
+func (b *Bridge) corsPolicyFor(route string) *corsPolicy {
+	for i := range b.cors {
+		if b.cors[i].match(route) {
+			return &b.cors[i]
+		}
+	}
+	return nil
+}
+
+// applyCORS sets the CORS response headers for a request to
+// service/method from r's origin, if the method's policy allows it.
+func (b *Bridge) applyCORS(w http.ResponseWriter, r *http.Request, service, method string) {
+	origin := r.Header.Get("Origin")
+	if origin == "" || len(b.cors) == 0 {
+		return
+	}
+	p := b.corsPolicyFor(service + "/" + method)
+	if p == nil {
+		return
+	}
+
+	h := w.Header()
+	h.Add("Vary", "Origin")
+	if !p.allows(origin) {
+		return
+	}
+	if p.anyOrigin && !p.credentials {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		h.Set("Access-Control-Allow-Origin", origin)
+	}
+	if p.credentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+	if r.Method == http.MethodOptions {
+		h.Set("Access-Control-Allow-Methods", b.httpMethod(service, method))
+		h.Set("Access-Control-Allow-Headers", p.headers)
+		if p.maxAge > 0 {
+			h.Set("Access-Control-Max-Age", strconv.Itoa(int(p.maxAge.Seconds())))
+		}
+	}
+}
+
+// handlePreflight answers CORS preflight requests for RPC routes. Origins
+// a method's policy doesn't allow get no CORS headers, which browsers
+// treat as a refusal.
+func (b *Bridge) handlePreflight(w http.ResponseWriter, r *http.Request) {
+	_, path := b.splitVersion(r.URL.Path)
+	service, method, ok := b.parseRPCPath(path)
+	if !ok {
+		http.Error(w, "Invalid path format. Use: /{service}/{method}", http.StatusBadRequest)
+		return
+	}
+	b.applyCORS(w, r, service, method)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,42 @@
+package bridge
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCORSPolicyPerMethod(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{
+		GRPCAddr: be.addr,
+		CORS: []CORSPolicy{
+			{Pattern: "test.v1.Echo/Echo", AllowedOrigins: []string{"*"}},
+			{Pattern: "test.v1.Echo/", AllowedOrigins: []string{"https://admin.example"}},
+		},
+	})
+	h := b.Handler()
+
+	for _, tt := range []struct {
+		method, path, origin, want string
+	}{
+		{http.MethodPost, "/test.v1.Echo/Echo", "https://app.example", "*"},
+		{http.MethodOptions, "/test.v1.Echo/Echo", "https://app.example", "*"},
+		{http.MethodPost, "/test.v1.Echo/OldEcho", "https://app.example", ""},
+		{http.MethodOptions, "/test.v1.Echo/OldEcho", "https://app.example", ""},
+		{http.MethodPost, "/test.v1.Echo/OldEcho", "https://admin.example", "https://admin.example"},
+		{http.MethodOptions, "/test.v1.Echo/OldEcho", "https://admin.example", "https://admin.example"},
+	} {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Origin", tt.origin)
+		if tt.method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		}
+		rec := serve(h, req)
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.want {
+			t.Errorf("%s %s from %s: Access-Control-Allow-Origin %q, want %q", tt.method, tt.path, tt.origin, got, tt.want)
+		}
+	}
+}
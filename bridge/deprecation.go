@@ -0,0 +1,161 @@
+package bridge
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// isDeprecated reports whether md, or the service it belongs to, is marked
+// with the deprecated option.
+func isDeprecated(md protoreflect.MethodDescriptor) bool {
+	if opts, ok := md.Options().(*descriptorpb.MethodOptions); ok && opts.GetDeprecated() {
+		return true
+	}
+	return serviceDeprecated(md.Parent())
+}
+
+func serviceDeprecated(d protoreflect.Descriptor) bool {
+	opts, ok := d.Options().(*descriptorpb.ServiceOptions)
+	return ok && opts.GetDeprecated()
+}
+
+// warnDeprecated flags a call to a deprecated method with a Deprecation
+// response header and a log line, so clients and operators can find
+// callers that need migrating.
+func warnDeprecated(h http.Header, md protoreflect.MethodDescriptor) {
+	if !isDeprecated(md) {
+		return
+	}
+	h.Set("Deprecation", "true")
+	log.Printf("⚠ Deprecated method called: %s", methodPath(md))
+}
+
+// writeRemoved answers a call to a method marked removed in the config
+// with 410 Gone, pointing to its replacement if it has one, and reports
+// whether it did.
+func (b *Bridge) writeRemoved(w http.ResponseWriter, service, method string) bool {
+	replacement, ok := b.removed[service+"/"+method]
+	if !ok {
+		return false
+	}
+	msg := fmt.Sprintf("%s/%s has been removed", service, method)
+	if replacement != "" {
+		msg += fmt.Sprintf("; use %s instead", replacement)
+		w.Header().Add("Link", fmt.Sprintf(`</%s>; rel="successor-version"`, replacement))
+	}
+	_, body := b.errorResponse(status.Error(codes.Unimplemented, msg))
+	b.writeJSON(w, http.StatusGone, body)
+	log.Printf("⚠ Removed method called: %s/%s", service, method)
+	return true
+}
+
+// warnDeprecatedFields adds a Warning response header naming the
+// deprecated fields that the JSON request data sets, so clients can move
+// off them before they are removed. The request is only decoded an extra
+// time if desc has deprecated fields at all.
+func (b *Bridge) warnDeprecatedFields(h http.Header, desc protoreflect.MessageDescriptor, data []byte) {
+	if !b.hasDeprecatedFields(desc) {
+		return
+	}
+	msg, err := b.decodeRequest(data, desc)
+	if err != nil {
+		// The request is rejected when the call decodes it
+		return
+	}
+	setDeprecatedFieldsWarning(h, deprecatedFields(msg, ""))
+}
+
+func setDeprecatedFieldsWarning(h http.Header, fields []string) {
+	if len(fields) == 0 {
+		return
+	}
+	h.Add("Warning", fmt.Sprintf(`299 - "deprecated fields used: %s"`, strings.Join(fields, ", ")))
+	log.Printf("⚠ Deprecated fields used: %s", strings.Join(fields, ", "))
+}
+
+// hasDeprecatedFields reports whether desc or any message nested in it
+// has a field marked deprecated, memoised per descriptor.
+func (b *Bridge) hasDeprecatedFields(desc protoreflect.MessageDescriptor) bool {
+	if v, ok := b.deprecatedTypes.Load(desc); ok {
+		return v.(bool)
+	}
+	found := containsDeprecatedField(desc, make(map[protoreflect.FullName]bool))
+	b.deprecatedTypes.Store(desc, found)
+	return found
+}
+
+func containsDeprecatedField(desc protoreflect.MessageDescriptor, seen map[protoreflect.FullName]bool) bool {
+	if seen[desc.FullName()] {
+		return false
+	}
+	seen[desc.FullName()] = true
+	fields := desc.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fieldDeprecated(fd) {
+			return true
+		}
+		if fd.IsMap() {
+			fd = fd.MapValue()
+		}
+		if fd.Message() != nil && containsDeprecatedField(fd.Message(), seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// deprecatedFields lists the deprecated fields m sets, as dotted paths
+// below prefix, each once and in sorted order.
+func deprecatedFields(m protoreflect.Message, prefix string) []string {
+	var found []string
+	seen := make(map[string]bool)
+	add := func(paths ...string) {
+		for _, p := range paths {
+			if !seen[p] {
+				seen[p] = true
+				found = append(found, p)
+			}
+		}
+	}
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		path := prefix + string(fd.Name())
+		if fieldDeprecated(fd) {
+			add(path)
+		}
+		switch {
+		case fd.IsMap():
+			if fd.MapValue().Message() == nil {
+				break
+			}
+			v.Map().Range(func(_ protoreflect.MapKey, v protoreflect.Value) bool {
+				add(deprecatedFields(v.Message(), path+".")...)
+				return true
+			})
+		case fd.Message() == nil:
+		case fd.IsList():
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				add(deprecatedFields(list.Get(i).Message(), path+".")...)
+			}
+		default:
+			add(deprecatedFields(v.Message(), path+".")...)
+		}
+		return true
+	})
+	sort.Strings(found)
+	return found
+}
+
+func fieldDeprecated(fd protoreflect.FieldDescriptor) bool {
+	opts, ok := fd.Options().(*descriptorpb.FieldOptions)
+	return ok && opts.GetDeprecated()
+}
@@ -0,0 +1,100 @@
+package bridge
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestDeprecatedMethodSetsHeader(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr})
+	h := b.Handler()
+
+	rec := post(h, "/test.v1.Echo/OldEcho", `{}`)
+	if got := rec.Header().Get("Deprecation"); rec.Code != 200 || got != "true" {
+		t.Fatalf("deprecated method: got %d with Deprecation %q, want 200 with true", rec.Code, got)
+	}
+	rec = post(h, "/test.v1.Echo/Echo", `{}`)
+	if got := rec.Header().Get("Deprecation"); got != "" {
+		t.Fatalf("current method: got Deprecation %q, want none", got)
+	}
+
+	services, _ := listServices(t, h, "?filter=test.v1.Echo")
+	if len(services) != 1 || len(services[0].Methods) == 0 {
+		t.Fatalf("/services listed %v, want test.v1.Echo", serviceNames(services))
+	}
+	for _, m := range services[0].Methods {
+		if m.Deprecated != (m.Name == "OldEcho") {
+			t.Fatalf("/services lists %s with deprecated %v", m.Name, m.Deprecated)
+		}
+	}
+}
+
+func TestRemovedMethodAnswersGone(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{
+		GRPCAddr: be.addr,
+		Methods: map[string]MethodConfig{
+			"test.v1.Echo/OldEcho": {Removed: true, Replacement: "test.v1.Echo/Echo"},
+		},
+	})
+	h := b.Handler()
+
+	rec := post(h, "/test.v1.Echo/OldEcho", `{}`)
+	var resp struct{ Error string }
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != 410 || be.Calls() != 0 {
+		t.Fatalf("got %d %s after %d backend calls, want 410 without any", rec.Code, rec.Body, be.Calls())
+	}
+	if !strings.Contains(resp.Error, "use test.v1.Echo/Echo instead") {
+		t.Fatalf("error %q doesn't point to the replacement", resp.Error)
+	}
+	if got, want := rec.Header().Get("Link"), `</test.v1.Echo/Echo>; rel="successor-version"`; got != want {
+		t.Fatalf("Link = %q, want %q", got, want)
+	}
+	if rec := post(h, "/test.v1.Echo/Echo", `{}`); rec.Code != 200 {
+		t.Fatalf("replacement: got %d %s", rec.Code, rec.Body)
+	}
+
+	_, err := NewBridge(Config{GRPCAddr: be.addr, Methods: map[string]MethodConfig{
+		"test.v1.Echo/OldEcho": {Replacement: "test.v1.Echo/Echo"},
+	}})
+	if err == nil {
+		t.Fatal("a replacement was accepted for a method that isn't removed")
+	}
+}
+
+func TestDeprecatedFieldUseSetsWarning(t *testing.T) {
+	// The descriptor set marks EchoRequest.tags deprecated
+	fd := protodesc.ToFileDescriptorProto(echoFile)
+	for _, f := range fd.MessageType[0].Field {
+		if f.GetName() == "tags" {
+			f.Options = &descriptorpb.FieldOptions{Deprecated: proto.Bool(true)}
+		}
+	}
+	set, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{fd}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "echo.binpb")
+	if err := os.WriteFile(path, set, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, DescriptorSet: path})
+	h := b.Handler()
+
+	rec := post(h, "/test.v1.Echo/Echo", `{"message": "hi", "tags": ["a"]}`)
+	if want := `299 - "deprecated fields used: tags"`; rec.Code != 200 || rec.Header().Get("Warning") != want {
+		t.Fatalf("got %d with Warning %q, want 200 with %q", rec.Code, rec.Header().Get("Warning"), want)
+	}
+	if rec := post(h, "/test.v1.Echo/Echo", `{"message": "hi"}`); rec.Code != 200 || rec.Header().Get("Warning") != "" {
+		t.Fatalf("no deprecated fields: got %d with Warning %q, want 200 without one", rec.Code, rec.Header().Get("Warning"))
+	}
+}
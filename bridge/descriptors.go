@@ -0,0 +1,141 @@
+package bridge
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// loadDescriptorFile reads a compiled schema: a FileDescriptorSet as
+// produced by protoc --descriptor_set_out, or a buf image from buf build.
+// A buf image is a FileDescriptorSet whose files carry an extra
+// buf-specific field, so both share one decoder. Binary and JSON
+// encodings are accepted, optionally gzip-compressed.
+func loadDescriptorFile(path string) (*protoregistry.Files, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read descriptor set: %w", err)
+	}
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid descriptor set %s: %w", path, err)
+		}
+		if data, err = io.ReadAll(zr); err != nil {
+			return nil, fmt.Errorf("invalid descriptor set %s: %w", path, err)
+		}
+	}
+
+	fds := &descriptorpb.FileDescriptorSet{}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		// buf's JSON images include a "bufExtension" member per file
+		err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, fds)
+	} else {
+		err = proto.Unmarshal(data, fds)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptor set %s: %w", path, err)
+	}
+
+	addMissingDependencies(fds)
+	files, err := protodesc.NewFiles(fds)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptor set %s: %w", path, err)
+	}
+	return files, nil
+}
+
+// addMissingDependencies appends imports that the set leaves out but that
+// are linked into the binary, such as the well-known types, which images
+// built with --exclude-imports omit.
+func addMissingDependencies(fds *descriptorpb.FileDescriptorSet) {
+	have := make(map[string]bool)
+	for _, fd := range fds.File {
+		have[fd.GetName()] = true
+	}
+	for i := 0; i < len(fds.File); i++ {
+		for _, dep := range fds.File[i].Dependency {
+			if have[dep] {
+				continue
+			}
+			if global, err := protoregistry.GlobalFiles.FindFileByPath(dep); err == nil {
+				fds.File = append(fds.File, protodesc.ToFileDescriptorProto(global))
+				have[dep] = true
+			}
+		}
+	}
+}
+
+// registerFiles registers every method of every service in files, so they
+// resolve without reflection.
+func (b *Bridge) registerFiles(files *protoregistry.Files) {
+	b.descriptorFiles = files
+	b.registeredMu.Lock()
+	defer b.registeredMu.Unlock()
+	if b.registered == nil {
+		b.registered = make(map[string]protoreflect.MethodDescriptor)
+	}
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		services := fd.Services()
+		for i := 0; i < services.Len(); i++ {
+			sd := services.Get(i)
+			methods := sd.Methods()
+			for j := 0; j < methods.Len(); j++ {
+				md := methods.Get(j)
+				b.registered[string(sd.FullName())+"/"+string(md.Name())] = md
+			}
+		}
+		return true
+	})
+}
+
+// descriptorSourceKey carries the descriptor source an X-Descriptor-Source
+// request header asked for.
+type descriptorSourceKey struct{}
+
+// Descriptor sources X-Descriptor-Source may force.
+const (
+	sourceReflection = "reflection"
+	sourceStatic     = "static"
+)
+
+// forceDescriptorSource reads X-Descriptor-Source, which with debug
+// headers enabled resolves the request's method from reflection or from
+// the static descriptors, loaded from a descriptor set or registered, even
+// when the other source has it too.
+func forceDescriptorSource(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		source := strings.ToLower(strings.TrimSpace(r.Header.Get("X-Descriptor-Source")))
+		switch source {
+		case "":
+		case sourceReflection, sourceStatic:
+			r = r.WithContext(context.WithValue(r.Context(), descriptorSourceKey{}, source))
+		default:
+			writeError(w, r, status.Errorf(codes.InvalidArgument, "invalid X-Descriptor-Source %q (want reflection or static)", source))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// descriptorSource returns the descriptor source forced for ctx's request,
+// or "" if either may be used.
+func descriptorSource(ctx context.Context) string {
+	source, _ := ctx.Value(descriptorSourceKey{}).(string)
+	return source
+}
@@ -0,0 +1,96 @@
+package bridge
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+)
+
+// bufImage encodes echoFile as buf build does with --exclude-imports: a
+// FileDescriptorSet whose file carries buf's extension field, without
+// the well-known types it imports.
+func bufImage(t *testing.T) []byte {
+	t.Helper()
+	file, err := proto.Marshal(protodesc.ToFileDescriptorProto(echoFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// buf.alpha.image.v1.ImageFileExtension{is_import: false}
+	var ext []byte
+	ext = protowire.AppendTag(ext, 1, protowire.VarintType)
+	ext = protowire.AppendVarint(ext, 0)
+	file = protowire.AppendTag(file, 8042, protowire.BytesType)
+	file = protowire.AppendBytes(file, ext)
+
+	var image []byte
+	image = protowire.AppendTag(image, 1, protowire.BytesType)
+	return protowire.AppendBytes(image, file)
+}
+
+func TestDescriptorSetLoadsBufImage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "image.binpb")
+	if err := os.WriteFile(path, bufImage(t), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	counter, lookups := countLookups()
+	be := startBackend(t, counter)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, DescriptorSet: path})
+	before := lookups.Load()
+
+	if _, err := b.descriptorFiles.FindDescriptorByName("test.v1.Echo.Echo"); err != nil {
+		t.Fatalf("image doesn't resolve test.v1.Echo.Echo: %v", err)
+	}
+	if rec := post(b.Handler(), "/test.v1.Echo/Echo", `{"message": "hi"}`); rec.Code != 200 {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+	if n := lookups.Load() - before; n != 0 {
+		t.Fatalf("method from the image made %d reflection lookups", n)
+	}
+}
+
+func TestDescriptorSourceHeaderForcesResolution(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "image.binpb")
+	if err := os.WriteFile(path, bufImage(t), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	counter, lookups := countLookups()
+	be := startBackend(t, counter)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, DescriptorSet: path, DebugHeaders: true})
+	h := b.Handler()
+
+	tests := []struct {
+		header, want string
+		lookups      bool
+	}{
+		{"", "static", false},
+		{"static", "static", false},
+		{"reflection", "reflection", true},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Descriptor-Source", tt.header)
+		before := lookups.Load()
+		rec := serve(h, req)
+		if got := rec.Header().Get("X-Descriptor-Source"); rec.Code != 200 || got != tt.want {
+			t.Fatalf("X-Descriptor-Source %q: got %d resolved from %q, want 200 from %q", tt.header, rec.Code, got, tt.want)
+		}
+		if looked := lookups.Load() > before; looked != tt.lookups {
+			t.Errorf("X-Descriptor-Source %q: reflection lookups made: %t, want %t", tt.header, looked, tt.lookups)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Descriptor-Source", "cache")
+	if rec := serve(h, req); rec.Code != 400 {
+		t.Fatalf("unknown source: got %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,182 @@
+package bridge
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const defaultShutdownTimeout = 10 * time.Second
+
+// drainer tracks the RPCs in flight so Shutdown can refuse new ones and
+// end the rest in stages: unary calls after the shutdown timeout, streams
+// after the longer stream drain timeout.
+type drainer struct {
+	mu       sync.Mutex
+	draining bool
+	calls    map[*drainCall]struct{}
+	idle     chan struct{} // closed once draining with no calls left
+
+	// window, if set, is reported to requests refused while draining
+	window *MaintenanceWindow
+}
+
+// drainCall is one tracked RPC.
+type drainCall struct {
+	stream atomic.Bool
+
+	mu      sync.Mutex
+	cancels []context.CancelFunc
+}
+
+type drainKey struct{}
+
+// track registers every request as a call, rejecting requests with 503
+// once shutdown has begun.
+func (d *drainer) track(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		c := &drainCall{cancels: []context.CancelFunc{cancel}}
+		if !d.add(c) {
+			w.Header().Set("Connection", "close")
+			var extra map[string]interface{}
+			if d.window != nil {
+				extra = map[string]interface{}{
+					"maintenance": maintenanceInfo{Reason: d.window.Reason, EstimatedEnd: timeOrNil(d.window.EstimatedEnd)},
+				}
+			}
+			writeErrorWith(w, r, withErrorCode(status.Error(codes.Unavailable, "bridge is shutting down"), errorBridgeUnavailable), extra)
+			return
+		}
+		defer d.remove(c)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, drainKey{}, c)))
+	})
+}
+
+func (d *drainer) add(c *drainCall) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.draining {
+		return false
+	}
+	if d.calls == nil {
+		d.calls = make(map[*drainCall]struct{})
+	}
+	d.calls[c] = struct{}{}
+	return true
+}
+
+func (d *drainer) remove(c *drainCall) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.calls, c)
+	if d.draining && len(d.calls) == 0 {
+		close(d.idle)
+	}
+}
+
+// start refuses new calls and returns a channel closed once the calls in
+// flight have ended.
+func (d *drainer) start() <-chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.draining {
+		d.draining = true
+		d.idle = make(chan struct{})
+		if len(d.calls) == 0 {
+			close(d.idle)
+		}
+	}
+	return d.idle
+}
+
+// cancel cancels the calls in flight, only unary ones unless streams is
+// set.
+func (d *drainer) cancel(streams bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for c := range d.calls {
+		if streams || !c.stream.Load() {
+			c.cancelAll()
+		}
+	}
+}
+
+func (c *drainCall) cancelAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, cancel := range c.cancels {
+		cancel()
+	}
+}
+
+// markStream gives the call ctx belongs to the stream drain timeout rather
+// than the unary one.
+func markStream(ctx context.Context) {
+	if c, ok := ctx.Value(drainKey{}).(*drainCall); ok {
+		c.stream.Store(true)
+	}
+}
+
+// detachStream returns a context with ctx's values that outlives ctx, for
+// streams that outlast their request such as WebSockets. Shutdown still
+// cancels it once the stream drain timeout has passed.
+func detachStream(ctx context.Context) (context.Context, context.CancelFunc) {
+	detached, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	if c, ok := ctx.Value(drainKey{}).(*drainCall); ok {
+		c.stream.Store(true)
+		c.mu.Lock()
+		c.cancels = append(c.cancels, cancel)
+		c.mu.Unlock()
+	}
+	return detached, cancel
+}
+
+// Shutdown stops the bridge gracefully. It stops accepting connections and
+// RPCs, lets unary calls in flight run for up to the shutdown timeout and
+// streams for up to the stream drain timeout, and cancels whatever is
+// still running after that. It returns once every call has ended, or with
+// ctx's error if ctx is done first. Serve then returns
+// http.ErrServerClosed. Backend connections stay open until Close.
+func (b *Bridge) Shutdown(ctx context.Context) error {
+	idle := b.drain.start()
+
+	grace := b.shutdownTimeout
+	if grace <= 0 {
+		grace = defaultShutdownTimeout
+	}
+	streamGrace := b.streamDrainTimeout
+	if streamGrace < grace {
+		streamGrace = grace
+	}
+	unaryTimer := time.AfterFunc(grace, func() { b.drain.cancel(false) })
+	defer unaryTimer.Stop()
+	streamTimer := time.AfterFunc(streamGrace, func() { b.drain.cancel(true) })
+	defer streamTimer.Stop()
+
+	b.serverMu.Lock()
+	srv := b.server
+	b.serverMu.Unlock()
+	if srv != nil {
+		if err := srv.Shutdown(ctx); err != nil {
+			srv.Close()
+			return err
+		}
+	}
+
+	// Hijacked connections, such as WebSockets, aren't waited for by
+	// http.Server.Shutdown
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		b.drain.cancel(true)
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,75 @@
+package bridge
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestShutdownGivesStreamsExtraDrainTime(t *testing.T) {
+	be := startBackend(t)
+	be.onUnary(func(ctx context.Context, req *dynamicpb.Message) (proto.Message, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, ShutdownTimeout: 100 * time.Millisecond, StreamDrainTimeout: 5 * time.Second})
+	srv := httptest.NewServer(b.Handler())
+	defer srv.Close()
+
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/test.v1.Echo/Bidi", "", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	ws.SetDeadline(time.Now().Add(5 * time.Second))
+	echo := func(msg string) {
+		t.Helper()
+		if err := websocket.Message.Send(ws, `{"message": "`+msg+`"}`); err != nil {
+			t.Fatal(err)
+		}
+		var got string
+		if err := websocket.Message.Receive(ws, &got); err != nil || !strings.Contains(got, msg) {
+			t.Fatalf("got %q, %v, want the echo of %s", got, err, msg)
+		}
+	}
+	echo("before")
+
+	unary := make(chan int)
+	go func() { unary <- post(b.Handler(), "/test.v1.Echo/Echo", `{}`).Code }()
+	// Both the stream and the unary call have reached the backend
+	for be.Calls() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	start := time.Now()
+	shutdown := make(chan error)
+	go func() { shutdown <- b.Shutdown(context.Background()) }()
+	if code := <-unary; code == 200 || time.Since(start) > time.Second {
+		t.Fatalf("unary call ended with %d after %v, want it cancelled after the 100ms grace", code, time.Since(start))
+	}
+
+	// Well past the unary grace, the stream still runs
+	time.Sleep(200 * time.Millisecond)
+	echo("during shutdown")
+	select {
+	case err := <-shutdown:
+		t.Fatalf("Shutdown returned %v with a stream still open", err)
+	default:
+	}
+
+	ws.Close()
+	select {
+	case err := <-shutdown:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Shutdown didn't return once the stream ended")
+	}
+}
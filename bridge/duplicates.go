@@ -0,0 +1,110 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// duplicateMode controls how request bodies with the same key twice in an
+// object are handled. protojson rejects them unless other rewriting, such
+// as null handling, has already merged them, so the bridge settles them
+// up front instead.
+type duplicateMode string
+
+const (
+	// duplicateLast keeps the last of the duplicated members.
+	duplicateLast duplicateMode = "last"
+	// duplicateError rejects the request.
+	duplicateError duplicateMode = "error"
+)
+
+func parseDuplicateMode(s string) (duplicateMode, error) {
+	switch m := duplicateMode(s); m {
+	case "":
+		return duplicateLast, nil
+	case duplicateLast, duplicateError:
+		return m, nil
+	default:
+		return "", fmt.Errorf("invalid duplicate key mode %q (want last or error)", s)
+	}
+}
+
+// applyDuplicateMode rewrites or validates body according to mode. Bodies
+// without duplicate keys are returned as they are.
+func applyDuplicateMode(mode duplicateMode, body []byte) ([]byte, error) {
+	if !json.Valid(body) {
+		// Leave syntax errors for protojson to report
+		return body, nil
+	}
+	var violations []*fieldViolation
+	findDuplicates(body, "", &violations)
+	if len(violations) == 0 {
+		return body, nil
+	}
+	if mode == duplicateError {
+		return nil, badRequest(violations, "invalid request body: duplicate key %s", violations[0].Field)
+	}
+	return keepLastMembers(body), nil
+}
+
+func findDuplicates(data []byte, path string, out *[]*fieldViolation) {
+	if members, err := objectMembers(data); err == nil {
+		seen := make(map[string]bool, len(members))
+		for _, m := range members {
+			memberPath := joinFieldPath(path, m.name)
+			if seen[m.name] {
+				addViolation(out, memberPath, "duplicate key")
+			}
+			seen[m.name] = true
+			findDuplicates(m.value, memberPath, out)
+		}
+		return
+	}
+	var elems []json.RawMessage
+	if isJSONArray(data) && json.Unmarshal(data, &elems) == nil {
+		for i, elem := range elems {
+			findDuplicates(elem, fmt.Sprintf("%s[%d]", path, i), out)
+		}
+	}
+}
+
+// keepLastMembers rewrites data keeping only the last member of each
+// object for every key.
+func keepLastMembers(data []byte) []byte {
+	var buf bytes.Buffer
+	if members, err := objectMembers(data); err == nil {
+		last := make(map[string]int, len(members))
+		for i, m := range members {
+			last[m.name] = i
+		}
+		buf.WriteByte('{')
+		n := 0
+		for i, m := range members {
+			if last[m.name] == i {
+				writeMember(&buf, n, m.name, keepLastMembers(m.value))
+				n++
+			}
+		}
+		buf.WriteByte('}')
+		return buf.Bytes()
+	}
+	var elems []json.RawMessage
+	if !isJSONArray(data) || json.Unmarshal(data, &elems) != nil {
+		return data
+	}
+	buf.WriteByte('[')
+	for i, elem := range elems {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(keepLastMembers(elem))
+	}
+	buf.WriteByte(']')
+	return buf.Bytes()
+}
+
+func isJSONArray(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && data[0] == '['
+}
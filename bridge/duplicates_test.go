@@ -0,0 +1,36 @@
+package bridge
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDuplicateKeys(t *testing.T) {
+	be := startBackend(t)
+	body := `{"message": "first", "data": {"a": 1, "a": 2}, "message": "second"}`
+
+	strict := newTestBridge(t, Config{GRPCAddr: be.addr, DuplicateKeys: "error"})
+	rec := post(strict.Handler(), "/test.v1.Echo/Echo", body)
+	if rec.Code != 400 || be.Calls() != 0 {
+		t.Fatalf("strict: got %d %s with %d backend calls, want 400 without any", rec.Code, rec.Body, be.Calls())
+	}
+	for _, field := range []string{`"message"`, `"data.a"`} {
+		if !strings.Contains(rec.Body.String(), field) {
+			t.Fatalf("strict: %s doesn't name the duplicate %s", rec.Body, field)
+		}
+	}
+
+	lenient := newTestBridge(t, Config{GRPCAddr: be.addr})
+	rec = post(lenient.Handler(), "/test.v1.Echo/Echo", body)
+	var resp struct {
+		Message string
+		Data    map[string]float64
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != 200 {
+		t.Fatalf("default: got %d %s", rec.Code, rec.Body)
+	}
+	if resp.Message != "second" || resp.Data["a"] != 2 {
+		t.Fatalf("default: got %+v, want the last value of each key", resp)
+	}
+}
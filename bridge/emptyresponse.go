@@ -0,0 +1,62 @@
+package bridge
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+)
+
+// emptyResponse is how a successful unary response without any populated
+// field, whatever its type, is answered.
+type emptyResponse string
+
+const (
+	// emptyResponseDefault renders the message like any other, with its
+	// unpopulated fields unless they are omitted.
+	emptyResponseDefault   emptyResponse = ""
+	emptyResponseObject    emptyResponse = "object"
+	emptyResponseBody      emptyResponse = "empty"
+	emptyResponseNoContent emptyResponse = "no-content"
+)
+
+func parseEmptyResponse(s string) (emptyResponse, error) {
+	switch e := emptyResponse(s); e {
+	case emptyResponseDefault, emptyResponseObject, emptyResponseBody, emptyResponseNoContent:
+		return e, nil
+	default:
+		return "", fmt.Errorf("invalid empty response %q (want object, empty or no-content)", s)
+	}
+}
+
+// emptyResponseFor returns how an empty response of method, as
+// "{service}/{method}", is answered.
+func (b *Bridge) emptyResponseFor(method string) emptyResponse {
+	if e, ok := b.emptyResponses[method]; ok {
+		return e
+	}
+	return b.emptyResponse
+}
+
+// isEmptyObject reports whether resp is the JSON object {}, which is what
+// an empty response is rendered as once emptyResponseFor applies.
+func isEmptyObject(resp []byte) bool {
+	return bytes.Equal(bytes.TrimSpace(resp), []byte("{}"))
+}
+
+// writeEmptyResponse answers an empty response to method with an empty
+// body or 204 if so configured, and reports whether it did.
+func (b *Bridge) writeEmptyResponse(w http.ResponseWriter, method string, resp []byte) bool {
+	if !isEmptyObject(resp) {
+		return false
+	}
+	switch b.emptyResponseFor(method) {
+	case emptyResponseBody:
+		w.Header().Set("Content-Length", "0")
+		w.WriteHeader(http.StatusOK)
+		return true
+	case emptyResponseNoContent:
+		w.WriteHeader(http.StatusNoContent)
+		return true
+	}
+	return false
+}
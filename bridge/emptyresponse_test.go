@@ -0,0 +1,52 @@
+package bridge
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEmptyResponseAnsweredAsConfigured(t *testing.T) {
+	tests := []struct {
+		name     string
+		global   string
+		method   string
+		wantCode int
+		wantBody string
+	}{
+		{"object", "object", "", 200, "{}"},
+		{"empty body", "empty", "", 200, ""},
+		{"no content", "no-content", "", 204, ""},
+		{"method override", "no-content", "object", 200, "{}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			be := startBackend(t)
+			b := newTestBridge(t, Config{
+				GRPCAddr:      be.addr,
+				EmptyResponse: tt.global,
+				Methods: map[string]MethodConfig{
+					"test.v1.Echo/Echo": {EmptyResponse: tt.method},
+				},
+			})
+			h := b.Handler()
+
+			rec := post(h, "/test.v1.Echo/Echo", `{}`)
+			if got := strings.TrimSpace(rec.Body.String()); rec.Code != tt.wantCode || got != tt.wantBody {
+				t.Fatalf("empty response: got %d %q, want %d %q", rec.Code, got, tt.wantCode, tt.wantBody)
+			}
+
+			rec = post(h, "/test.v1.Echo/Echo", `{"message": "hi"}`)
+			var resp struct {
+				Message string `json:"message"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != 200 || resp.Message != "hi" {
+				t.Fatalf("populated response: got %d %s", rec.Code, rec.Body)
+			}
+		})
+	}
+
+	if _, err := NewBridge(Config{GRPCAddr: "localhost:1", EmptyResponse: "null"}); err == nil {
+		t.Fatal("an invalid empty response was accepted")
+	}
+}
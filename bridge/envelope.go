@@ -0,0 +1,112 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+)
+
+// envelope wraps list responses as {"items", "next_page_token", "total"},
+// so every list method has the same shape whatever its fields are called.
+type envelope struct {
+	items, nextPageToken, total string
+	status                      bool
+}
+
+func newEnvelope(cfg *EnvelopeConfig) (*envelope, error) {
+	if cfg.Items == "" {
+		return nil, fmt.Errorf("items must name the repeated field to wrap")
+	}
+	return &envelope{items: cfg.Items, nextPageToken: cfg.NextPageToken, total: cfg.Total, status: cfg.Status}, nil
+}
+
+// apply builds the envelope from a JSON response message, with the call's
+// timing under "_timing" if it is given.
+func (e *envelope) apply(data []byte, timing *callTiming) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	out := struct {
+		Items         json.RawMessage `json:"items"`
+		NextPageToken string          `json:"next_page_token"`
+		Total         json.RawMessage `json:"total,omitempty"`
+		Code          string          `json:"code,omitempty"`
+		GRPCStatus    *codes.Code     `json:"grpc_status,omitempty"`
+		Timing        *timingBlock    `json:"_timing,omitempty"`
+	}{Items: json.RawMessage("[]")}
+	if timing != nil {
+		out.Timing = timing.block()
+	}
+	if e.status {
+		ok := codes.OK
+		out.Code, out.GRPCStatus = ok.String(), &ok
+	}
+
+	if items, ok := envelopeField(fields, e.items); ok && !isJSONNull(items) {
+		out.Items = items
+	}
+	if e.nextPageToken != "" {
+		if token, ok := envelopeField(fields, e.nextPageToken); ok && !isJSONNull(token) {
+			if err := json.Unmarshal(token, &out.NextPageToken); err != nil {
+				return nil, fmt.Errorf("%s is not a string", e.nextPageToken)
+			}
+		}
+	}
+	if e.total != "" {
+		out.Total = json.RawMessage("0")
+		if total, ok := envelopeField(fields, e.total); ok && !isJSONNull(total) {
+			// protojson quotes 64-bit integers
+			if s, err := strconv.Unquote(string(total)); err == nil {
+				total = json.RawMessage(s)
+			}
+			if _, err := strconv.ParseFloat(string(total), 64); err != nil {
+				return nil, fmt.Errorf("%s is not a number", e.total)
+			}
+			out.Total = total
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(out); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// envelopeField looks name up in fields under either its proto name, e.g.
+// next_page_token, or its JSON name, nextPageToken, so config works with
+// either naming in responses.
+func envelopeField(fields map[string]json.RawMessage, name string) (json.RawMessage, bool) {
+	if v, ok := fields[name]; ok {
+		return v, true
+	}
+	v, ok := fields[jsonCamelCase(name)]
+	return v, ok
+}
+
+// jsonCamelCase converts a proto field name to its default JSON name.
+func jsonCamelCase(name string) string {
+	var sb strings.Builder
+	upper := false
+	for _, c := range name {
+		switch {
+		case c == '_':
+			upper = true
+		case upper && 'a' <= c && c <= 'z':
+			sb.WriteRune(c - 'a' + 'A')
+			upper = false
+		default:
+			sb.WriteRune(c)
+			upper = false
+		}
+	}
+	return sb.String()
+}
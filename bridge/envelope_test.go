@@ -0,0 +1,126 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestEnvelopeWrapsListResponse(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{
+		GRPCAddr: be.addr,
+		Methods: map[string]MethodConfig{
+			"test.v1.Echo/Echo": {Envelope: &EnvelopeConfig{Items: "tags", NextPageToken: "message", Total: "count"}},
+		},
+	})
+	h := b.Handler()
+
+	tests := []struct {
+		body string
+		want map[string]interface{}
+	}{
+		{
+			`{"tags": ["a", "b"], "message": "page-2", "count": 7}`,
+			map[string]interface{}{"items": []interface{}{"a", "b"}, "next_page_token": "page-2", "total": 7.0},
+		},
+		{
+			`{}`,
+			map[string]interface{}{"items": []interface{}{}, "next_page_token": "", "total": 0.0},
+		},
+	}
+	for _, tt := range tests {
+		rec := post(h, "/test.v1.Echo/Echo", tt.body)
+		if rec.Code != 200 {
+			t.Fatalf("%s: got %d %s", tt.body, rec.Code, rec.Body)
+		}
+		var got map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("%s: got %v, want %v", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestEnvelopeTimingBlockOnRequest(t *testing.T) {
+	be := startBackend(t)
+	be.onUnary(func(ctx context.Context, req *dynamicpb.Message) (proto.Message, error) {
+		time.Sleep(20 * time.Millisecond)
+		return req, nil
+	})
+	b := newTestBridge(t, Config{
+		GRPCAddr: be.addr,
+		Methods: map[string]MethodConfig{
+			"test.v1.Echo/Echo": {Envelope: &EnvelopeConfig{Items: "tags"}},
+		},
+	})
+
+	for _, flags := range []string{"", "timing"} {
+		req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", strings.NewReader(`{"tags": ["a"]}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Bridge-Flags", flags)
+		rec := serve(b.Handler(), req)
+		var resp struct {
+			Items  []string
+			Timing map[string]float64 `json:"_timing"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != 200 {
+			t.Fatalf("flags %q: got %d %s", flags, rec.Code, rec.Body)
+		}
+		if flags == "" {
+			if resp.Timing != nil {
+				t.Fatalf("timing reported without being asked for: %v", resp.Timing)
+			}
+			continue
+		}
+		for _, key := range []string{"resolve_ms", "backend_ms", "marshal_ms"} {
+			if _, ok := resp.Timing[key]; !ok {
+				t.Errorf("_timing %v has no %s", resp.Timing, key)
+			}
+		}
+		if resp.Timing["backend_ms"] < 20 {
+			t.Errorf("backend_ms = %v, want at least the backend's 20ms", resp.Timing["backend_ms"])
+		}
+	}
+}
+
+func TestEnvelopeStatusReportsOK(t *testing.T) {
+	for _, status := range []bool{false, true} {
+		be := startBackend(t)
+		b := newTestBridge(t, Config{
+			GRPCAddr: be.addr,
+			Methods: map[string]MethodConfig{
+				"test.v1.Echo/Echo": {Envelope: &EnvelopeConfig{Items: "tags", Status: status}},
+			},
+		})
+		rec := post(b.Handler(), "/test.v1.Echo/Echo", `{"tags": ["a"]}`)
+		if rec.Code != 200 {
+			t.Fatalf("status %v: got %d %s", status, rec.Code, rec.Body)
+		}
+		var got map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		code, hasCode := got["code"]
+		grpcStatus, hasStatus := got["grpc_status"]
+		if !status {
+			if hasCode || hasStatus {
+				t.Fatalf("status reported without the option: %s", rec.Body)
+			}
+			continue
+		}
+		if code != "OK" || !hasStatus || grpcStatus != 0.0 {
+			t.Fatalf("got code %v grpc_status %v, want OK and 0", code, grpcStatus)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package bridge
+
+import (
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorCode is the bridge's own name for the condition behind an error
+// response, returned under "error_code" so that clients can branch on it
+// whatever gRPC code and HTTP status the error is answered with.
+type errorCode string
+
+const (
+	// errorInvalidPayload is a request body that isn't a valid request
+	// message: malformed JSON or form data, unknown fields, values of the
+	// wrong type, or a body that fails the method's request schema.
+	errorInvalidPayload errorCode = "INVALID_PAYLOAD"
+	// errorInvalidRequest is any other request rejected as invalid, by the
+	// bridge or by the backend.
+	errorInvalidRequest errorCode = "INVALID_REQUEST"
+	// errorMethodNotFound is a service or method that can't be resolved.
+	errorMethodNotFound errorCode = "METHOD_NOT_FOUND"
+	// errorBridgeTimeout is a call that ran past its deadline.
+	errorBridgeTimeout errorCode = "BRIDGE_TIMEOUT"
+	// errorBackendUnavailable is a backend that can't be reached or that
+	// reported itself unavailable, including while its circuit is open.
+	errorBackendUnavailable errorCode = "BACKEND_UNAVAILABLE"
+	// errorBridgeOverloaded is a request shed by the bridge's concurrency
+	// limits.
+	errorBridgeOverloaded errorCode = "BRIDGE_OVERLOADED"
+	// errorBridgeUnavailable is a request refused during maintenance or
+	// shutdown.
+	errorBridgeUnavailable errorCode = "BRIDGE_UNAVAILABLE"
+	// errorRateLimited is a rate limit or quota, the bridge's or the
+	// backend's.
+	errorRateLimited        errorCode = "RATE_LIMITED"
+	errorUnauthenticated    errorCode = "UNAUTHENTICATED"
+	errorPermissionDenied   errorCode = "PERMISSION_DENIED"
+	errorNotFound           errorCode = "NOT_FOUND"
+	errorConflict           errorCode = "CONFLICT"
+	errorPreconditionFailed errorCode = "PRECONDITION_FAILED"
+	errorNotImplemented     errorCode = "NOT_IMPLEMENTED"
+	errorCanceled           errorCode = "CANCELED"
+	errorInternal           errorCode = "INTERNAL_ERROR"
+)
+
+// withErrorCode tags err with the bridge error code of the condition that
+// caused it, which takes precedence over the one its gRPC code maps to.
+// err's status is unchanged.
+func withErrorCode(err error, code errorCode) error {
+	if err == nil {
+		return nil
+	}
+	return &codedError{err: err, code: code}
+}
+
+// invalidPayload tags err, the failure to decode a request body, as
+// INVALID_PAYLOAD.
+func invalidPayload(err error) error {
+	return withErrorCode(err, errorInvalidPayload)
+}
+
+type codedError struct {
+	err  error
+	code errorCode
+}
+
+func (e *codedError) Error() string              { return e.err.Error() }
+func (e *codedError) GRPCStatus() *status.Status { return status.Convert(e.err) }
+func (e *codedError) Unwrap() error              { return e.err }
+
+// errorCodeOf returns the bridge error code of err: the one it was tagged
+// with, or else the one its gRPC code maps to.
+func errorCodeOf(err error) errorCode {
+	var ce *codedError
+	if errors.As(err, &ce) {
+		return ce.code
+	}
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.OutOfRange:
+		return errorInvalidRequest
+	case codes.DeadlineExceeded:
+		return errorBridgeTimeout
+	case codes.Unavailable:
+		return errorBackendUnavailable
+	case codes.ResourceExhausted:
+		return errorRateLimited
+	case codes.Unauthenticated:
+		return errorUnauthenticated
+	case codes.PermissionDenied:
+		return errorPermissionDenied
+	case codes.NotFound:
+		return errorNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return errorConflict
+	case codes.FailedPrecondition:
+		return errorPreconditionFailed
+	case codes.Unimplemented:
+		return errorNotImplemented
+	case codes.Canceled:
+		return errorCanceled
+	default:
+		return errorInternal
+	}
+}
@@ -0,0 +1,74 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestErrorResponsesCarryStableCodes(t *testing.T) {
+	be := startBackend(t)
+	be.onUnary(func(ctx context.Context, req *dynamicpb.Message) (proto.Message, error) {
+		switch req.Get(echoRequest.Fields().ByName("message")).String() {
+		case "slow":
+			<-ctx.Done()
+			return nil, ctx.Err()
+		case "down":
+			return nil, status.Error(codes.Unavailable, "down")
+		case "bad":
+			return nil, status.Error(codes.InvalidArgument, "bad")
+		}
+		return req, nil
+	})
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, CallTimeout: 50 * time.Millisecond})
+	h := b.Handler()
+
+	tests := []struct {
+		name, path, body string
+		want             errorCode
+	}{
+		{"timeout", "/test.v1.Echo/Echo", `{"message": "slow"}`, errorBridgeTimeout},
+		{"malformed body", "/test.v1.Echo/Echo", `{"message": `, errorInvalidPayload},
+		{"wrong type", "/test.v1.Echo/Echo", `{"count": "three"}`, errorInvalidPayload},
+		{"unknown method", "/test.v1.Echo/Missing", `{}`, errorMethodNotFound},
+		{"backend unavailable", "/test.v1.Echo/Echo", `{"message": "down"}`, errorBackendUnavailable},
+		{"backend rejected", "/test.v1.Echo/Echo", `{"message": "bad"}`, errorInvalidRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := post(h, tt.path, tt.body)
+			var resp struct {
+				ErrorCode errorCode `json:"error_code"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("%d %s: %v", rec.Code, rec.Body, err)
+			}
+			if resp.ErrorCode != tt.want {
+				t.Fatalf("got %d %s, want error_code %s", rec.Code, rec.Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestTaggedErrorCodeTakesPrecedence(t *testing.T) {
+	err := status.Error(codes.InvalidArgument, "invalid request body")
+	if got := errorCodeOf(err); got != errorInvalidRequest {
+		t.Fatalf("untagged: got %s, want %s", got, errorInvalidRequest)
+	}
+	tagged := invalidPayload(err)
+	if got := errorCodeOf(tagged); got != errorInvalidPayload {
+		t.Fatalf("tagged: got %s, want %s", got, errorInvalidPayload)
+	}
+	if status.Code(tagged) != codes.InvalidArgument {
+		t.Fatalf("tagging changed the status to %s", status.Code(tagged))
+	}
+	if withErrorCode(nil, errorInternal) != nil {
+		t.Fatal("tagging nil returned an error")
+	}
+}
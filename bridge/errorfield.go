@@ -0,0 +1,143 @@
+package bridge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorField finds domain errors that a backend reports in a field of an
+// otherwise successful response.
+type errorField struct {
+	path     []string
+	message  []string
+	code     codes.Code
+	status   int
+	statuses map[string]int
+	ok       map[string]bool
+}
+
+func newErrorField(cfg *ErrorFieldConfig) (*errorField, error) {
+	if cfg.Field == "" {
+		return nil, fmt.Errorf("field must name the response field holding the error")
+	}
+	f := &errorField{
+		path:     strings.Split(cfg.Field, "."),
+		code:     codes.FailedPrecondition,
+		status:   cfg.Status,
+		statuses: cfg.Statuses,
+		ok:       make(map[string]bool),
+	}
+	if cfg.Message != "" {
+		f.message = strings.Split(cfg.Message, ".")
+	}
+	if cfg.Code != "" {
+		if err := f.code.UnmarshalJSON([]byte(strconv.Quote(strings.ToUpper(cfg.Code)))); err != nil || f.code == codes.OK {
+			return nil, fmt.Errorf("invalid code %q (want a gRPC code other than OK, like NOT_FOUND)", cfg.Code)
+		}
+	}
+	if f.status == 0 {
+		f.status = httpStatusFromCode(f.code)
+	}
+	for value, code := range cfg.Statuses {
+		if code < 400 || code > 599 {
+			return nil, fmt.Errorf("invalid status %d for %q (want 4xx or 5xx)", code, value)
+		}
+	}
+	if f.status < 400 || f.status > 599 {
+		return nil, fmt.Errorf("invalid status %d (want 4xx or 5xx)", f.status)
+	}
+	for _, v := range cfg.OK {
+		f.ok[v] = true
+	}
+	return f, nil
+}
+
+// responseError is a domain error found in a successful response, answered
+// with the HTTP status configured for it rather than its code's.
+type responseError struct {
+	st         *status.Status
+	httpStatus int
+}
+
+func (e *responseError) Error() string              { return e.st.Err().Error() }
+func (e *responseError) GRPCStatus() *status.Status { return e.st }
+
+// responseErrorStatus returns the HTTP status err is answered with: the
+// configured one for domain errors in responses, code otherwise.
+func responseErrorStatus(err error, code int) int {
+	var re *responseError
+	if errors.As(err, &re) {
+		return re.httpStatus
+	}
+	return code
+}
+
+// check returns the error that the JSON response data of method reports,
+// or nil if the error field is unset, empty, zero or one of the values
+// configured as OK. The value is passed on as the reason of an ErrorInfo
+// detail.
+func (f *errorField) check(method string, data []byte) error {
+	raw, ok := lookupField(data, f.path)
+	if !ok || isJSONNull(raw) {
+		return nil
+	}
+	value := string(raw)
+	if s, err := strconv.Unquote(value); err == nil {
+		value = s
+	}
+	switch value {
+	case "", "0", "false", "{}", "[]":
+		return nil
+	}
+	if f.ok[value] {
+		return nil
+	}
+
+	msg := fmt.Sprintf("%s: %s", strings.Join(f.path, "."), value)
+	if f.message != nil {
+		if m, ok := lookupField(data, f.message); ok {
+			var s string
+			if json.Unmarshal(m, &s) == nil && s != "" {
+				msg = s
+			}
+		}
+	}
+	st := status.New(f.code, msg)
+	service, _, _ := strings.Cut(method, "/")
+	if withInfo, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   value,
+		Domain:   service,
+		Metadata: map[string]string{"field": strings.Join(f.path, ".")},
+	}); err == nil {
+		st = withInfo
+	}
+	httpStatus := f.status
+	if s, ok := f.statuses[value]; ok {
+		httpStatus = s
+	}
+	return &responseError{st: st, httpStatus: httpStatus}
+}
+
+// lookupField follows path through nested JSON objects in data, matching
+// each field by its proto or JSON name.
+func lookupField(data []byte, path []string) (json.RawMessage, bool) {
+	v := json.RawMessage(data)
+	for _, name := range path {
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(v, &fields) != nil {
+			return nil, false
+		}
+		var ok bool
+		if v, ok = envelopeField(fields, name); !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
@@ -0,0 +1,66 @@
+package bridge
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestErrorFieldTranslatesSuccessfulResponse(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{
+		GRPCAddr: be.addr,
+		Methods: map[string]MethodConfig{
+			"test.v1.Echo/Echo": {ErrorField: &ErrorFieldConfig{
+				Field:    "message",
+				OK:       []string{"ERROR_CODE_UNSPECIFIED"},
+				Code:     "not_found",
+				Statuses: map[string]int{"OUT_OF_STOCK": 409},
+			}},
+		},
+	})
+	h := b.Handler()
+
+	tests := []struct {
+		message    string
+		wantStatus int
+	}{
+		{"", 200},
+		{"ERROR_CODE_UNSPECIFIED", 200},
+		{"NO_SUCH_ITEM", 404},
+		{"OUT_OF_STOCK", 409},
+	}
+	for _, tt := range tests {
+		rec := post(h, "/test.v1.Echo/Echo", `{"message": "`+tt.message+`"}`)
+		if rec.Code != tt.wantStatus {
+			t.Fatalf("%q: got %d %s, want %d", tt.message, rec.Code, rec.Body, tt.wantStatus)
+		}
+		if tt.wantStatus == 200 {
+			continue
+		}
+		var resp struct {
+			Error   string `json:"error"`
+			Code    string `json:"code"`
+			Details []struct {
+				Reason   string            `json:"reason"`
+				Domain   string            `json:"domain"`
+				Metadata map[string]string `json:"metadata"`
+			} `json:"details"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Error != "message: "+tt.message || resp.Code != "NotFound" || len(resp.Details) != 1 {
+			t.Fatalf("%q: got %s", tt.message, rec.Body)
+		}
+		if d := resp.Details[0]; d.Reason != tt.message || d.Domain != "test.v1.Echo" || d.Metadata["field"] != "message" {
+			t.Fatalf("%q: ErrorInfo %+v", tt.message, d)
+		}
+	}
+
+	for _, cfg := range []*ErrorFieldConfig{{}, {Field: "message", Code: "OK"}, {Field: "message", Status: 200}} {
+		_, err := NewBridge(Config{GRPCAddr: be.addr, Methods: map[string]MethodConfig{"test.v1.Echo/Echo": {ErrorField: cfg}}})
+		if err == nil {
+			t.Errorf("error field %+v was accepted", cfg)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// errorResponse renders err as a JSON error body with the HTTP status
+// matching its gRPC code and the bridge error code of its condition under
+// "error_code". Status details, such as google.rpc.BadRequest,
+// are included in their protojson form under "details".
+func errorResponse(err error) (int, []byte) {
+	code, resp := errorFields(err)
+	body, _ := json.Marshal(resp)
+	return code, body
+}
+
+func errorFields(err error) (int, map[string]interface{}) {
+	st := status.Convert(err)
+	resp := map[string]interface{}{
+		"error":      st.Message(),
+		"code":       st.Code().String(),
+		"error_code": errorCodeOf(err),
+	}
+	if details := st.Proto().GetDetails(); len(details) > 0 {
+		rendered := make([]json.RawMessage, 0, len(details))
+		for _, d := range details {
+			if b, err := detailMarshaler.Marshal(d); err == nil {
+				rendered = append(rendered, b)
+			}
+		}
+		resp["details"] = rendered
+	}
+	return httpStatusFromCode(st.Code()), resp
+}
+
+// errorResponse renders err like the errorResponse function. In dev mode
+// it adds the chain of errors wrapped by err under "error_chain", to help
+// debug resolution and invocation failures; production responses leave
+// it out.
+func (b *Bridge) errorResponse(err error) (int, []byte) {
+	if !b.devMode {
+		return errorResponse(err)
+	}
+	code, resp := errorFields(err)
+	resp["error_chain"] = errorChain(err, nil)
+	body, _ := json.Marshal(resp)
+	return code, body
+}
+
+// streamError renders err as the error frame that ends a stream, which
+// unlike an error response carries the request's ID under "request_id",
+// since the frame may be read long after the response headers.
+func (b *Bridge) streamError(ctx context.Context, err error) []byte {
+	_, resp := errorFields(err)
+	if b.devMode {
+		resp["error_chain"] = errorChain(err, nil)
+	}
+	if id := middleware.GetReqID(ctx); id != "" {
+		resp["request_id"] = id
+	}
+	body, _ := json.Marshal(resp)
+	return body
+}
+
+func (b *Bridge) writeError(w http.ResponseWriter, err error) {
+	code, body := b.errorResponse(err)
+	b.writeJSON(w, code, body)
+}
+
+// withCause attaches cause to the status error st, so that the chain of
+// errors that led to it shows in dev mode. st's code, message and details
+// are unchanged.
+func withCause(st, cause error) error {
+	return &causedError{st: st, cause: cause}
+}
+
+type causedError struct {
+	st    error
+	cause error
+}
+
+func (e *causedError) Error() string              { return e.st.Error() }
+func (e *causedError) GRPCStatus() *status.Status { return status.Convert(e.st) }
+func (e *causedError) Unwrap() error              { return e.cause }
+
+// chainLink is one error of an error chain, outermost first.
+type chainLink struct {
+	Type  string `json:"type"`
+	Error string `json:"error"`
+}
+
+func errorChain(err error, chain []chainLink) []chainLink {
+	for err != nil {
+		if ce, ok := err.(*codedError); ok {
+			err = ce.err
+			continue
+		}
+		link := err
+		if ce, ok := err.(*causedError); ok {
+			link = ce.st
+		}
+		chain = append(chain, chainLink{Type: fmt.Sprintf("%T", link), Error: err.Error()})
+		switch u := err.(type) {
+		case interface{ Unwrap() []error }:
+			for _, e := range u.Unwrap() {
+				chain = errorChain(e, chain)
+			}
+			return chain
+		case interface{ Unwrap() error }:
+			err = u.Unwrap()
+		default:
+			err = nil
+		}
+	}
+	return chain
+}
+
+// detailMarshaler uses proto field names, matching how google.rpc error
+// details are documented (e.g. field_violations).
+var detailMarshaler = protojson.MarshalOptions{UseProtoNames: true}
+
+// writeError is the error writer of middlewares that don't hold the
+// Bridge; they learn its trailing newline setting from r's context.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	writeErrorWith(w, r, err, nil)
+}
+
+// writeErrorWith is writeError with extra members added to the body.
+func writeErrorWith(w http.ResponseWriter, r *http.Request, err error, extra map[string]interface{}) {
+	code, resp := errorFields(err)
+	for k, v := range extra {
+		resp[k] = v
+	}
+	body, _ := json.Marshal(resp)
+	if r.Context().Value(jsonNewlineKey{}) != nil {
+		body = withNewline(body)
+	}
+	writeJSON(w, code, body)
+}
+
+func writeJSON(w http.ResponseWriter, code int, body []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(body)
+}
+
+// writeJSON writes a JSON body, ending it with a newline if the bridge is
+// configured to.
+func (b *Bridge) writeJSON(w http.ResponseWriter, code int, body []byte) {
+	if b.jsonNewline {
+		body = withNewline(body)
+	}
+	writeJSON(w, code, body)
+}
+
+// encodeJSON writes v as a JSON body.
+func (b *Bridge) encodeJSON(w http.ResponseWriter, code int, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		b.writeError(w, withCause(status.Errorf(codes.Internal, "failed to encode response: %v", err), err))
+		return
+	}
+	b.writeJSON(w, code, body)
+}
+
+// jsonNewlineKey marks requests to a bridge that ends JSON bodies with a
+// newline.
+type jsonNewlineKey struct{}
+
+func markJSONNewline(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), jsonNewlineKey{}, true)))
+	})
+}
+
+func withNewline(body []byte) []byte {
+	if bytes.HasSuffix(body, []byte("\n")) {
+		return body
+	}
+	return append(body[:len(body):len(body)], '\n')
+}
+
+// httpStatusFromCode maps gRPC status codes to HTTP status codes, following
+// the mapping in google/rpc/code.proto.
+func httpStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,69 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDevModeErrorChain(t *testing.T) {
+	be := startBackend(t)
+	for _, dev := range []bool{true, false} {
+		b := newTestBridge(t, Config{GRPCAddr: be.addr, DevMode: dev})
+		rec := post(b.Handler(), "/test.v1.Echo/Echo", `{"count": "x"}`)
+		var resp struct {
+			Error      string
+			ErrorChain []chainLink `json:"error_chain"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != 400 {
+			t.Fatalf("dev mode %v: got %d %s", dev, rec.Code, rec.Body)
+		}
+		if !dev {
+			if resp.ErrorChain != nil {
+				t.Fatalf("production response includes the error chain: %s", rec.Body)
+			}
+			continue
+		}
+		// The status error, then the protojson error it wraps and so on
+		if len(resp.ErrorChain) < 2 || resp.ErrorChain[0].Type != "*status.Error" {
+			t.Fatalf("error chain %+v, want the status error followed by its causes", resp.ErrorChain)
+		}
+		if cause := resp.ErrorChain[1]; cause.Type == "*status.Error" || cause.Error == "" || cause.Error == resp.Error {
+			t.Fatalf("second link %+v isn't the decoding error", cause)
+		}
+	}
+}
+
+func TestJSONTrailingNewline(t *testing.T) {
+	be := startBackend(t)
+	for _, newline := range []bool{false, true} {
+		b := newTestBridge(t, Config{GRPCAddr: be.addr, JSONTrailingNewline: newline})
+		h := b.Handler()
+
+		badTimeout := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", strings.NewReader(`{}`))
+		badTimeout.Header.Set("Content-Type", "application/json")
+		badTimeout.Header.Set("Grpc-Timeout", "soon")
+		responses := []struct {
+			name string
+			rec  *httptest.ResponseRecorder
+			code int
+		}{
+			{"success", post(h, "/test.v1.Echo/Echo", `{"message": "hi"}`), 200},
+			{"bridge error", post(h, "/test.v1.Echo/Echo", `{"count": "x"}`), 400},
+			{"middleware error", serve(h, badTimeout), 400},
+			{"health", serve(h, httptest.NewRequest(http.MethodGet, "/health", nil)), 200},
+		}
+		for _, r := range responses {
+			body := r.rec.Body.Bytes()
+			if r.rec.Code != r.code || !json.Valid(body) {
+				t.Fatalf("%s: got %d %s, want %d with a JSON body", r.name, r.rec.Code, body, r.code)
+			}
+			if got := bytes.HasSuffix(body, []byte("\n")); got != newline {
+				t.Errorf("trailing newline %t, %s: body ends with a newline: %t", newline, r.name, got)
+			}
+		}
+	}
+}
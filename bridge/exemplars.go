@@ -0,0 +1,55 @@
+package bridge
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// traceKey is the context key of the trace ID a request was sent in.
+type traceKey struct{}
+
+// traceContext records the trace ID of requests carrying a sampled W3C
+// traceparent header, as sent by OpenTelemetry-instrumented clients and
+// proxies, for latency histograms to attach to their observations as
+// exemplars.
+func traceContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, ok := sampledTraceID(r.Header.Get("traceparent")); ok {
+			r = r.WithContext(context.WithValue(r.Context(), traceKey{}, id))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// traceID returns the trace ID recorded in ctx by traceContext, if any.
+func traceID(ctx context.Context) string {
+	id, _ := ctx.Value(traceKey{}).(string)
+	return id
+}
+
+// sampledTraceID parses a traceparent header, version-traceid-spanid-flags,
+// and returns its trace ID if the sampled flag is set. Unsampled traces
+// aren't recorded, so an exemplar never leads to a trace that wasn't kept.
+func sampledTraceID(header string) (string, bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return "", false
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return "", false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil || flags[0]&1 == 0 {
+		return "", false
+	}
+	id := strings.ToLower(parts[1])
+	if _, err := hex.DecodeString(id); err != nil || strings.Trim(id, "0") == "" {
+		return "", false
+	}
+	if _, err := hex.DecodeString(parts[2]); err != nil || strings.Trim(parts[2], "0") == "" {
+		return "", false
+	}
+	return id, true
+}
@@ -0,0 +1,56 @@
+package bridge
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// pushbackTrailer is the trailer gRPC servers tell clients how long to
+// wait before retrying with, in milliseconds.
+const pushbackTrailer = "grpc-retry-pushback-ms"
+
+// exhausted adjusts the response to a backend call that failed with err,
+// answered with code: a ResourceExhausted error, usually a rate limit or
+// quota, is answered with the configured status, 429 by default, and a
+// Retry-After header from the backend's pushback, taken from its
+// grpc-retry-pushback-ms trailer or a google.rpc.RetryInfo detail. It
+// returns the status to answer with.
+func (b *Bridge) exhausted(h http.Header, err error, trailer metadata.MD, code int) int {
+	if status.Code(err) != codes.ResourceExhausted {
+		return code
+	}
+	if b.exhaustedStatus != 0 {
+		code = b.exhaustedStatus
+	}
+	if d, ok := pushback(err, trailer); ok {
+		h.Set("Retry-After", strconv.Itoa(int(math.Ceil(d.Seconds()))))
+	}
+	return code
+}
+
+// pushback returns how long the backend asked to be left alone after err.
+// A negative pushback trailer means not to retry at all.
+func pushback(err error, trailer metadata.MD) (time.Duration, bool) {
+	if v := trailer.Get(pushbackTrailer); len(v) > 0 {
+		ms, err := strconv.Atoi(v[0])
+		if err != nil || ms < 0 {
+			return 0, false
+		}
+		return time.Duration(ms) * time.Millisecond, true
+	}
+	for _, d := range status.Convert(err).Details() {
+		if info, ok := d.(*errdetails.RetryInfo); ok && info.GetRetryDelay() != nil {
+			if delay := info.GetRetryDelay().AsDuration(); delay >= 0 {
+				return delay, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,58 @@
+package bridge
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestResourceExhaustedAnswers429WithRetryAfter(t *testing.T) {
+	withRetryInfo, err := status.New(codes.ResourceExhausted, "quota").
+		WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(3 * time.Second)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name           string
+		status         int
+		pushback       string
+		err            error
+		wantCode       int
+		wantRetryAfter string
+	}{
+		{"pushback trailer", 0, "1500", status.Error(codes.ResourceExhausted, "rate limited"), 429, "2"},
+		{"retry info", 0, "", withRetryInfo.Err(), 429, "3"},
+		{"no pushback", 0, "", status.Error(codes.ResourceExhausted, "rate limited"), 429, ""},
+		{"configured status", 503, "1000", status.Error(codes.ResourceExhausted, "overloaded"), 503, "1"},
+		{"other code", 0, "1000", status.Error(codes.Unavailable, "down"), 503, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			be := startBackend(t)
+			be.onUnary(func(ctx context.Context, req *dynamicpb.Message) (proto.Message, error) {
+				if tt.pushback != "" {
+					grpc.SetTrailer(ctx, metadata.Pairs(pushbackTrailer, tt.pushback))
+				}
+				return nil, tt.err
+			})
+			b := newTestBridge(t, Config{GRPCAddr: be.addr, ResourceExhaustedStatus: tt.status})
+			rec := post(b.Handler(), "/test.v1.Echo/Echo", `{}`)
+			if got := rec.Header().Get("Retry-After"); rec.Code != tt.wantCode || got != tt.wantRetryAfter {
+				t.Fatalf("got %d Retry-After %q, want %d %q", rec.Code, got, tt.wantCode, tt.wantRetryAfter)
+			}
+		})
+	}
+
+	if _, err := NewBridge(Config{GRPCAddr: "localhost:1", ResourceExhaustedStatus: 200}); err == nil {
+		t.Fatal("a success status was accepted for ResourceExhausted")
+	}
+}
@@ -0,0 +1,187 @@
+package bridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// FanIn merges several server streaming methods into the single NDJSON
+// stream served at GET /fan-in/{name}.
+type FanIn struct {
+	Sources []FanInSource `json:"sources"`
+}
+
+// FanInSource is one stream of a FanIn.
+type FanInSource struct {
+	// Name tags the source's lines. Defaults to Method; sources calling
+	// the same method need distinct names.
+	Name string `json:"name"`
+	// Method is the server streaming method to call, as
+	// "{service}/{method}".
+	Method string `json:"method"`
+	// Request is the JSON request the stream is opened with. Defaults to
+	// {}.
+	Request json.RawMessage `json:"request"`
+}
+
+type fanInSource struct {
+	name    string
+	service string
+	method  string
+	request []byte
+}
+
+func compileFanIns(fanIns map[string]FanIn) (map[string][]fanInSource, error) {
+	compiled := make(map[string][]fanInSource, len(fanIns))
+	for name, f := range fanIns {
+		if len(f.Sources) == 0 {
+			return nil, fmt.Errorf("fan-in %s: no sources", name)
+		}
+		names := make(map[string]bool)
+		for _, s := range f.Sources {
+			i := strings.LastIndex(s.Method, "/")
+			if i <= 0 || i == len(s.Method)-1 {
+				return nil, fmt.Errorf("fan-in %s: invalid method %q (want {service}/{method})", name, s.Method)
+			}
+			request := []byte(s.Request)
+			if len(request) == 0 {
+				request = []byte("{}")
+			}
+			if !json.Valid(request) {
+				return nil, fmt.Errorf("fan-in %s: request for %s is not valid JSON", name, s.Method)
+			}
+			source := s.Name
+			if source == "" {
+				source = s.Method
+			}
+			if names[source] {
+				return nil, fmt.Errorf("fan-in %s: duplicate source %s", name, source)
+			}
+			names[source] = true
+			compiled[name] = append(compiled[name], fanInSource{
+				name:    source,
+				service: s.Method[:i],
+				method:  s.Method[i+1:],
+				request: request,
+			})
+		}
+	}
+	return compiled, nil
+}
+
+// handleFanIn serves GET /fan-in/{name}. It opens every source stream of
+// the fan-in at once and writes their messages as they arrive, one
+// {"source", "message"} line each. A source that ends adds a
+// {"source", "done": true} line, or {"source", "error"} if it failed,
+// while the others carry on; the response ends with the last source.
+func (b *Bridge) handleFanIn(w http.ResponseWriter, r *http.Request) {
+	markStream(r.Context())
+	name := chi.URLParam(r, "name")
+	sources, ok := b.fanIns[name]
+	if !ok {
+		b.writeError(w, status.Errorf(codes.NotFound, "fan-in %q not found", name))
+		return
+	}
+	enc, err := b.jsonEncoding(r)
+	if err != nil {
+		b.writeError(w, err)
+		return
+	}
+
+	// Resolve every source before the response starts, so that a
+	// misconfigured fan-in fails as a whole
+	calls := make([]fanInCall, len(sources))
+	for i, src := range sources {
+		be := b.backendFor(src.service)
+		md, err := b.findMethod(r.Context(), be, src.service, src.method)
+		if err != nil {
+			b.writeError(w, err)
+			return
+		}
+		if md.IsStreamingClient() || !md.IsStreamingServer() {
+			b.writeError(w, status.Errorf(codes.FailedPrecondition, "fan-in %s: %s is not a server streaming method", name, methodPath(md)[1:]))
+			return
+		}
+		req, err := b.decodeRequest(src.request, md.Input())
+		if err != nil {
+			b.writeError(w, err)
+			return
+		}
+		calls[i] = fanInCall{source: src.name, be: be, md: md, req: req}
+	}
+
+	log.Printf("→ Fan-in %s: %d streams", name, len(calls))
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+	var mu sync.Mutex
+	writeLine := func(line fanInLine) error {
+		data, err := json.Marshal(line)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if _, err := w.Write(append(data, '\n')); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	var wg sync.WaitGroup
+	for _, c := range calls {
+		wg.Add(1)
+		go func(c fanInCall) {
+			defer wg.Done()
+			ctx, cancel := b.withCallTimeout(r.Context(), c.md)
+			defer cancel()
+			err := b.guard(c.md, func() error {
+				return b.invokeServerStream(ctx, c.be, c.md, c.req, func(metadata.MD) {}, func(msg proto.Message) error {
+					line, err := enc.marshal(msg, "", b.types)
+					if err != nil {
+						return err
+					}
+					return clientGone(ctx, writeLine(fanInLine{Source: c.source, Message: line}))
+				})
+			})
+			if err != nil {
+				log.Printf("✗ Fan-in %s: %s failed: %v", name, c.source, err)
+				writeLine(fanInLine{Source: c.source, Error: b.streamError(r.Context(), err)})
+				return
+			}
+			writeLine(fanInLine{Source: c.source, Done: true, RequestID: middleware.GetReqID(r.Context())})
+		}(c)
+	}
+	wg.Wait()
+	log.Printf("✓ Fan-in %s sent", name)
+}
+
+// fanInCall is a resolved source of a fan-in.
+type fanInCall struct {
+	source string
+	be     *backend
+	md     protoreflect.MethodDescriptor
+	req    *dynamicpb.Message
+}
+
+// fanInLine is one line of a fan-in response.
+type fanInLine struct {
+	Source    string          `json:"source"`
+	Message   json.RawMessage `json:"message,omitempty"`
+	Done      bool            `json:"done,omitempty"`
+	Error     json.RawMessage `json:"error,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+}
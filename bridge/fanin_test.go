@@ -0,0 +1,49 @@
+package bridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestFanInMergesTaggedStreams(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{
+		GRPCAddr: be.addr,
+		FanIns: map[string]FanIn{"dashboard": {Sources: []FanInSource{
+			{Name: "a", Method: "test.v1.Echo/ServerStream", Request: json.RawMessage(`{"message": "a", "count": 2}`)},
+			{Name: "b", Method: "test.v1.Echo/ServerStream", Request: json.RawMessage(`{"message": "b", "count": 3}`)},
+		}}},
+	})
+	h := b.Handler()
+
+	rec := serve(h, httptest.NewRequest(http.MethodGet, "/fan-in/dashboard", nil))
+	if rec.Code != 200 || be.Calls() != 2 {
+		t.Fatalf("got %d %s after %d backend calls, want 200 after 2", rec.Code, rec.Body, be.Calls())
+	}
+	// The streams interleave, but each keeps its own order
+	got := make(map[string][]string)
+	for _, line := range ndjsonLines(t, rec.Body.String()) {
+		source := line["source"].(string)
+		if line["done"] == true {
+			got[source] = append(got[source], "done")
+			continue
+		}
+		msg := line["message"].(map[string]interface{})
+		if msg["message"] != source {
+			t.Fatalf("line %v is tagged with the wrong source", line)
+		}
+		got[source] = append(got[source], fmt.Sprint(msg["message"], msg["count"]))
+	}
+	want := map[string][]string{"a": {"a1", "a2", "done"}, "b": {"b1", "b2", "b3", "done"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	if rec := serve(h, httptest.NewRequest(http.MethodGet, "/fan-in/nope", nil)); rec.Code != 404 {
+		t.Fatalf("unknown fan-in: got %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,75 @@
+package bridge
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// requestFlagNames lists the flags X-Bridge-Flags accepts.
+var requestFlagNames = map[string]bool{
+	// pretty indents unary and client streaming responses, which is the
+	// default; pretty=false renders them on one line
+	"pretty": true,
+	// emit-unpopulated includes fields with default values, like the
+	// emit_unpopulated query parameter
+	"emit-unpopulated": true,
+	// include-metadata returns a unary call's backend headers as
+	// Grpc-Metadata-* response headers
+	"include-metadata": true,
+	// no-retry disables bridge-level retries, like X-No-Retry
+	"no-retry": true,
+	// timing adds the call's resolve, backend and marshal durations to
+	// enveloped responses under "_timing"
+	"timing": true,
+}
+
+// requestFlags parses the X-Bridge-Flags header of r, a comma-separated
+// list of flags that each turn a per-request behavior on, or off with
+// "=false": X-Bridge-Flags: pretty=false, emit-unpopulated, no-retry.
+func requestFlags(r *http.Request) (map[string]bool, error) {
+	flags := make(map[string]bool)
+	for _, v := range r.Header.Values("X-Bridge-Flags") {
+		for _, item := range strings.Split(v, ",") {
+			item = strings.ToLower(strings.TrimSpace(item))
+			if item == "" {
+				continue
+			}
+			name, value, hasValue := strings.Cut(item, "=")
+			name = strings.TrimSpace(name)
+			if !requestFlagNames[name] {
+				return nil, status.Errorf(codes.InvalidArgument, "unknown X-Bridge-Flags flag %q (want %s)", name, strings.Join(knownRequestFlags(), ", "))
+			}
+			on := true
+			if hasValue {
+				var err error
+				if on, err = strconv.ParseBool(strings.TrimSpace(value)); err != nil {
+					return nil, status.Errorf(codes.InvalidArgument, "invalid X-Bridge-Flags value for %s: %q (want true or false)", name, value)
+				}
+			}
+			flags[name] = on
+		}
+	}
+	return flags, nil
+}
+
+// requestFlag reports whether r sets the flag name to true. Malformed
+// headers are rejected when the response encoding is chosen, so they
+// count as not set here.
+func requestFlag(r *http.Request, name string) bool {
+	flags, err := requestFlags(r)
+	return err == nil && flags[name]
+}
+
+func knownRequestFlags() []string {
+	names := make([]string, 0, len(requestFlagNames))
+	for name := range requestFlagNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
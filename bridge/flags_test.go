@@ -0,0 +1,52 @@
+package bridge
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestBridgeFlagsHeaderAppliesEveryFlag(t *testing.T) {
+	be := startBackend(t)
+	be.onUnary(func(ctx context.Context, req *dynamicpb.Message) (proto.Message, error) {
+		grpc.SetHeader(ctx, metadata.Pairs("x-shard", "7"))
+		return req, nil
+	})
+	b := newTestBridge(t, Config{GRPCAddr: be.addr})
+	h := b.Handler()
+	echo := func(flags string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", strings.NewReader(`{"message": "hi"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if flags != "" {
+			req.Header.Set("X-Bridge-Flags", flags)
+		}
+		return serve(h, req)
+	}
+
+	rec := echo("")
+	if body := rec.Body.String(); rec.Code != 200 || !strings.Contains(body, "\n") || !strings.Contains(body, `"count"`) {
+		t.Fatalf("without flags: got %d %q, want an indented body with unpopulated fields", rec.Code, body)
+	}
+	if got := rec.Header().Get("Grpc-Metadata-X-Shard"); got != "" {
+		t.Fatalf("without flags: backend metadata %q was returned", got)
+	}
+
+	rec = echo("pretty=false, emit-unpopulated=false, include-metadata")
+	if body := rec.Body.String(); rec.Code != 200 || strings.Contains(body, "\n") || strings.Contains(body, `"count"`) {
+		t.Fatalf("with flags: got %d %q, want one line without unpopulated fields", rec.Code, body)
+	}
+	if got := rec.Header().Get("Grpc-Metadata-X-Shard"); got != "7" {
+		t.Fatalf("with flags: Grpc-Metadata-X-Shard = %q, want 7", got)
+	}
+
+	if rec := echo("pretty, turbo"); rec.Code != 400 || !strings.Contains(rec.Body.String(), "turbo") {
+		t.Fatalf("unknown flag: got %d %s, want 400 naming it", rec.Code, rec.Body)
+	}
+}
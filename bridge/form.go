@@ -0,0 +1,214 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"sort"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// defaultFormPayload is the form field whose value is used as the JSON
+// request body.
+const defaultFormPayload = "payload"
+
+// maxFormMemory is how much of a multipart form is held in memory; larger
+// file parts spill to temporary files.
+const maxFormMemory = 32 << 20
+
+// formBody translates a body posted as an HTML form, multipart/form-data
+// or application/x-www-form-urlencoded, into the JSON request for desc.
+// The payload field, if present, holds the request as JSON, and every
+// other field sets the message field of the same proto or JSON name,
+// taking precedence over the payload. Bodies of other types are returned
+// unchanged.
+func (b *Bridge) formBody(contentType string, desc protoreflect.MessageDescriptor, body []byte) ([]byte, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return body, nil
+	}
+	var values url.Values
+	var files map[string][]*multipart.FileHeader
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		// readBody stands in {} for an empty body
+		if string(body) == "{}" {
+			return body, nil
+		}
+		values, err = url.ParseQuery(string(body))
+		if err != nil {
+			return nil, invalidPayload(withCause(status.Errorf(codes.InvalidArgument, "invalid form body: %v", err), err))
+		}
+	case "multipart/form-data":
+		form, err := multipart.NewReader(bytes.NewReader(body), params["boundary"]).ReadForm(maxFormMemory)
+		if err != nil {
+			return nil, invalidPayload(withCause(status.Errorf(codes.InvalidArgument, "invalid multipart body: %v", err), err))
+		}
+		defer form.RemoveAll()
+		values, files = form.Value, form.File
+	default:
+		return body, nil
+	}
+
+	payload := []byte("{}")
+	if v, ok := values[b.formPayload]; ok {
+		payload = []byte(v[0])
+		delete(values, b.formPayload)
+	} else if f, ok := files[b.formPayload]; ok {
+		if payload, err = readFormFile(f[0]); err != nil {
+			return nil, err
+		}
+		delete(files, b.formPayload)
+	}
+	if b.devMode {
+		payload = relaxJSON(payload)
+	}
+	members, err := objectMembers(payload)
+	if err != nil {
+		return nil, invalidPayload(withCause(status.Errorf(codes.InvalidArgument, "form field %s is not a JSON object: %v", b.formPayload, err), err))
+	}
+
+	fields := make(map[string][]json.RawMessage)
+	var violations []*fieldViolation
+	set := func(name string, fd protoreflect.FieldDescriptor, value json.RawMessage, err error) {
+		if err != nil {
+			addViolation(&violations, name, err.Error())
+			return
+		}
+		fields[fd.JSONName()] = append(fields[fd.JSONName()], value)
+	}
+	keys := make([]string, 0, len(values))
+	for name := range values {
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+	for _, name := range keys {
+		fd := formField(desc, name, &violations)
+		if fd == nil {
+			continue
+		}
+		for _, v := range values[name] {
+			value, err := headerFieldValue(fd, v)
+			set(name, fd, value, err)
+		}
+	}
+	for name, fs := range files {
+		fd := formField(desc, name, &violations)
+		if fd == nil {
+			continue
+		}
+		if fd.Kind() != protoreflect.BytesKind {
+			addViolation(&violations, name, "files can only be uploaded to bytes fields")
+			continue
+		}
+		for _, f := range fs {
+			content, err := readFormFile(f)
+			if err != nil {
+				return nil, err
+			}
+			value, err := json.Marshal(content)
+			set(name, fd, value, err)
+		}
+	}
+	if len(violations) > 0 {
+		return nil, invalidPayload(badRequest(violations, "invalid form fields"))
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fd := desc.Fields().ByJSONName(name)
+		value := fields[name][0]
+		kept := members[:0]
+		var payloadValues []json.RawMessage
+		for _, m := range members {
+			if m.name != string(fd.Name()) && m.name != fd.JSONName() {
+				kept = append(kept, m)
+				continue
+			}
+			if fd.IsList() && b.formRepeated == formAppend && !isJSONNull(m.value) {
+				if err := json.Unmarshal(m.value, &payloadValues); err != nil {
+					addViolation(&violations, name, "the payload's value is not an array")
+				}
+			}
+		}
+		if fd.IsList() {
+			value, _ = json.Marshal(append(payloadValues, fields[name]...))
+		}
+		members = append(kept, jsonMember{name: fd.JSONName(), value: value})
+	}
+	if len(violations) > 0 {
+		return nil, invalidPayload(badRequest(violations, "invalid form fields"))
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range members {
+		writeMember(&buf, i, m.name, m.value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// formRepeatedMode is how the values form fields give a repeated field
+// combine with those the payload has for it.
+type formRepeatedMode string
+
+const (
+	// formReplace drops the payload's values.
+	formReplace formRepeatedMode = "replace"
+	// formAppend keeps the payload's values, followed by the form's.
+	formAppend formRepeatedMode = "append"
+)
+
+func parseFormRepeatedMode(s string) (formRepeatedMode, error) {
+	switch m := formRepeatedMode(s); m {
+	case "":
+		return formReplace, nil
+	case formReplace, formAppend:
+		return m, nil
+	default:
+		return "", fmt.Errorf("invalid form repeated field mode %q (want replace or append)", s)
+	}
+}
+
+// formField returns the scalar or repeated scalar field of desc that a form
+// field name sets, recording a violation if there is none.
+func formField(desc protoreflect.MessageDescriptor, name string, violations *[]*fieldViolation) protoreflect.FieldDescriptor {
+	fd := desc.Fields().ByName(protoreflect.Name(name))
+	if fd == nil {
+		fd = desc.Fields().ByJSONName(name)
+	}
+	switch {
+	case fd == nil:
+		addViolation(violations, name, "unknown field")
+		return nil
+	case fd.IsMap() || fd.Message() != nil:
+		addViolation(violations, name, "only scalar fields can be set from a form")
+		return nil
+	}
+	return fd
+}
+
+func readFormFile(fh *multipart.FileHeader) ([]byte, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return nil, withCause(status.Errorf(codes.InvalidArgument, "failed to read form file %s: %v", fh.Filename, err), err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, withCause(status.Errorf(codes.InvalidArgument, "failed to read form file %s: %v", fh.Filename, err), err)
+	}
+	return data, nil
+}
@@ -0,0 +1,99 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestURLEncodedFormPopulatesMessage(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr})
+
+	req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", strings.NewReader("count=3&tags=a&tags=b"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := serve(b.Handler(), req)
+	if rec.Code != 200 {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		Count json.Number `json:"count"`
+		Tags  []string    `json:"tags"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Count != "3" || !reflect.DeepEqual(resp.Tags, []string{"a", "b"}) {
+		t.Fatalf("got count %s and tags %q, want 3 and [a b]", resp.Count, resp.Tags)
+	}
+}
+
+func TestMultipartPayloadFieldInvokesMethod(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr})
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("payload", `{"message": "hi", "count": 1}`)
+	// Other fields override the payload's
+	mw.WriteField("count", "5")
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec := serve(b.Handler(), req)
+	if rec.Code != 200 || be.Calls() != 1 {
+		t.Fatalf("got %d %s after %d backend calls, want 200 after 1", rec.Code, rec.Body, be.Calls())
+	}
+	var resp struct {
+		Message string      `json:"message"`
+		Count   json.Number `json:"count"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Message != "hi" || resp.Count != "5" {
+		t.Fatalf("got message %q and count %s, want hi and 5", resp.Message, resp.Count)
+	}
+}
+
+func TestFormRepeatedFieldsReplaceOrAppend(t *testing.T) {
+	tests := []struct {
+		mode string
+		want []string
+	}{
+		{"", []string{"c", "d"}},
+		{"replace", []string{"c", "d"}},
+		{"append", []string{"a", "b", "c", "d"}},
+	}
+	for _, tt := range tests {
+		be := startBackend(t)
+		b := newTestBridge(t, Config{GRPCAddr: be.addr, FormRepeatedFields: tt.mode})
+
+		form := url.Values{"payload": {`{"tags": ["a", "b"]}`}, "tags": {"c", "d"}}
+		req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := serve(b.Handler(), req)
+		if rec.Code != 200 {
+			t.Fatalf("mode %q: got %d %s", tt.mode, rec.Code, rec.Body)
+		}
+		var resp struct {
+			Tags []string `json:"tags"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(resp.Tags, tt.want) {
+			t.Fatalf("mode %q: got tags %q, want %q", tt.mode, resp.Tags, tt.want)
+		}
+	}
+
+	if _, err := NewBridge(Config{GRPCAddr: "localhost:1", FormRepeatedFields: "merge"}); err == nil {
+		t.Fatal("an invalid repeated field mode was accepted")
+	}
+}
@@ -0,0 +1,159 @@
+package bridge
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"sigs.k8s.io/yaml"
+)
+
+// responseFormat is the encoding of a successful unary response body.
+type responseFormat int
+
+const (
+	formatJSON responseFormat = iota
+	formatProto
+	formatYAML
+)
+
+// parseFormat parses a format name as used in the format query parameter
+// and the response_format method setting.
+func parseFormat(s string) (responseFormat, bool) {
+	switch s {
+	case "json":
+		return formatJSON, true
+	case "proto":
+		return formatProto, true
+	case "yaml":
+		return formatYAML, true
+	}
+	return formatJSON, false
+}
+
+// negotiateFormat picks the response format for a call to md. The format
+// query parameter takes precedence over the method's response_format,
+// which takes precedence over the Accept header, since browsers can't
+// easily set the latter. Requests without an Accept header or accepting
+// */* get the bridge's default format; unrecognized Accept types fall
+// back to JSON.
+func (b *Bridge) negotiateFormat(r *http.Request, md protoreflect.MethodDescriptor) (responseFormat, error) {
+	if q := r.URL.Query().Get("format"); q != "" {
+		if f, ok := parseFormat(q); ok {
+			return f, nil
+		}
+		return formatJSON, status.Errorf(codes.InvalidArgument, "invalid format %q (want json, proto or yaml)", q)
+	}
+	if f, ok := b.methodFormats[strings.TrimPrefix(methodPath(md), "/")]; ok {
+		return f, nil
+	}
+
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return b.defaultFormat, nil
+	}
+	for _, part := range strings.Split(accept, ",") {
+		mt, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mt {
+		case "*/*":
+			return b.defaultFormat, nil
+		case "application/json":
+			return formatJSON, nil
+		case "application/x-protobuf", "application/protobuf":
+			return formatProto, nil
+		case "application/yaml", "application/x-yaml", "text/yaml":
+			return formatYAML, nil
+		}
+	}
+	return formatJSON, nil
+}
+
+// jsonEncoding returns how responses to r are rendered. They include
+// fields with default values according to the emit_unpopulated query
+// parameter if given, then the X-Bridge-Flags header, and the bridge's
+// default otherwise.
+func (b *Bridge) jsonEncoding(r *http.Request) (jsonEncoding, error) {
+	enc := jsonEncoding{
+		emitUnpopulated:   !b.omitUnpopulated,
+		omitEmptyRepeated: b.omitEmptyRepeated,
+		deterministic:     b.deterministic,
+	}
+	flags, err := requestFlags(r)
+	if err != nil {
+		return enc, err
+	}
+	if emit, ok := flags["emit-unpopulated"]; ok {
+		enc.emitUnpopulated = emit
+	}
+	if pretty, ok := flags["pretty"]; ok {
+		enc.compact = !pretty
+	}
+	q := r.URL.Query().Get("emit_unpopulated")
+	if q == "" {
+		return enc, nil
+	}
+	emit, err := strconv.ParseBool(q)
+	if err != nil {
+		return enc, status.Errorf(codes.InvalidArgument, "invalid emit_unpopulated %q (want true or false)", q)
+	}
+	enc.emitUnpopulated = emit
+	return enc, nil
+}
+
+// encodeResponse converts a JSON response body of md to format, returning
+// the body and its content type.
+func (b *Bridge) encodeResponse(format responseFormat, md protoreflect.MethodDescriptor, body []byte) ([]byte, string, error) {
+	switch format {
+	case formatProto:
+		msg, err := jsonToMessage(body, md.Output(), b.types)
+		if err != nil {
+			return nil, "", withCause(status.Errorf(codes.Internal, "response cannot be encoded as protobuf: %v", err), err)
+		}
+		out, err := proto.Marshal(msg)
+		if err != nil {
+			return nil, "", withCause(status.Errorf(codes.Internal, "failed to marshal response: %v", err), err)
+		}
+		return out, "application/x-protobuf", nil
+	case formatYAML:
+		out, err := yaml.JSONToYAML(body)
+		if err != nil {
+			return nil, "", withCause(status.Errorf(codes.Internal, "response cannot be encoded as YAML: %v", err), err)
+		}
+		return out, "application/yaml", nil
+	}
+	return body, "application/json", nil
+}
+
+// writeResponse writes a unary call's response in format. Error bodies are
+// always JSON.
+func (b *Bridge) writeResponse(w http.ResponseWriter, code int, format responseFormat, md protoreflect.MethodDescriptor, body []byte) {
+	if code != http.StatusOK || format == formatJSON {
+		if b.jsonNewline {
+			body = withNewline(body)
+		}
+		if b.bufferResponses {
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		}
+		writeJSON(w, code, body)
+		return
+	}
+	out, contentType, err := b.encodeResponse(format, md, body)
+	if err != nil {
+		b.writeError(w, err)
+		return
+	}
+	if b.bufferResponses {
+		w.Header().Set("Content-Length", strconv.Itoa(len(out)))
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(code)
+	w.Write(out)
+}
@@ -0,0 +1,141 @@
+package bridge
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestFormatQueryOverridesAccept(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr})
+
+	req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo?format=proto", strings.NewReader(`{"message": "hi"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	rec := serve(b.Handler(), req)
+	if ct := rec.Header().Get("Content-Type"); rec.Code != 200 || ct != "application/x-protobuf" {
+		t.Fatalf("got %d %s, want 200 application/x-protobuf", rec.Code, ct)
+	}
+	m := dynamicpb.NewMessage(echoRequest)
+	if err := proto.Unmarshal(rec.Body.Bytes(), m); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Get(echoRequest.Fields().ByName("message")).String(); got != "hi" {
+		t.Fatalf("decoded message %q, want hi", got)
+	}
+}
+
+func TestEmitUnpopulatedQueryOverridesDefault(t *testing.T) {
+	be := startBackend(t)
+	for _, omit := range []bool{false, true} {
+		h := newTestBridge(t, Config{GRPCAddr: be.addr, OmitUnpopulated: omit}).Handler()
+		for _, tt := range []struct {
+			query string
+			want  bool
+		}{
+			{"", !omit},
+			{"?emit_unpopulated=true", true},
+			{"?emit_unpopulated=false", false},
+		} {
+			rec := post(h, "/test.v1.Echo/Echo"+tt.query, `{"message": "hi"}`)
+			var resp map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); rec.Code != 200 || err != nil {
+				t.Fatalf("got %d %s", rec.Code, rec.Body)
+			}
+			if _, got := resp["count"]; got != tt.want {
+				t.Errorf("OmitUnpopulated %v, query %q: count emitted %v, want %v", omit, tt.query, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestMethodResponseFormat(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{
+		GRPCAddr: be.addr,
+		Methods: map[string]MethodConfig{
+			"test.v1.Echo/Echo": {ResponseFormat: "proto"},
+		},
+	})
+	h := b.Handler()
+
+	for path, want := range map[string]string{
+		"/test.v1.Echo/Echo":    "application/x-protobuf",
+		"/test.v1.Echo/OldEcho": "application/json",
+	} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"message": "hi"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		rec := serve(h, req)
+		if ct := rec.Header().Get("Content-Type"); rec.Code != 200 || ct != want {
+			t.Errorf("%s: got %d %s, want %s", path, rec.Code, ct, want)
+		}
+	}
+}
+
+func TestDefaultFormatWithoutAccept(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, DefaultFormat: "proto"})
+	for _, tt := range []struct {
+		accept string
+		want   string
+	}{
+		{"", "application/x-protobuf"},
+		{"*/*", "application/x-protobuf"},
+		{"application/json", "application/json"},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", strings.NewReader(`{"message": "hi"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		rec := serve(b.Handler(), req)
+		if ct := rec.Header().Get("Content-Type"); rec.Code != 200 || ct != tt.want {
+			t.Fatalf("Accept %q: got %d %s, want 200 %s", tt.accept, rec.Code, ct, tt.want)
+		}
+		if tt.want != "application/x-protobuf" {
+			continue
+		}
+		m := dynamicpb.NewMessage(echoRequest)
+		if err := proto.Unmarshal(rec.Body.Bytes(), m); err != nil {
+			t.Fatal(err)
+		}
+		if got := m.Get(echoRequest.Fields().ByName("message")).String(); got != "hi" {
+			t.Fatalf("Accept %q: decoded message %q, want hi", tt.accept, got)
+		}
+	}
+
+	if _, err := NewBridge(Config{GRPCAddr: be.addr, DefaultFormat: "xml"}); err == nil {
+		t.Fatal("an unknown default format was accepted")
+	}
+}
+
+func TestBufferedResponsesSetContentLength(t *testing.T) {
+	be := startBackend(t)
+	for _, buffer := range []bool{false, true} {
+		b := newTestBridge(t, Config{GRPCAddr: be.addr, BufferResponses: buffer})
+		for _, accept := range []string{"application/json", "application/x-protobuf"} {
+			req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", strings.NewReader(`{"message": "`+strings.Repeat("x", 10000)+`"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept", accept)
+			rec := serve(b.Handler(), req)
+			if rec.Code != 200 {
+				t.Fatalf("%s: got %d %s", accept, rec.Code, rec.Body)
+			}
+			got, want := rec.Header().Get("Content-Length"), ""
+			if buffer {
+				want = strconv.Itoa(rec.Body.Len())
+			}
+			if got != want {
+				t.Errorf("buffering %t, %s: Content-Length %q, want %q", buffer, accept, got, want)
+			}
+		}
+	}
+}
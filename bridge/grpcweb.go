@@ -0,0 +1,178 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	grpcWebFrameData    = 0x00
+	grpcWebFrameTrailer = 0x80
+)
+
+// grpcWebMode reports whether contentType is a gRPC-Web content type and,
+// if so, whether it is the base64 "-text" variant.
+func grpcWebMode(contentType string) (isWeb, isText bool) {
+	mt, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case strings.HasPrefix(mt, "application/grpc-web-text"):
+		return true, true
+	case strings.HasPrefix(mt, "application/grpc-web"):
+		return true, false
+	}
+	return false, false
+}
+
+// handleGRPCWeb serves a unary call from a gRPC-Web client. The request and
+// response bodies are length-prefixed protobuf frames, base64-encoded for
+// application/grpc-web-text. Call status is always reported in a trailer
+// frame with HTTP 200, as gRPC-Web clients expect.
+func (b *Bridge) handleGRPCWeb(w http.ResponseWriter, r *http.Request, be *backend, service, method string, isText bool) {
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	w.Header().Set("Content-Type", contentType)
+
+	var frames bytes.Buffer
+	var timing callTiming
+	resp, err := b.invokeGRPCWeb(w, r, be, service, method, isText, &timing)
+	if err == nil {
+		writeGRPCWebFrame(&frames, grpcWebFrameData, resp)
+	}
+	writeGRPCWebFrame(&frames, grpcWebFrameTrailer, grpcWebTrailer(err))
+
+	out := frames.Bytes()
+	if isText {
+		out = []byte(base64.StdEncoding.EncodeToString(out))
+	}
+	timing.setHeaders(w.Header(), b.debugHeaders)
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+
+	if err != nil {
+		log.Printf("✗ RPC failed: %v", err)
+		return
+	}
+	log.Printf("✓ Response sent (grpc-web)")
+}
+
+func (b *Bridge) invokeGRPCWeb(w http.ResponseWriter, r *http.Request, be *backend, service, method string, isText bool, timing *callTiming) ([]byte, error) {
+	md, err := b.findMethod(r.Context(), be, service, method)
+	if err != nil {
+		return nil, err
+	}
+	r = r.WithContext(withMethod(r.Context(), md))
+	warnDeprecated(w.Header(), md)
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		return nil, status.Errorf(codes.Unimplemented, "grpc-web streaming is not supported for %s", md.FullName())
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, withCause(status.Errorf(codes.InvalidArgument, "failed to read body: %v", err), err)
+	}
+	if isText {
+		if body, err = decodeGRPCWebText(body); err != nil {
+			return nil, withCause(status.Errorf(codes.InvalidArgument, "invalid grpc-web-text body: %v", err), err)
+		}
+	}
+	payload, err := readGRPCWebMessage(body)
+	if err != nil {
+		return nil, withCause(status.Errorf(codes.InvalidArgument, "invalid grpc-web frame: %v", err), err)
+	}
+
+	req := dynamicpb.NewMessage(md.Input())
+	if err := proto.Unmarshal(payload, req); err != nil {
+		return nil, withCause(status.Errorf(codes.InvalidArgument, "invalid request message: %v", err), err)
+	}
+	resp := dynamicpb.NewMessage(md.Output())
+	ctx, cancel := b.withCallTimeout(r.Context(), md)
+	defer cancel()
+	err = b.guard(md, func() error {
+		defer timing.addBackend(time.Now())
+		return be.conn.Invoke(ctx, methodPath(md), req, resp, timing.peerOption())
+	})
+	if err != nil {
+		be.observe(err)
+		return nil, err
+	}
+	return proto.Marshal(resp)
+}
+
+// readGRPCWebMessage returns the payload of the first data frame in body.
+func readGRPCWebMessage(body []byte) ([]byte, error) {
+	for len(body) > 0 {
+		if len(body) < 5 {
+			return nil, errors.New("truncated frame header")
+		}
+		flag := body[0]
+		n := binary.BigEndian.Uint32(body[1:5])
+		if uint64(len(body)-5) < uint64(n) {
+			return nil, errors.New("truncated frame")
+		}
+		payload := body[5 : 5+n]
+		if flag&grpcWebFrameTrailer == 0 {
+			return payload, nil
+		}
+		body = body[5+n:]
+	}
+	// An empty body is an empty message
+	return nil, nil
+}
+
+func writeGRPCWebFrame(buf *bytes.Buffer, flag byte, payload []byte) {
+	var header [5]byte
+	header[0] = flag
+	binary.BigEndian.PutUint32(header[1:], uint32(len(payload)))
+	buf.Write(header[:])
+	buf.Write(payload)
+}
+
+// grpcWebTrailer renders the status of err as a trailer frame payload.
+func grpcWebTrailer(err error) []byte {
+	st := status.Convert(err)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "grpc-status: %d\r\n", st.Code())
+	if msg := st.Message(); msg != "" {
+		fmt.Fprintf(&buf, "grpc-message: %s\r\n", url.PathEscape(msg))
+	}
+	return buf.Bytes()
+}
+
+// decodeGRPCWebText decodes a grpc-web-text body, which may be a
+// concatenation of separately padded base64 chunks.
+func decodeGRPCWebText(body []byte) ([]byte, error) {
+	body = bytes.Join(bytes.Fields(body), nil)
+
+	var out []byte
+	for len(body) > 0 {
+		// Each chunk ends at the first quantum containing padding
+		end := len(body)
+		if i := bytes.IndexByte(body, '='); i >= 0 {
+			end = i
+			for end < len(body) && body[end] == '=' {
+				end++
+			}
+		}
+		chunk, err := base64.StdEncoding.DecodeString(string(body[:end]))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, chunk...)
+		body = body[end:]
+	}
+	return out, nil
+}
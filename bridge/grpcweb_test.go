@@ -0,0 +1,81 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// grpcWebTextCall makes a grpc-web-text call of test.v1.Echo/Echo with
+// message and returns the decoded data frame, if any, and trailer.
+func grpcWebTextCall(t *testing.T, h http.Handler, message string) (*dynamicpb.Message, string) {
+	t.Helper()
+	req := dynamicpb.NewMessage(echoRequest)
+	req.Set(echoRequest.Fields().ByName("message"), protoreflect.ValueOfString(message))
+	payload, err := proto.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var frame bytes.Buffer
+	writeGRPCWebFrame(&frame, grpcWebFrameData, payload)
+
+	httpReq := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", strings.NewReader(base64.StdEncoding.EncodeToString(frame.Bytes())))
+	httpReq.Header.Set("Content-Type", "application/grpc-web-text")
+	rec := serve(h, httpReq)
+	if rec.Code != 200 || rec.Header().Get("Content-Type") != "application/grpc-web-text" {
+		t.Fatalf("got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	body, err := decodeGRPCWebText(rec.Body.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var data *dynamicpb.Message
+	var trailer string
+	for len(body) >= 5 {
+		n := binary.BigEndian.Uint32(body[1:5])
+		payload := body[5 : 5+n]
+		if body[0]&grpcWebFrameTrailer != 0 {
+			trailer = string(payload)
+		} else {
+			data = dynamicpb.NewMessage(echoRequest)
+			if err := proto.Unmarshal(payload, data); err != nil {
+				t.Fatal(err)
+			}
+		}
+		body = body[5+n:]
+	}
+	return data, trailer
+}
+
+func TestGRPCWebTextRoundTrip(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr})
+
+	resp, trailer := grpcWebTextCall(t, b.Handler(), "hello")
+	if resp == nil || resp.Get(echoRequest.Fields().ByName("message")).String() != "hello" {
+		t.Fatalf("data frame %v, want the echoed message", resp)
+	}
+	if !strings.Contains(trailer, "grpc-status: 0\r\n") {
+		t.Fatalf("trailer %q, want status 0", trailer)
+	}
+
+	be.onUnary(func(context.Context, *dynamicpb.Message) (proto.Message, error) {
+		return nil, status.Error(codes.NotFound, "no such user")
+	})
+	resp, trailer = grpcWebTextCall(t, b.Handler(), "hello")
+	if resp != nil || !strings.Contains(trailer, "grpc-status: 5\r\n") || !strings.Contains(trailer, "grpc-message: no%20such%20user") {
+		t.Fatalf("failed call: data %v, trailer %q", resp, trailer)
+	}
+}
@@ -0,0 +1,425 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func (b *Bridge) handleRPC(w http.ResponseWriter, r *http.Request) {
+	// Extract version, service and method from URL
+	version, path := b.splitVersion(r.URL.Path)
+	service, method, ok := b.parseRPCPath(path)
+	if !ok {
+		http.Error(w, "Invalid path format. Use: /{service}/{method}", http.StatusBadRequest)
+		return
+	}
+	if b.foldCase && b.replayer == nil {
+		service, method = b.canonicalMethod(r.Context(), version, service, method)
+	}
+	fullMethod := fmt.Sprintf("/%s/%s", service, method)
+	w, r, audited := b.audit(w, r, fullMethod)
+	defer audited()
+	b.applyCORS(w, r, service, method)
+	if b.writeRemoved(w, service, method) {
+		return
+	}
+
+	isWeb, isText := grpcWebMode(r.Header.Get("Content-Type"))
+	isWS := isWebSocket(r)
+	if verb := b.httpMethod(service, method); r.Method != verb && !isWeb && !isWS {
+		w.Header().Set("Allow", verb)
+		http.Error(w, fmt.Sprintf("%s is served under %s", fullMethod, verb), http.StatusMethodNotAllowed)
+		return
+	}
+	if err := b.checkRequiredHeaders(r, service, method); err != nil {
+		b.writeError(w, err)
+		return
+	}
+	if !isWeb && !isWS && !b.checkRequestType(w, r, fullMethod[1:]) {
+		return
+	}
+	if l := b.methodLimits[fullMethod[1:]]; l != nil {
+		if err := l.acquire(r.Context()); err != nil {
+			log.Printf("✗ Shed %s: %v", fullMethod, err)
+			w.Header().Set("Retry-After", "1")
+			b.writeError(w, withErrorCode(status.Errorf(codes.Unavailable, "%s: %s", fullMethod, status.Convert(err).Message()), errorBridgeOverloaded))
+			return
+		}
+		defer l.release()
+	}
+
+	if version != "" {
+		log.Printf("→ RPC call: %s (%s)", fullMethod, version)
+	} else {
+		log.Printf("→ RPC call: %s", fullMethod)
+	}
+
+	if b.replayer != nil {
+		b.replay(w, r, fullMethod)
+		return
+	}
+
+	be, err := b.backendForRequest(r, service, version)
+	if err != nil {
+		b.writeError(w, err)
+		return
+	}
+
+	if isWeb {
+		b.handleGRPCWeb(w, r, be, service, method, isText)
+		return
+	}
+
+	var timing callTiming
+	resolveStart := time.Now()
+	md, err := b.findMethod(r.Context(), be, service, method)
+	if err != nil {
+		b.writeError(w, err)
+		log.Printf("✗ RPC failed: %v", err)
+		return
+	}
+	timing.resolve = time.Since(resolveStart)
+	r = r.WithContext(withMethod(r.Context(), md))
+	warnDeprecated(w.Header(), md)
+	schemaLinks(w.Header(), md)
+	if b.debugHeaders {
+		w.Header().Set("X-Grpc-Input-Type", string(md.Input().FullName()))
+		w.Header().Set("X-Grpc-Output-Type", string(md.Output().FullName()))
+		w.Header().Set("X-Descriptor-Source", b.sourceOf(md))
+	}
+
+	bidi := md.IsStreamingClient() && md.IsStreamingServer()
+	switch {
+	case isWS && !bidi:
+		b.writeError(w, status.Errorf(codes.InvalidArgument, "WebSocket is only supported for bidirectional streaming methods"))
+		return
+	case isWS:
+		b.handleBidiWebSocket(w, r, be, md)
+		return
+	case md.IsStreamingClient() && !md.IsStreamingServer():
+		b.handleClientStream(w, r, be, md)
+		return
+	case md.IsStreamingServer() && !md.IsStreamingClient():
+		b.handleServerStream(w, r, be, md)
+		return
+	case md.IsStreamingServer():
+		b.writeError(w, status.Errorf(codes.Unimplemented, "bidirectional streaming method %s is only served over WebSocket", md.FullName()))
+		return
+	}
+
+	format, err := b.negotiateFormat(r, md)
+	if err != nil {
+		b.writeError(w, err)
+		return
+	}
+	if !b.checkResponseType(w, fullMethod[1:], format) {
+		return
+	}
+	if isProtobuf(r.Header.Get("Content-Type")) {
+		if format != formatProto {
+			b.writeError(w, status.Errorf(codes.InvalidArgument, "protobuf request bodies need a protobuf response (Accept: application/x-protobuf)"))
+			return
+		}
+		b.handlePassthrough(w, r, be, md)
+		return
+	}
+	enc, err := b.jsonEncoding(r)
+	if err != nil {
+		b.writeError(w, err)
+		return
+	}
+	if b.debugHeaders {
+		enc.setHeader(w.Header())
+	}
+
+	body, err := readBody(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read body: %v", err), http.StatusBadRequest)
+		return
+	}
+	body, err = b.formBody(r.Header.Get("Content-Type"), md.Input(), body)
+	if err != nil {
+		b.writeError(w, err)
+		return
+	}
+
+	respStatus := http.StatusOK
+	var resp []byte
+	reqBody, err := b.fillHeaderFields(fullMethod, md.Input(), r.Header, body)
+	if err == nil {
+		err = b.checkRequestSchema(fullMethod, reqBody)
+	}
+	if err == nil {
+		reqBody, err = b.transformRequest(fullMethod, reqBody)
+	}
+	if err == nil {
+		b.warnDeprecatedFields(w.Header(), md.Input(), reqBody)
+	}
+	if err == nil && b.operations != nil && prefersAsync(r) {
+		b.startOperation(w, r, be, md, reqBody, enc)
+		return
+	}
+	cache := b.caches[fullMethod[1:]]
+	var cacheKey string
+	cached := false
+	if err == nil && cache != nil {
+		cacheKey = cache.key(r, enc, reqBody)
+		resp, cached = cache.lookup(w.Header(), cacheKey)
+	}
+	if err == nil && !cached {
+		ctx, cancel := b.withCallTimeout(r.Context(), md)
+		defer cancel()
+		resp, md, err = b.callUnary(ctx, r, be, md, reqBody, enc, &timing)
+		if err == nil && timing.empty && b.emptyResponseFor(fullMethod[1:]) != emptyResponseDefault {
+			resp = []byte("{}")
+		}
+		if err == nil && cache != nil {
+			cache.store(cacheKey, resp)
+		}
+	}
+	if err == nil {
+		var reported *callTiming
+		if requestFlag(r, "timing") {
+			reported = &timing
+		}
+		resp, err = b.transformResponse(fullMethod, resp, reported)
+	}
+	if err != nil {
+		respStatus, resp = b.errorResponse(err)
+		respStatus = b.exhausted(w.Header(), err, timing.trailer, responseErrorStatus(err, respStatus))
+	}
+
+	if b.recorder != nil {
+		if err := b.recorder.Record(fullMethod, body, respStatus, resp); err != nil {
+			log.Printf("✗ Failed to record interaction: %v", err)
+		}
+	}
+
+	timing.setHeaders(w.Header(), b.debugHeaders)
+	if requestFlag(r, "include-metadata") {
+		setMetadataHeaders(w.Header(), timing.header)
+	}
+	switch {
+	case err == nil && b.writeEmptyResponse(w, fullMethod[1:], resp):
+	case err == nil && format == formatJSON && b.streamedUnary(r, fullMethod[1:]):
+		b.writeStreamedUnary(w, r, resp)
+	default:
+		b.writeResponse(w, respStatus, format, md, resp)
+	}
+	if err != nil {
+		log.Printf("✗ RPC failed: %v", err)
+		return
+	}
+	log.Printf("✓ Response sent")
+}
+
+// callUnary makes a unary call with the configured circuit breaker and
+// retries. It returns the method along with the response, since a method
+// whose cached schema turned out stale is resolved again.
+func (b *Bridge) callUnary(ctx context.Context, r *http.Request, be *backend, md protoreflect.MethodDescriptor, reqBody []byte, enc jsonEncoding, timing *callTiming) ([]byte, protoreflect.MethodDescriptor, error) {
+	var resp []byte
+	err := b.guard(md, func() error {
+		return b.retry(ctx, r, func() (err error) {
+			resp, err = b.invokeRPC(ctx, be, md, reqBody, enc, timing)
+			if fresh, ok := b.refreshMethod(ctx, be, md, err); ok {
+				md = fresh
+				resp, err = b.invokeRPC(ctx, be, md, reqBody, enc, timing)
+			}
+			return err
+		})
+	})
+	return resp, md, err
+}
+
+// parseRPCPath splits an RPC path into service and method. A single
+// segment names a method of the default service, if one is configured.
+func (b *Bridge) parseRPCPath(path string) (service, method string, ok bool) {
+	if service, method, ok := b.splitBasePath(path); ok {
+		return service, method, true
+	}
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	switch {
+	case len(parts) == 2 && parts[0] != "" && parts[1] != "":
+		return parts[0], parts[1], true
+	case len(parts) == 1 && parts[0] != "" && b.defaultService != "":
+		return b.defaultService, parts[0], true
+	}
+	return "", "", false
+}
+
+func (b *Bridge) replay(w http.ResponseWriter, r *http.Request, fullMethod string) {
+	body, err := readBody(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	in, ok := b.replayer.Lookup(fullMethod, body)
+	if !ok {
+		b.writeError(w, status.Errorf(codes.NotFound, "no recorded interaction for %s with this body", fullMethod))
+		return
+	}
+	b.writeJSON(w, in.Status, in.Response)
+	log.Printf("✓ Response replayed")
+}
+
+// readBody reads the request body, treating an empty body as an empty
+// JSON object.
+func readBody(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		body = []byte("{}")
+	}
+	return body, nil
+}
+
+// invokeRPC performs a dynamic unary gRPC invocation, translating JSON to
+// and from protobuf.
+func (b *Bridge) invokeRPC(ctx context.Context, be *backend, md protoreflect.MethodDescriptor, reqJSON []byte, enc jsonEncoding, timing *callTiming) ([]byte, error) {
+	req, err := b.decodeRequest(reqJSON, md.Input())
+	if err != nil {
+		return nil, err
+	}
+	resp := dynamicpb.NewMessage(md.Output())
+
+	fullMethod := methodPath(md)
+	err = b.intercept(ctx, fullMethod, req, resp, func() error {
+		start := time.Now()
+		err := be.conn.Invoke(ctx, fullMethod, req, resp, timing.peerOption(), timing.headerOption(), timing.trailerOption())
+		timing.addBackend(start)
+		if err != nil {
+			be.observe(err)
+		}
+		return b.connRefused(be, err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	timing.empty = proto.Size(resp) == 0
+
+	defer timing.addMarshal(time.Now())
+	return messageToJSON(resp, enc, b.types)
+}
+
+// decodeRequest parses one JSON request message, applying the configured
+// null handling.
+func (b *Bridge) decodeRequest(data []byte, desc protoreflect.MessageDescriptor) (*dynamicpb.Message, error) {
+	if b.devMode {
+		data = relaxJSON(data)
+	}
+	data, err := applyDuplicateMode(b.dupKeys, data)
+	if err != nil {
+		return nil, invalidPayload(err)
+	}
+	data, err = applyNullMode(b.nullMode, data, desc)
+	if err != nil {
+		return nil, invalidPayload(err)
+	}
+	msg, err := jsonToMessage(data, desc, b.types)
+	if err != nil {
+		if violations := fieldViolations(data, desc); len(violations) > 0 {
+			return nil, invalidPayload(withCause(badRequest(violations, "invalid request body: %v", err), err))
+		}
+		return nil, invalidPayload(withCause(status.Errorf(codes.InvalidArgument, "invalid request body: %v", err), err))
+	}
+	return msg, nil
+}
+
+// methodPath returns the gRPC path of md, e.g. "/pkg.Service/Method".
+func methodPath(md protoreflect.MethodDescriptor) string {
+	return fmt.Sprintf("/%s/%s", md.Parent().FullName(), md.Name())
+}
+
+type methodKey struct{}
+
+// MethodFromContext returns the descriptor of the method called by the
+// request that ctx belongs to, once the bridge has resolved it. Interceptors
+// can use it to inspect the method's schema and options, e.g. to decide
+// whether a response may be cached.
+func MethodFromContext(ctx context.Context) (protoreflect.MethodDescriptor, bool) {
+	md, ok := ctx.Value(methodKey{}).(protoreflect.MethodDescriptor)
+	return md, ok
+}
+
+func withMethod(ctx context.Context, md protoreflect.MethodDescriptor) context.Context {
+	return context.WithValue(ctx, methodKey{}, md)
+}
+
+// refreshMethod re-resolves md via reflection after a call to it failed
+// with Unimplemented, which suggests the backend was redeployed with a
+// different schema since md was cached. It reports whether md is still
+// served, in which case the call is worth making once more. NotFound
+// isn't taken as stale: backends answer it for missing resources, e.g. a
+// user that doesn't exist, and a method missing from the cached schema is
+// already looked up afresh by the resolver.
+func (b *Bridge) refreshMethod(ctx context.Context, be *backend, md protoreflect.MethodDescriptor, err error) (protoreflect.MethodDescriptor, bool) {
+	if status.Code(err) != codes.Unimplemented {
+		return nil, false
+	}
+	service, method := string(md.Parent().FullName()), string(md.Name())
+	if _, ok := b.registeredMethod(service, method); ok {
+		return nil, false
+	}
+	be.resolver.invalidate(service)
+	fresh, err := be.resolver.FindMethod(ctx, service, method)
+	if err != nil || fresh.IsStreamingClient() || fresh.IsStreamingServer() {
+		return nil, false
+	}
+	log.Printf("↻ Re-resolved %s after Unimplemented", methodPath(fresh))
+	return fresh, true
+}
+
+// sourceOf reports whether md was resolved from the static descriptors or
+// by reflection.
+func (b *Bridge) sourceOf(md protoreflect.MethodDescriptor) string {
+	if static, ok := b.registeredMethod(string(md.Parent().FullName()), string(md.Name())); ok && static == md {
+		return sourceStatic
+	}
+	return sourceReflection
+}
+
+// findMethod resolves service/method, preferring methods registered with
+// RegisterMethod, then those of the sources added with
+// WithDescriptorSource, over reflection against be, unless the request
+// forced one source with X-Descriptor-Source.
+func (b *Bridge) findMethod(ctx context.Context, be *backend, service, method string) (protoreflect.MethodDescriptor, error) {
+	if !b.exposed(service) {
+		return nil, withErrorCode(status.Errorf(codes.NotFound, "service %q not found", service), errorMethodNotFound)
+	}
+	source := descriptorSource(ctx)
+	if source != sourceReflection {
+		if md, ok := b.registeredMethod(service, method); ok {
+			return md, nil
+		}
+		if source == sourceStatic {
+			return nil, withErrorCode(status.Errorf(codes.NotFound, "method %q of service %q is not in the static descriptors", method, service), errorMethodNotFound)
+		}
+		if md, ok, err := b.findInSources(service, method); ok || err != nil {
+			return md, err
+		}
+	}
+	md, err := be.resolver.FindMethod(ctx, service, method)
+	if err != nil {
+		be.observe(err)
+		if status.Code(err) == codes.NotFound {
+			err = withErrorCode(err, errorMethodNotFound)
+		}
+		return nil, err
+	}
+	return md, nil
+}
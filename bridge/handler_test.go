@@ -0,0 +1,79 @@
+package bridge
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mizrahidaniel/grpc-http-bridge/bridgetest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestDefaultServiceMethodPaths(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, DefaultService: "test.v1.Echo"})
+
+	rec := post(b.Handler(), "/Echo", `{"message": "short"}`)
+	if rec.Code != 200 || !strings.Contains(rec.Body.String(), `"short"`) {
+		t.Fatalf("/Echo: got %d %s", rec.Code, rec.Body)
+	}
+	// Fully qualified paths still work
+	if rec := post(b.Handler(), "/test.v1.Echo/Echo", `{}`); rec.Code != 200 {
+		t.Fatalf("/test.v1.Echo/Echo: got %d %s", rec.Code, rec.Body)
+	}
+	if be.Calls() != 2 {
+		t.Fatalf("backend answered %d calls, want 2", be.Calls())
+	}
+	if rec := post(b.Handler(), "/Missing", `{}`); rec.Code != 404 {
+		t.Fatalf("/Missing: got %d, want 404", rec.Code)
+	}
+}
+
+func TestMethodFromContext(t *testing.T) {
+	be := startBackend(t)
+	var seen []protoreflect.FullName
+	b := newTestBridge(t, Config{GRPCAddr: be.addr}, WithUnaryInterceptor(func(ctx context.Context, fullMethod string, req, resp proto.Message, invoke func() error) error {
+		md, ok := MethodFromContext(ctx)
+		if !ok {
+			return status.Error(codes.Internal, "no method in context")
+		}
+		seen = append(seen, md.FullName())
+		return invoke()
+	}))
+	for _, path := range []string{"/test.v1.Echo/Echo", "/test.v1.Echo/OldEcho"} {
+		if rec := post(b.Handler(), path, `{}`); rec.Code != 200 {
+			t.Fatalf("%s: got %d %s", path, rec.Code, rec.Body)
+		}
+	}
+	if want := []protoreflect.FullName{"test.v1.Echo.Echo", "test.v1.Echo.OldEcho"}; !reflect.DeepEqual(seen, want) {
+		t.Fatalf("interceptor saw methods %v, want %v", seen, want)
+	}
+	if _, ok := MethodFromContext(context.Background()); ok {
+		t.Fatal("a context without a request has a method")
+	}
+}
+
+func TestDebugHeadersReportMessageTypes(t *testing.T) {
+	be := bridgetest.Start(t)
+	for _, debug := range []bool{false, true} {
+		b := newTestBridge(t, Config{GRPCAddr: be.Addr, DebugHeaders: debug})
+		rec := post(b.Handler(), "/bridgetest.v1.Greeter/SayHello", `{"name": "Ada"}`)
+		if rec.Code != 200 {
+			t.Fatalf("got %d %s", rec.Code, rec.Body)
+		}
+		input, output := "", ""
+		if debug {
+			input, output = "bridgetest.v1.HelloRequest", "bridgetest.v1.HelloReply"
+		}
+		if got := rec.Header().Get("X-Grpc-Input-Type"); got != input {
+			t.Errorf("debug %t: X-Grpc-Input-Type %q, want %q", debug, got, input)
+		}
+		if got := rec.Header().Get("X-Grpc-Output-Type"); got != output {
+			t.Errorf("debug %t: X-Grpc-Output-Type %q, want %q", debug, got, output)
+		}
+	}
+}
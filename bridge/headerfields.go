@@ -0,0 +1,153 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// fillHeaderFields sets the request fields that header_fields in the
+// config file maps to headers of h, converting each header value to its
+// field's type. A header that is present replaces any value the body has
+// for its field; absent headers leave the body as it is.
+func (b *Bridge) fillHeaderFields(fullMethod string, desc protoreflect.MessageDescriptor, h http.Header, data []byte) ([]byte, error) {
+	fields := b.headerFields[fullMethod[1:]]
+	if len(fields) == 0 {
+		return data, nil
+	}
+	if b.devMode {
+		data = relaxJSON(data)
+	}
+	members, err := objectMembers(data)
+	if err != nil {
+		// Leave bodies that aren't objects for protojson to report
+		return data, nil
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		header := fields[name]
+		values := h.Values(header)
+		if len(values) == 0 {
+			continue
+		}
+		fd := desc.Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			fd = desc.Fields().ByJSONName(name)
+		}
+		if fd == nil {
+			return nil, status.Errorf(codes.Internal, "header_fields: %s has no field %q", desc.FullName(), name)
+		}
+		if fd.IsList() || fd.IsMap() || fd.Message() != nil {
+			return nil, status.Errorf(codes.Internal, "header_fields: field %s is not a scalar", fd.FullName())
+		}
+		value, err := headerFieldValue(fd, values[0])
+		if err != nil {
+			var violations []*fieldViolation
+			addViolation(&violations, fd.JSONName(), err.Error())
+			return nil, badRequest(violations, "invalid %s header: %v", header, err)
+		}
+
+		kept := members[:0]
+		for _, m := range members {
+			if m.name != string(fd.Name()) && m.name != fd.JSONName() {
+				kept = append(kept, m)
+			}
+		}
+		members = append(kept, jsonMember{name: fd.JSONName(), value: value})
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range members {
+		writeMember(&buf, i, m.name, m.value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// headerFieldReader fills the header fields of every message of a client
+// stream read with next.
+func (b *Bridge) headerFieldReader(next messageReader, md protoreflect.MethodDescriptor, h http.Header) messageReader {
+	if len(b.headerFields[methodPath(md)[1:]]) == 0 {
+		return next
+	}
+	return func() ([]byte, error) {
+		data, err := next()
+		if err != nil {
+			return nil, err
+		}
+		return b.fillHeaderFields(methodPath(md), md.Input(), h, data)
+	}
+}
+
+// headerFieldValue converts a header value to the JSON form of the scalar
+// field fd.
+func headerFieldValue(fd protoreflect.FieldDescriptor, v string) (json.RawMessage, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind, protoreflect.BytesKind:
+		return json.Marshal(v)
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a bool", v)
+		}
+		return json.Marshal(b)
+	case protoreflect.EnumKind:
+		if n, err := strconv.ParseInt(v, 10, 32); err == nil {
+			return json.Marshal(n)
+		}
+		if fd.Enum().Values().ByName(protoreflect.Name(v)) == nil {
+			return nil, fmt.Errorf("%q is not a %s value", v, fd.Enum().FullName())
+		}
+		return json.Marshal(v)
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", v)
+		}
+		// JSON numbers can't hold these; protojson spells them as strings
+		switch {
+		case math.IsNaN(f):
+			return json.Marshal("NaN")
+		case math.IsInf(f, 1):
+			return json.Marshal("Infinity")
+		case math.IsInf(f, -1):
+			return json.Marshal("-Infinity")
+		}
+		return json.Marshal(f)
+	}
+
+	bits := 64
+	switch fd.Kind() {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		bits = 32
+	}
+	switch fd.Kind() {
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := strconv.ParseUint(v, 10, bits)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid %s", v, fd.Kind())
+		}
+		return json.Marshal(n)
+	default:
+		n, err := strconv.ParseInt(v, 10, bits)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid %s", v, fd.Kind())
+		}
+		return json.Marshal(n)
+	}
+}
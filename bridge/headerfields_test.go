@@ -0,0 +1,45 @@
+package bridge
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHeaderFieldsPopulateRequest(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{
+		GRPCAddr: be.addr,
+		Methods: map[string]MethodConfig{
+			"test.v1.Echo/Echo": {HeaderFields: map[string]string{"message": "X-Tenant", "count": "X-Count"}},
+		},
+	})
+	h := b.Handler()
+	echo := func(body string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		return serve(h, req)
+	}
+
+	rec := echo(`{"message": "from body", "tags": ["a"]}`, map[string]string{"X-Tenant": "acme", "X-Count": "3"})
+	var resp struct {
+		Message string
+		Count   int
+		Tags    []string
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != 200 {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+	if resp.Message != "acme" || resp.Count != 3 || len(resp.Tags) != 1 {
+		t.Fatalf("got %+v, want message and count from the headers and tags from the body", resp)
+	}
+
+	if rec := echo(`{}`, map[string]string{"X-Count": "three"}); rec.Code != 400 || be.Calls() != 1 {
+		t.Fatalf("unconvertible header: got %d %s, want 400 without a backend call", rec.Code, rec.Body)
+	}
+}
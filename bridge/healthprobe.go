@@ -0,0 +1,138 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// readyTimeout bounds each backend probe made for GET /ready.
+const readyTimeout = 5 * time.Second
+
+// HealthProbe designates a backend unary method that is called to check a
+// backend's health, instead of the standard gRPC health service. A backend
+// is healthy while the call succeeds.
+type HealthProbe struct {
+	// Method is the method to call, as "{service}/{method}".
+	Method string `json:"method"`
+	// Request is the JSON request sent with every probe. Defaults to {}.
+	Request json.RawMessage `json:"request"`
+}
+
+type healthProbe struct {
+	service string
+	method  string
+	request []byte
+}
+
+func compileHealthProbe(p *HealthProbe) (*healthProbe, error) {
+	if p == nil || p.Method == "" {
+		return nil, nil
+	}
+	i := strings.LastIndex(p.Method, "/")
+	if i <= 0 || i == len(p.Method)-1 {
+		return nil, fmt.Errorf("health probe: invalid method %q (want {service}/{method})", p.Method)
+	}
+	request := []byte(p.Request)
+	if len(request) == 0 {
+		request = []byte("{}")
+	}
+	if !json.Valid(request) {
+		return nil, fmt.Errorf("health probe: request is not valid JSON")
+	}
+	return &healthProbe{
+		service: p.Method[:i],
+		method:  p.Method[i+1:],
+		request: request,
+	}, nil
+}
+
+// probe checks be with the configured health probe method, or with the
+// standard gRPC health service if there is none.
+func (b *Bridge) probe(ctx context.Context, be *backend) bool {
+	p := b.healthProbe
+	if p == nil {
+		return be.probe(ctx)
+	}
+	md, err := b.findMethod(ctx, be, p.service, p.method)
+	if err != nil || md.IsStreamingClient() || md.IsStreamingServer() {
+		return false
+	}
+	req, err := jsonToMessage(p.request, md.Input(), b.types)
+	if err != nil {
+		return false
+	}
+	resp := dynamicpb.NewMessage(md.Output())
+	return be.conn.Invoke(ctx, methodPath(md), req, resp) == nil
+}
+
+// handleReady serves GET /ready, which probes every backend and answers
+// 503 unless all of them are healthy and, with a maximum descriptor age
+// set, none of their cached descriptors are older than that.
+func (b *Bridge) handleReady(w http.ResponseWriter, r *http.Request) {
+	list := b.backendList()
+	results := make([]bool, len(list))
+	var wg sync.WaitGroup
+	for i, be := range list {
+		wg.Add(1)
+		go func(i int, be *backend) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+			defer cancel()
+			results[i] = b.probe(ctx, be)
+		}(i, be)
+	}
+	wg.Wait()
+
+	ready := true
+	backends := make(map[string]bool, len(list))
+	for i, be := range list {
+		backends[be.addr] = results[i]
+		ready = ready && results[i]
+	}
+
+	body := map[string]interface{}{"backends": backends}
+	if b.maxDescriptorAge > 0 {
+		stale := []string{}
+		for _, be := range list {
+			if be.resolver.cacheAge() > b.maxDescriptorAge {
+				stale = append(stale, be.addr)
+			}
+		}
+		if len(stale) > 0 {
+			ready = false
+			body["status"] = "degraded"
+		}
+		body["stale_descriptors"] = stale
+	}
+	body["ready"] = ready
+
+	code := http.StatusOK
+	if !ready {
+		code = http.StatusServiceUnavailable
+	}
+	b.encodeJSON(w, code, body)
+}
+
+// handleRefreshDescriptors serves POST /admin/descriptors/refresh, which
+// drops every backend's cached descriptors so that they are fetched
+// again, with any schema changes, on next use.
+func (b *Bridge) handleRefreshDescriptors(w http.ResponseWriter, r *http.Request) {
+	list := b.backendList()
+	for _, be := range list {
+		be.resolver.reset()
+	}
+	b.methodDocs.Range(func(md, _ interface{}) bool {
+		b.methodDocs.Delete(md)
+		return true
+	})
+	log.Printf("↻ Dropped cached descriptors of %d backend(s)", len(list))
+	w.WriteHeader(http.StatusNoContent)
+}
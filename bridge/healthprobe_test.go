@@ -0,0 +1,101 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestReadyCallsHealthProbeMethod(t *testing.T) {
+	be := startBackend(t)
+	var failing atomic.Bool
+	var probed atomic.Value
+	be.onUnary(func(ctx context.Context, req *dynamicpb.Message) (proto.Message, error) {
+		probed.Store(req.Get(echoRequest.Fields().ByName("message")).String())
+		if failing.Load() {
+			return nil, status.Error(codes.Internal, "database unreachable")
+		}
+		return req, nil
+	})
+	b := newTestBridge(t, Config{
+		GRPCAddr:    be.addr,
+		HealthProbe: &HealthProbe{Method: "test.v1.Echo/Echo", Request: json.RawMessage(`{"message": "ping"}`)},
+	})
+	h := b.Handler()
+
+	ready := func() (int, bool) {
+		t.Helper()
+		rec := serve(h, httptest.NewRequest(http.MethodGet, "/ready", nil))
+		var resp struct {
+			Ready    bool
+			Backends map[string]bool
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%d %s: %v", rec.Code, rec.Body, err)
+		}
+		if resp.Backends[be.addr] != resp.Ready {
+			t.Fatalf("backends %v disagree with ready %v", resp.Backends, resp.Ready)
+		}
+		return rec.Code, resp.Ready
+	}
+
+	if code, ok := ready(); code != 200 || !ok {
+		t.Fatalf("healthy probe: got %d ready %v, want 200 true", code, ok)
+	}
+	if got, _ := probed.Load().(string); got != "ping" {
+		t.Fatalf("probe sent message %q, want the configured ping", got)
+	}
+	failing.Store(true)
+	if code, ok := ready(); code != 503 || ok {
+		t.Fatalf("failing probe: got %d ready %v, want 503 false", code, ok)
+	}
+}
+
+func TestReadyDegradedWhenDescriptorsAreStale(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{
+		GRPCAddr:         be.addr,
+		HealthProbe:      &HealthProbe{Method: "test.v1.Echo/Echo", Request: json.RawMessage(`{}`)},
+		MaxDescriptorAge: 100 * time.Millisecond,
+		AdminToken:       "s3cret",
+	})
+	h := b.Handler()
+
+	ready := func() (int, map[string]interface{}) {
+		t.Helper()
+		rec := serve(h, httptest.NewRequest(http.MethodGet, "/ready", nil))
+		var resp map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return rec.Code, resp
+	}
+	if code, resp := ready(); code != 200 || resp["status"] != nil {
+		t.Fatalf("fresh descriptors: got %d %v, want 200", code, resp)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	code, resp := ready()
+	stale, _ := resp["stale_descriptors"].([]interface{})
+	if code != 503 || resp["status"] != "degraded" || len(stale) != 1 || stale[0] != be.addr {
+		t.Fatalf("stale descriptors: got %d %v, want 503 degraded with %s stale", code, resp, be.addr)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/descriptors/refresh", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	if rec := serve(h, req); rec.Code != 204 {
+		t.Fatalf("refresh: got %d %s, want 204", rec.Code, rec.Body)
+	}
+	if code, resp := ready(); code != 200 {
+		t.Fatalf("after a refresh: got %d %v, want 200", code, resp)
+	}
+}
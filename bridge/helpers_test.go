@@ -0,0 +1,317 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	_ "google.golang.org/protobuf/types/known/anypb"
+	_ "google.golang.org/protobuf/types/known/structpb"
+)
+
+// echoFile describes the service the test backend serves:
+//
+//	message EchoRequest {
+//	  string message = 1;
+//	  int32 count = 2;
+//	  repeated string tags = 3;
+//	  google.protobuf.Struct data = 4;
+//	  google.protobuf.Any extra = 5;
+//	}
+//	service Echo {
+//	  rpc Echo(EchoRequest) returns (EchoRequest);
+//	  // ServerStream sends count copies of the request, numbered from 1
+//	  rpc ServerStream(EchoRequest) returns (stream EchoRequest);
+//	  // ClientStream answers with the number of messages received
+//	  rpc ClientStream(stream EchoRequest) returns (EchoRequest);
+//	  rpc Bidi(stream EchoRequest) returns (stream EchoRequest);
+//	  // OldEcho is Echo under its deprecated name
+//	  rpc OldEcho(EchoRequest) returns (EchoRequest) { option deprecated = true; }
+//	}
+//
+// It's registered globally, so that the backend's reflection serves it.
+var echoFile = func() protoreflect.FileDescriptor {
+	s := proto.String
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{Name: s(name), JsonName: s(name), Number: proto.Int32(number), Type: typ.Enum(), Label: label.Enum()}
+	}
+	optional, repeated := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL, descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+	data := field("data", 4, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, optional)
+	data.TypeName = s(".google.protobuf.Struct")
+	extra := field("extra", 5, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, optional)
+	extra.TypeName = s(".google.protobuf.Any")
+	fd := &descriptorpb.FileDescriptorProto{
+		Name:       s("test/v1/echo.proto"),
+		Package:    s("test.v1"),
+		Syntax:     s("proto3"),
+		Dependency: []string{"google/protobuf/struct.proto", "google/protobuf/any.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: s("EchoRequest"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("message", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional),
+				field("count", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32, optional),
+				field("tags", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING, repeated),
+				data,
+				extra,
+			},
+		}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: s("Echo"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{Name: s("Echo"), InputType: s(".test.v1.EchoRequest"), OutputType: s(".test.v1.EchoRequest")},
+				{Name: s("ServerStream"), InputType: s(".test.v1.EchoRequest"), OutputType: s(".test.v1.EchoRequest"), ServerStreaming: proto.Bool(true)},
+				{Name: s("ClientStream"), InputType: s(".test.v1.EchoRequest"), OutputType: s(".test.v1.EchoRequest"), ClientStreaming: proto.Bool(true)},
+				{Name: s("Bidi"), InputType: s(".test.v1.EchoRequest"), OutputType: s(".test.v1.EchoRequest"), ClientStreaming: proto.Bool(true), ServerStreaming: proto.Bool(true)},
+				{Name: s("OldEcho"), InputType: s(".test.v1.EchoRequest"), OutputType: s(".test.v1.EchoRequest"), Options: &descriptorpb.MethodOptions{Deprecated: proto.Bool(true)}},
+			},
+		}},
+	}
+	f, err := protodesc.NewFile(fd, protoregistry.GlobalFiles)
+	if err != nil {
+		panic(err)
+	}
+	if err := protoregistry.GlobalFiles.RegisterFile(f); err != nil {
+		panic(err)
+	}
+	return f
+}()
+
+var echoRequest = echoFile.Messages().ByName("EchoRequest")
+
+// testBackend is an in-process test.v1.Echo backend with reflection.
+type testBackend struct {
+	addr  string
+	calls atomic.Int64
+
+	mu        sync.Mutex
+	unary     func(ctx context.Context, req *dynamicpb.Message) (proto.Message, error)
+	streamErr error
+}
+
+// startBackend starts a backend for the duration of t. Echo answers with
+// the request until onUnary says otherwise.
+func startBackend(t *testing.T, opts ...grpc.ServerOption) *testBackend {
+	t.Helper()
+	be := &testBackend{}
+	srv := grpc.NewServer(opts...)
+	srv.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "test.v1.Echo",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{
+			{MethodName: "Echo", Handler: be.echo},
+			{MethodName: "OldEcho", Handler: be.echo},
+		},
+		Streams: []grpc.StreamDesc{
+			{StreamName: "ServerStream", ServerStreams: true, Handler: be.serverStream},
+			{StreamName: "ClientStream", ClientStreams: true, Handler: be.clientStream},
+			{StreamName: "Bidi", ClientStreams: true, ServerStreams: true, Handler: be.bidi},
+		},
+	}, struct{}{})
+	reflection.Register(srv)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	be.addr = lis.Addr().String()
+	return be
+}
+
+// onUnary makes f answer Echo calls.
+func (be *testBackend) onUnary(f func(ctx context.Context, req *dynamicpb.Message) (proto.Message, error)) {
+	be.mu.Lock()
+	defer be.mu.Unlock()
+	be.unary = f
+}
+
+// failStreams makes ServerStream end with err after its messages.
+func (be *testBackend) failStreams(err error) {
+	be.mu.Lock()
+	defer be.mu.Unlock()
+	be.streamErr = err
+}
+
+func (be *testBackend) echo(_ interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+	be.calls.Add(1)
+	m := dynamicpb.NewMessage(echoRequest)
+	if err := dec(m); err != nil {
+		return nil, err
+	}
+	be.mu.Lock()
+	unary := be.unary
+	be.mu.Unlock()
+	if unary == nil {
+		return m, nil
+	}
+	return unary(ctx, m)
+}
+
+// Calls returns how many Echo calls the backend has answered.
+func (be *testBackend) Calls() int {
+	return int(be.calls.Load())
+}
+
+func (be *testBackend) serverStream(_ interface{}, st grpc.ServerStream) error {
+	be.calls.Add(1)
+	m := dynamicpb.NewMessage(echoRequest)
+	if err := st.RecvMsg(m); err != nil {
+		return err
+	}
+	count := echoRequest.Fields().ByName("count")
+	for i := int64(0); i < m.Get(count).Int(); i++ {
+		out := proto.Clone(m).(*dynamicpb.Message)
+		out.Set(count, protoreflect.ValueOfInt32(int32(i+1)))
+		if err := st.SendMsg(out); err != nil {
+			return err
+		}
+	}
+	be.mu.Lock()
+	defer be.mu.Unlock()
+	return be.streamErr
+}
+
+func (be *testBackend) clientStream(_ interface{}, st grpc.ServerStream) error {
+	be.calls.Add(1)
+	var n int32
+	for {
+		if err := st.RecvMsg(dynamicpb.NewMessage(echoRequest)); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		n++
+	}
+	out := dynamicpb.NewMessage(echoRequest)
+	out.Set(echoRequest.Fields().ByName("count"), protoreflect.ValueOfInt32(n))
+	return st.SendMsg(out)
+}
+
+func (be *testBackend) bidi(_ interface{}, st grpc.ServerStream) error {
+	be.calls.Add(1)
+	for {
+		m := dynamicpb.NewMessage(echoRequest)
+		if err := st.RecvMsg(m); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := st.SendMsg(m); err != nil {
+			return err
+		}
+	}
+}
+
+// countLookups returns a server option counting the reflection streams a
+// backend serves, each of which is a descriptor lookup.
+func countLookups() (grpc.ServerOption, *atomic.Int64) {
+	var n atomic.Int64
+	return grpc.ChainStreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if strings.HasPrefix(info.FullMethod, "/grpc.reflection.") {
+			n.Add(1)
+		}
+		return handler(srv, ss)
+	}), &n
+}
+
+// newTestBridge returns a bridge for cfg, closed when t ends.
+func newTestBridge(t *testing.T, cfg Config, opts ...Option) *Bridge {
+	t.Helper()
+	b, err := NewBridge(cfg, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(b.Close)
+	return b
+}
+
+// post sends a JSON body to path on h.
+func post(h http.Handler, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return serve(h, req)
+}
+
+func serve(h http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// writeCert writes a self-signed certificate for 127.0.0.1 and its key to
+// PEM files in a temporary directory.
+func writeCert(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "bridge"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+// captureLog redirects the standard logger until the returned function is
+// called, which returns what was logged.
+func captureLog(t *testing.T) func() string {
+	t.Helper()
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	restored := false
+	restore := func() string {
+		if !restored {
+			log.SetOutput(os.Stderr)
+			restored = true
+		}
+		return buf.String()
+	}
+	t.Cleanup(func() { restore() })
+	return restore
+}
@@ -0,0 +1,35 @@
+package bridge
+
+import (
+	"net/http"
+)
+
+// indexLinks lists the bridge's endpoints for GET /.
+type indexLinks struct {
+	Health       string `json:"health"`
+	Version      string `json:"version"`
+	Capabilities string `json:"capabilities"`
+	Services     string `json:"services"`
+	RPC          string `json:"rpc"`
+	Admin        string `json:"admin,omitempty"`
+}
+
+// handleIndex serves GET /, a small index of the bridge's endpoints so the
+// API can be discovered from its root.
+func (b *Bridge) handleIndex(w http.ResponseWriter, r *http.Request) {
+	links := indexLinks{
+		Health:       "/health",
+		Version:      "/version",
+		Capabilities: "/capabilities",
+		Services:     "/services",
+		RPC:          "/{service}/{method}",
+	}
+	if b.adminToken != "" {
+		links.Admin = "/admin/maintenance"
+	}
+	b.encodeJSON(w, http.StatusOK, map[string]interface{}{
+		"name":    "grpc-http-bridge",
+		"version": Version,
+		"links":   links,
+	})
+}
@@ -0,0 +1,49 @@
+package bridge
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFaviconIsNotAnRPC(t *testing.T) {
+	counter, streams := countLookups()
+	be := startBackend(t, counter)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, DefaultService: "test.v1.Echo"})
+	lookups := streams.Load()
+
+	rec := serve(b.Handler(), httptest.NewRequest(http.MethodGet, "/favicon.ico", nil))
+	if rec.Code != http.StatusNoContent || rec.Body.Len() != 0 {
+		t.Fatalf("got %d %q, want an empty 204", rec.Code, rec.Body)
+	}
+	if be.Calls() != 0 || streams.Load() != lookups {
+		t.Fatalf("favicon request reached the backend: %d calls, %d reflection lookups", be.Calls(), streams.Load()-lookups)
+	}
+}
+
+func TestRootIndex(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr})
+	rec := serve(b.Handler(), httptest.NewRequest(http.MethodGet, "/", nil))
+	var index struct {
+		Name  string
+		Links indexLinks
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &index); rec.Code != 200 || err != nil {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+	if index.Name != "grpc-http-bridge" || index.Links.Health != "/health" || index.Links.Services != "/services" {
+		t.Fatalf("index %+v", index)
+	}
+	for _, link := range []string{index.Links.Health, index.Links.Version, index.Links.Capabilities, index.Links.Services} {
+		if rec := serve(b.Handler(), httptest.NewRequest(http.MethodGet, link, nil)); rec.Code != 200 {
+			t.Errorf("GET %s: got %d, want 200", link, rec.Code)
+		}
+	}
+
+	b = newTestBridge(t, Config{GRPCAddr: be.addr, DisableRootIndex: true})
+	if rec := serve(b.Handler(), httptest.NewRequest(http.MethodGet, "/", nil)); rec.Code != 404 {
+		t.Fatalf("disabled index: got %d, want 404", rec.Code)
+	}
+}
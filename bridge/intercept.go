@@ -0,0 +1,35 @@
+package bridge
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// UnaryInterceptor wraps a unary call at the message level, after the
+// request JSON has been decoded and before the response is encoded.
+// fullMethod is the gRPC path, e.g. "/pkg.Service/Method". invoke calls the
+// next interceptor or the backend, filling resp; an interceptor may skip
+// it and fill resp itself, for example from a cache.
+type UnaryInterceptor func(ctx context.Context, fullMethod string, req, resp proto.Message, invoke func() error) error
+
+// WithUnaryInterceptor adds a message-level interceptor to unary calls.
+// Unlike WithInterceptor, it runs in the bridge rather than the gRPC client,
+// so it can answer a call without a backend connection being involved.
+// Interceptors run in the order they are added.
+func WithUnaryInterceptor(interceptor UnaryInterceptor) Option {
+	return func(o *options) {
+		o.bridgeInterceptors = append(o.bridgeInterceptors, interceptor)
+	}
+}
+
+// intercept runs invoke through the bridge's unary interceptors.
+func (b *Bridge) intercept(ctx context.Context, fullMethod string, req, resp proto.Message, invoke func() error) error {
+	for i := len(b.interceptors) - 1; i >= 0; i-- {
+		interceptor, next := b.interceptors[i], invoke
+		invoke = func() error {
+			return interceptor(ctx, fullMethod, req, resp, next)
+		}
+	}
+	return invoke()
+}
@@ -0,0 +1,51 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestUnaryInterceptorServesCachedResponse(t *testing.T) {
+	be := startBackend(t)
+	var mu sync.Mutex
+	cache := make(map[string][]byte)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr}, WithUnaryInterceptor(func(ctx context.Context, fullMethod string, req, resp proto.Message, invoke func() error) error {
+		key, err := proto.Marshal(req)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		cached, ok := cache[fullMethod+string(key)]
+		mu.Unlock()
+		if ok {
+			return proto.Unmarshal(cached, resp)
+		}
+		if err := invoke(); err != nil {
+			return err
+		}
+		out, err := proto.Marshal(resp)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		cache[fullMethod+string(key)] = out
+		mu.Unlock()
+		return nil
+	}))
+	h := b.Handler()
+
+	for i := 0; i < 3; i++ {
+		rec := post(h, "/test.v1.Echo/Echo", `{"message": "hi"}`)
+		var resp struct{ Message string }
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); rec.Code != 200 || err != nil || resp.Message != "hi" {
+			t.Fatalf("call %d: got %d %s", i+1, rec.Code, rec.Body)
+		}
+	}
+	if n := be.Calls(); n != 1 {
+		t.Fatalf("backend saw %d calls, want the interceptor to answer all but the first", n)
+	}
+}
@@ -0,0 +1,506 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// jsonSchema is a compiled JSON Schema that request bodies are checked
+// against before they are parsed into messages. It supports the validation
+// keywords useful for cross-field rules: type, enum, const, the numeric,
+// string and array bounds, pattern, properties, required,
+// additionalProperties, items, dependentRequired, allOf, anyOf, oneOf, not
+// and if/then/else. Other keywords, such as $ref and format, are ignored.
+type jsonSchema struct {
+	// always, if set, is the result of a boolean schema
+	always *bool
+
+	types   []string
+	enum    []interface{}
+	hasEnum bool
+	constV  interface{}
+	hasCons bool
+
+	minimum, maximum                   *float64
+	exclusiveMinimum, exclusiveMaximum *float64
+	multipleOf                         *float64
+
+	minLength, maxLength *int
+	pattern              *regexp.Regexp
+
+	minItems, maxItems *int
+	uniqueItems        bool
+	items              *jsonSchema
+
+	properties           map[string]*jsonSchema
+	required             []string
+	additionalProperties *jsonSchema
+	dependentRequired    map[string][]string
+
+	allOf, anyOf, oneOf []*jsonSchema
+	not                 *jsonSchema
+	ifS, thenS, elseS   *jsonSchema
+}
+
+// schemaDocument is the JSON form of a schema object.
+type schemaDocument struct {
+	Type  json.RawMessage `json:"type"`
+	Enum  json.RawMessage `json:"enum"`
+	Const json.RawMessage `json:"const"`
+
+	Minimum          *float64 `json:"minimum"`
+	Maximum          *float64 `json:"maximum"`
+	ExclusiveMinimum *float64 `json:"exclusiveMinimum"`
+	ExclusiveMaximum *float64 `json:"exclusiveMaximum"`
+	MultipleOf       *float64 `json:"multipleOf"`
+
+	MinLength *int   `json:"minLength"`
+	MaxLength *int   `json:"maxLength"`
+	Pattern   string `json:"pattern"`
+
+	MinItems    *int            `json:"minItems"`
+	MaxItems    *int            `json:"maxItems"`
+	UniqueItems bool            `json:"uniqueItems"`
+	Items       json.RawMessage `json:"items"`
+
+	Properties           map[string]json.RawMessage `json:"properties"`
+	Required             []string                   `json:"required"`
+	AdditionalProperties json.RawMessage            `json:"additionalProperties"`
+	DependentRequired    map[string][]string        `json:"dependentRequired"`
+
+	AllOf []json.RawMessage `json:"allOf"`
+	AnyOf []json.RawMessage `json:"anyOf"`
+	OneOf []json.RawMessage `json:"oneOf"`
+	Not   json.RawMessage   `json:"not"`
+	If    json.RawMessage   `json:"if"`
+	Then  json.RawMessage   `json:"then"`
+	Else  json.RawMessage   `json:"else"`
+}
+
+var schemaTypes = map[string]bool{
+	"null": true, "boolean": true, "object": true, "array": true,
+	"number": true, "integer": true, "string": true,
+}
+
+func compileJSONSchema(data json.RawMessage) (*jsonSchema, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, nil
+	}
+	if string(trimmed) == "true" || string(trimmed) == "false" {
+		always := string(trimmed) == "true"
+		return &jsonSchema{always: &always}, nil
+	}
+
+	var doc schemaDocument
+	if err := json.Unmarshal(trimmed, &doc); err != nil {
+		return nil, err
+	}
+	s := &jsonSchema{
+		minimum:           doc.Minimum,
+		maximum:           doc.Maximum,
+		exclusiveMinimum:  doc.ExclusiveMinimum,
+		exclusiveMaximum:  doc.ExclusiveMaximum,
+		multipleOf:        doc.MultipleOf,
+		minLength:         doc.MinLength,
+		maxLength:         doc.MaxLength,
+		minItems:          doc.MinItems,
+		maxItems:          doc.MaxItems,
+		uniqueItems:       doc.UniqueItems,
+		required:          doc.Required,
+		dependentRequired: doc.DependentRequired,
+	}
+
+	if len(doc.Type) > 0 {
+		var one string
+		if err := json.Unmarshal(doc.Type, &one); err == nil {
+			s.types = []string{one}
+		} else if err := json.Unmarshal(doc.Type, &s.types); err != nil {
+			return nil, fmt.Errorf("type must be a string or an array of strings")
+		}
+		for _, t := range s.types {
+			if !schemaTypes[t] {
+				return nil, fmt.Errorf("unknown type %q", t)
+			}
+		}
+	}
+	if len(doc.Enum) > 0 {
+		v, err := decodeJSONValue(doc.Enum)
+		if err != nil {
+			return nil, err
+		}
+		values, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("enum must be an array")
+		}
+		s.enum, s.hasEnum = values, true
+	}
+	if len(doc.Const) > 0 {
+		v, err := decodeJSONValue(doc.Const)
+		if err != nil {
+			return nil, err
+		}
+		s.constV, s.hasCons = v, true
+	}
+	if doc.Pattern != "" {
+		re, err := regexp.Compile(doc.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		s.pattern = re
+	}
+
+	var err error
+	sub := func(name string, raw json.RawMessage) *jsonSchema {
+		if err != nil {
+			return nil
+		}
+		var compiled *jsonSchema
+		if compiled, err = compileJSONSchema(raw); err != nil {
+			err = fmt.Errorf("%s: %w", name, err)
+		}
+		return compiled
+	}
+	subs := func(name string, raws []json.RawMessage) []*jsonSchema {
+		var out []*jsonSchema
+		for i, raw := range raws {
+			if c := sub(fmt.Sprintf("%s[%d]", name, i), raw); c != nil {
+				out = append(out, c)
+			}
+		}
+		return out
+	}
+	s.items = sub("items", doc.Items)
+	s.additionalProperties = sub("additionalProperties", doc.AdditionalProperties)
+	if len(doc.Properties) > 0 {
+		s.properties = make(map[string]*jsonSchema, len(doc.Properties))
+		for name, raw := range doc.Properties {
+			if c := sub("properties."+name, raw); c != nil {
+				s.properties[name] = c
+			}
+		}
+	}
+	s.allOf = subs("allOf", doc.AllOf)
+	s.anyOf = subs("anyOf", doc.AnyOf)
+	s.oneOf = subs("oneOf", doc.OneOf)
+	s.not = sub("not", doc.Not)
+	s.ifS = sub("if", doc.If)
+	s.thenS = sub("then", doc.Then)
+	s.elseS = sub("else", doc.Else)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// decodeJSONValue decodes data keeping numbers as json.Number, so integers
+// can be told apart from other numbers.
+func decodeJSONValue(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// validate checks v, decoded with decodeJSONValue, appending a violation
+// for each rule it breaks.
+func (s *jsonSchema) validate(v interface{}, path string, out *[]*fieldViolation) {
+	if s.always != nil {
+		if !*s.always {
+			addViolation(out, path, "is not allowed")
+		}
+		return
+	}
+
+	if len(s.types) > 0 && !matchesType(v, s.types) {
+		addViolation(out, path, "must be of type "+strings.Join(s.types, " or "))
+		// The other keywords assume the right type
+		return
+	}
+	if s.hasEnum {
+		found := false
+		for _, e := range s.enum {
+			if jsonEqual(v, e) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			addViolation(out, path, "must be one of "+formatJSONValues(s.enum))
+		}
+	}
+	if s.hasCons && !jsonEqual(v, s.constV) {
+		addViolation(out, path, "must be "+formatJSONValues([]interface{}{s.constV}))
+	}
+
+	switch v := v.(type) {
+	case json.Number:
+		s.validateNumber(v, path, out)
+	case string:
+		s.validateString(v, path, out)
+	case []interface{}:
+		s.validateArray(v, path, out)
+	case map[string]interface{}:
+		s.validateObject(v, path, out)
+	}
+
+	for _, sub := range s.allOf {
+		sub.validate(v, path, out)
+	}
+	if len(s.anyOf) > 0 {
+		matched := false
+		for _, sub := range s.anyOf {
+			if sub.matches(v) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			addViolation(out, path, "must match at least one anyOf schema")
+		}
+	}
+	if len(s.oneOf) > 0 {
+		n := 0
+		for _, sub := range s.oneOf {
+			if sub.matches(v) {
+				n++
+			}
+		}
+		if n != 1 {
+			addViolation(out, path, fmt.Sprintf("must match exactly one oneOf schema, matched %d", n))
+		}
+	}
+	if s.not != nil && s.not.matches(v) {
+		addViolation(out, path, "must not match the not schema")
+	}
+	if s.ifS != nil {
+		if s.ifS.matches(v) {
+			if s.thenS != nil {
+				s.thenS.validate(v, path, out)
+			}
+		} else if s.elseS != nil {
+			s.elseS.validate(v, path, out)
+		}
+	}
+}
+
+func (s *jsonSchema) matches(v interface{}) bool {
+	var out []*fieldViolation
+	s.validate(v, "", &out)
+	return len(out) == 0
+}
+
+func (s *jsonSchema) validateNumber(n json.Number, path string, out *[]*fieldViolation) {
+	f, err := n.Float64()
+	if err != nil {
+		return
+	}
+	if s.minimum != nil && f < *s.minimum {
+		addViolation(out, path, fmt.Sprintf("must be >= %g", *s.minimum))
+	}
+	if s.maximum != nil && f > *s.maximum {
+		addViolation(out, path, fmt.Sprintf("must be <= %g", *s.maximum))
+	}
+	if s.exclusiveMinimum != nil && f <= *s.exclusiveMinimum {
+		addViolation(out, path, fmt.Sprintf("must be > %g", *s.exclusiveMinimum))
+	}
+	if s.exclusiveMaximum != nil && f >= *s.exclusiveMaximum {
+		addViolation(out, path, fmt.Sprintf("must be < %g", *s.exclusiveMaximum))
+	}
+	if s.multipleOf != nil && *s.multipleOf > 0 {
+		if q := f / *s.multipleOf; math.Abs(q-math.Round(q)) > 1e-9 {
+			addViolation(out, path, fmt.Sprintf("must be a multiple of %g", *s.multipleOf))
+		}
+	}
+}
+
+func (s *jsonSchema) validateString(str, path string, out *[]*fieldViolation) {
+	n := utf8.RuneCountInString(str)
+	if s.minLength != nil && n < *s.minLength {
+		addViolation(out, path, fmt.Sprintf("must be at least %d characters", *s.minLength))
+	}
+	if s.maxLength != nil && n > *s.maxLength {
+		addViolation(out, path, fmt.Sprintf("must be at most %d characters", *s.maxLength))
+	}
+	if s.pattern != nil && !s.pattern.MatchString(str) {
+		addViolation(out, path, fmt.Sprintf("must match %q", s.pattern.String()))
+	}
+}
+
+func (s *jsonSchema) validateArray(items []interface{}, path string, out *[]*fieldViolation) {
+	if s.minItems != nil && len(items) < *s.minItems {
+		addViolation(out, path, fmt.Sprintf("must have at least %d items", *s.minItems))
+	}
+	if s.maxItems != nil && len(items) > *s.maxItems {
+		addViolation(out, path, fmt.Sprintf("must have at most %d items", *s.maxItems))
+	}
+	if s.uniqueItems {
+	unique:
+		for i := range items {
+			for j := 0; j < i; j++ {
+				if jsonEqual(items[i], items[j]) {
+					addViolation(out, path, "must not contain duplicate items")
+					break unique
+				}
+			}
+		}
+	}
+	if s.items != nil {
+		for i, item := range items {
+			s.items.validate(item, fmt.Sprintf("%s[%d]", path, i), out)
+		}
+	}
+}
+
+func (s *jsonSchema) validateObject(obj map[string]interface{}, path string, out *[]*fieldViolation) {
+	for _, name := range s.required {
+		if _, ok := obj[name]; !ok {
+			addViolation(out, joinFieldPath(path, name), "is required")
+		}
+	}
+
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, dep := range s.dependentRequired[key] {
+			if _, ok := obj[dep]; !ok {
+				addViolation(out, joinFieldPath(path, dep), fmt.Sprintf("is required when %s is present", key))
+			}
+		}
+		if prop, ok := s.properties[key]; ok {
+			prop.validate(obj[key], joinFieldPath(path, key), out)
+		} else if s.additionalProperties != nil {
+			s.additionalProperties.validate(obj[key], joinFieldPath(path, key), out)
+		}
+	}
+}
+
+func matchesType(v interface{}, types []string) bool {
+	for _, t := range types {
+		switch v := v.(type) {
+		case nil:
+			if t == "null" {
+				return true
+			}
+		case bool:
+			if t == "boolean" {
+				return true
+			}
+		case string:
+			if t == "string" {
+				return true
+			}
+		case []interface{}:
+			if t == "array" {
+				return true
+			}
+		case map[string]interface{}:
+			if t == "object" {
+				return true
+			}
+		case json.Number:
+			if t == "number" {
+				return true
+			}
+			if t == "integer" {
+				if f, err := v.Float64(); err == nil && f == math.Trunc(f) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// jsonEqual reports whether two decoded JSON values are equal, comparing
+// numbers by value so that 1 and 1.0 are the same.
+func jsonEqual(a, b interface{}) bool {
+	switch a := a.(type) {
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		fa, errA := a.Float64()
+		fb, errB := b.Float64()
+		return errA == nil && errB == nil && fa == fb
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !jsonEqual(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, av := range a {
+			bv, ok := b[k]
+			if !ok || !jsonEqual(av, bv) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
+
+func formatJSONValues(values []interface{}) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		data, _ := json.Marshal(v)
+		parts[i] = string(data)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// checkRequestSchema validates a JSON request message against the
+// request_schema configured for fullMethod, if any.
+func (b *Bridge) checkRequestSchema(fullMethod string, data []byte) error {
+	s := b.requestSchemas[fullMethod[1:]]
+	if s == nil {
+		return nil
+	}
+	if b.devMode {
+		data = relaxJSON(data)
+	}
+	v, err := decodeJSONValue(data)
+	if err != nil {
+		return invalidPayload(withCause(status.Errorf(codes.InvalidArgument, "invalid request body: %v", err), err))
+	}
+	var violations []*fieldViolation
+	s.validate(v, "", &violations)
+	if len(violations) == 0 {
+		return nil
+	}
+	summary := make([]string, len(violations))
+	for i, fv := range violations {
+		summary[i] = fv.Description
+		if fv.Field != "" {
+			summary[i] = fv.Field + " " + fv.Description
+		}
+	}
+	return invalidPayload(badRequest(violations, "request body does not match schema: %s", strings.Join(summary, "; ")))
+}
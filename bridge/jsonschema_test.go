@@ -0,0 +1,57 @@
+package bridge
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRequestSchemaRejectsViolations(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{
+		GRPCAddr: be.addr,
+		Methods: map[string]MethodConfig{
+			"test.v1.Echo/Echo": {RequestSchema: json.RawMessage(`{
+				"type": "object",
+				"dependentRequired": {"count": ["message"]},
+				"properties": {
+					"count": {"type": "integer", "minimum": 1},
+					"tags": {"type": "array", "maxItems": 2}
+				}
+			}`)},
+		},
+	})
+	h := b.Handler()
+
+	rec := post(h, "/test.v1.Echo/Echo", `{"count": 0, "tags": ["a", "b", "c"]}`)
+	if rec.Code != 400 || be.Calls() != 0 {
+		t.Fatalf("got %d %s with %d backend calls, want 400 without any", rec.Code, rec.Body, be.Calls())
+	}
+	var resp struct {
+		ErrorCode string `json:"error_code"`
+		Details   []struct {
+			FieldViolations []struct {
+				Field, Description string
+			} `json:"field_violations"`
+		}
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || len(resp.Details) != 1 {
+		t.Fatalf("error body %s, want one BadRequest", rec.Body)
+	}
+	got := make(map[string]string)
+	for _, v := range resp.Details[0].FieldViolations {
+		got[v.Field] = v.Description
+	}
+	want := map[string]string{
+		"message": "is required when count is present",
+		"count":   "must be >= 1",
+		"tags":    "must have at most 2 items",
+	}
+	if resp.ErrorCode != "INVALID_PAYLOAD" || !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %s violations %v, want INVALID_PAYLOAD %v", resp.ErrorCode, got, want)
+	}
+
+	if rec := post(h, "/test.v1.Echo/Echo", `{"count": 1, "message": "hi"}`); rec.Code != 200 {
+		t.Fatalf("valid body: got %d %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,35 @@
+package bridge
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDevModeAcceptsCommentsAndTrailingCommas(t *testing.T) {
+	be := startBackend(t)
+	body := `{
+		// hand-written
+		"message": "hi, // not a comment",
+		"tags": ["a", "b",], /* trailing */
+	}`
+	for _, devMode := range []bool{false, true} {
+		b := newTestBridge(t, Config{GRPCAddr: be.addr, DevMode: devMode})
+		rec := post(b.Handler(), "/test.v1.Echo/Echo", body)
+		if !devMode {
+			if rec.Code != 400 {
+				t.Fatalf("without dev mode: got %d, want 400", rec.Code)
+			}
+			continue
+		}
+		var resp struct {
+			Message string
+			Tags    []string
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); rec.Code != 200 || err != nil {
+			t.Fatalf("dev mode: got %d %s", rec.Code, rec.Body)
+		}
+		if resp.Message != "hi, // not a comment" || len(resp.Tags) != 2 {
+			t.Fatalf("dev mode: parsed %+v", resp)
+		}
+	}
+}
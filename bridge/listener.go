@@ -0,0 +1,78 @@
+package bridge
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"syscall"
+)
+
+// listen opens the TCP listener Serve accepts connections on, applying the
+// configured socket options.
+func (b *Bridge) listen(addr string) (net.Listener, error) {
+	lc := b.listenConfig()
+	ln, err := lc.Listen(context.Background(), "tcp", addr)
+	if err != nil || b.maxConns <= 0 {
+		return ln, err
+	}
+	return &limitListener{Listener: ln, slots: make(chan struct{}, b.maxConns), rejected: &b.connRejects}, nil
+}
+
+// limitListener keeps at most cap(slots) accepted connections open.
+// Connections beyond that are closed as soon as they are accepted, rather
+// than left waiting in the backlog, so clients fail fast and can try
+// another instance.
+type limitListener struct {
+	net.Listener
+	slots    chan struct{}
+	rejected *atomic.Uint64
+}
+
+func (l *limitListener) Accept() (net.Conn, error) {
+	for {
+		c, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		select {
+		case l.slots <- struct{}{}:
+			return &limitConn{Conn: c, slots: l.slots}, nil
+		default:
+			l.rejected.Add(1)
+			c.Close()
+		}
+	}
+}
+
+// limitConn frees its listener slot when it is closed.
+type limitConn struct {
+	net.Conn
+	slots  chan struct{}
+	closed sync.Once
+}
+
+func (c *limitConn) Close() error {
+	err := c.Conn.Close()
+	c.closed.Do(func() { <-c.slots })
+	return err
+}
+
+// listenConfig returns the net.ListenConfig for the HTTP listener. With
+// reusePort set, several bridge processes can bind the same port, so a new
+// one can start accepting before the old one stops. tcpKeepAlive sets the
+// keepalive period of accepted connections: zero keeps Go's default and a
+// negative value disables keepalives.
+func (b *Bridge) listenConfig() net.ListenConfig {
+	lc := net.ListenConfig{KeepAlive: b.tcpKeepAlive}
+	if b.reusePort {
+		lc.Control = func(network, address string, c syscall.RawConn) error {
+			var sockErr error
+			if err := c.Control(func(fd uintptr) { sockErr = setReusePort(fd) }); err != nil {
+				return err
+			}
+			return sockErr
+		}
+	}
+	return lc
+}
@@ -0,0 +1,65 @@
+package bridge
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestListenConfigKeepAlive(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, TCPKeepAlive: 42 * time.Second})
+	if lc := b.listenConfig(); lc.KeepAlive != 42*time.Second || lc.Control != nil {
+		t.Fatalf("listen config has keepalive %v and control set %v, want 42s without socket options", lc.KeepAlive, lc.Control != nil)
+	}
+}
+
+func TestMaxConnectionsClosesExtraConnections(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, MaxConnections: 1})
+	ln, err := b.listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	accepted := make(chan net.Conn)
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- c
+		}
+	}()
+	dial := func() net.Conn {
+		t.Helper()
+		c, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { c.Close() })
+		return c
+	}
+
+	dial()
+	first := <-accepted
+	extra := dial()
+	extra.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := extra.Read(make([]byte, 1)); err != io.EOF {
+		t.Fatalf("connection over the limit: read got %v, want EOF", err)
+	}
+	if got := metric(t, b.Handler(), "bridge_connections_rejected_total"); got != 1 {
+		t.Fatalf("rejected connections = %v, want 1", got)
+	}
+
+	first.Close()
+	dial()
+	select {
+	case c := <-accepted:
+		c.Close()
+	case <-time.After(5 * time.Second):
+		t.Fatal("closing a connection didn't make room for another")
+	}
+}
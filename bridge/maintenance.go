@@ -0,0 +1,144 @@
+package bridge
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultMaintenanceMessage    = "down for maintenance"
+	defaultMaintenanceRetryAfter = time.Minute
+)
+
+// maintenance is the state toggled with POST /admin/maintenance. While
+// enabled, RPC routes return 503.
+type maintenance struct {
+	// window holds the configured defaults
+	window MaintenanceWindow
+
+	mu           sync.RWMutex
+	enabled      bool
+	message      string
+	retryAfter   time.Duration
+	estimatedEnd time.Time
+}
+
+// maintenanceState is the JSON form of maintenance, both in requests to
+// and responses from /admin/maintenance.
+type maintenanceState struct {
+	Enabled      bool       `json:"enabled"`
+	Message      string     `json:"message,omitempty"`
+	RetryAfter   Duration   `json:"retry_after,omitempty"`
+	EstimatedEnd *time.Time `json:"estimated_end,omitempty"`
+}
+
+// maintenanceInfo is the "maintenance" member of the body of requests
+// turned away during maintenance or a drain.
+type maintenanceInfo struct {
+	Reason       string     `json:"reason,omitempty"`
+	EstimatedEnd *time.Time `json:"estimated_end,omitempty"`
+	RetryAfter   Duration   `json:"retry_after,omitempty"`
+}
+
+func (m *maintenance) state() maintenanceState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return maintenanceState{
+		Enabled:      m.enabled,
+		Message:      m.message,
+		RetryAfter:   Duration(m.retryAfter),
+		EstimatedEnd: timeOrNil(m.estimatedEnd),
+	}
+}
+
+func (m *maintenance) set(s maintenanceState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.enabled = s.Enabled
+	m.message, m.retryAfter, m.estimatedEnd = "", 0, time.Time{}
+	if !m.enabled {
+		return
+	}
+	m.message = s.Message
+	if m.message == "" {
+		m.message = m.window.Reason
+	}
+	if m.message == "" {
+		m.message = defaultMaintenanceMessage
+	}
+	m.retryAfter = time.Duration(s.RetryAfter)
+	if m.retryAfter <= 0 {
+		m.retryAfter = defaultMaintenanceRetryAfter
+	}
+	m.estimatedEnd = m.window.EstimatedEnd
+	if s.EstimatedEnd != nil {
+		m.estimatedEnd = *s.EstimatedEnd
+	}
+}
+
+// gate rejects requests with 503 while maintenance is enabled.
+func (m *maintenance) gate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s := m.state()
+		if s.Enabled {
+			w.Header().Set("Retry-After", strconv.Itoa(int(time.Duration(s.RetryAfter).Seconds())))
+			writeErrorWith(w, r, withErrorCode(status.Error(codes.Unavailable, s.Message), errorBridgeUnavailable), map[string]interface{}{
+				"maintenance": maintenanceInfo{
+					Reason:       s.Message,
+					EstimatedEnd: s.EstimatedEnd,
+					RetryAfter:   s.RetryAfter,
+				},
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// timeOrNil returns &t, or nil for the zero time.
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// handleMaintenance serves GET and POST /admin/maintenance. A POST body of
+// {"enabled": true, "message": "...", "retry_after": "5m",
+// "estimated_end": "2026-10-14T12:00:00Z"} enables maintenance mode and
+// {"enabled": false} disables it.
+func (b *Bridge) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		body, err := readBody(r)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to read body: %v", err), http.StatusBadRequest)
+			return
+		}
+		var s maintenanceState
+		if err := strictUnmarshal(body, &s); err != nil {
+			writeError(w, r, status.Errorf(codes.InvalidArgument, "invalid maintenance request: %v", err))
+			return
+		}
+		b.maintenance.set(s)
+	}
+	b.encodeJSON(w, http.StatusOK, b.maintenance.state())
+}
+
+// adminAuth requires the admin token as a bearer token.
+func (b *Bridge) adminAuth(next http.Handler) http.Handler {
+	want := []byte("Bearer " + b.adminToken)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			writeError(w, r, status.Error(codes.Unauthenticated, "admin token required"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,83 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMaintenanceToggle(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, AdminToken: "secret"})
+	h := b.Handler()
+	toggle := func(body string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/admin/maintenance", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		if rec := serve(h, req); rec.Code != 200 {
+			t.Fatalf("POST /admin/maintenance: got %d %s", rec.Code, rec.Body)
+		}
+	}
+
+	toggle(`{"enabled": true, "message": "database upgrade", "retry_after": "5m"}`)
+	rec := post(h, "/test.v1.Echo/Echo", `{}`)
+	if rec.Code != 503 || rec.Header().Get("Retry-After") != "300" || !strings.Contains(rec.Body.String(), "database upgrade") {
+		t.Fatalf("during maintenance: got %d, Retry-After %q, %s", rec.Code, rec.Header().Get("Retry-After"), rec.Body)
+	}
+	if be.Calls() != 0 {
+		t.Fatal("a call reached the backend during maintenance")
+	}
+	if rec := serve(h, httptest.NewRequest(http.MethodGet, "/health", nil)); rec.Code != 200 {
+		t.Fatalf("/health during maintenance: got %d, want 200", rec.Code)
+	}
+
+	toggle(`{"enabled": false}`)
+	if rec := post(h, "/test.v1.Echo/Echo", `{}`); rec.Code != 200 {
+		t.Fatalf("after maintenance: got %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestMaintenanceMetadataIn503Body(t *testing.T) {
+	be := startBackend(t)
+	end := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	b := newTestBridge(t, Config{
+		GRPCAddr:    be.addr,
+		AdminToken:  "secret",
+		Maintenance: &MaintenanceWindow{Reason: "database upgrade", EstimatedEnd: end},
+	})
+	h := b.Handler()
+
+	maintenanceOf := func(rec *httptest.ResponseRecorder) maintenanceInfo {
+		t.Helper()
+		var resp struct{ Maintenance maintenanceInfo }
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != 503 {
+			t.Fatalf("got %d %s, want 503", rec.Code, rec.Body)
+		}
+		return resp.Maintenance
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/maintenance", strings.NewReader(`{"enabled": true}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	if rec := serve(h, req); rec.Code != 200 {
+		t.Fatalf("POST /admin/maintenance: got %d %s", rec.Code, rec.Body)
+	}
+	m := maintenanceOf(post(h, "/test.v1.Echo/Echo", `{}`))
+	if m.Reason != "database upgrade" || m.EstimatedEnd == nil || !m.EstimatedEnd.Equal(end) {
+		t.Fatalf("maintenance mode: got %+v, want the configured reason and end %v", m, end)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/admin/maintenance", strings.NewReader(`{"enabled": false}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	serve(h, req)
+	if err := b.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	m = maintenanceOf(post(h, "/test.v1.Echo/Echo", `{}`))
+	if m.Reason != "database upgrade" || m.EstimatedEnd == nil || !m.EstimatedEnd.Equal(end) {
+		t.Fatalf("draining: got %+v, want the configured reason and end %v", m, end)
+	}
+}
@@ -0,0 +1,31 @@
+package bridge
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// metadataHeaderPrefix prefixes backend metadata forwarded as response
+// headers, so it can't be mistaken for the bridge's own headers.
+const metadataHeaderPrefix = "Grpc-Metadata-"
+
+// setMetadataHeaders adds md to h as Grpc-Metadata-* headers. Keys gRPC
+// reserves for itself are left out, and binary (-bin) values are base64
+// encoded as they are on the wire.
+func setMetadataHeaders(h http.Header, md metadata.MD) {
+	for key, values := range md {
+		if strings.HasPrefix(key, "grpc-") || strings.HasPrefix(key, ":") || key == "content-type" {
+			continue
+		}
+		name := metadataHeaderPrefix + key
+		for _, v := range values {
+			if strings.HasSuffix(key, "-bin") {
+				v = base64.StdEncoding.EncodeToString([]byte(v))
+			}
+			h.Add(name, v)
+		}
+	}
+}
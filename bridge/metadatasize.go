@@ -0,0 +1,72 @@
+package bridge
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// metadataEntryOverhead is what HTTP/2, and so gRPC's header list limit,
+// counts for every header on top of its name and value.
+const metadataEntryOverhead = 32
+
+// forwardedEntry is a metadata key the bridge sends a backend on behalf of
+// a request, with the size of all its values.
+type forwardedEntry struct {
+	key  string
+	size int
+}
+
+// limitMetadata enforces MaxForwardedMetadata on the metadata forwarded
+// for r: the client certificate principal and the bearer token. Over the
+// limit, the request is rejected with 400, or under the "drop" policy the
+// largest entries are left out until the rest fit.
+func (b *Bridge) limitMetadata(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		md, _ := metadata.FromOutgoingContext(ctx)
+		var entries []forwardedEntry
+		total := 0
+		for key, values := range md {
+			e := forwardedEntry{key: key}
+			for _, v := range values {
+				e.size += len(key) + len(v) + metadataEntryOverhead
+			}
+			entries = append(entries, e)
+		}
+		if token, ok := ctx.Value(bearerKey{}).(string); ok {
+			entries = append(entries, forwardedEntry{"authorization", len("authorization") + len("Bearer "+token) + metadataEntryOverhead})
+		}
+		for _, e := range entries {
+			total += e.size
+		}
+		if total <= b.metadataLimit {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if b.metadataPolicy != "drop" {
+			b.writeError(w, status.Errorf(codes.InvalidArgument, "forwarded metadata is %d bytes, over the limit of %d", total, b.metadataLimit))
+			return
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].size > entries[j].size })
+		for _, e := range entries {
+			if total <= b.metadataLimit {
+				break
+			}
+			if e.key == "authorization" {
+				ctx = context.WithValue(ctx, bearerKey{}, nil)
+			} else {
+				md.Delete(e.key)
+			}
+			total -= e.size
+			log.Printf("⚠ Dropped forwarded metadata %s: over the limit of %d bytes", e.key, b.metadataLimit)
+		}
+		next.ServeHTTP(w, r.WithContext(metadata.NewOutgoingContext(ctx, md)))
+	})
+}
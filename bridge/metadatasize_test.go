@@ -0,0 +1,48 @@
+package bridge
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestForwardedMetadataLimit(t *testing.T) {
+	be := startBackend(t)
+	echoMetadata(be, "x-principal")
+	// x-principal plus its value plus 32 is 143 bytes for the long
+	// principal, over the limit, and 48 for the short one
+	long := strings.Repeat("a", 100)
+	tests := []struct {
+		policy, principal string
+		code              int
+		forwarded         string
+	}{
+		{"reject", "alice", 200, "alice"},
+		{"reject", long, 400, ""},
+		{"drop", long, 200, ""},
+	}
+	for _, tt := range tests {
+		b := newTestBridge(t, Config{GRPCAddr: be.addr, PrincipalMetadata: "X-Principal", MaxForwardedMetadata: 100, ForwardedMetadataPolicy: tt.policy})
+		req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := serve(b.Handler(), withClientCert(req, tt.principal))
+		if rec.Code != tt.code {
+			t.Fatalf("%s, %d byte principal: got %d %s, want %d", tt.policy, len(tt.principal), rec.Code, rec.Body, tt.code)
+		}
+		if rec.Code != 200 {
+			if !strings.Contains(rec.Body.String(), "over the limit of 100") {
+				t.Fatalf("rejection %s doesn't explain the limit", rec.Body)
+			}
+			continue
+		}
+		var resp struct{ Message string }
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Message != tt.forwarded {
+			t.Fatalf("%s, %d byte principal: backend got %q, want %q", tt.policy, len(tt.principal), resp.Message, tt.forwarded)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/connectivity"
+)
+
+// connectivityStates are the states reported by bridge_backend_state.
+var connectivityStates = []connectivity.State{
+	connectivity.Idle,
+	connectivity.Connecting,
+	connectivity.Ready,
+	connectivity.TransientFailure,
+	connectivity.Shutdown,
+}
+
+// latencyBuckets are the upper bounds, in seconds, of latency histograms.
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// sizeBuckets are the upper bounds, in bytes, of payload size histograms.
+var sizeBuckets = []float64{64, 256, 1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20, 4 << 20, 16 << 20}
+
+// histogram is a cumulative histogram in the Prometheus sense: each bucket
+// counts the observations at or below its bound.
+type histogram struct {
+	// bounds are the buckets' upper bounds; nil means latencyBuckets
+	bounds []float64
+
+	mu     sync.Mutex
+	counts []uint64 // per bucket, not cumulative; the last is +Inf
+	sum    float64
+	// exemplars holds, per bucket, the latest observation made in a
+	// traced request
+	exemplars []exemplar
+}
+
+// exemplar is an observation linked to the trace it was made in.
+type exemplar struct {
+	traceID string
+	value   float64
+	at      time.Time
+}
+
+func (h *histogram) buckets() []float64 {
+	if h.bounds == nil {
+		return latencyBuckets
+	}
+	return h.bounds
+}
+
+// observe adds d to h, as an exemplar too if ctx carries a trace ID.
+func (h *histogram) observe(ctx context.Context, d time.Duration) {
+	h.add(ctx, d.Seconds())
+}
+
+// add adds v to h, as an exemplar too if ctx carries a trace ID.
+func (h *histogram) add(ctx context.Context, v float64) {
+	bounds := h.buckets()
+	i := sort.SearchFloat64s(bounds, v)
+	id := traceID(ctx)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.counts == nil {
+		h.counts = make([]uint64, len(bounds)+1)
+	}
+	h.counts[i]++
+	h.sum += v
+	if id != "" {
+		if h.exemplars == nil {
+			h.exemplars = make([]exemplar, len(bounds)+1)
+		}
+		h.exemplars[i] = exemplar{traceID: id, value: v, at: time.Now()}
+	}
+}
+
+// write writes h in the Prometheus text format as name with labels, which
+// may be empty. In the OpenMetrics format buckets also carry their
+// exemplar, if any.
+func (h *histogram) write(w io.Writer, name, labels string, openMetrics bool) {
+	bounds := h.buckets()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var total uint64
+	for i := 0; i <= len(bounds); i++ {
+		if h.counts != nil {
+			total += h.counts[i]
+		}
+		le := "+Inf"
+		if i < len(bounds) {
+			le = strconv.FormatFloat(bounds[i], 'f', -1, 64)
+		}
+		fmt.Fprintf(w, "%s_bucket{%sle=%q} %d", name, labelPrefix(labels), le, total)
+		if openMetrics && h.exemplars != nil && h.exemplars[i].traceID != "" {
+			e := h.exemplars[i]
+			fmt.Fprintf(w, " # {trace_id=%q} %g %.3f", e.traceID, e.value, float64(e.at.UnixMilli())/1000)
+		}
+		fmt.Fprintln(w)
+	}
+	if labels != "" {
+		labels = "{" + labels + "}"
+	}
+	fmt.Fprintf(w, "%s_sum%s %g\n", name, labels, h.sum)
+	fmt.Fprintf(w, "%s_count%s %d\n", name, labels, total)
+}
+
+// labelPrefix returns labels followed by a comma, for more labels to
+// follow, or nothing if there are none.
+func labelPrefix(labels string) string {
+	if labels == "" {
+		return ""
+	}
+	return labels + ","
+}
+
+// handleMetrics serves GET /metrics in the Prometheus text format, or in
+// the OpenMetrics format, with exemplars, to scrapers that ask for it.
+func (b *Bridge) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	backends := b.backendList()
+	sort.Slice(backends, func(i, j int) bool { return backends[i].addr < backends[j].addr })
+
+	openMetrics := strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+	if openMetrics {
+		w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	}
+	family := func(name, typ, help string) {
+		// OpenMetrics names counter families without the _total suffix
+		// their samples have
+		if openMetrics && typ == "counter" {
+			name = strings.TrimSuffix(name, "_total")
+		}
+		fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+		fmt.Fprintf(w, "# TYPE %s %s\n", name, typ)
+	}
+
+	family("bridge_reflection_duration_seconds", "histogram", "Latency of reflection requests to backends.")
+	for _, be := range backends {
+		be.resolver.latency.write(w, "bridge_reflection_duration_seconds", fmt.Sprintf("backend=%q", be.addr), openMetrics)
+	}
+	family("bridge_reflection_errors_total", "counter", "Reflection requests to backends that failed.")
+	for _, be := range backends {
+		fmt.Fprintf(w, "bridge_reflection_errors_total{backend=%q} %d\n", be.addr, be.resolver.errors.Load())
+	}
+	family("bridge_backend_requests_total", "counter", "Calls made to backends, excluding reflection and health checks.")
+	for _, be := range backends {
+		fmt.Fprintf(w, "bridge_backend_requests_total{backend=%q} %d\n", be.addr, be.stats.requests.Load())
+	}
+	family("bridge_backend_errors_total", "counter", "Calls to backends that failed.")
+	for _, be := range backends {
+		fmt.Fprintf(w, "bridge_backend_errors_total{backend=%q} %d\n", be.addr, be.stats.errors.Load())
+	}
+	family("bridge_backend_state", "gauge", "Connectivity state of backend connections, 1 for the current state.")
+	for _, be := range backends {
+		current := be.conn.GetState()
+		for _, state := range connectivityStates {
+			v := 0
+			if state == current {
+				v = 1
+			}
+			fmt.Fprintf(w, "bridge_backend_state{backend=%q,state=%q} %d\n", be.addr, state, v)
+		}
+	}
+	if b.maxConns > 0 {
+		family("bridge_connections_rejected_total", "counter", "HTTP connections closed because --max-connections were open.")
+		fmt.Fprintf(w, "bridge_connections_rejected_total %d\n", b.connRejects.Load())
+	}
+	if b.requestSizes != nil {
+		family("bridge_request_size_bytes", "histogram", "Body sizes of requests, as read by the bridge.")
+		b.requestSizes.write(w, "bridge_request_size_bytes", "", openMetrics)
+		family("bridge_response_size_bytes", "histogram", "Body sizes of responses, before compression.")
+		b.responseSizes.write(w, "bridge_response_size_bytes", "", openMetrics)
+	}
+	if openMetrics {
+		fmt.Fprintln(w, "# EOF")
+	}
+}
@@ -0,0 +1,142 @@
+package bridge
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mizrahidaniel/grpc-http-bridge/bridgetest"
+	"google.golang.org/grpc"
+)
+
+// metric returns the value /metrics reports for series, e.g.
+// `bridge_reflection_errors_total{backend="127.0.0.1:1234"}`, or 0 if it
+// isn't listed.
+func metric(t *testing.T, h http.Handler, series string) float64 {
+	t.Helper()
+	rec := serve(h, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	sc := bufio.NewScanner(rec.Body)
+	for sc.Scan() {
+		if value, ok := strings.CutPrefix(sc.Text(), series+" "); ok {
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return v
+		}
+	}
+	return 0
+}
+
+func TestReflectionMetrics(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr})
+	h := b.Handler()
+	count := `bridge_reflection_duration_seconds_count{backend="` + be.addr + `"}`
+	errors := `bridge_reflection_errors_total{backend="` + be.addr + `"}`
+
+	before := metric(t, h, count)
+	if rec := post(h, "/test.v1.Echo/Echo", `{}`); rec.Code != 200 {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+	if got := metric(t, h, count); got <= before {
+		t.Fatalf("reflection lookups counted %v, want more than %v", got, before)
+	}
+	if got := metric(t, h, errors); got != 0 {
+		t.Fatalf("reflection errors %v, want 0", got)
+	}
+	if got := metric(t, h, `bridge_backend_requests_total{backend="`+be.addr+`"}`); got != 1 {
+		t.Fatalf("backend requests %v, want the one call without lookups", got)
+	}
+
+	// A backend without reflection fails every lookup
+	srv := grpc.NewServer()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	h = newTestBridge(t, Config{GRPCAddr: lis.Addr().String()}).Handler()
+	post(h, "/test.v1.Echo/Echo", `{}`)
+	if got := metric(t, h, `bridge_reflection_errors_total{backend="`+lis.Addr().String()+`"}`); got < 1 {
+		t.Fatalf("reflection errors %v, want the failed lookup counted", got)
+	}
+}
+
+func TestBackendMetricsPerTarget(t *testing.T) {
+	echo, greeter := startBackend(t), bridgetest.Start(t)
+	b := newTestBridge(t, Config{
+		GRPCAddr: echo.addr,
+		Services: map[string]ServiceConfig{bridgetest.Service: {Primary: greeter.Addr}},
+	})
+	h := b.Handler()
+
+	post(h, "/test.v1.Echo/Echo", `{}`)
+	post(h, "/test.v1.Echo/ServerStream", `{"count": 2}`)
+	post(h, "/bridgetest.v1.Greeter/SayHello", `{"name": "Ada"}`)
+	post(h, "/bridgetest.v1.Greeter/Fail", `{"code": 5}`)
+	post(h, "/bridgetest.v1.Greeter/Fail", `{"code": 5}`)
+
+	for _, tt := range []struct {
+		addr             string
+		requests, errors float64
+	}{
+		{echo.addr, 2, 0},
+		{greeter.Addr, 3, 2},
+	} {
+		label := `{backend="` + tt.addr + `"}`
+		if got := metric(t, h, "bridge_backend_requests_total"+label); got != tt.requests {
+			t.Errorf("%s: requests = %v, want %v", tt.addr, got, tt.requests)
+		}
+		if got := metric(t, h, "bridge_backend_errors_total"+label); got != tt.errors {
+			t.Errorf("%s: errors = %v, want %v", tt.addr, got, tt.errors)
+		}
+		if got := metric(t, h, `bridge_backend_state{backend="`+tt.addr+`",state="READY"}`); got != 1 {
+			t.Errorf("%s: READY state = %v, want 1", tt.addr, got)
+		}
+	}
+}
+
+func TestReflectionLatencyExemplarCarriesTraceID(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, MetricsExemplars: true})
+	h := b.Handler()
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	if rec := serve(h, req); rec.Code != 200 {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+
+	exemplars := func(accept string) []string {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept", accept)
+		var found []string
+		sc := bufio.NewScanner(serve(h, req).Body)
+		for sc.Scan() {
+			if line := sc.Text(); strings.HasPrefix(line, "bridge_reflection_duration_seconds_bucket{") && strings.Contains(line, " # ") {
+				found = append(found, line)
+			}
+		}
+		return found
+	}
+	found := exemplars("application/openmetrics-text; version=1.0.0")
+	if len(found) == 0 {
+		t.Fatal("no reflection latency bucket has an exemplar")
+	}
+	for _, line := range found {
+		if !strings.Contains(line, `# {trace_id="`+traceID+`"}`) {
+			t.Fatalf("exemplar %q doesn't carry trace ID %s", line, traceID)
+		}
+	}
+	if found := exemplars("text/plain"); len(found) != 0 {
+		t.Fatalf("Prometheus text format has exemplars: %q", found)
+	}
+}
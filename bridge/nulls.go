@@ -0,0 +1,139 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// nullMode controls how explicit JSON nulls in request bodies are handled.
+type nullMode string
+
+const (
+	// nullClear keeps protojson semantics: null resets the field to its
+	// default value.
+	nullClear nullMode = "clear"
+	// nullIgnore drops null members before parsing, leaving the field
+	// untouched.
+	nullIgnore nullMode = "ignore"
+	// nullError rejects any request that sets a field to null.
+	nullError nullMode = "error"
+)
+
+func parseNullMode(s string) (nullMode, error) {
+	switch m := nullMode(s); m {
+	case "":
+		return nullClear, nil
+	case nullClear, nullIgnore, nullError:
+		return m, nil
+	default:
+		return "", fmt.Errorf("invalid null mode %q (want clear, ignore or error)", s)
+	}
+}
+
+// applyNullMode rewrites or validates body according to mode before it is
+// handed to protojson. Nulls inside google.protobuf.Value, Struct and
+// ListValue are data, not field clears, and are never touched.
+func applyNullMode(mode nullMode, body []byte, desc protoreflect.MessageDescriptor) ([]byte, error) {
+	if mode == nullClear || !bytes.Contains(body, []byte("null")) {
+		return body, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		// Leave syntax errors for protojson to report
+		return body, nil
+	}
+
+	if err := walkNulls(mode, v, desc, ""); err != nil {
+		return nil, err
+	}
+	if mode == nullError {
+		return body, nil
+	}
+	return json.Marshal(v)
+}
+
+func walkNulls(mode nullMode, v interface{}, desc protoreflect.MessageDescriptor, path string) error {
+	obj, ok := v.(map[string]interface{})
+	if !ok || isJSONValueType(desc) {
+		return nil
+	}
+
+	for key, val := range obj {
+		fd := findJSONField(desc, key)
+		if fd == nil {
+			continue
+		}
+		fieldPath := key
+		if path != "" {
+			fieldPath = path + "." + key
+		}
+
+		if val == nil {
+			if fd.Message() != nil && isJSONValueType(fd.Message()) && !fd.IsList() && !fd.IsMap() {
+				continue
+			}
+			switch mode {
+			case nullError:
+				return badRequest([]*fieldViolation{{Field: fieldPath, Description: "must not be null"}},
+					"field %q must not be null", fieldPath)
+			case nullIgnore:
+				delete(obj, key)
+			}
+			continue
+		}
+
+		switch {
+		case fd.IsMap():
+			if vd := fd.MapValue(); vd.Message() != nil {
+				if m, ok := val.(map[string]interface{}); ok {
+					for k, item := range m {
+						if err := walkNulls(mode, item, vd.Message(), fieldPath+"."+k); err != nil {
+							return err
+						}
+					}
+				}
+			}
+		case fd.IsList():
+			if fd.Message() != nil {
+				if items, ok := val.([]interface{}); ok {
+					for i, item := range items {
+						if err := walkNulls(mode, item, fd.Message(), fmt.Sprintf("%s[%d]", fieldPath, i)); err != nil {
+							return err
+						}
+					}
+				}
+			}
+		case fd.Message() != nil:
+			if err := walkNulls(mode, val, fd.Message(), fieldPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// findJSONField resolves a JSON object key to a field, accepting both the
+// JSON name and the original proto name like protojson does.
+func findJSONField(desc protoreflect.MessageDescriptor, key string) protoreflect.FieldDescriptor {
+	fields := desc.Fields()
+	if fd := fields.ByJSONName(key); fd != nil {
+		return fd
+	}
+	return fields.ByName(protoreflect.Name(key))
+}
+
+// isJSONValueType reports whether desc is a well-known type whose JSON form
+// is arbitrary JSON rather than an object of fields.
+func isJSONValueType(desc protoreflect.MessageDescriptor) bool {
+	switch desc.FullName() {
+	case "google.protobuf.Value", "google.protobuf.Struct", "google.protobuf.ListValue":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package bridge
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestApplyNullMode(t *testing.T) {
+	body := `{"message":null,"count":2,"data":{"keep":null}}`
+	tests := []struct {
+		mode    nullMode
+		want    string
+		wantErr bool
+	}{
+		{nullClear, body, false},
+		{nullIgnore, `{"count":2,"data":{"keep":null}}`, false},
+		{nullError, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			got, err := applyNullMode(tt.mode, []byte(body), echoRequest)
+			if tt.wantErr {
+				if status.Code(err) != codes.InvalidArgument {
+					t.Fatalf("got %v, want InvalidArgument", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// Nulls inside a Struct are data, even in error mode
+	if _, err := applyNullMode(nullError, []byte(`{"data":{"keep":null}}`), echoRequest); err != nil {
+		t.Fatalf("null inside a Struct: %v", err)
+	}
+}
+
+func TestNullFieldsErrorNamesTheField(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, NullFields: "error"})
+	rec := post(b.Handler(), "/test.v1.Echo/Echo", `{"message": null}`)
+	if rec.Code != 400 || !strings.Contains(rec.Body.String(), `"field":"message"`) {
+		t.Fatalf("got %d %s, want 400 naming message", rec.Code, rec.Body)
+	}
+	if be.Calls() != 0 {
+		t.Fatal("rejected request reached the backend")
+	}
+}
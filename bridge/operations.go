@@ -0,0 +1,185 @@
+package bridge
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// operation is a unary call accepted with Prefer: respond-async, whose
+// result is polled from GET /operations/{id}.
+type operation struct {
+	id      string
+	done    bool
+	status  int
+	body    []byte
+	expires time.Time
+}
+
+// operationStore keeps up to max operations. Finished operations are kept
+// for ttl, which also bounds how long a call may run.
+type operationStore struct {
+	max int
+	ttl time.Duration
+
+	mu  sync.Mutex
+	ops map[string]*operation
+	// order holds operation IDs oldest first, for eviction
+	order []string
+}
+
+func newOperationStore(max int, ttl time.Duration) *operationStore {
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	return &operationStore{max: max, ttl: ttl, ops: make(map[string]*operation)}
+}
+
+// add registers a new running operation. When the store is full, the
+// oldest finished operation is dropped to make room; if every operation is
+// still running, add fails with ResourceExhausted.
+func (s *operationStore) add() (*operation, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate operation ID: %v", err)
+	}
+	op := &operation{id: hex.EncodeToString(b[:]), expires: time.Now().Add(s.ttl)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire(time.Now())
+	if len(s.ops) >= s.max && !s.evictDone() {
+		return nil, status.Errorf(codes.ResourceExhausted, "too many async operations in flight (%d)", s.max)
+	}
+	s.ops[op.id] = op
+	s.order = append(s.order, op.id)
+	return op, nil
+}
+
+// expire drops finished operations past their expiry.
+func (s *operationStore) expire(now time.Time) {
+	kept := s.order[:0]
+	for _, id := range s.order {
+		if op := s.ops[id]; op.done && now.After(op.expires) {
+			delete(s.ops, id)
+			continue
+		}
+		kept = append(kept, id)
+	}
+	s.order = kept
+}
+
+func (s *operationStore) evictDone() bool {
+	for i, id := range s.order {
+		if s.ops[id].done {
+			delete(s.ops, id)
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (s *operationStore) finish(op *operation, code int, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	op.done, op.status, op.body = true, code, body
+	op.expires = time.Now().Add(s.ttl)
+}
+
+// get returns a snapshot of the operation id.
+func (s *operationStore) get(id string) (operation, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire(time.Now())
+	op, ok := s.ops[id]
+	if !ok {
+		return operation{}, false
+	}
+	return *op, true
+}
+
+// prefersAsync reports whether r asks to be answered before the call
+// completes, with Prefer: respond-async (RFC 7240).
+func prefersAsync(r *http.Request) bool {
+	for _, v := range r.Header.Values("Prefer") {
+		for _, pref := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(pref), "respond-async") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// startOperation answers 202 Accepted and makes the call in the background,
+// storing its result for GET /operations/{id}. The call outlives the
+// request, but is bounded by the method's call timeout and the operation
+// TTL.
+func (b *Bridge) startOperation(w http.ResponseWriter, r *http.Request, be *backend, md protoreflect.MethodDescriptor, reqBody []byte, enc jsonEncoding) {
+	op, err := b.operations.add()
+	if err != nil {
+		b.writeError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), b.operations.ttl)
+	r = r.Clone(ctx)
+	go func() {
+		defer cancel()
+		callCtx, cancelCall := b.withCallTimeout(ctx, md)
+		defer cancelCall()
+
+		var timing callTiming
+		resp, md, err := b.callUnary(callCtx, r, be, md, reqBody, enc, &timing)
+		if err == nil {
+			resp, err = b.transformResponse(methodPath(md), resp, nil)
+		}
+		code := http.StatusOK
+		if err != nil {
+			code, resp = b.errorResponse(err)
+			code = responseErrorStatus(err, code)
+			log.Printf("✗ Operation %s failed: %v", op.id, err)
+		} else {
+			log.Printf("✓ Operation %s done", op.id)
+		}
+		b.operations.finish(op, code, resp)
+	}()
+
+	w.Header().Set("Location", "/operations/"+op.id)
+	b.encodeJSON(w, http.StatusAccepted, map[string]interface{}{"id": op.id, "done": false})
+}
+
+// handleOperation serves GET /operations/{id}: {"id", "done": false} while
+// the call runs, then its response under "response" together with its
+// HTTP status, or the error body under "error".
+func (b *Bridge) handleOperation(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	op, ok := b.operations.get(id)
+	if !ok {
+		b.writeError(w, status.Errorf(codes.NotFound, "operation %q not found", id))
+		return
+	}
+
+	resp := map[string]interface{}{"id": op.id, "done": op.done}
+	if op.done {
+		resp["status"] = op.status
+		if op.status == http.StatusOK {
+			resp["response"] = json.RawMessage(op.body)
+		} else {
+			resp["error"] = json.RawMessage(op.body)
+		}
+	}
+	b.encodeJSON(w, http.StatusOK, resp)
+}
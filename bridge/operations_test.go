@@ -0,0 +1,70 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestAsyncOperationIsPolledForResult(t *testing.T) {
+	be := startBackend(t)
+	release := make(chan struct{})
+	be.onUnary(func(ctx context.Context, req *dynamicpb.Message) (proto.Message, error) {
+		<-release
+		return req, nil
+	})
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, AsyncOperations: 4})
+	h := b.Handler()
+
+	req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", strings.NewReader(`{"message": "later"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Prefer", "respond-async")
+	rec := serve(h, req)
+	location := rec.Header().Get("Location")
+	if rec.Code != 202 || !strings.HasPrefix(location, "/operations/") {
+		t.Fatalf("got %d with Location %q, want 202 with an operation", rec.Code, location)
+	}
+
+	type result struct {
+		Done     bool
+		Status   int
+		Response struct{ Message string }
+	}
+	poll := func() result {
+		t.Helper()
+		rec := serve(h, httptest.NewRequest(http.MethodGet, location, nil))
+		var res result
+		if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil || rec.Code != 200 {
+			t.Fatalf("GET %s: got %d %s", location, rec.Code, rec.Body)
+		}
+		return res
+	}
+	if res := poll(); res.Done {
+		t.Fatalf("operation done before the call returned: %+v", res)
+	}
+
+	close(release)
+	deadline := time.Now().Add(5 * time.Second)
+	res := poll()
+	for !res.Done {
+		if time.Now().After(deadline) {
+			t.Fatal("operation never finished")
+		}
+		time.Sleep(10 * time.Millisecond)
+		res = poll()
+	}
+	if res.Status != 200 || res.Response.Message != "later" {
+		t.Fatalf("got %+v, want status 200 with the echoed message", res)
+	}
+
+	if rec := serve(h, httptest.NewRequest(http.MethodGet, "/operations/nope", nil)); rec.Code != 404 {
+		t.Fatalf("unknown operation: got %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,168 @@
+package bridge
+
+import (
+	"crypto/tls"
+	"io"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+)
+
+// Option customizes how a Bridge connects to and calls its backends.
+type Option func(*options)
+
+type options struct {
+	tls                *tls.Config
+	keepalive          *keepalive.ClientParameters
+	serviceConfig      string
+	connectTimeout     time.Duration
+	idleTimeout        *time.Duration
+	forwardBearer      bool
+	unaryInterceptors  []grpc.UnaryClientInterceptor
+	streamInterceptors []grpc.StreamClientInterceptor
+	extra              []grpc.DialOption
+	bridgeInterceptors []UnaryInterceptor
+	streamObservers    []StreamObserver
+	encoders           map[string]func(io.Writer, int) io.Writer
+	userAgent          string
+	sources            []DescriptorSource
+	tracing            bool
+}
+
+// defaultUserAgent is the user agent backends see calls from without
+// WithUserAgent.
+const defaultUserAgent = "grpc-http-bridge/{version}"
+
+// WithTLS connects to backends over TLS using cfg. Without it, connections
+// are plaintext.
+func WithTLS(cfg *tls.Config) Option {
+	return func(o *options) {
+		o.tls = cfg
+	}
+}
+
+// WithKeepalive sends keepalive pings to backends according to params.
+func WithKeepalive(params keepalive.ClientParameters) Option {
+	return func(o *options) {
+		o.keepalive = &params
+	}
+}
+
+// WithConnectTimeout bounds each attempt to establish a backend
+// connection, including the TLS handshake, independently of per-call
+// deadlines. It also bounds the initial connection attempt in NewBridge,
+// which otherwise waits 5 seconds.
+func WithConnectTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.connectTimeout = d
+	}
+}
+
+// WithIdleTimeout puts backend connections into the IDLE state, closing
+// their transports, after d without calls or streams. They are
+// re-established on the next call. Zero disables idleness; without this
+// option gRPC's default of 30 minutes applies.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.idleTimeout = &d
+	}
+}
+
+// WithServiceConfig sets the default gRPC service config for backend
+// connections, such as method retry policies. serviceConfig is the JSON
+// form described at https://github.com/grpc/grpc/blob/master/doc/service_config.md.
+// A service config published by the backend's name resolver takes
+// precedence.
+func WithServiceConfig(serviceConfig string) Option {
+	return func(o *options) {
+		o.serviceConfig = serviceConfig
+	}
+}
+
+// WithUserAgent sets the user agent the bridge identifies itself to
+// backends with, in which {version} stands for Version. gRPC appends its
+// own, as in "grpc-http-bridge/v1.2.0 grpc-go/1.60.1". Defaults to
+// "grpc-http-bridge/{version}".
+func WithUserAgent(userAgent string) Option {
+	return func(o *options) {
+		o.userAgent = userAgent
+	}
+}
+
+// WithInterceptor adds a unary client interceptor to backend connections.
+// Interceptors run in the order they are added.
+func WithInterceptor(interceptor grpc.UnaryClientInterceptor) Option {
+	return func(o *options) {
+		o.unaryInterceptors = append(o.unaryInterceptors, interceptor)
+	}
+}
+
+// WithStreamInterceptor adds a stream client interceptor to backend
+// connections. Interceptors run in the order they are added.
+func WithStreamInterceptor(interceptor grpc.StreamClientInterceptor) Option {
+	return func(o *options) {
+		o.streamInterceptors = append(o.streamInterceptors, interceptor)
+	}
+}
+
+// WithDialOptions passes additional options to every backend dial. They
+// are applied after the options derived from the other Option functions,
+// so they take precedence.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.extra = append(o.extra, opts...)
+	}
+}
+
+// dialOptions returns the grpc.DialOptions described by o.
+func (o *options) dialOptions() []grpc.DialOption {
+	creds := insecure.NewCredentials()
+	if o.tls != nil {
+		creds = credentials.NewTLS(o.tls)
+	}
+	userAgent := o.userAgent
+	if userAgent == "" {
+		userAgent = defaultUserAgent
+	}
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithUserAgent(strings.ReplaceAll(userAgent, "{version}", Version)),
+	}
+
+	if o.keepalive != nil {
+		opts = append(opts, grpc.WithKeepaliveParams(*o.keepalive))
+	}
+	if o.connectTimeout > 0 {
+		// gRPC gives each attempt the larger of MinConnectTimeout and the
+		// current backoff, so the base delay mustn't exceed the timeout
+		bo := backoff.DefaultConfig
+		if bo.BaseDelay > o.connectTimeout {
+			bo.BaseDelay = o.connectTimeout
+		}
+		opts = append(opts, grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff:           bo,
+			MinConnectTimeout: o.connectTimeout,
+		}))
+	}
+	if o.forwardBearer {
+		opts = append(opts, grpc.WithPerRPCCredentials(bearerCredentials{}))
+	}
+	if o.idleTimeout != nil {
+		opts = append(opts, grpc.WithIdleTimeout(*o.idleTimeout))
+	}
+	if o.serviceConfig != "" {
+		opts = append(opts, grpc.WithDefaultServiceConfig(o.serviceConfig))
+	}
+	if len(o.unaryInterceptors) > 0 {
+		opts = append(opts, grpc.WithChainUnaryInterceptor(o.unaryInterceptors...))
+	}
+	if len(o.streamInterceptors) > 0 {
+		opts = append(opts, grpc.WithChainStreamInterceptor(o.streamInterceptors...))
+	}
+	return append(opts, o.extra...)
+}
@@ -0,0 +1,179 @@
+package bridge
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestOptionsBuildDialOptions(t *testing.T) {
+	base := len((&options{}).dialOptions())
+	if base != 2 {
+		t.Fatalf("default dial options: %d, want credentials and user agent", base)
+	}
+
+	extra := grpc.WithAuthority("backend.internal")
+	noop := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	var o options
+	for _, opt := range []Option{
+		WithKeepalive(keepalive.ClientParameters{Time: time.Minute}),
+		WithConnectTimeout(time.Second),
+		WithIdleTimeout(time.Minute),
+		WithServiceConfig(`{"loadBalancingConfig": [{"round_robin": {}}]}`),
+		WithInterceptor(noop),
+		WithDialOptions(extra),
+	} {
+		opt(&o)
+	}
+	opts := o.dialOptions()
+	if len(opts) != base+6 {
+		t.Fatalf("got %d dial options, want %d", len(opts), base+6)
+	}
+	if opts[len(opts)-1] != extra {
+		t.Fatal("WithDialOptions options aren't applied last")
+	}
+}
+
+func TestBridgeDialsWithOptions(t *testing.T) {
+	be := startBackend(t)
+	var mu sync.Mutex
+	var methods []string
+	b := newTestBridge(t, Config{GRPCAddr: be.addr}, WithInterceptor(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		mu.Lock()
+		methods = append(methods, method)
+		mu.Unlock()
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}))
+	if rec := post(b.Handler(), "/test.v1.Echo/Echo", `{}`); rec.Code != 200 {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(methods) != 1 || methods[0] != "/test.v1.Echo/Echo" {
+		t.Fatalf("interceptor saw %v, want the Echo call", methods)
+	}
+}
+
+func TestServiceConfigRetriesAtTheGRPCLayer(t *testing.T) {
+	be := startBackend(t)
+	var failed atomic.Bool
+	be.onUnary(func(ctx context.Context, req *dynamicpb.Message) (proto.Message, error) {
+		if !failed.Swap(true) {
+			return nil, status.Error(codes.Unavailable, "try again")
+		}
+		return req, nil
+	})
+	b := newTestBridge(t, Config{GRPCAddr: be.addr}, WithServiceConfig(`{
+		"methodConfig": [{
+			"name": [{"service": "test.v1.Echo"}],
+			"retryPolicy": {
+				"maxAttempts": 3,
+				"initialBackoff": "0.01s",
+				"maxBackoff": "0.1s",
+				"backoffMultiplier": 2,
+				"retryableStatusCodes": ["UNAVAILABLE"]
+			}
+		}]
+	}`))
+	if rec := post(b.Handler(), "/test.v1.Echo/Echo", `{}`); rec.Code != 200 {
+		t.Fatalf("got %d %s, want the retry policy to retry past the failure", rec.Code, rec.Body)
+	}
+	if n := be.Calls(); n != 2 {
+		t.Fatalf("backend saw %d calls, want 2", n)
+	}
+}
+
+func TestConnectTimeoutBoundsSlowHandshake(t *testing.T) {
+	// The backend accepts connections but never answers the TLS handshake
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	start := time.Now()
+	_, err = NewBridge(Config{GRPCAddr: lis.Addr().String()}, WithTLS(&tls.Config{InsecureSkipVerify: true}), WithConnectTimeout(200*time.Millisecond))
+	if elapsed := time.Since(start); err == nil || elapsed > time.Second {
+		t.Fatalf("got %v after %v, want a failure within the 200ms handshake deadline", err, elapsed)
+	}
+	if !strings.Contains(err.Error(), "handshake") {
+		t.Fatalf("error %q doesn't say the handshake failed", err)
+	}
+}
+
+func TestIdleTimeoutIdlesBackendConnection(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr}, WithIdleTimeout(100*time.Millisecond))
+	h := b.Handler()
+	if rec := post(h, "/test.v1.Echo/Echo", `{}`); rec.Code != 200 {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn := b.backend.conn
+	for state := conn.GetState(); state != connectivity.Idle; state = conn.GetState() {
+		if !conn.WaitForStateChange(ctx, state) {
+			t.Fatalf("connection still %v, want IDLE after the idle timeout", state)
+		}
+	}
+	if rec := post(h, "/test.v1.Echo/Echo", `{}`); rec.Code != 200 {
+		t.Fatalf("after idling: got %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestUserAgentReachesBackend(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"default", nil, "grpc-http-bridge/" + Version + " grpc-go/"},
+		{"configured", []Option{WithUserAgent("billing-edge/{version}")}, "billing-edge/" + Version + " grpc-go/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			be := startBackend(t)
+			echoMetadata(be, "user-agent")
+			b := newTestBridge(t, Config{GRPCAddr: be.addr}, tt.opts...)
+			rec := post(b.Handler(), "/test.v1.Echo/Echo", `{}`)
+			if rec.Code != 200 {
+				t.Fatalf("got %d %s", rec.Code, rec.Body)
+			}
+			var resp struct {
+				Message string `json:"message"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(resp.Message, tt.want) {
+				t.Fatalf("backend saw user agent %q, want %q...", resp.Message, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package bridge
+
+import (
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// overridableMethods are the verbs X-HTTP-Method-Override may select.
+var overridableMethods = map[string]bool{
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+// methodOverride lets clients behind proxies that only pass GET and POST
+// reach methods served under other verbs, by sending a POST with an
+// X-HTTP-Method-Override header. It runs before routing.
+func methodOverride(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if override := strings.ToUpper(r.Header.Get("X-HTTP-Method-Override")); overridableMethods[override] {
+				r.Method = override
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// httpMethod returns the verb service/method is served under, set with
+// http_method in the config file.
+func (b *Bridge) httpMethod(service, method string) string {
+	if verb, ok := b.httpMethods[service+"/"+method]; ok {
+		return verb
+	}
+	return http.MethodPost
+}
+
+// checkRequiredHeaders returns InvalidArgument if r lacks any header that
+// required_headers in the config file lists for service/method.
+func (b *Bridge) checkRequiredHeaders(r *http.Request, service, method string) error {
+	for _, h := range b.requiredHeaders[service+"/"+method] {
+		if r.Header.Get(h) == "" {
+			return status.Errorf(codes.InvalidArgument, "missing required header %s", h)
+		}
+	}
+	return nil
+}
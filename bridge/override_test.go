@@ -0,0 +1,55 @@
+package bridge
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMethodOverrideRoutesToDeleteMethod(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{
+		GRPCAddr: be.addr,
+		Methods: map[string]MethodConfig{
+			"test.v1.Echo/Echo": {HTTPMethod: http.MethodDelete},
+		},
+	})
+	h := b.Handler()
+
+	if rec := post(h, "/test.v1.Echo/Echo", `{}`); rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("plain POST: got %d, want 405", rec.Code)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", strings.NewReader(`{"message": "hi"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-HTTP-Method-Override", "delete")
+	if rec := serve(h, req); rec.Code != 200 || be.Calls() != 1 {
+		t.Fatalf("overridden POST: got %d %s with %d backend calls, want 200 and one call", rec.Code, rec.Body, be.Calls())
+	}
+}
+
+func TestRequiredHeaders(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{
+		GRPCAddr: be.addr,
+		Methods: map[string]MethodConfig{
+			"test.v1.Echo/Echo": {RequiredHeaders: []string{"X-Tenant"}},
+		},
+	})
+	h := b.Handler()
+
+	rec := post(h, "/test.v1.Echo/Echo", `{}`)
+	if rec.Code != 400 || !strings.Contains(rec.Body.String(), "X-Tenant") || be.Calls() != 0 {
+		t.Fatalf("without the header: got %d %s with %d backend calls, want 400 naming X-Tenant", rec.Code, rec.Body, be.Calls())
+	}
+	req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Tenant", "acme")
+	if rec := serve(h, req); rec.Code != 200 {
+		t.Fatalf("with the header: got %d %s", rec.Code, rec.Body)
+	}
+	// Other methods don't need it
+	if rec := post(h, "/test.v1.Echo/OldEcho", `{}`); rec.Code != 200 {
+		t.Fatalf("other method: got %d %s", rec.Code, rec.Body)
+	}
+}
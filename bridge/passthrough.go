@@ -0,0 +1,85 @@
+package bridge
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// rawCodec sends and receives messages that are already encoded protobuf,
+// without decoding them.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	return *v.(*[]byte), nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	*v.(*[]byte) = data
+	return nil
+}
+
+func (rawCodec) Name() string { return "proto" }
+
+// isProtobuf reports whether contentType is a protobuf media type.
+func isProtobuf(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mt == "application/x-protobuf" || mt == "application/protobuf")
+}
+
+// handlePassthrough serves a unary call whose request is protobuf and whose
+// response is wanted as protobuf. The bodies are forwarded to and from the
+// backend as they are, never decoded, so settings that work on the JSON
+// form of a call, namely header fields, request schemas, transforms,
+// caching, interceptors and recording, don't apply. Errors are still
+// rendered as JSON.
+func (b *Bridge) handlePassthrough(w http.ResponseWriter, r *http.Request, be *backend, md protoreflect.MethodDescriptor) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	var resp []byte
+	var timing callTiming
+	ctx, cancel := b.withCallTimeout(r.Context(), md)
+	defer cancel()
+	fullMethod := methodPath(md)
+	err = b.guard(md, func() error {
+		return b.retry(ctx, r, func() error {
+			start := time.Now()
+			err := be.conn.Invoke(ctx, fullMethod, &body, &resp,
+				grpc.ForceCodec(rawCodec{}), timing.peerOption(), timing.headerOption(), timing.trailerOption())
+			timing.addBackend(start)
+			if err != nil {
+				be.observe(err)
+			}
+			return b.connRefused(be, err)
+		})
+	})
+
+	timing.setHeaders(w.Header(), b.debugHeaders)
+	if requestFlag(r, "include-metadata") {
+		setMetadataHeaders(w.Header(), timing.header)
+	}
+	if err != nil {
+		code, body := b.errorResponse(err)
+		b.writeJSON(w, b.exhausted(w.Header(), err, timing.trailer, code), body)
+		log.Printf("✗ RPC failed: %v", err)
+		return
+	}
+	if b.bufferResponses {
+		w.Header().Set("Content-Length", strconv.Itoa(len(resp)))
+	}
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+	log.Printf("✓ Response sent (passthrough)")
+}
@@ -0,0 +1,47 @@
+package bridge
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestProtobufPassthroughSkipsJSON(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr})
+
+	m := dynamicpb.NewMessage(echoRequest)
+	m.Set(echoRequest.Fields().ByName("message"), protoreflect.ValueOfString("hi"))
+	body, err := proto.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A field the descriptor doesn't know survives only if the bytes are
+	// never converted to and from JSON
+	body = protowire.AppendTag(body, 99, protowire.BytesType)
+	body = protowire.AppendString(body, "unknown")
+
+	req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Accept", "application/x-protobuf")
+	rec := serve(b.Handler(), req)
+	if rec.Code != 200 || rec.Header().Get("Content-Type") != "application/x-protobuf" {
+		t.Fatalf("got %d %s %q", rec.Code, rec.Header().Get("Content-Type"), rec.Body)
+	}
+	if !bytes.Equal(rec.Body.Bytes(), body) {
+		t.Fatalf("response %x, want the echoed request %x with its unknown field", rec.Body.Bytes(), body)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Accept", "application/json")
+	if rec := serve(b.Handler(), req); rec.Code != 400 {
+		t.Fatalf("protobuf request wanting JSON: got %d, want 400", rec.Code)
+	}
+}
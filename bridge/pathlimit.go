@@ -0,0 +1,29 @@
+package bridge
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// limitPathLength answers requests whose path, as sent, is longer than max
+// bytes with 414, before routing spends any work on it.
+func limitPathLength(max int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := len(r.URL.EscapedPath())
+			if n <= max {
+				next.ServeHTTP(w, r)
+				return
+			}
+			_, resp := errorFields(status.Errorf(codes.InvalidArgument, "request path is %d bytes, over the limit of %d", n, max))
+			body, _ := json.Marshal(resp)
+			if r.Context().Value(jsonNewlineKey{}) != nil {
+				body = withNewline(body)
+			}
+			writeJSON(w, http.StatusRequestURITooLong, body)
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package bridge
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOverLongPathAnswers414(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, MaxPathLength: 64})
+	h := b.Handler()
+
+	if rec := post(h, "/test.v1.Echo/Echo", `{}`); rec.Code != 200 {
+		t.Fatalf("short path: got %d %s", rec.Code, rec.Body)
+	}
+	rec := post(h, "/test.v1.Echo/"+strings.Repeat("x", 100), `{}`)
+	if rec.Code != 414 || !strings.Contains(rec.Body.String(), "over the limit of 64") {
+		t.Fatalf("long path: got %d %s, want 414", rec.Code, rec.Body)
+	}
+	if be.Calls() != 1 {
+		t.Fatalf("backend answered %d calls, want only the short path's", be.Calls())
+	}
+}
@@ -0,0 +1,130 @@
+package bridge
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type principalKey struct{}
+
+// PrincipalFromContext returns the authenticated principal of the request
+// that ctx belongs to, if the client presented a verified certificate.
+func PrincipalFromContext(ctx context.Context) (string, bool) {
+	p, ok := ctx.Value(principalKey{}).(string)
+	return p, ok
+}
+
+// certPrincipal returns the principal named by a client certificate: its
+// subject common name, or failing that its first URI, DNS or email SAN.
+func certPrincipal(cert *x509.Certificate) string {
+	switch {
+	case cert.Subject.CommonName != "":
+		return cert.Subject.CommonName
+	case len(cert.URIs) > 0:
+		return cert.URIs[0].String()
+	case len(cert.DNSNames) > 0:
+		return cert.DNSNames[0]
+	case len(cert.EmailAddresses) > 0:
+		return cert.EmailAddresses[0]
+	}
+	return ""
+}
+
+// principalMiddleware attaches the client certificate principal to the
+// request context and, if forwardKey is set, to the outgoing gRPC metadata
+// under that key. Any client-supplied value for the key is discarded.
+func principalMiddleware(forwardKey string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if forwardKey != "" {
+				r.Header.Del(forwardKey)
+			}
+			// Only certificates that passed verification name a principal
+			if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.PeerCertificates) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			principal := certPrincipal(r.TLS.PeerCertificates[0])
+			if principal == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), principalKey{}, principal)
+			if forwardKey != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, forwardKey, principal)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// requireClientCert rejects requests from clients without a verified
+// certificate with 401, unless they call one of the public methods.
+func (b *Bridge) requireClientCert(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := PrincipalFromContext(r.Context()); ok || b.isPublic(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		writeError(w, r, status.Error(codes.Unauthenticated, "client certificate required"))
+	})
+}
+
+// isPublic reports whether r calls a public method, resolving its path
+// the way handleRPC does.
+func (b *Bridge) isPublic(r *http.Request) bool {
+	version, path := b.splitVersion(r.URL.Path)
+	service, method, ok := b.parseRPCPath(path)
+	if !ok {
+		return false
+	}
+	if b.foldCase && b.replayer == nil {
+		service, method = b.canonicalMethod(r.Context(), version, service, method)
+	}
+	return b.publicMethods[service+"/"+method]
+}
+
+// serverTLSConfig builds the front-end TLS config. With a client CA file,
+// client certificates are verified against it; clientAuth "optional"
+// accepts clients without one.
+func serverTLSConfig(cfg Config) (*tls.Config, error) {
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.TLSClientCAFile == "" {
+		return tlsCfg, nil
+	}
+
+	pem, err := os.ReadFile(cfg.TLSClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", cfg.TLSClientCAFile)
+	}
+	tlsCfg.ClientCAs = pool
+
+	switch cfg.TLSClientAuth {
+	case "", "require":
+		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+		if len(cfg.PublicMethods) > 0 {
+			// requireClientCert turns away clients without one instead,
+			// except for public methods
+			tlsCfg.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+	case "optional":
+		tlsCfg.ClientAuth = tls.VerifyClientCertIfGiven
+	default:
+		return nil, fmt.Errorf("invalid client auth mode %q (want require or optional)", cfg.TLSClientAuth)
+	}
+	return tlsCfg, nil
+}
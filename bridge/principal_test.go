@@ -0,0 +1,96 @@
+package bridge
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// echoMetadata makes be answer Echo with the values of the metadata key
+// it received, joined with commas, as the message.
+func echoMetadata(be *testBackend, key string) {
+	be.onUnary(func(ctx context.Context, req *dynamicpb.Message) (proto.Message, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		req.Set(echoRequest.Fields().ByName("message"), protoreflect.ValueOfString(strings.Join(md.Get(key), ",")))
+		return req, nil
+	})
+}
+
+// withClientCert makes req look like it came over TLS with a verified
+// client certificate for cn.
+func withClientCert(req *http.Request, cn string) *http.Request {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: cn}}
+	req.TLS = &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{cert},
+		VerifiedChains:   [][]*x509.Certificate{{cert}},
+	}
+	return req
+}
+
+func TestClientCertPrincipalIsForwarded(t *testing.T) {
+	be := startBackend(t)
+	echoMetadata(be, "x-principal")
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, PrincipalMetadata: "X-Principal"})
+
+	req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	// A client can't claim a principal of its own
+	req.Header.Set("X-Principal", "mallory")
+	rec := serve(b.Handler(), withClientCert(req, "alice"))
+	var resp struct{ Message string }
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); rec.Code != 200 || err != nil || resp.Message != "alice" {
+		t.Fatalf("got %d %s, want the backend to see principal alice only", rec.Code, rec.Body)
+	}
+
+	var seen string
+	probe := principalMiddleware("")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = PrincipalFromContext(r.Context())
+	}))
+	probe.ServeHTTP(httptest.NewRecorder(), withClientCert(httptest.NewRequest(http.MethodGet, "/", nil), "bob"))
+	if seen != "bob" {
+		t.Fatalf("PrincipalFromContext = %q, want bob", seen)
+	}
+}
+
+func TestPublicMethodsNeedNoClientCert(t *testing.T) {
+	be := startBackend(t)
+	certFile, keyFile := writeCert(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, TLSCertFile: certFile, TLSKeyFile: keyFile, TLSClientCAFile: certFile, PublicMethods: []string{"test.v1.Echo/OldEcho"}})
+	if b.tlsConfig.ClientAuth != tls.VerifyClientCertIfGiven {
+		t.Fatalf("handshake ClientAuth = %v, want clients without a certificate let through", b.tlsConfig.ClientAuth)
+	}
+	h := b.Handler()
+
+	call := func(method, cn string) int {
+		req := httptest.NewRequest(http.MethodPost, method, strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		if cn != "" {
+			req = withClientCert(req, cn)
+		}
+		return serve(h, req).Code
+	}
+	tests := []struct {
+		method, cn string
+		want       int
+	}{
+		{"/test.v1.Echo/OldEcho", "", 200},
+		{"/test.v1.Echo/Echo", "", 401},
+		{"/test.v1.Echo/Echo", "alice", 200},
+	}
+	for _, tt := range tests {
+		if got := call(tt.method, tt.cn); got != tt.want {
+			t.Errorf("%s with certificate %q: got %d, want %d", tt.method, tt.cn, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package bridge
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// problemDetails renders every error response as an RFC 7807
+// application/problem+json document. Errors are written as JSON in many
+// places, middlewares included, so they are rewritten on their way out
+// rather than at each of them: a response with an error status and a
+// JSON error body is held back and turned into a problem document with
+// the request path as its instance.
+func problemDetails(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pw := &problemWriter{ResponseWriter: w}
+		next.ServeHTTP(pw, r)
+		pw.finish(r.URL.Path)
+	})
+}
+
+// problemWriter passes responses through, except error responses with a
+// JSON body, which it buffers until finish.
+type problemWriter struct {
+	http.ResponseWriter
+	code    int
+	held    *bytes.Buffer
+	written bool
+}
+
+func (pw *problemWriter) WriteHeader(code int) {
+	if pw.written || pw.held != nil {
+		return
+	}
+	if mt, _, _ := mime.ParseMediaType(pw.Header().Get("Content-Type")); code >= http.StatusBadRequest && mt == "application/json" {
+		pw.code, pw.held = code, &bytes.Buffer{}
+		return
+	}
+	pw.written = true
+	pw.ResponseWriter.WriteHeader(code)
+}
+
+func (pw *problemWriter) Write(p []byte) (int, error) {
+	if pw.held != nil {
+		return pw.held.Write(p)
+	}
+	pw.written = true
+	return pw.ResponseWriter.Write(p)
+}
+
+func (pw *problemWriter) Flush() {
+	if pw.held == nil {
+		http.NewResponseController(pw.ResponseWriter).Flush()
+	}
+}
+
+func (pw *problemWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(pw.ResponseWriter).Hijack()
+}
+
+func (pw *problemWriter) Unwrap() http.ResponseWriter {
+	return pw.ResponseWriter
+}
+
+// finish writes the held error response, as a problem document if its
+// body is a bridge error.
+func (pw *problemWriter) finish(instance string) {
+	if pw.held == nil {
+		return
+	}
+	body := pw.held.Bytes()
+	if problem, ok := problemBody(pw.code, body, instance); ok {
+		body = problem
+		pw.Header().Set("Content-Type", "application/problem+json")
+		if pw.Header().Get("Content-Length") != "" {
+			pw.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		}
+	}
+	pw.ResponseWriter.WriteHeader(pw.code)
+	pw.ResponseWriter.Write(body)
+}
+
+// problemBody turns a bridge error body, {"error": ..., "code": ...},
+// into a problem document. "error" becomes "detail", and the other
+// members, like "code" and "details", are kept as extension members. It
+// reports false for other bodies, which are left as they are.
+func problemBody(code int, body []byte, instance string) ([]byte, bool) {
+	var members map[string]json.RawMessage
+	if json.Unmarshal(body, &members) != nil {
+		return nil, false
+	}
+	var detail, grpcCode string
+	if json.Unmarshal(members["error"], &detail) != nil || json.Unmarshal(members["code"], &grpcCode) != nil {
+		return nil, false
+	}
+	delete(members, "error")
+
+	title := http.StatusText(code)
+	if title == "" {
+		title = grpcCode
+	}
+	problem := map[string]interface{}{
+		"type":     "about:blank",
+		"title":    title,
+		"status":   code,
+		"detail":   detail,
+		"instance": instance,
+	}
+	for k, v := range members {
+		problem[k] = v
+	}
+	out, err := json.Marshal(problem)
+	if err != nil {
+		return nil, false
+	}
+	if bytes.HasSuffix(body, []byte("\n")) {
+		out = append(out, '\n')
+	}
+	return out, true
+}
@@ -0,0 +1,53 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestProblemErrorFormat(t *testing.T) {
+	be := startBackend(t)
+	be.onUnary(func(ctx context.Context, req *dynamicpb.Message) (proto.Message, error) {
+		if req.Get(echoRequest.Fields().ByName("message")).String() == "missing" {
+			return nil, status.Error(codes.NotFound, "no such user")
+		}
+		return req, nil
+	})
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, ErrorFormat: "problem"})
+	h := b.Handler()
+
+	rec := post(h, "/test.v1.Echo/Echo", `{"message": "missing"}`)
+	if ct := rec.Header().Get("Content-Type"); rec.Code != 404 || ct != "application/problem+json" {
+		t.Fatalf("got %d %s, want 404 application/problem+json", rec.Code, ct)
+	}
+	var problem map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"type":     "about:blank",
+		"title":    "Not Found",
+		"status":   404.0,
+		"detail":   "no such user",
+		"instance": "/test.v1.Echo/Echo",
+		"code":     "NotFound",
+	}
+	for k, v := range want {
+		if problem[k] != v {
+			t.Errorf("%s = %v, want %v", k, problem[k], v)
+		}
+	}
+	if _, ok := problem["error"]; ok {
+		t.Errorf("problem document kept the bridge's error member: %v", problem)
+	}
+
+	if rec := post(h, "/test.v1.Echo/Echo", `{"message": "hi"}`); rec.Code != 200 || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("success: got %d %s, want 200 application/json", rec.Code, rec.Header().Get("Content-Type"))
+	}
+}
@@ -0,0 +1,107 @@
+package bridge
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// rateLimiter allows each client a fixed number of requests per window.
+// Clients are identified by their certificate principal if they have one
+// and by their IP address otherwise.
+type rateLimiter struct {
+	limit  int
+	window time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	clients map[string]*rateWindow
+	swept   time.Time
+}
+
+// rateWindow counts one client's requests in the window that started at
+// start.
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:   limit,
+		window:  window,
+		now:     time.Now,
+		clients: make(map[string]*rateWindow),
+	}
+}
+
+// take counts a request from client. It reports whether the request is
+// within the limit, how many more the client may make in the current
+// window and when that window ends.
+func (l *rateLimiter) take(client string) (ok bool, remaining int, reset time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.swept) >= l.window {
+		// Forget clients whose windows have ended so the map doesn't grow
+		// with every address ever seen
+		for c, w := range l.clients {
+			if now.Sub(w.start) >= l.window {
+				delete(l.clients, c)
+			}
+		}
+		l.swept = now
+	}
+
+	w := l.clients[client]
+	if w == nil || now.Sub(w.start) >= l.window {
+		w = &rateWindow{start: now}
+		l.clients[client] = w
+	}
+	reset = w.start.Add(l.window)
+	if w.count >= l.limit {
+		return false, 0, reset
+	}
+	w.count++
+	return true, l.limit - w.count, reset
+}
+
+// middleware rejects requests over the limit with 429. Every response
+// carries X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset
+// (a Unix time) so clients can throttle themselves.
+func (l *rateLimiter) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok, remaining, reset := l.take(rateLimitKey(r))
+
+		h := w.Header()
+		h.Set("X-RateLimit-Limit", strconv.Itoa(l.limit))
+		h.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		h.Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		if !ok {
+			wait := math.Ceil(reset.Sub(l.now()).Seconds())
+			h.Set("Retry-After", strconv.Itoa(int(math.Max(wait, 1))))
+			writeError(w, r, status.Errorf(codes.ResourceExhausted, "rate limit of %d requests per %s exceeded", l.limit, l.window))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitKey identifies the client r came from.
+func rateLimitKey(r *http.Request) string {
+	if p, ok := PrincipalFromContext(r.Context()); ok {
+		return "principal:" + p
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
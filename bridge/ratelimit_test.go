@@ -0,0 +1,43 @@
+package bridge
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRateLimitHeaders(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, RateLimit: 2, RateLimitWindow: time.Minute})
+	start := time.Unix(1_700_000_000, 0)
+	now := start
+	b.limiter.now = func() time.Time { return now }
+	h := b.Handler()
+
+	for i, want := range []struct {
+		code       int
+		remaining  string
+		retryAfter string
+	}{
+		{200, "1", ""},
+		{200, "0", ""},
+		{429, "0", "50"},
+	} {
+		rec := post(h, "/test.v1.Echo/Echo", `{}`)
+		got := rec.Header()
+		if rec.Code != want.code || got.Get("X-RateLimit-Limit") != "2" || got.Get("X-RateLimit-Remaining") != want.remaining || got.Get("Retry-After") != want.retryAfter {
+			t.Fatalf("request %d: got %d with limit %q, remaining %q, Retry-After %q; want %d with 2, %s, %q",
+				i+1, rec.Code, got.Get("X-RateLimit-Limit"), got.Get("X-RateLimit-Remaining"), got.Get("Retry-After"), want.code, want.remaining, want.retryAfter)
+		}
+		if reset := got.Get("X-RateLimit-Reset"); reset != strconv.FormatInt(start.Add(time.Minute).Unix(), 10) {
+			t.Fatalf("request %d: X-RateLimit-Reset %s, want the end of the window", i+1, reset)
+		}
+		if i == 0 {
+			// The window started with the first request
+			now = now.Add(10 * time.Second)
+		}
+	}
+	if be.Calls() != 2 {
+		t.Fatalf("backend saw %d calls, want 2", be.Calls())
+	}
+}
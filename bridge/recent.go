@@ -0,0 +1,122 @@
+package bridge
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// maxRecentErrorBody bounds how much of an error response is kept to read
+// its code and message from.
+const maxRecentErrorBody = 4 << 10
+
+// recentRequest is one entry of GET /debug/requests.
+type recentRequest struct {
+	Time     time.Time `json:"time"`
+	Method   string    `json:"method"`
+	Status   int       `json:"status"`
+	Code     string    `json:"code,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Duration Duration  `json:"duration"`
+}
+
+// recentRequests is a ring buffer of the last RPC requests, for
+// troubleshooting without a tracing backend.
+type recentRequests struct {
+	mu      sync.Mutex
+	entries []recentRequest
+	next    int
+	full    bool
+}
+
+func newRecentRequests(n int) *recentRequests {
+	return &recentRequests{entries: make([]recentRequest, n)}
+}
+
+func (rr *recentRequests) add(e recentRequest) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	rr.entries[rr.next] = e
+	rr.next = (rr.next + 1) % len(rr.entries)
+	if rr.next == 0 {
+		rr.full = true
+	}
+}
+
+// list returns the buffered requests, newest first.
+func (rr *recentRequests) list() []recentRequest {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	n := rr.next
+	if rr.full {
+		n = len(rr.entries)
+	}
+	out := make([]recentRequest, 0, n)
+	for i := 1; i <= n; i++ {
+		out = append(out, rr.entries[(rr.next-i+len(rr.entries))%len(rr.entries)])
+	}
+	return out
+}
+
+// record adds every request to the buffer once it has been served. The
+// error code and message are read from error bodies.
+func (rr *recentRequests) record(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		body := &limitedBuffer{limit: maxRecentErrorBody}
+		ww.Tee(body)
+		next.ServeHTTP(ww, r)
+
+		e := recentRequest{
+			Time:     start,
+			Method:   r.URL.Path,
+			Status:   ww.Status(),
+			Duration: Duration(time.Since(start)),
+		}
+		switch {
+		case e.Status == 0 && isWebSocket(r):
+			// Hijacked by the WebSocket handshake
+			e.Status = http.StatusSwitchingProtocols
+		case e.Status == 0:
+			e.Status = http.StatusOK
+		case e.Status >= 400:
+			var errBody struct {
+				Code  string `json:"code"`
+				Error string `json:"error"`
+			}
+			if err := json.Unmarshal(body.data, &errBody); err == nil {
+				e.Code, e.Error = errBody.Code, errBody.Error
+			} else {
+				// Plain text from http.Error
+				e.Error = strings.TrimSpace(string(body.data))
+			}
+		}
+		rr.add(e)
+	})
+}
+
+// limitedBuffer keeps the first limit bytes written to it.
+type limitedBuffer struct {
+	data  []byte
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - len(b.data); room > 0 {
+		if len(p) < room {
+			room = len(p)
+		}
+		b.data = append(b.data, p[:room]...)
+	}
+	return len(p), nil
+}
+
+// handleRecentRequests serves GET /debug/requests.
+func (b *Bridge) handleRecentRequests(w http.ResponseWriter, r *http.Request) {
+	b.encodeJSON(w, http.StatusOK, map[string]interface{}{"requests": b.recent.list()})
+}
@@ -0,0 +1,69 @@
+package bridge
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestRecentRequestsKeepsLastN(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, AdminToken: "secret", RecentRequests: 2})
+	h := b.Handler()
+
+	post(h, "/test.v1.Echo/Echo", `{"message": "evicted"}`)
+	post(h, "/test.v1.Echo/Echo", `{"message": "kept"}`)
+	post(h, "/test.v1.Echo/Nope", `{}`)
+
+	if rec := serve(h, httptest.NewRequest(http.MethodGet, "/debug/requests", nil)); rec.Code != 401 {
+		t.Fatalf("without the admin token: got %d, want 401", rec.Code)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/debug/requests", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := serve(h, req)
+	var resp struct {
+		Requests []recentRequest `json:"requests"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("%d %s: %v", rec.Code, rec.Body, err)
+	}
+
+	type entry struct {
+		Method string
+		Status int
+		Code   string
+	}
+	var got []entry
+	for _, r := range resp.Requests {
+		if r.Time.IsZero() || r.Duration <= 0 {
+			t.Fatalf("entry %+v has no time or duration", r)
+		}
+		got = append(got, entry{r.Method, r.Status, r.Code})
+	}
+	want := []entry{
+		{"/test.v1.Echo/Nope", 404, "NotFound"},
+		{"/test.v1.Echo/Echo", 200, ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	if resp.Requests[0].Error == "" {
+		t.Fatal("the failed request's error wasn't kept")
+	}
+}
+
+func TestRecentRequestsNeedAdminToken(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, RecentRequests: 100})
+	h := b.Handler()
+
+	post(h, "/test.v1.Echo/Echo", `{"message": "hi"}`)
+	if b.recent != nil {
+		t.Fatal("recent requests kept without an admin token to read them")
+	}
+	if rec := serve(h, httptest.NewRequest(http.MethodGet, "/debug/requests", nil)); rec.Code == http.StatusOK {
+		t.Fatalf("GET /debug/requests served without an admin token: %s", rec.Body)
+	}
+}
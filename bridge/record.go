@@ -0,0 +1,139 @@
+package bridge
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// interaction is one recorded request/response pair, stored as a single
+// JSONL line.
+type interaction struct {
+	Method   string          `json:"method"`
+	Request  json.RawMessage `json:"request"`
+	Status   int             `json:"status"`
+	Response json.RawMessage `json:"response"`
+}
+
+// recorder appends interactions to a JSONL file.
+type recorder struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func newRecorder(path string) (*recorder, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open record file: %w", err)
+	}
+	return &recorder{file: f}, nil
+}
+
+func (r *recorder) Record(method string, reqBody []byte, status int, respBody []byte) error {
+	line, err := json.Marshal(interaction{
+		Method:   method,
+		Request:  rawJSON(reqBody),
+		Status:   status,
+		Response: rawJSON(respBody),
+	})
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, err = r.file.Write(append(line, '\n'))
+	return err
+}
+
+func (r *recorder) Close() error {
+	return r.file.Close()
+}
+
+// replayer serves previously recorded interactions, matched by method and
+// request body.
+type replayer struct {
+	interactions map[string]interaction
+}
+
+func loadReplayer(path string) (*replayer, error) {
+	interactions, err := readInteractions(path)
+	if err != nil {
+		return nil, err
+	}
+	rp := &replayer{interactions: make(map[string]interaction)}
+	for _, in := range interactions {
+		// Later recordings of the same call win.
+		rp.interactions[replayKey(in.Method, in.Request)] = in
+	}
+	return rp, nil
+}
+
+// readInteractions reads the recording at path, in the order it was
+// recorded.
+func readInteractions(path string) ([]interaction, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open replay file: %w", err)
+	}
+	defer f.Close()
+
+	var interactions []interaction
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var in interaction
+		if err := json.Unmarshal(line, &in); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		interactions = append(interactions, in)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read replay file: %w", err)
+	}
+	return interactions, nil
+}
+
+func (rp *replayer) Lookup(method string, reqBody []byte) (interaction, bool) {
+	in, ok := rp.interactions[replayKey(method, reqBody)]
+	return in, ok
+}
+
+// replayKey identifies a call by method and a canonical form of its body,
+// so that formatting and key order don't affect matching.
+func replayKey(method string, body []byte) string {
+	return method + " " + string(canonicalJSON(body))
+}
+
+func canonicalJSON(body []byte) []byte {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return bytes.TrimSpace(body)
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return bytes.TrimSpace(body)
+	}
+	return out
+}
+
+// rawJSON returns body as a JSON value, quoting it as a string if it isn't
+// valid JSON.
+func rawJSON(body []byte) json.RawMessage {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return json.RawMessage("{}")
+	}
+	if json.Valid(body) {
+		return json.RawMessage(body)
+	}
+	quoted, _ := json.Marshal(string(body))
+	return quoted
+}
@@ -0,0 +1,39 @@
+package bridge
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mizrahidaniel/grpc-http-bridge/bridgetest"
+)
+
+func TestRecordThenReplayWithoutBackend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calls.jsonl")
+	be := bridgetest.Start(t)
+	recording := newTestBridge(t, Config{GRPCAddr: be.Addr, RecordFile: path})
+	rec := post(recording.Handler(), "/bridgetest.v1.Greeter/SayHello", `{"name": "Ada"}`)
+	if rec.Code != 200 {
+		t.Fatalf("recorded call: %d %s", rec.Code, rec.Body)
+	}
+	recorded := string(canonicalJSON(rec.Body.Bytes()))
+	recording.Close()
+	be.Close()
+
+	replaying := newTestBridge(t, Config{ReplayFile: path})
+	if replaying.backend != nil || len(replaying.backends) != 0 {
+		t.Fatal("replay mode dialed a backend")
+	}
+	// Formatting and key order don't affect matching
+	rec = post(replaying.Handler(), "/bridgetest.v1.Greeter/SayHello", `{"name":"Ada"}`)
+	if got := string(canonicalJSON(rec.Body.Bytes())); rec.Code != 200 || got != recorded {
+		t.Fatalf("replayed %d %q, want 200 %q", rec.Code, rec.Body, recorded)
+	}
+	if n := be.Calls(); n != 1 {
+		t.Fatalf("backend answered %d calls, want 1", n)
+	}
+
+	rec = post(replaying.Handler(), "/bridgetest.v1.Greeter/SayHello", `{"name": "Grace"}`)
+	if rec.Code != 404 {
+		t.Fatalf("unrecorded body: got %d, want 404", rec.Code)
+	}
+}
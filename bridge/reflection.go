@@ -0,0 +1,113 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// symbolDescriptor is the GET /reflection/{symbol} response.
+type symbolDescriptor struct {
+	Symbol string `json:"symbol"`
+	// Kind is service, method, message, field, enum or enum_value
+	Kind string `json:"kind"`
+	File string `json:"file"`
+	// Descriptor is the symbol's descriptor proto, e.g. a DescriptorProto
+	// for a message, in protojson form
+	Descriptor json.RawMessage `json:"descriptor"`
+}
+
+// handleReflection serves GET /reflection/{symbol}, the descriptor of a
+// fully qualified service, method, message, field or enum, fetched with
+// a FileContainingSymbol reflection lookup. Symbols from a descriptor set
+// are answered without asking a backend.
+func (b *Bridge) handleReflection(w http.ResponseWriter, r *http.Request) {
+	symbol := chi.URLParam(r, "symbol")
+	d, err := b.lookupSymbol(r.Context(), protoreflect.FullName(symbol))
+	if err != nil {
+		b.writeError(w, err)
+		return
+	}
+
+	var kind string
+	var desc proto.Message
+	switch d := d.(type) {
+	case protoreflect.ServiceDescriptor:
+		kind, desc = "service", protodesc.ToServiceDescriptorProto(d)
+	case protoreflect.MethodDescriptor:
+		kind, desc = "method", protodesc.ToMethodDescriptorProto(d)
+	case protoreflect.MessageDescriptor:
+		kind, desc = "message", protodesc.ToDescriptorProto(d)
+	case protoreflect.FieldDescriptor:
+		kind, desc = "field", protodesc.ToFieldDescriptorProto(d)
+	case protoreflect.EnumDescriptor:
+		kind, desc = "enum", protodesc.ToEnumDescriptorProto(d)
+	case protoreflect.EnumValueDescriptor:
+		kind, desc = "enum_value", protodesc.ToEnumValueDescriptorProto(d)
+	default:
+		b.writeError(w, status.Errorf(codes.NotFound, "symbol %q is not a service, method, message, field or enum", symbol))
+		return
+	}
+	out, err := protojson.Marshal(desc)
+	if err != nil {
+		b.writeError(w, withCause(status.Errorf(codes.Internal, "failed to encode descriptor: %v", err), err))
+		return
+	}
+	b.encodeJSON(w, http.StatusOK, symbolDescriptor{
+		Symbol:     symbol,
+		Kind:       kind,
+		File:       d.ParentFile().Path(),
+		Descriptor: out,
+	})
+}
+
+// lookupSymbol finds the descriptor named name in the descriptor set, if
+// one is loaded, or else asks each backend in turn. Services that aren't
+// exposed, and their methods, are reported as not found.
+func (b *Bridge) lookupSymbol(ctx context.Context, name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	if !name.IsValid() {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid symbol %q (want a fully qualified name like pkg.v1.Message)", name)
+	}
+	d, err := b.findSymbol(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	service := d
+	if md, ok := d.(protoreflect.MethodDescriptor); ok {
+		service = md.Parent()
+	}
+	if sd, ok := service.(protoreflect.ServiceDescriptor); ok && !b.exposed(string(sd.FullName())) {
+		return nil, status.Errorf(codes.NotFound, "symbol %q not found", name)
+	}
+	return d, nil
+}
+
+func (b *Bridge) findSymbol(ctx context.Context, name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	if files := b.descriptorFiles; files != nil {
+		if d, err := files.FindDescriptorByName(name); err == nil {
+			return d, nil
+		}
+	}
+	for _, be := range b.backendList() {
+		files, err := be.resolver.fileContainingSymbol(ctx, string(name))
+		if status.Code(err) == codes.NotFound {
+			continue
+		}
+		if err != nil {
+			be.observe(err)
+			return nil, err
+		}
+		if d, err := files.FindDescriptorByName(name); err == nil {
+			return d, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "symbol %q not found", name)
+}
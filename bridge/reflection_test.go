@@ -0,0 +1,51 @@
+package bridge
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestReflectionServesMessageFields(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr})
+	h := b.Handler()
+
+	rec := serve(h, httptest.NewRequest(http.MethodGet, "/reflection/test.v1.EchoRequest", nil))
+	if rec.Code != 200 {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		Symbol, Kind, File string
+		Descriptor         struct {
+			Name  string
+			Field []struct {
+				Name   string
+				Number int
+				Type   string
+			}
+		}
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Symbol != "test.v1.EchoRequest" || resp.Kind != "message" || resp.File != "test/v1/echo.proto" || resp.Descriptor.Name != "EchoRequest" {
+		t.Fatalf("got %s %s in %s named %s, want message test.v1.EchoRequest in test/v1/echo.proto", resp.Kind, resp.Symbol, resp.File, resp.Descriptor.Name)
+	}
+	var fields []string
+	for _, f := range resp.Descriptor.Field {
+		fields = append(fields, f.Name)
+	}
+	if want := []string{"message", "count", "tags", "data", "extra"}; !reflect.DeepEqual(fields, want) {
+		t.Fatalf("fields %q, want %q", fields, want)
+	}
+	if f := resp.Descriptor.Field[1]; f.Number != 2 || f.Type != "TYPE_INT32" {
+		t.Fatalf("count is field %d of %s, want 2 of TYPE_INT32", f.Number, f.Type)
+	}
+
+	if rec := serve(h, httptest.NewRequest(http.MethodGet, "/reflection/test.v1.Missing", nil)); rec.Code != 404 {
+		t.Fatalf("unknown symbol: got %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,42 @@
+package bridge
+
+import (
+	"errors"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// defaultRefusedMessage is the error message for calls to a backend that
+// refused the connection, with {addr} standing for its address.
+const defaultRefusedMessage = "backend connection refused at {addr}"
+
+// refusedError reports that a call failed because its backend actively
+// refused the connection, rather than a transient failure. Such calls
+// fail fast: retrying an immediate refusal rarely helps soon enough.
+type refusedError struct {
+	msg   string
+	cause error
+}
+
+func (e *refusedError) Error() string              { return e.msg }
+func (e *refusedError) GRPCStatus() *status.Status { return status.New(codes.Unavailable, e.msg) }
+func (e *refusedError) Unwrap() error              { return e.cause }
+
+// connRefused turns err into a refusedError if be refused the connection
+// the call needed, and returns it unchanged otherwise. gRPC only reports
+// the dial error in the status message, so that is what is matched.
+func (b *Bridge) connRefused(be *backend, err error) error {
+	if status.Code(err) != codes.Unavailable || !strings.Contains(status.Convert(err).Message(), "connection refused") {
+		return err
+	}
+	return &refusedError{msg: strings.ReplaceAll(b.refusedMessage, "{addr}", be.addr), cause: err}
+}
+
+// isRefused reports whether err is a refused connection, which isn't
+// retried.
+func isRefused(err error) bool {
+	var refused *refusedError
+	return errors.As(err, &refused)
+}
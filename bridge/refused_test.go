@@ -0,0 +1,52 @@
+package bridge
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mizrahidaniel/grpc-http-bridge/bridgetest"
+)
+
+func TestRefusedConnectionFailsFastWithMessage(t *testing.T) {
+	be := bridgetest.Start(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.Addr, RetryMax: 3, RetryBackoff: time.Second, RetryJitter: "none"})
+	h := b.Handler()
+	if rec := post(h, "/bridgetest.v1.Greeter/SayHello", `{"name": "Ada"}`); rec.Code != 200 {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+	be.Close()
+	// The call that finds the connection gone isn't refused; wait for the
+	// reconnect that is
+	want := "backend connection refused at " + be.Addr
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		req := httptest.NewRequest(http.MethodPost, "/bridgetest.v1.Greeter/SayHello", strings.NewReader(`{"name": "Ada"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-No-Retry", "true")
+		if strings.Contains(serve(h, req).Body.String(), want) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("backend never refused the connection")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// A retry would wait a second first
+	start := time.Now()
+	rec := post(h, "/bridgetest.v1.Greeter/SayHello", `{"name": "Ada"}`)
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("refused call took %v, want it answered without retrying", elapsed)
+	}
+	var resp struct{ Error string }
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != 503 || resp.Error != want {
+		t.Fatalf("got %d %q, want 503 %q", rec.Code, resp.Error, want)
+	}
+}
@@ -0,0 +1,107 @@
+package bridge
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// RegisterMethod pre-registers the request and response types of a unary
+// method so calls to it skip reflection entirely. fullMethod is
+// "package.Service/Method", with or without a leading slash.
+func (b *Bridge) RegisterMethod(fullMethod string, in, out protoreflect.MessageDescriptor) error {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok || service == "" || method == "" || strings.Contains(method, "/") {
+		return fmt.Errorf("invalid method name %q (want package.Service/Method)", fullMethod)
+	}
+
+	md, err := synthesizeMethod(protoreflect.FullName(service), protoreflect.Name(method), in, out)
+	if err != nil {
+		return fmt.Errorf("failed to register %s: %w", fullMethod, err)
+	}
+
+	b.registeredMu.Lock()
+	defer b.registeredMu.Unlock()
+	if b.registered == nil {
+		b.registered = make(map[string]protoreflect.MethodDescriptor)
+	}
+	b.registered[service+"/"+method] = md
+	return nil
+}
+
+func (b *Bridge) registeredMethod(service, method string) (protoreflect.MethodDescriptor, bool) {
+	b.registeredMu.RLock()
+	defer b.registeredMu.RUnlock()
+	md, ok := b.registered[service+"/"+method]
+	return md, ok
+}
+
+func (b *Bridge) registeredMethods() []protoreflect.MethodDescriptor {
+	b.registeredMu.RLock()
+	defer b.registeredMu.RUnlock()
+	list := make([]protoreflect.MethodDescriptor, 0, len(b.registered))
+	for _, md := range b.registered {
+		list = append(list, md)
+	}
+	return list
+}
+
+// synthesizeMethod builds a method descriptor for service/method from its
+// message types, by declaring the service in a file of its own.
+func synthesizeMethod(service protoreflect.FullName, method protoreflect.Name, in, out protoreflect.MessageDescriptor) (protoreflect.MethodDescriptor, error) {
+	if !service.IsValid() || !method.IsValid() {
+		return nil, fmt.Errorf("invalid service or method name")
+	}
+
+	deps := new(protoregistry.Files)
+	for _, fd := range []protoreflect.FileDescriptor{in.ParentFile(), out.ParentFile()} {
+		if err := registerFile(deps, fd); err != nil {
+			return nil, err
+		}
+	}
+
+	imports := []string{in.ParentFile().Path()}
+	if out.ParentFile().Path() != in.ParentFile().Path() {
+		imports = append(imports, out.ParentFile().Path())
+	}
+
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("bridge/registered/" + string(service) + ".proto"),
+		Package:    proto.String(string(service.Parent())),
+		Syntax:     proto.String("proto3"),
+		Dependency: imports,
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String(string(service.Name())),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:       proto.String(string(method)),
+				InputType:  proto.String("." + string(in.FullName())),
+				OutputType: proto.String("." + string(out.FullName())),
+			}},
+		}},
+	}
+
+	fd, err := protodesc.NewFile(fdp, deps)
+	if err != nil {
+		return nil, err
+	}
+	return fd.Services().Get(0).Methods().Get(0), nil
+}
+
+// registerFile adds fd and its transitive imports to files.
+func registerFile(files *protoregistry.Files, fd protoreflect.FileDescriptor) error {
+	if _, err := files.FindFileByPath(fd.Path()); err == nil {
+		return nil
+	}
+	imports := fd.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		if err := registerFile(files, imports.Get(i).FileDescriptor); err != nil {
+			return err
+		}
+	}
+	return files.RegisterFile(fd)
+}
@@ -0,0 +1,33 @@
+package bridge
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegisteredMethodSkipsReflection(t *testing.T) {
+	counter, lookups := countLookups()
+	be := startBackend(t, counter)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr})
+	if err := b.RegisterMethod("test.v1.Echo/Echo", echoRequest, echoRequest); err != nil {
+		t.Fatal(err)
+	}
+	before := lookups.Load()
+
+	rec := post(b.Handler(), "/test.v1.Echo/Echo", `{"message": "hi"}`)
+	if rec.Code != 200 || !strings.Contains(rec.Body.String(), `"hi"`) {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+	if be.Calls() != 1 {
+		t.Fatalf("backend answered %d calls, want 1", be.Calls())
+	}
+	if n := lookups.Load() - before; n != 0 {
+		t.Fatalf("registered method made %d reflection lookups", n)
+	}
+
+	// Unregistered methods are still resolved by reflection
+	post(b.Handler(), "/test.v1.Echo/ServerStream", `{"count": 1}`)
+	if lookups.Load() == before {
+		t.Fatal("unregistered method made no reflection lookup")
+	}
+}
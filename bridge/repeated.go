@@ -0,0 +1,148 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// dropEmptyRepeated removes empty repeated and map fields from data, the
+// compact protojson rendering of a desc message, recursing into nested
+// messages. Well-known types are left as they are: their JSON forms don't
+// mirror their fields, and an empty ListValue or Struct is a value.
+func dropEmptyRepeated(data []byte, desc protoreflect.MessageDescriptor) ([]byte, error) {
+	if wellKnown(desc) || isJSONNull(data) {
+		return data, nil
+	}
+	members, err := objectMembers(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	n := 0
+	for _, m := range members {
+		value := m.value
+		if fd := desc.Fields().ByJSONName(m.name); fd != nil {
+			if (fd.IsList() || fd.IsMap()) && isEmptyContainer(value) {
+				continue
+			}
+			if value, err = dropEmptyRepeatedIn(fd, value); err != nil {
+				return nil, err
+			}
+		}
+		writeMember(&buf, n, m.name, value)
+		n++
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// dropEmptyRepeatedIn applies dropEmptyRepeated to the messages held by
+// field fd, whose JSON value is data.
+func dropEmptyRepeatedIn(fd protoreflect.FieldDescriptor, data []byte) ([]byte, error) {
+	switch {
+	case fd.IsMap():
+		values := fd.MapValue().Message()
+		if values == nil {
+			return data, nil
+		}
+		entries, err := objectMembers(data)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		buf.WriteByte('{')
+		for i, e := range entries {
+			v, err := dropEmptyRepeated(e.value, values)
+			if err != nil {
+				return nil, err
+			}
+			writeMember(&buf, i, e.name, v)
+		}
+		buf.WriteByte('}')
+		return buf.Bytes(), nil
+
+	case fd.Message() == nil:
+		return data, nil
+
+	case fd.IsList():
+		var elems []json.RawMessage
+		if err := json.Unmarshal(data, &elems); err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		buf.WriteByte('[')
+		for i, e := range elems {
+			v, err := dropEmptyRepeated(e, fd.Message())
+			if err != nil {
+				return nil, err
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(v)
+		}
+		buf.WriteByte(']')
+		return buf.Bytes(), nil
+
+	default:
+		return dropEmptyRepeated(data, fd.Message())
+	}
+}
+
+// writeMember appends the i-th member of an object to buf, keeping the
+// key's characters as protojson wrote them rather than HTML-escaping them.
+func writeMember(buf *bytes.Buffer, i int, name string, value []byte) {
+	if i > 0 {
+		buf.WriteByte(',')
+	}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(name)
+	buf.Truncate(buf.Len() - 1) // Encode's trailing newline
+	buf.WriteByte(':')
+	buf.Write(value)
+}
+
+type jsonMember struct {
+	name  string
+	value json.RawMessage
+}
+
+// objectMembers returns the members of the JSON object data in order.
+func objectMembers(data []byte) ([]jsonMember, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil {
+		return nil, err
+	} else if tok != json.Delim('{') {
+		return nil, fmt.Errorf("expected object, got %v", tok)
+	}
+
+	var members []jsonMember
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		name, _ := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		members = append(members, jsonMember{name: name, value: value})
+	}
+	return members, nil
+}
+
+func isJSONNull(data []byte) bool {
+	return string(bytes.TrimSpace(data)) == "null"
+}
+
+func isEmptyContainer(data []byte) bool {
+	s := string(bytes.TrimSpace(data))
+	return s == "[]" || s == "{}"
+}
@@ -0,0 +1,48 @@
+package bridge
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestDiffRecordingReportsChangedResponses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calls.jsonl")
+	old := startBackend(t)
+	recording := newTestBridge(t, Config{GRPCAddr: old.addr, RecordFile: path})
+	for _, body := range []string{`{"message": "hi", "count": 1}`, `{"message": "bye", "count": 2}`} {
+		if rec := post(recording.Handler(), "/test.v1.Echo/Echo", body); rec.Code != 200 {
+			t.Fatalf("recorded call: %d %s", rec.Code, rec.Body)
+		}
+	}
+	recording.Close()
+
+	// The new version answers the first call differently
+	upgraded := startBackend(t)
+	upgraded.onUnary(func(ctx context.Context, req *dynamicpb.Message) (proto.Message, error) {
+		if req.Get(echoRequest.Fields().ByName("count")).Int() == 1 {
+			req.Set(echoRequest.Fields().ByName("message"), protoreflect.ValueOfString("hello"))
+		}
+		return req, nil
+	})
+	b := newTestBridge(t, Config{GRPCAddr: upgraded.addr})
+	report, err := b.DiffRecording(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Replayed != 2 || len(report.Diffs) != 1 {
+		t.Fatalf("replayed %d with %d diffs, want 2 with 1: %+v", report.Replayed, len(report.Diffs), report.Diffs)
+	}
+	d := report.Diffs[0]
+	if d.Method != "/test.v1.Echo/Echo" || d.OldStatus != 200 || d.NewStatus != 200 {
+		t.Fatalf("diff %s %d → %d, want /test.v1.Echo/Echo 200 → 200", d.Method, d.OldStatus, d.NewStatus)
+	}
+	if want := []string{`.message: "hi" → "hello"`}; !reflect.DeepEqual(d.Changes, want) {
+		t.Fatalf("changes %q, want %q", d.Changes, want)
+	}
+}
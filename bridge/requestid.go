@@ -0,0 +1,107 @@
+package bridge
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// requestIDScheme selects the format of generated request IDs.
+type requestIDScheme string
+
+const (
+	// requestIDSequential is chi's default of host/prefix-counter.
+	requestIDSequential requestIDScheme = "sequential"
+	// requestIDUUID is a random (version 4) UUID.
+	requestIDUUID requestIDScheme = "uuid"
+	// requestIDKSUID is a KSUID: a timestamp and 128 random bits, base62
+	// encoded as 27 characters that sort by creation time.
+	requestIDKSUID requestIDScheme = "ksuid"
+	// requestIDShort is 64 random bits as 11 base62 characters.
+	requestIDShort requestIDScheme = "short"
+)
+
+func parseRequestIDScheme(s string) (requestIDScheme, error) {
+	switch scheme := requestIDScheme(s); scheme {
+	case "":
+		return requestIDSequential, nil
+	case requestIDSequential, requestIDUUID, requestIDKSUID, requestIDShort:
+		return scheme, nil
+	default:
+		return "", fmt.Errorf("invalid request ID scheme %q (want sequential, uuid, ksuid or short)", s)
+	}
+}
+
+// requestID gives every request an ID, kept from its X-Request-Id header
+// if it has one, and returns it in the X-Request-Id response header. The
+// ID is stored where chi's middleware.GetReqID finds it.
+func requestID(scheme requestIDScheme) func(http.Handler) http.Handler {
+	if scheme == requestIDSequential {
+		return func(next http.Handler) http.Handler {
+			return middleware.RequestID(echoRequestID(next))
+		}
+	}
+	return func(next http.Handler) http.Handler {
+		next = echoRequestID(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(middleware.RequestIDHeader)
+			if id == "" {
+				id = newRequestID(scheme)
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), middleware.RequestIDKey, id)))
+		})
+	}
+}
+
+func echoRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := middleware.GetReqID(r.Context()); id != "" {
+			w.Header().Set(middleware.RequestIDHeader, id)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func newRequestID(scheme requestIDScheme) string {
+	switch scheme {
+	case requestIDUUID:
+		var b [16]byte
+		rand.Read(b[:])
+		b[6] = b[6]&0x0f | 0x40 // version 4
+		b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+	case requestIDKSUID:
+		var b [20]byte
+		binary.BigEndian.PutUint32(b[:4], uint32(time.Now().Unix()-ksuidEpoch))
+		rand.Read(b[4:])
+		return base62(b[:], 27)
+	default:
+		var b [8]byte
+		rand.Read(b[:])
+		return base62(b[:], 11)
+	}
+}
+
+// ksuidEpoch is the start of KSUID timestamps, 2014-05-13T16:53:20Z.
+const ksuidEpoch = 1400000000
+
+const base62Digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// base62 encodes b as a big-endian number, padded with leading zeros to
+// width characters.
+func base62(b []byte, width int) string {
+	n := new(big.Int).SetBytes(b)
+	out := make([]byte, width)
+	base, mod := big.NewInt(62), new(big.Int)
+	for i := width - 1; i >= 0; i-- {
+		n.DivMod(n, base, mod)
+		out[i] = base62Digits[mod.Int64()]
+	}
+	return string(out)
+}
@@ -0,0 +1,59 @@
+package bridge
+
+import (
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestIDFormats(t *testing.T) {
+	be := startBackend(t)
+	tests := []struct {
+		format string
+		want   *regexp.Regexp
+	}{
+		{"", regexp.MustCompile(`^[^/]+/[A-Za-z0-9]{10}-\d{6}$`)},
+		{"uuid", regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)},
+		{"ksuid", regexp.MustCompile(`^[0-9A-Za-z]{27}$`)},
+		{"short", regexp.MustCompile(`^[0-9A-Za-z]{11}$`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			h := newTestBridge(t, Config{GRPCAddr: be.addr, RequestIDFormat: tt.format}).Handler()
+			first := post(h, "/test.v1.Echo/Echo", `{}`).Header().Get("X-Request-Id")
+			second := post(h, "/test.v1.Echo/Echo", `{}`).Header().Get("X-Request-Id")
+			if !tt.want.MatchString(first) || !tt.want.MatchString(second) || first == second {
+				t.Fatalf("IDs %q and %q, want distinct IDs matching %s", first, second, tt.want)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", strings.NewReader(`{}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Request-Id", "from-client")
+			if got := serve(h, req).Header().Get("X-Request-Id"); got != "from-client" {
+				t.Fatalf("client's request ID came back as %q", got)
+			}
+		})
+	}
+
+	if _, err := NewBridge(Config{GRPCAddr: be.addr, RequestIDFormat: "snowflake"}); err == nil {
+		t.Fatal("an unknown request ID format was accepted")
+	}
+}
+
+func TestKSUIDStartsWithTimestamp(t *testing.T) {
+	id := newRequestID(requestIDKSUID)
+	n := new(big.Int)
+	for _, c := range []byte(id) {
+		n.Mul(n, big.NewInt(62))
+		n.Add(n, big.NewInt(int64(strings.IndexByte(base62Digits, c))))
+	}
+	// The timestamp is the top 32 of the 160 bits
+	ts := new(big.Int).Rsh(n, 128).Int64() + ksuidEpoch
+	if d := time.Since(time.Unix(ts, 0)); d < 0 || d > time.Minute {
+		t.Fatalf("KSUID %s has timestamp %v, want now", id, time.Unix(ts, 0))
+	}
+}
@@ -0,0 +1,310 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// maxReflectionMsgSize bounds reflection responses, which carry whole
+// schema files and can exceed gRPC's default 4MB receive limit.
+const maxReflectionMsgSize = 64 << 20
+
+// resolver looks up service descriptors on the backend via gRPC server
+// reflection and caches them by service name.
+type resolver struct {
+	client grpc_reflection_v1alpha.ServerReflectionClient
+
+	mu       sync.RWMutex
+	services map[string]protoreflect.ServiceDescriptor
+	fetched  map[string]time.Time // when each cached service was fetched
+	messages map[string]protoreflect.MessageDescriptor
+	folded   map[string]string // lowercased service name to declared name
+
+	// retries, if set, retries reflection requests that fail with
+	// Unavailable, apart from the calls they resolve
+	retries *retryPolicy
+
+	// latency and errors track reflection requests for /metrics, apart
+	// from the calls they resolve
+	latency histogram
+	errors  atomic.Uint64
+}
+
+func newResolver(client grpc_reflection_v1alpha.ServerReflectionClient) *resolver {
+	return &resolver{
+		client:   client,
+		services: make(map[string]protoreflect.ServiceDescriptor),
+		fetched:  make(map[string]time.Time),
+		messages: make(map[string]protoreflect.MessageDescriptor),
+		folded:   make(map[string]string),
+	}
+}
+
+// FindMethod returns the descriptor for service/method. A method missing
+// from a cached service may have been added by a backend redeploy, so the
+// service is fetched again before it is reported as not found.
+func (r *resolver) FindMethod(ctx context.Context, service, method string) (protoreflect.MethodDescriptor, error) {
+	r.mu.RLock()
+	sd, ok := r.services[service]
+	r.mu.RUnlock()
+	if ok {
+		if md := sd.Methods().ByName(protoreflect.Name(method)); md != nil {
+			return md, nil
+		}
+		r.invalidate(service)
+	}
+
+	sd, err := r.FindService(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+
+	md := sd.Methods().ByName(protoreflect.Name(method))
+	if md == nil {
+		return nil, status.Errorf(codes.NotFound, "method %q not found in service %q", method, service)
+	}
+	return md, nil
+}
+
+// FindService returns the descriptor for service, asking the backend for
+// the file that defines it on a cache miss.
+func (r *resolver) FindService(ctx context.Context, service string) (protoreflect.ServiceDescriptor, error) {
+	r.mu.RLock()
+	sd, ok := r.services[service]
+	r.mu.RUnlock()
+	if ok {
+		return sd, nil
+	}
+
+	sd, err := r.findService(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.services[service] = sd
+	r.fetched[service] = time.Now()
+	r.mu.Unlock()
+	return sd, nil
+}
+
+// invalidate drops service from the cache, so it is fetched again on next
+// use.
+func (r *resolver) invalidate(service string) {
+	r.mu.Lock()
+	delete(r.services, service)
+	delete(r.fetched, service)
+	r.mu.Unlock()
+}
+
+// reset drops every cached descriptor, so each is fetched again on next
+// use.
+func (r *resolver) reset() {
+	r.mu.Lock()
+	r.services = make(map[string]protoreflect.ServiceDescriptor)
+	r.fetched = make(map[string]time.Time)
+	r.messages = make(map[string]protoreflect.MessageDescriptor)
+	r.folded = make(map[string]string)
+	r.mu.Unlock()
+}
+
+// cacheAge is how long ago the longest-cached service was fetched, or
+// zero if none is cached.
+func (r *resolver) cacheAge() time.Duration {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var oldest time.Time
+	for _, t := range r.fetched {
+		if oldest.IsZero() || t.Before(oldest) {
+			oldest = t
+		}
+	}
+	if oldest.IsZero() {
+		return 0
+	}
+	return time.Since(oldest)
+}
+
+// ListServices returns the names of all services the backend exposes via
+// reflection.
+func (r *resolver) ListServices(ctx context.Context) ([]string, error) {
+	resp, err := r.request(ctx, &grpc_reflection_v1alpha.ServerReflectionRequest{
+		MessageRequest: &grpc_reflection_v1alpha.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	list := resp.GetListServicesResponse()
+	if list == nil {
+		return nil, status.Errorf(codes.Internal, "unexpected reflection response to list services")
+	}
+	names := make([]string, 0, len(list.GetService()))
+	for _, svc := range list.GetService() {
+		names = append(names, svc.GetName())
+	}
+	return names, nil
+}
+
+func (r *resolver) findService(ctx context.Context, service string) (protoreflect.ServiceDescriptor, error) {
+	files, err := r.fileContainingSymbol(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "service %q not found", service)
+	}
+	sd, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "%q is not a service", service)
+	}
+	return sd, nil
+}
+
+// fileContainingSymbol fetches the file defining symbol, together with its
+// transitive dependencies.
+func (r *resolver) fileContainingSymbol(ctx context.Context, symbol string) (*protoregistry.Files, error) {
+	resp, err := r.request(ctx, &grpc_reflection_v1alpha.ServerReflectionRequest{
+		MessageRequest: &grpc_reflection_v1alpha.ServerReflectionRequest_FileContainingSymbol{
+			FileContainingSymbol: symbol,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if errResp := resp.GetErrorResponse(); errResp != nil {
+		return nil, status.Errorf(codes.Code(errResp.GetErrorCode()), "symbol %q not found: %s", symbol, errResp.GetErrorMessage())
+	}
+
+	fdResp := resp.GetFileDescriptorResponse()
+	if fdResp == nil {
+		return nil, fmt.Errorf("unexpected reflection response for %q", symbol)
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	if set.File, err = parseFileDescriptors(fdResp.GetFileDescriptorProto()); err != nil {
+		return nil, err
+	}
+	if err := r.fetchDependencies(ctx, set); err != nil {
+		return nil, err
+	}
+	addMissingDependencies(set)
+
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build descriptors: %w", err)
+	}
+	return files, nil
+}
+
+// fetchDependencies requests, file by file, any imports of set's files
+// that the backend left out of its response. Servers are free to omit
+// files they consider already sent, or to split large schemas across
+// responses; imports the backend doesn't know are left for the caller.
+func (r *resolver) fetchDependencies(ctx context.Context, set *descriptorpb.FileDescriptorSet) error {
+	have := make(map[string]bool)
+	for _, fd := range set.File {
+		have[fd.GetName()] = true
+	}
+	requested := make(map[string]bool)
+	for i := 0; i < len(set.File); i++ {
+		for _, dep := range set.File[i].Dependency {
+			if have[dep] || requested[dep] {
+				continue
+			}
+			requested[dep] = true
+			resp, err := r.request(ctx, &grpc_reflection_v1alpha.ServerReflectionRequest{
+				MessageRequest: &grpc_reflection_v1alpha.ServerReflectionRequest_FileByFilename{
+					FileByFilename: dep,
+				},
+			})
+			if err != nil {
+				return err
+			}
+			fdps, err := parseFileDescriptors(resp.GetFileDescriptorResponse().GetFileDescriptorProto())
+			if err != nil {
+				return err
+			}
+			for _, fdp := range fdps {
+				if !have[fdp.GetName()] {
+					have[fdp.GetName()] = true
+					set.File = append(set.File, fdp)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func parseFileDescriptors(raw [][]byte) ([]*descriptorpb.FileDescriptorProto, error) {
+	fdps := make([]*descriptorpb.FileDescriptorProto, 0, len(raw))
+	for _, b := range raw {
+		fdp := &descriptorpb.FileDescriptorProto{}
+		if err := proto.Unmarshal(b, fdp); err != nil {
+			return nil, fmt.Errorf("invalid file descriptor: %w", err)
+		}
+		fdps = append(fdps, fdp)
+	}
+	return fdps, nil
+}
+
+// request sends req and returns the response, retrying under the
+// resolver's retry policy, if it has one.
+func (r *resolver) request(ctx context.Context, req *grpc_reflection_v1alpha.ServerReflectionRequest) (resp *grpc_reflection_v1alpha.ServerReflectionResponse, err error) {
+	if r.retries == nil {
+		return r.send(ctx, req)
+	}
+	err = r.retries.do(ctx, func() error {
+		resp, err = r.send(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+// send sends a single request over a dedicated reflection stream and
+// returns the response. Lookups never share a stream: its Send and Recv
+// would have to be serialized, and a response could be taken by the
+// wrong lookup, so concurrent lookups each pay for a stream instead.
+func (r *resolver) send(ctx context.Context, req *grpc_reflection_v1alpha.ServerReflectionRequest) (resp *grpc_reflection_v1alpha.ServerReflectionResponse, err error) {
+	start := time.Now()
+	defer func() {
+		r.latency.observe(ctx, time.Since(start))
+		if err != nil {
+			r.errors.Add(1)
+		}
+	}()
+
+	// The stream isn't read to its end, so cancelling is what releases it
+	// once the response is in, rather than the end of ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := r.client.ServerReflectionInfo(ctx, grpc.MaxCallRecvMsgSize(maxReflectionMsgSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open reflection stream: %w", err)
+	}
+	defer stream.CloseSend()
+
+	if err := stream.Send(req); err != nil {
+		return nil, fmt.Errorf("reflection request failed: %w", err)
+	}
+	resp, err = stream.Recv()
+	if err != nil {
+		return nil, fmt.Errorf("reflection response failed: %w", err)
+	}
+	return resp, nil
+}
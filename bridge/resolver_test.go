@@ -0,0 +1,213 @@
+package bridge
+
+import (
+	"context"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// TestResolverConcurrentLookups checks that lookups running at once each
+// get their own answer; run it with -race to check they share no stream.
+func TestResolverConcurrentLookups(t *testing.T) {
+	be := startBackend(t)
+	conn, err := grpc.Dial(be.addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	r := newResolver(grpc_reflection_v1alpha.NewServerReflectionClient(conn))
+
+	ctx := context.Background()
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			for round := 0; round < 5; round++ {
+				if i%2 == 0 {
+					// Drop the cache so that every round fetches again
+					r.invalidate("test.v1.Echo")
+					sd, err := r.FindService(ctx, "test.v1.Echo")
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					if sd.FullName() != "test.v1.Echo" || sd.Methods().Len() != echoFile.Services().Get(0).Methods().Len() {
+						t.Errorf("FindService returned %s with %d methods", sd.FullName(), sd.Methods().Len())
+					}
+					continue
+				}
+				services, err := r.ListServices(ctx)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if !slices.Contains(services, "test.v1.Echo") {
+					t.Errorf("ListServices returned %v", services)
+				}
+			}
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+}
+
+func TestResolverFetchesDescriptorsOverDefaultMessageSize(t *testing.T) {
+	// A proto2 default value pads the file past gRPC's 4MB receive limit
+	s := proto.String
+	fd := &descriptorpb.FileDescriptorProto{
+		Name:    s("test/v1/large.proto"),
+		Package: s("test.v1"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: s("Padded"),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:         s("padding"),
+				JsonName:     s("padding"),
+				Number:       proto.Int32(1),
+				Type:         descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				Label:        descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				DefaultValue: s(strings.Repeat("x", 5<<20)),
+			}},
+		}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name:   s("Large"),
+			Method: []*descriptorpb.MethodDescriptorProto{{Name: s("Get"), InputType: s(".test.v1.Padded"), OutputType: s(".test.v1.Padded")}},
+		}},
+	}
+	if size := proto.Size(fd); size <= 4<<20 {
+		t.Fatalf("descriptor is %d bytes, want over the 4MB default", size)
+	}
+	file, err := protodesc.NewFile(fd, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := new(protoregistry.Files)
+	if err := files.RegisterFile(file); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := grpc.NewServer()
+	padded := file.Messages().ByName("Padded")
+	srv.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "test.v1.Large",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Get",
+			Handler: func(_ interface{}, _ context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+				m := dynamicpb.NewMessage(padded)
+				return m, dec(m)
+			},
+		}},
+	}, struct{}{})
+	grpc_reflection_v1alpha.RegisterServerReflectionServer(srv, reflection.NewServer(reflection.ServerOptions{Services: srv, DescriptorResolver: files}))
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	b := newTestBridge(t, Config{GRPCAddr: lis.Addr().String()})
+	if rec := post(b.Handler(), "/test.v1.Large/Get", `{}`); rec.Code != 200 {
+		t.Fatalf("got %d %.200s", rec.Code, rec.Body)
+	}
+}
+
+func TestUnimplementedReResolvesCachedMethod(t *testing.T) {
+	counter, lookups := countLookups()
+	be := startBackend(t, counter)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr})
+	h := b.Handler()
+	if rec := post(h, "/test.v1.Echo/Echo", `{}`); rec.Code != 200 {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+	cached := lookups.Load()
+
+	// The backend is redeployed: the first call after it lands on an
+	// instance that no longer serves the cached method
+	var redeployed atomic.Bool
+	be.onUnary(func(ctx context.Context, req *dynamicpb.Message) (proto.Message, error) {
+		if !redeployed.Swap(true) {
+			return nil, status.Error(codes.Unimplemented, "unknown method Echo")
+		}
+		return req, nil
+	})
+	rec := post(h, "/test.v1.Echo/Echo", `{"message": "hi"}`)
+	if rec.Code != 200 || be.Calls() != 3 {
+		t.Fatalf("got %d %s after %d backend calls, want 200 after 3", rec.Code, rec.Body, be.Calls())
+	}
+	if lookups.Load() == cached {
+		t.Fatal("the method wasn't resolved again after Unimplemented")
+	}
+}
+
+func TestNotFoundKeepsCachedMethod(t *testing.T) {
+	counter, lookups := countLookups()
+	be := startBackend(t, counter)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr})
+	h := b.Handler()
+	if rec := post(h, "/test.v1.Echo/Echo", `{}`); rec.Code != 200 {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+	cached := lookups.Load()
+
+	be.onUnary(func(ctx context.Context, req *dynamicpb.Message) (proto.Message, error) {
+		return nil, status.Error(codes.NotFound, "user 123 not found")
+	})
+	rec := post(h, "/test.v1.Echo/Echo", `{"message": "hi"}`)
+	if rec.Code != 404 || be.Calls() != 2 {
+		t.Fatalf("got %d %s after %d backend calls, want 404 after 2", rec.Code, rec.Body, be.Calls())
+	}
+	if lookups.Load() != cached {
+		t.Fatal("the method was resolved again after the backend answered NotFound")
+	}
+}
+
+func TestTransientReflectionFailureIsRetried(t *testing.T) {
+	var failures, lookups atomic.Int64
+	be := startBackend(t, grpc.ChainStreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !strings.HasPrefix(info.FullMethod, "/grpc.reflection.") {
+			return handler(srv, ss)
+		}
+		lookups.Add(1)
+		if failures.Add(-1) >= 0 {
+			return status.Error(codes.Unavailable, "reflection restarting")
+		}
+		return handler(srv, ss)
+	}))
+
+	for _, tt := range []struct {
+		retries int
+		want    int
+	}{{0, 503}, {3, 200}} {
+		b := newTestBridge(t, Config{GRPCAddr: be.addr, ReflectionRetries: tt.retries, ReflectionRetryBackoff: time.Millisecond})
+		before := lookups.Load()
+		failures.Store(2)
+		rec := post(b.Handler(), "/test.v1.Echo/Echo", `{}`)
+		if rec.Code != tt.want {
+			t.Fatalf("%d reflection retries: got %d %s, want %d", tt.retries, rec.Code, rec.Body, tt.want)
+		}
+		if tt.retries > 0 && lookups.Load()-before < 3 {
+			t.Fatalf("resolved after %d reflection requests, want the 2 failures retried", lookups.Load()-before)
+		}
+	}
+}
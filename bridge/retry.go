@@ -0,0 +1,193 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type jitterStrategy int
+
+const (
+	jitterNone jitterStrategy = iota
+	jitterFull
+	jitterEqual
+	jitterDecorrelated
+)
+
+func parseJitter(s string) (jitterStrategy, error) {
+	switch s {
+	case "none":
+		return jitterNone, nil
+	case "", "full":
+		return jitterFull, nil
+	case "equal":
+		return jitterEqual, nil
+	case "decorrelated":
+		return jitterDecorrelated, nil
+	}
+	return 0, fmt.Errorf("invalid retry jitter %q (want none, full, equal or decorrelated)", s)
+}
+
+// retryPolicy retries calls that fail with Unavailable, or whose error
+// message names one of messages, waiting an exponentially growing,
+// jittered delay between attempts.
+type retryPolicy struct {
+	max      int
+	base     time.Duration
+	cap      time.Duration
+	jitter   jitterStrategy
+	messages []string
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func newRetryPolicy(max int, base, cap time.Duration, jitter jitterStrategy) *retryPolicy {
+	if base <= 0 {
+		base = 100 * time.Millisecond
+	}
+	if cap < base {
+		cap = base
+	}
+	return &retryPolicy{
+		max:    max,
+		base:   base,
+		cap:    cap,
+		jitter: jitter,
+		rnd:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// delay returns how long to wait before retry number attempt (from 0),
+// given the previous delay.
+func (p *retryPolicy) delay(attempt int, prev time.Duration) time.Duration {
+	ceiling := p.cap
+	if attempt < 32 {
+		if d := p.base << attempt; d > 0 && d < ceiling {
+			ceiling = d
+		}
+	}
+
+	switch p.jitter {
+	case jitterFull:
+		return p.randBetween(0, ceiling)
+	case jitterEqual:
+		return ceiling/2 + p.randBetween(0, ceiling-ceiling/2)
+	case jitterDecorrelated:
+		// Each delay is drawn from [base, 3*prev), independent of attempt
+		if prev < p.base {
+			prev = p.base
+		}
+		d := p.randBetween(p.base, 3*prev)
+		if d > p.cap {
+			d = p.cap
+		}
+		return d
+	}
+	return ceiling
+}
+
+// randBetween returns a random duration in [lo, hi), or lo if the range
+// is empty.
+func (p *retryPolicy) randBetween(lo, hi time.Duration) time.Duration {
+	if hi <= lo {
+		return lo
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return lo + time.Duration(p.rnd.Int63n(int64(hi-lo)))
+}
+
+// retriable reports whether a call that failed with err is retried: it
+// failed with Unavailable, or with a message that names one of the
+// configured messages, and the backend didn't refuse the connection.
+// Cancelled calls and deadlines the client set are never retried.
+func (p *retryPolicy) retriable(err error) bool {
+	if err == nil || isRefused(err) {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.Unavailable:
+		return true
+	case codes.OK, codes.Canceled, codes.DeadlineExceeded:
+		return false
+	}
+	msg := status.Convert(err).Message()
+	for _, m := range p.messages {
+		if containsWord(msg, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsWord reports whether s contains phrase, ignoring case, as whole
+// words, so that "retry" matches "please retry later" but not "retryable:
+// false" or "no_retry".
+func containsWord(s, phrase string) bool {
+	s, phrase = strings.ToLower(s), strings.ToLower(phrase)
+	for i := 0; ; {
+		j := strings.Index(s[i:], phrase)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(phrase)
+		if !wordRuneAt(s, start-1) && !wordRuneAt(s, end) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+// wordRuneAt reports whether the byte at i, if in s, is part of a word.
+func wordRuneAt(s string, i int) bool {
+	if i < 0 || i >= len(s) {
+		return false
+	}
+	c := rune(s[i])
+	return c == '_' || c == '-' || c >= 0x80 || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+// do runs call, retrying it while it fails retriably. It gives up early if
+// ctx is done.
+func (p *retryPolicy) do(ctx context.Context, call func() error) error {
+	err := call()
+	var prev time.Duration
+	for attempt := 0; attempt < p.max && p.retriable(err); attempt++ {
+		prev = p.delay(attempt, prev)
+		select {
+		case <-time.After(prev):
+		case <-ctx.Done():
+			return err
+		}
+		err = call()
+	}
+	return err
+}
+
+// retry runs call under the bridge's retry policy, if one is configured
+// and r doesn't opt out with X-No-Retry.
+func (b *Bridge) retry(ctx context.Context, r *http.Request, call func() error) error {
+	if b.retries == nil || noRetry(r) {
+		return call()
+	}
+	return b.retries.do(ctx, call)
+}
+
+// noRetry reports whether r carries X-No-Retry: true, which clients send
+// for calls they know aren't safe to repeat even on methods that normally
+// are. It also stops server streams from being resumed.
+func noRetry(r *http.Request) bool {
+	v, err := strconv.ParseBool(r.Header.Get("X-No-Retry"))
+	return err == nil && v || requestFlag(r, "no-retry")
+}
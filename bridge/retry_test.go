@@ -0,0 +1,104 @@
+package bridge
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mizrahidaniel/grpc-http-bridge/bridgetest"
+)
+
+func TestRetryJitterDelays(t *testing.T) {
+	const base, cap = 100 * time.Millisecond, time.Second
+	tests := []struct {
+		jitter string
+		// delay for attempt 2, whose exponential ceiling is 400ms
+		min, max time.Duration
+	}{
+		{"none", 400 * time.Millisecond, 400 * time.Millisecond},
+		{"full", 0, 400 * time.Millisecond},
+		{"", 0, 400 * time.Millisecond},
+		{"equal", 200 * time.Millisecond, 400 * time.Millisecond},
+		// Drawn from [base, 3*prev), whatever the attempt
+		{"decorrelated", base, 600 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.jitter, func(t *testing.T) {
+			jitter, err := parseJitter(tt.jitter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p := newRetryPolicy(3, base, cap, jitter)
+			for i := 0; i < 1000; i++ {
+				if d := p.delay(2, 200*time.Millisecond); d < tt.min || d > tt.max {
+					t.Fatalf("delay = %v, want within [%v, %v]", d, tt.min, tt.max)
+				}
+			}
+		})
+	}
+
+	p := newRetryPolicy(3, base, cap, jitterDecorrelated)
+	for i := 0; i < 1000; i++ {
+		if d := p.delay(0, 10*time.Second); d > cap {
+			t.Fatalf("decorrelated delay = %v, want capped at %v", d, cap)
+		}
+	}
+	if _, err := parseJitter("random"); err == nil {
+		t.Fatal("an unknown strategy was accepted")
+	}
+}
+
+func TestNoRetryHeaderSuppressesRetries(t *testing.T) {
+	be := bridgetest.Start(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.Addr, RetryMax: 2, RetryBackoff: time.Millisecond})
+	h := b.Handler()
+
+	unavailable := `{"code": 14, "message": "down"}`
+	if rec := post(h, "/bridgetest.v1.Greeter/Fail", unavailable); rec.Code != 503 || be.Calls() != 3 {
+		t.Fatalf("got %d after %d calls, want 503 after 3", rec.Code, be.Calls())
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/bridgetest.v1.Greeter/Fail", strings.NewReader(unavailable))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-No-Retry", "true")
+	calls := be.Calls()
+	if rec := serve(h, req); rec.Code != 503 || be.Calls()-calls != 1 {
+		t.Fatalf("X-No-Retry: got %d after %d calls, want 503 after 1", rec.Code, be.Calls()-calls)
+	}
+}
+
+func TestRetryMessagesMakeErrorsRetriable(t *testing.T) {
+	be := bridgetest.Start(t)
+	b := newTestBridge(t, Config{
+		GRPCAddr:      be.Addr,
+		RetryMax:      2,
+		RetryBackoff:  time.Millisecond,
+		RetryJitter:   "none",
+		RetryMessages: []string{"try again"},
+	})
+	h := b.Handler()
+
+	tests := []struct {
+		body  string
+		calls int
+	}{
+		{`{"code": 13, "message": "shard moving, Try Again later"}`, 3},
+		{`{"code": 13, "message": "shard lost"}`, 1},
+		{`{"code": 13, "message": "never_try again_"}`, 1},
+		{`{"code": 4, "message": "try again"}`, 1},
+		{`{"code": 14, "message": "down"}`, 3},
+	}
+	for _, tt := range tests {
+		before := be.Calls()
+		post(h, "/bridgetest.v1.Greeter/Fail", tt.body)
+		if got := be.Calls() - before; got != tt.calls {
+			t.Errorf("%s: %d backend calls, want %d", tt.body, got, tt.calls)
+		}
+	}
+
+	if _, err := NewBridge(Config{GRPCAddr: be.Addr, RetryMessages: []string{"try again"}}); err == nil {
+		t.Fatal("retry messages were accepted without RetryMax")
+	}
+}
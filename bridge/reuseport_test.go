@@ -0,0 +1,24 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package bridge
+
+import "testing"
+
+func TestReusePortSharesListenAddress(t *testing.T) {
+	be := startBackend(t)
+	for _, reuse := range []bool{false, true} {
+		b := newTestBridge(t, Config{GRPCAddr: be.addr, ReusePort: reuse})
+		first, err := b.listen("127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := b.listen(first.Addr().String())
+		if err == nil {
+			second.Close()
+		}
+		first.Close()
+		if shared := err == nil; shared != reuse {
+			t.Fatalf("ReusePort %v: second listener on the port got %v", reuse, err)
+		}
+	}
+}
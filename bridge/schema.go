@@ -0,0 +1,181 @@
+package bridge
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// exampleDepth bounds how deep example requests nest messages, so
+// recursive types terminate.
+const exampleDepth = 3
+
+// methodSchema describes a method and its messages for
+// GET /services/{service}/{method}.
+type methodSchema struct {
+	methodInfo
+	Input  messageSchema `json:"input"`
+	Output messageSchema `json:"output"`
+}
+
+type messageSchema struct {
+	Name   string      `json:"name"`
+	Fields []fieldInfo `json:"fields"`
+}
+
+type fieldInfo struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Repeated bool   `json:"repeated,omitempty"`
+}
+
+// schemaLinks sets a Link header pointing at md's schema and example
+// request, so clients can navigate from a response to its description.
+func schemaLinks(h http.Header, md protoreflect.MethodDescriptor) {
+	schema := "/services" + methodPath(md)
+	h.Add("Link", fmt.Sprintf(`<%s>; rel="describedby", <%s/example>; rel="example"`, schema, schema))
+}
+
+// methodDocs are the schema and example request served for a method,
+// built on first use.
+type methodDocs struct {
+	schema  methodSchema
+	example []byte
+}
+
+// docsFor returns md's schema and example request, building them the
+// first time they are asked for.
+func (b *Bridge) docsFor(md protoreflect.MethodDescriptor) (*methodDocs, error) {
+	if v, ok := b.methodDocs.Load(md); ok {
+		return v.(*methodDocs), nil
+	}
+	msg := dynamicpb.NewMessage(md.Input())
+	fillExample(msg, exampleDepth)
+	example, err := protojson.MarshalOptions{EmitUnpopulated: true, Indent: "  "}.Marshal(msg)
+	if err != nil {
+		return nil, withCause(status.Errorf(codes.Internal, "failed to build example: %v", err), err)
+	}
+	docs := &methodDocs{
+		schema: methodSchema{
+			methodInfo: b.describeMethod(md),
+			Input:      describeMessage(md.Input()),
+			Output:     describeMessage(md.Output()),
+		},
+		example: example,
+	}
+	b.methodDocs.Store(md, docs)
+	return docs, nil
+}
+
+// handleMethodSchema serves GET /services/{service}/{method}.
+func (b *Bridge) handleMethodSchema(w http.ResponseWriter, r *http.Request) {
+	md, ok := b.schemaMethod(w, r)
+	if !ok {
+		return
+	}
+	docs, err := b.docsFor(md)
+	if err != nil {
+		b.writeError(w, err)
+		return
+	}
+	b.encodeJSON(w, http.StatusOK, docs.schema)
+}
+
+// handleMethodExample serves GET /services/{service}/{method}/example, a
+// request body with every field present.
+func (b *Bridge) handleMethodExample(w http.ResponseWriter, r *http.Request) {
+	md, ok := b.schemaMethod(w, r)
+	if !ok {
+		return
+	}
+	docs, err := b.docsFor(md)
+	if err != nil {
+		b.writeError(w, err)
+		return
+	}
+	b.writeJSON(w, http.StatusOK, docs.example)
+}
+
+// schemaMethod resolves the method named in r's path, writing an error if
+// it can't be found.
+func (b *Bridge) schemaMethod(w http.ResponseWriter, r *http.Request) (protoreflect.MethodDescriptor, bool) {
+	service, method := chi.URLParam(r, "service"), chi.URLParam(r, "method")
+	if md, ok := b.registeredMethod(service, method); ok {
+		return md, true
+	}
+	if b.replayer != nil {
+		b.writeError(w, status.Errorf(codes.NotFound, "no schema for /%s/%s in replay mode", service, method))
+		return nil, false
+	}
+	md, err := b.findMethod(r.Context(), b.backendFor(service), service, method)
+	if err != nil {
+		b.writeError(w, err)
+		return nil, false
+	}
+	return md, true
+}
+
+func describeMessage(desc protoreflect.MessageDescriptor) messageSchema {
+	schema := messageSchema{Name: string(desc.FullName()), Fields: []fieldInfo{}}
+	fields := desc.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		info := fieldInfo{Name: fd.JSONName(), Type: fieldType(fd)}
+		if fd.IsMap() {
+			info.Type = fmt.Sprintf("map<%s, %s>", fieldType(fd.MapKey()), fieldType(fd.MapValue()))
+		} else {
+			info.Repeated = fd.IsList()
+		}
+		schema.Fields = append(schema.Fields, info)
+	}
+	return schema
+}
+
+func fieldType(fd protoreflect.FieldDescriptor) string {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return string(fd.Message().FullName())
+	case protoreflect.EnumKind:
+		return string(fd.Enum().FullName())
+	}
+	return fd.Kind().String()
+}
+
+// fillExample populates msg's message fields and gives lists one element,
+// down to depth levels. Scalars are left to EmitUnpopulated, and
+// well-known types are left unset since their JSON forms are special.
+func fillExample(msg protoreflect.Message, depth int) {
+	if depth == 0 {
+		return
+	}
+	fields := msg.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		switch {
+		case fd.IsMap():
+		case fd.IsList():
+			if fd.Message() != nil && wellKnown(fd.Message()) {
+				continue
+			}
+			list := msg.Mutable(fd).List()
+			elem := list.NewElement()
+			if fd.Message() != nil {
+				fillExample(elem.Message(), depth-1)
+			}
+			list.Append(elem)
+		case fd.Message() != nil && !wellKnown(fd.Message()):
+			fillExample(msg.Mutable(fd).Message(), depth-1)
+		}
+	}
+}
+
+func wellKnown(desc protoreflect.MessageDescriptor) bool {
+	return strings.HasPrefix(string(desc.FullName()), "google.protobuf.")
+}
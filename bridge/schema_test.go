@@ -0,0 +1,24 @@
+package bridge
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseLinksToSchema(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr})
+	h := b.Handler()
+
+	rec := post(h, "/test.v1.Echo/Echo", `{}`)
+	want := `</services/test.v1.Echo/Echo>; rel="describedby", </services/test.v1.Echo/Echo/example>; rel="example"`
+	if got := rec.Header().Get("Link"); rec.Code != 200 || got != want {
+		t.Fatalf("got %d with Link %q, want 200 with %q", rec.Code, got, want)
+	}
+	for _, link := range []string{"/services/test.v1.Echo/Echo", "/services/test.v1.Echo/Echo/example"} {
+		if rec := serve(h, httptest.NewRequest(http.MethodGet, link, nil)); rec.Code != 200 {
+			t.Fatalf("GET %s: got %d, want 200", link, rec.Code)
+		}
+	}
+}
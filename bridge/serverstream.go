@@ -0,0 +1,311 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// flushPolicy controls how often streamed messages are flushed to the
+// client. The zero value flushes every message.
+type flushPolicy struct {
+	// messages, if above 1, is how many messages are batched per flush.
+	messages int
+	// interval, if set, flushes pending messages at most this long after
+	// they were written.
+	interval time.Duration
+}
+
+// handleServerStream serves a server-streaming method as newline-delimited
+// JSON, one response message per line. An error after the first message
+// is reported as a final {"error", "code", "details"} line, since the
+// status has already been sent. Methods configured with buffer_stream
+// instead get a single JSON array once the stream has ended, as do
+// HTTP/1.0 clients, which can't read a chunked response.
+func (b *Bridge) handleServerStream(w http.ResponseWriter, r *http.Request, be *backend, md protoreflect.MethodDescriptor) {
+	markStream(r.Context())
+	body, err := readBody(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read body: %v", err), http.StatusBadRequest)
+		return
+	}
+	body, err = b.formBody(r.Header.Get("Content-Type"), md.Input(), body)
+	if err != nil {
+		b.writeError(w, err)
+		return
+	}
+	body, err = b.fillHeaderFields(methodPath(md), md.Input(), r.Header, body)
+	if err != nil {
+		b.writeError(w, err)
+		return
+	}
+	if err := b.checkRequestSchema(methodPath(md), body); err != nil {
+		b.writeError(w, err)
+		return
+	}
+	req, err := b.decodeRequest(body, md.Input())
+	if err != nil {
+		b.writeError(w, err)
+		return
+	}
+	setDeprecatedFieldsWarning(w.Header(), deprecatedFields(req, ""))
+	enc, err := b.jsonEncoding(r)
+	if err != nil {
+		b.writeError(w, err)
+		return
+	}
+	if b.debugHeaders {
+		enc.setHeader(w.Header())
+	}
+	filter, err := parseStreamFilter(r.URL.Query()["where"], md.Output())
+	if err != nil {
+		b.writeError(w, err)
+		return
+	}
+	ctx, cancel := b.withCallTimeout(r.Context(), md)
+	defer cancel()
+
+	rc := http.NewResponseController(w)
+	out := newBatchFlusher(w, rc.Flush, b.flushPolicies[methodPath(md)[1:]])
+	arrayField, buffered := b.bufferedStream(r, methodPath(md)[1:])
+	collected := []json.RawMessage{}
+	started := false
+	emitLine := func(line []byte) error {
+		if buffered {
+			collected = append(collected, line)
+			return nil
+		}
+		if !started {
+			started = true
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.WriteHeader(http.StatusOK)
+		}
+		return out.write(append(line, '\n'))
+	}
+	err = b.guard(md, func() error {
+		var opts []grpc.CallOption
+		for resumes := 0; ; resumes++ {
+			header := func(hdr metadata.MD) {
+				if !started {
+					setMetadataHeaders(w.Header(), hdr)
+				}
+			}
+			err := b.invokeServerStream(ctx, be, md, req, header, func(msg proto.Message) error {
+				if !filter.match(msg) {
+					return nil
+				}
+				// Each message is rendered on a single line
+				line, err := enc.marshal(msg, "", b.types)
+				if err != nil {
+					return err
+				}
+				return clientGone(ctx, emitLine(line))
+			}, opts...)
+			if status.Code(err) != codes.Unavailable || isRefused(err) || resumes >= b.streamResumes || noRetry(r) || ctx.Err() != nil {
+				return err
+			}
+
+			// Re-send the request once the backend is reachable again,
+			// telling the client where the new stream starts
+			log.Printf("↻ Resuming stream %s (%d/%d): %v", methodPath(md), resumes+1, b.streamResumes, err)
+			opts = []grpc.CallOption{grpc.WaitForReady(true)}
+			if buffered {
+				// The new stream starts over
+				collected = collected[:0]
+			} else if started {
+				marker, _ := json.Marshal(map[string]interface{}{"resumed": resumes + 1, "error": status.Convert(err).Message(), "request_id": middleware.GetReqID(r.Context())})
+				if err := emitLine(marker); err != nil {
+					return err
+				}
+			}
+		}
+	})
+	out.close()
+
+	if err != nil {
+		log.Printf("✗ RPC failed: %v", err)
+		if !started {
+			b.writeError(w, err)
+			return
+		}
+		w.Write(append(b.streamError(r.Context(), err), '\n'))
+		rc.Flush()
+		return
+	}
+	if buffered && len(collected) == 0 || !buffered && !started {
+		switch b.emptyStreamFor(methodPath(md)[1:]) {
+		case emptyNoContent:
+			w.WriteHeader(http.StatusNoContent)
+			log.Printf("✓ Stream sent (empty)")
+			return
+		case emptyArray:
+			buffered = true
+		case emptyNDJSON:
+			// HTTP/1.0 clients can't read NDJSON, even an empty stream
+			buffered = !r.ProtoAtLeast(1, 1)
+		}
+	}
+	if buffered {
+		var resp interface{} = collected
+		if arrayField != "" {
+			resp = map[string]interface{}{arrayField: collected}
+		}
+		body, err := json.Marshal(resp)
+		if err != nil {
+			b.writeError(w, withCause(status.Errorf(codes.Internal, "failed to encode stream: %v", err), err))
+			return
+		}
+		b.writeJSON(w, http.StatusOK, body)
+	} else {
+		if !started {
+			// An empty stream is still a valid, empty NDJSON body
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.WriteHeader(http.StatusOK)
+		}
+		if b.streamEnds {
+			end, _ := json.Marshal(controlFrame{Control: controlEnd, RequestID: middleware.GetReqID(r.Context())})
+			w.Write(append(end, '\n'))
+		}
+	}
+	log.Printf("✓ Stream sent")
+}
+
+// emptyStream is how a server stream without messages is answered.
+type emptyStream string
+
+const (
+	// emptyDefault answers in the stream's own format.
+	emptyDefault   emptyStream = ""
+	emptyArray     emptyStream = "array"
+	emptyNDJSON    emptyStream = "ndjson"
+	emptyNoContent emptyStream = "no-content"
+)
+
+func parseEmptyStream(s string) (emptyStream, error) {
+	switch e := emptyStream(s); e {
+	case emptyDefault, emptyArray, emptyNDJSON, emptyNoContent:
+		return e, nil
+	default:
+		return "", fmt.Errorf("invalid empty stream response %q (want array, ndjson or no-content)", s)
+	}
+}
+
+// emptyStreamFor returns how an empty stream of method, as
+// "{service}/{method}", is answered.
+func (b *Bridge) emptyStreamFor(method string) emptyStream {
+	if e, ok := b.emptyStreams[method]; ok {
+		return e
+	}
+	return b.emptyStream
+}
+
+// invokeServerStream sends req, passes the backend's initial metadata to
+// header and then each response message to emit until the backend ends
+// the stream.
+func (b *Bridge) invokeServerStream(ctx context.Context, be *backend, md protoreflect.MethodDescriptor, req proto.Message, header func(metadata.MD), emit func(proto.Message) error, opts ...grpc.CallOption) error {
+	stream, err := b.newStream(ctx, be, &grpc.StreamDesc{ServerStreams: true}, md, opts...)
+	if err != nil {
+		be.observe(err)
+		return err
+	}
+	if err := stream.SendMsg(req); err != nil && err != io.EOF {
+		return err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+	// Header waits for the backend's headers, so they are set before the
+	// first message is written
+	if h, err := stream.Header(); err == nil {
+		header(h)
+	}
+
+	for {
+		resp := dynamicpb.NewMessage(md.Output())
+		if err := stream.RecvMsg(resp); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			be.observe(err)
+			return err
+		}
+		if err := emit(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// batchFlusher writes to w and flushes according to a flushPolicy.
+type batchFlusher struct {
+	w      io.Writer
+	flush  func() error
+	policy flushPolicy
+
+	mu      sync.Mutex
+	pending int
+	timer   *time.Timer
+}
+
+func newBatchFlusher(w io.Writer, flush func() error, policy flushPolicy) *batchFlusher {
+	return &batchFlusher{w: w, flush: flush, policy: policy}
+}
+
+func (f *batchFlusher) write(p []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, err := f.w.Write(p); err != nil {
+		return err
+	}
+	f.pending++
+
+	switch {
+	case f.policy.messages > 1 && f.pending >= f.policy.messages:
+		return f.flushLocked()
+	case f.policy.interval > 0:
+		if f.timer == nil {
+			f.timer = time.AfterFunc(f.policy.interval, func() {
+				f.mu.Lock()
+				defer f.mu.Unlock()
+				f.flushLocked()
+			})
+		}
+		return nil
+	case f.policy.messages <= 1:
+		return f.flushLocked()
+	}
+	return nil
+}
+
+// close flushes anything still pending and stops the interval timer.
+func (f *batchFlusher) close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.flushLocked()
+}
+
+func (f *batchFlusher) flushLocked() error {
+	if f.timer != nil {
+		f.timer.Stop()
+		f.timer = nil
+	}
+	if f.pending == 0 {
+		return nil
+	}
+	f.pending = 0
+	return f.flush()
+}
@@ -0,0 +1,296 @@
+package bridge
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ndjsonLines splits an NDJSON body into its decoded lines.
+func ndjsonLines(t *testing.T, body string) []map[string]interface{} {
+	t.Helper()
+	var lines []map[string]interface{}
+	sc := bufio.NewScanner(strings.NewReader(body))
+	for sc.Scan() {
+		var line map[string]interface{}
+		if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+			t.Fatalf("line %q: %v", sc.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestServerStreamErrorFrameCarriesDetails(t *testing.T) {
+	st, err := status.New(codes.InvalidArgument, "bad page token").WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "page_token", Description: "expired"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The line a stream that fails after its first message ends with
+	_, frame := (&Bridge{}).errorResponse(st.Err())
+	lines := ndjsonLines(t, string(frame)+"\n")
+	if len(lines) != 1 || lines[0]["code"] != "InvalidArgument" || lines[0]["error"] != "bad page token" {
+		t.Fatalf("error frame %s", frame)
+	}
+	details, _ := lines[0]["details"].([]interface{})
+	if len(details) != 1 {
+		t.Fatalf("error frame details %v, want one BadRequest", lines[0]["details"])
+	}
+	violations, _ := details[0].(map[string]interface{})["field_violations"].([]interface{})
+	if len(violations) != 1 || violations[0].(map[string]interface{})["field"] != "page_token" {
+		t.Fatalf("field_violations %v, want page_token", violations)
+	}
+}
+
+func TestServerStreamBuffersForHTTP10(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, EmptyStream: "ndjson"})
+	http10 := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/ServerStream", strings.NewReader(body))
+		req.Proto, req.ProtoMajor, req.ProtoMinor = "HTTP/1.0", 1, 0
+		req.Header.Set("Content-Type", "application/json")
+		return serve(b.Handler(), req)
+	}
+
+	rec := http10(`{"count": 2}`)
+	var messages []struct{ Count int }
+	if err := json.Unmarshal(rec.Body.Bytes(), &messages); err != nil {
+		t.Fatalf("got %d %q, want a JSON array: %v", rec.Code, rec.Body, err)
+	}
+	if rec.Code != 200 || !strings.HasPrefix(rec.Header().Get("Content-Type"), "application/json") || len(messages) != 2 || messages[1].Count != 2 {
+		t.Fatalf("got %d %s %s, want both messages in one array", rec.Code, rec.Header().Get("Content-Type"), rec.Body)
+	}
+
+	// An empty stream configured to answer as NDJSON still gets the array
+	rec = http10(`{"count": 0}`)
+	if rec.Code != 200 || strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Fatalf("empty stream: got %d %q, want []", rec.Code, rec.Body)
+	}
+}
+
+func TestBatchFlusherFlushesEveryNMessages(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{
+		GRPCAddr: be.addr,
+		Methods: map[string]MethodConfig{
+			"test.v1.Echo/ServerStream": {FlushMessages: 5},
+		},
+	})
+
+	var buf bytes.Buffer
+	var flushedAt []int
+	out := newBatchFlusher(&buf, func() error {
+		flushedAt = append(flushedAt, strings.Count(buf.String(), "\n"))
+		return nil
+	}, b.flushPolicies["test.v1.Echo/ServerStream"])
+	for i := 0; i < 12; i++ {
+		if err := out.write([]byte("{}\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out.close()
+	if want := []int{5, 10, 12}; !reflect.DeepEqual(flushedAt, want) {
+		t.Fatalf("flushed after messages %v, want %v", flushedAt, want)
+	}
+}
+
+func TestServerStreamResumesAfterDisconnect(t *testing.T) {
+	// The first stream drops with Unavailable after its messages
+	var dropped atomic.Bool
+	be := startBackend(t, grpc.StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		err := handler(srv, ss)
+		if info.FullMethod == "/test.v1.Echo/ServerStream" && err == nil && !dropped.Swap(true) {
+			return status.Error(codes.Unavailable, "connection reset")
+		}
+		return err
+	}))
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, StreamResumes: 1})
+
+	rec := post(b.Handler(), "/test.v1.Echo/ServerStream", `{"count": 2}`)
+	lines := ndjsonLines(t, rec.Body.String())
+	if rec.Code != 200 || len(lines) != 5 {
+		t.Fatalf("got %d with %d lines, want 200 with two messages, a marker and two more:\n%s", rec.Code, len(lines), rec.Body)
+	}
+	if lines[2]["resumed"] != 1.0 || lines[2]["error"] != "connection reset" {
+		t.Fatalf("marker %v, want the first resume", lines[2])
+	}
+	for i, want := range []float64{1, 2, 0, 1, 2} {
+		if i != 2 && lines[i]["count"] != want {
+			t.Fatalf("line %d is %v, want count %v", i+1, lines[i], want)
+		}
+	}
+	if n := be.Calls(); n != 2 {
+		t.Fatalf("backend saw %d streams, want 2", n)
+	}
+}
+
+func TestServerStreamForwardsInitialMetadata(t *testing.T) {
+	be := startBackend(t, grpc.StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := ss.SendHeader(metadata.Pairs("x-shard", "7", "trace-bin", "\x01\x02")); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}))
+	b := newTestBridge(t, Config{GRPCAddr: be.addr})
+
+	rec := post(b.Handler(), "/test.v1.Echo/ServerStream", `{"count": 2}`)
+	if rec.Code != 200 || len(ndjsonLines(t, rec.Body.String())) != 2 {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+	// Result has the headers as they were when the first message was written
+	h := rec.Result().Header
+	if got := h.Get("Grpc-Metadata-X-Shard"); got != "7" {
+		t.Fatalf("Grpc-Metadata-X-Shard = %q, want 7", got)
+	}
+	if got := h.Get("Grpc-Metadata-Trace-Bin"); got != "AQI=" {
+		t.Fatalf("Grpc-Metadata-Trace-Bin = %q, want AQI=", got)
+	}
+}
+
+func TestBufferedStreamWrappedUnderField(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{
+		GRPCAddr: be.addr,
+		Methods: map[string]MethodConfig{
+			"test.v1.Echo/ServerStream": {BufferStream: true, StreamField: "results"},
+		},
+	})
+
+	rec := post(b.Handler(), "/test.v1.Echo/ServerStream", `{"message": "hi", "count": 2}`)
+	if ct := rec.Header().Get("Content-Type"); rec.Code != 200 || ct != "application/json" {
+		t.Fatalf("got %d %s %s, want a JSON body", rec.Code, ct, rec.Body)
+	}
+	var resp map[string][]struct {
+		Message string
+		Count   int
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("%s: %v", rec.Body, err)
+	}
+	results, ok := resp["results"]
+	if !ok || len(resp) != 1 || len(results) != 2 || results[0].Count != 1 || results[1].Count != 2 {
+		t.Fatalf("got %s, want both messages under results", rec.Body)
+	}
+
+	_, err := NewBridge(Config{GRPCAddr: be.addr, Methods: map[string]MethodConfig{
+		"test.v1.Echo/ServerStream": {StreamField: "results"},
+	}})
+	if err == nil {
+		t.Fatal("stream_field was accepted without buffer_stream")
+	}
+}
+
+func TestServerStreamFramesCarryRequestID(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, StreamEndFrames: true})
+
+	rec := post(b.Handler(), "/test.v1.Echo/ServerStream", `{"count": 2}`)
+	id := rec.Header().Get("X-Request-Id")
+	lines := ndjsonLines(t, rec.Body.String())
+	if rec.Code != 200 || len(lines) != 3 || id == "" {
+		t.Fatalf("got %d with %d lines and request ID %q, want 200 with two messages and an end frame:\n%s", rec.Code, len(lines), id, rec.Body)
+	}
+	if end := lines[2]; end["_control"] != "end" || end["request_id"] != id {
+		t.Fatalf("end frame %v, want request_id %q", end, id)
+	}
+
+	be.failStreams(status.Error(codes.Internal, "boom"))
+	rec = post(b.Handler(), "/test.v1.Echo/ServerStream", `{"count": 1}`)
+	id = rec.Header().Get("X-Request-Id")
+	lines = ndjsonLines(t, rec.Body.String())
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want a message and an error frame:\n%s", len(lines), rec.Body)
+	}
+	if frame := lines[1]; frame["error"] != "boom" || frame["request_id"] != id {
+		t.Fatalf("error frame %v, want request_id %q", frame, id)
+	}
+}
+
+func TestEmptyStreamAnsweredAsConfigured(t *testing.T) {
+	tests := []struct {
+		name     string
+		global   string
+		method   string
+		wantCode int
+		wantCT   string
+		wantBody string
+	}{
+		{"default", "", "", 200, "application/x-ndjson", ""},
+		{"array", "array", "", 200, "application/json", "[]"},
+		{"ndjson", "ndjson", "", 200, "application/x-ndjson", ""},
+		{"no content", "no-content", "", 204, "", ""},
+		{"method override", "no-content", "array", 200, "application/json", "[]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			be := startBackend(t)
+			b := newTestBridge(t, Config{
+				GRPCAddr:    be.addr,
+				EmptyStream: tt.global,
+				Methods: map[string]MethodConfig{
+					"test.v1.Echo/ServerStream": {EmptyStream: tt.method},
+				},
+			})
+			rec := post(b.Handler(), "/test.v1.Echo/ServerStream", `{"message": "hi", "count": 0}`)
+			ct := rec.Header().Get("Content-Type")
+			if rec.Code != tt.wantCode || !strings.HasPrefix(ct, tt.wantCT) || strings.TrimSpace(rec.Body.String()) != tt.wantBody {
+				t.Fatalf("got %d %q %q, want %d %q %q", rec.Code, ct, rec.Body, tt.wantCode, tt.wantCT, tt.wantBody)
+			}
+		})
+	}
+
+	if _, err := NewBridge(Config{GRPCAddr: "localhost:1", EmptyStream: "null"}); err == nil {
+		t.Fatal("an invalid empty stream response was accepted")
+	}
+}
+
+func TestStreamingSettingOverridesDeclaredShape(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{
+		GRPCAddr:        be.addr,
+		StreamEndFrames: true,
+		Methods: map[string]MethodConfig{
+			"test.v1.Echo/ServerStream": {Streaming: "buffered"},
+			"test.v1.Echo/Echo":         {Streaming: "streamed"},
+		},
+	})
+	h := b.Handler()
+
+	rec := post(h, "/test.v1.Echo/ServerStream", `{"message": "hi", "count": 2}`)
+	if ct := rec.Header().Get("Content-Type"); rec.Code != 200 || ct != "application/json" {
+		t.Fatalf("buffered stream: got %d %s %s, want a JSON array", rec.Code, ct, rec.Body)
+	}
+	var messages []struct{ Count int }
+	if err := json.Unmarshal(rec.Body.Bytes(), &messages); err != nil || len(messages) != 2 || messages[1].Count != 2 {
+		t.Fatalf("buffered stream: got %s, want both messages in one array", rec.Body)
+	}
+
+	rec = post(h, "/test.v1.Echo/Echo", `{"message": "hi"}`)
+	if ct := rec.Header().Get("Content-Type"); rec.Code != 200 || ct != "application/x-ndjson" {
+		t.Fatalf("streamed unary: got %d %s %s, want NDJSON", rec.Code, ct, rec.Body)
+	}
+	lines := ndjsonLines(t, rec.Body.String())
+	if len(lines) != 2 || lines[0]["message"] != "hi" || lines[1]["_control"] != "end" {
+		t.Fatalf("streamed unary: got %s, want the message and an end frame", rec.Body)
+	}
+
+	for _, mc := range []MethodConfig{{Streaming: "chunked"}, {Streaming: "streamed", BufferStream: true}} {
+		if _, err := NewBridge(Config{GRPCAddr: be.addr, Methods: map[string]MethodConfig{"test.v1.Echo/ServerStream": mc}}); err == nil {
+			t.Errorf("method config %+v was accepted", mc)
+		}
+	}
+}
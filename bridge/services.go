@@ -0,0 +1,174 @@
+package bridge
+
+import (
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// serviceInfo describes one service in the /services listing.
+type serviceInfo struct {
+	Name       string       `json:"name"`
+	Deprecated bool         `json:"deprecated,omitempty"`
+	Methods    []methodInfo `json:"methods"`
+}
+
+type methodInfo struct {
+	Name            string   `json:"name"`
+	Path            string   `json:"path"`
+	HTTPMethod      string   `json:"http_method"`
+	InputType       string   `json:"input_type"`
+	OutputType      string   `json:"output_type"`
+	ClientStreaming bool     `json:"client_streaming"`
+	ServerStreaming bool     `json:"server_streaming"`
+	Deprecated      bool     `json:"deprecated,omitempty"`
+	Tags            []string `json:"tags,omitempty"`
+}
+
+// handleServices lists the services reachable through the bridge. The
+// optional filter query parameter restricts the listing to service names
+// with the given prefix, or matching it as a glob if it contains any of
+// "*?[".
+func (b *Bridge) handleServices(w http.ResponseWriter, r *http.Request) {
+	match, err := serviceFilter(r.URL.Query().Get("filter"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	services := make(map[string]serviceInfo)
+
+	for _, be := range b.backendList() {
+		names, err := be.resolver.ListServices(ctx)
+		if err != nil {
+			be.observe(err)
+			b.writeError(w, err)
+			return
+		}
+		for _, name := range names {
+			if strings.HasPrefix(name, "grpc.reflection.") || !match(name) || !b.exposed(name) || b.primaryFor(name) != be {
+				continue
+			}
+			sd, err := be.resolver.FindService(ctx, name)
+			if err != nil {
+				b.writeError(w, err)
+				return
+			}
+			services[name] = b.describeService(sd)
+		}
+	}
+
+	// Methods registered with RegisterMethod are listed even when the
+	// backend doesn't expose them via reflection.
+	for _, md := range b.registeredMethods() {
+		name := string(md.Parent().FullName())
+		if !match(name) || !b.exposed(name) {
+			continue
+		}
+		info, ok := services[name]
+		if !ok {
+			info = serviceInfo{Name: name, Deprecated: serviceDeprecated(md.Parent())}
+		}
+		if !hasMethod(info, string(md.Name())) {
+			info.Methods = append(info.Methods, b.describeMethod(md))
+		}
+		services[name] = info
+	}
+
+	if err := b.sourceServices(services, match); err != nil {
+		b.writeError(w, err)
+		return
+	}
+
+	list := make([]serviceInfo, 0, len(services))
+	for _, info := range services {
+		list = append(list, info)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	b.encodeJSON(w, http.StatusOK, map[string]interface{}{
+		"services": list,
+	})
+}
+
+// exposed reports whether service may be served, given the services
+// configured with ExposeServices.
+func (b *Bridge) exposed(service string) bool {
+	if len(b.exposeServices) == 0 {
+		return true
+	}
+	for _, pattern := range b.exposeServices {
+		if ok, _ := path.Match(pattern, service); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// serviceFilter returns a predicate for the filter query parameter.
+func serviceFilter(filter string) (func(string) bool, error) {
+	if filter == "" {
+		return func(string) bool { return true }, nil
+	}
+	if !strings.ContainsAny(filter, "*?[") {
+		return func(name string) bool { return strings.HasPrefix(name, filter) }, nil
+	}
+	if _, err := path.Match(filter, ""); err != nil {
+		return nil, err
+	}
+	return func(name string) bool {
+		ok, _ := path.Match(filter, name)
+		return ok
+	}, nil
+}
+
+// backendList returns every backend, the default one first. It is empty
+// in replay mode.
+func (b *Bridge) backendList() []*backend {
+	if b.backend == nil {
+		return nil
+	}
+	list := []*backend{b.backend}
+	for _, be := range b.backends {
+		if be != b.backend {
+			list = append(list, be)
+		}
+	}
+	return list
+}
+
+func (b *Bridge) describeService(sd protoreflect.ServiceDescriptor) serviceInfo {
+	info := serviceInfo{Name: string(sd.FullName()), Deprecated: serviceDeprecated(sd)}
+	methods := sd.Methods()
+	for i := 0; i < methods.Len(); i++ {
+		info.Methods = append(info.Methods, b.describeMethod(methods.Get(i)))
+	}
+	return info
+}
+
+func (b *Bridge) describeMethod(md protoreflect.MethodDescriptor) methodInfo {
+	return methodInfo{
+		Name:            string(md.Name()),
+		Path:            methodPath(md),
+		HTTPMethod:      b.httpMethod(string(md.Parent().FullName()), string(md.Name())),
+		InputType:       string(md.Input().FullName()),
+		OutputType:      string(md.Output().FullName()),
+		ClientStreaming: md.IsStreamingClient(),
+		ServerStreaming: md.IsStreamingServer(),
+		Deprecated:      isDeprecated(md),
+		Tags:            b.methodTags[strings.TrimPrefix(methodPath(md), "/")],
+	}
+}
+
+func hasMethod(info serviceInfo, name string) bool {
+	for _, m := range info.Methods {
+		if m.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package bridge
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// listServices returns the services GET /services lists for query.
+func listServices(t *testing.T, h http.Handler, query string) ([]serviceInfo, int) {
+	t.Helper()
+	rec := serve(h, httptest.NewRequest(http.MethodGet, "/services"+query, nil))
+	var resp struct{ Services []serviceInfo }
+	if rec.Code == 200 {
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return resp.Services, rec.Code
+}
+
+func serviceNames(services []serviceInfo) []string {
+	names := []string{}
+	for _, s := range services {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func TestServicesFilter(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr})
+	if err := b.RegisterMethod("other.v1.Thing/Do", echoRequest, echoRequest); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		filter string
+		want   []string
+	}{
+		{"", []string{"other.v1.Thing", "test.v1.Echo"}},
+		{"test.", []string{"test.v1.Echo"}},
+		{"*.v1.Thing", []string{"other.v1.Thing"}},
+		{"nothing", []string{}},
+	} {
+		services, code := listServices(t, b.Handler(), "?filter="+tt.filter)
+		if got := serviceNames(services); code != 200 || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("filter %q: got %d %v, want %v", tt.filter, code, got, tt.want)
+		}
+	}
+	if _, code := listServices(t, b.Handler(), "?filter=%5B"); code != 400 {
+		t.Errorf("malformed glob: got %d, want 400", code)
+	}
+}
+
+func TestServicesListsMethodTags(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{
+		GRPCAddr: be.addr,
+		Methods: map[string]MethodConfig{
+			"test.v1.Echo/Echo": {Tags: []string{"public", "greeting"}},
+		},
+	})
+
+	services, _ := listServices(t, b.Handler(), "?filter=test.v1.Echo")
+	if len(services) != 1 {
+		t.Fatalf("listed %v, want test.v1.Echo", serviceNames(services))
+	}
+	for _, m := range services[0].Methods {
+		var want []string
+		if m.Name == "Echo" {
+			want = []string{"public", "greeting"}
+		}
+		if !reflect.DeepEqual(m.Tags, want) {
+			t.Fatalf("%s tags = %v, want %v", m.Name, m.Tags, want)
+		}
+	}
+}
+
+func TestExposeServicesHidesOthers(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, ExposeServices: []string{"other.v1.*"}})
+	if err := b.RegisterMethod("other.v1.Thing/Do", echoRequest, echoRequest); err != nil {
+		t.Fatal(err)
+	}
+	h := b.Handler()
+
+	if rec := post(h, "/test.v1.Echo/Echo", `{}`); rec.Code != 404 || be.Calls() != 0 {
+		t.Fatalf("unexposed service: got %d with %d backend calls, want 404 without any", rec.Code, be.Calls())
+	}
+	services, code := listServices(t, h, "")
+	if got, want := serviceNames(services), []string{"other.v1.Thing"}; code != 200 || !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %d %v, want %v", code, got, want)
+	}
+}
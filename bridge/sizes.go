@@ -0,0 +1,99 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// payloadSampling selects the requests whose payload sizes are logged.
+// The zero value logs every request.
+type payloadSampling struct {
+	// threshold, if set, is the request or response size in bytes from
+	// which a request is always logged. Smaller ones are logged at rate.
+	threshold int64
+	rate      float64
+	// slow, if set, is the duration from which a request is always
+	// logged, whatever its size.
+	slow time.Duration
+}
+
+// logged reports whether a request with the given sizes and duration is
+// logged, biased towards the rare expensive requests so they aren't
+// drowned out by the common cheap ones.
+func (s payloadSampling) logged(in, out int64, elapsed time.Duration) bool {
+	if s.threshold <= 0 || in >= s.threshold || out >= s.threshold {
+		return true
+	}
+	if s.slow > 0 && elapsed > s.slow {
+		return true
+	}
+	return s.rate > 0 && rand.Float64() < s.rate
+}
+
+// payloadSizes are the body sizes of a request and its response, put in
+// the request's context by the access log for measurePayloadSizes to fill
+// in.
+type payloadSizes struct {
+	in, out int64
+	// logged is set if the request was sampled, for the access log to
+	// report its sizes
+	logged bool
+}
+
+type payloadSizesKey struct{}
+
+// withPayloadSizes returns ctx with somewhere for the request's body sizes
+// to be recorded.
+func withPayloadSizes(ctx context.Context) context.Context {
+	return context.WithValue(ctx, payloadSizesKey{}, &payloadSizes{})
+}
+
+// field returns the sizes as they're added to the access log line, or
+// nothing if they aren't logged.
+func (s *payloadSizes) field() string {
+	if s == nil || !s.logged {
+		return ""
+	}
+	return fmt.Sprintf(" request=%dB response=%dB", s.in, s.out)
+}
+
+// measurePayloadSizes counts how many body bytes each request had and its
+// response was given, as read by the handler and as written by it, so
+// unusually large payloads stand out. Every request is added to the size
+// histograms, and the sampled ones have their sizes access logged.
+func (b *Bridge) measurePayloadSizes(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		body := &countingReader{ReadCloser: r.Body}
+		r.Body = body
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		out := int64(ww.BytesWritten())
+		b.requestSizes.add(r.Context(), float64(body.n))
+		b.responseSizes.add(r.Context(), float64(out))
+		if s, ok := r.Context().Value(payloadSizesKey{}).(*payloadSizes); ok {
+			s.in, s.out = body.n, out
+			s.logged = b.payloadSampling.logged(body.n, out, time.Since(start))
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package bridge
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPayloadSizesInAccessLog(t *testing.T) {
+	be := startBackend(t)
+	for _, format := range []string{"text", "clf"} {
+		b := newTestBridge(t, Config{GRPCAddr: be.addr, LogPayloadSizes: true, LogFormat: format})
+		var out bytes.Buffer
+		b.logOut = &out
+		h := b.Handler()
+
+		body := `{"message": "` + strings.Repeat("x", 1000) + `"}`
+		rec := post(h, "/test.v1.Echo/Echo", body)
+		if rec.Code != 200 {
+			t.Fatalf("%s: got %d %s", format, rec.Code, rec.Body)
+		}
+		line := out.String()
+		if want := fmt.Sprintf(" request=%dB response=%dB\n", len(body), rec.Body.Len()); !strings.Contains(line, "/test.v1.Echo/Echo") || !strings.HasSuffix(line, want) {
+			t.Fatalf("%s: access log %q, want the request's line ending in %q", format, line, want)
+		}
+		if n := strings.Count(line, "\n"); n != 1 {
+			t.Fatalf("%s: logged %d lines, want the access log line alone", format, n)
+		}
+	}
+}
+
+func TestPayloadSizeMetrics(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, LogPayloadSizes: true})
+	b.logOut = io.Discard
+	h := b.Handler()
+
+	small := post(h, "/test.v1.Echo/Echo", `{"message": "hi"}`)
+	large := post(h, "/test.v1.Echo/Echo", `{"message": "`+strings.Repeat("x", 2000)+`"}`)
+	if small.Code != 200 || large.Code != 200 {
+		t.Fatalf("got %d and %d", small.Code, large.Code)
+	}
+	// One scrape, as scrapes are requests too
+	rec := serve(h, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	scraped := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write(rec.Body.Bytes()) })
+	tests := []struct {
+		series string
+		want   float64
+	}{
+		{`bridge_request_size_bytes_bucket{le="64"}`, 1},
+		{`bridge_request_size_bytes_bucket{le="4096"}`, 2},
+		{`bridge_request_size_bytes_count`, 2},
+		{`bridge_request_size_bytes_sum`, float64(len(`{"message": "hi"}`) + len(`{"message": "`) + 2000 + len(`"}`))},
+		{`bridge_response_size_bytes_bucket{le="256"}`, 1},
+		{`bridge_response_size_bytes_bucket{le="+Inf"}`, 2},
+		{`bridge_response_size_bytes_sum`, float64(small.Body.Len() + large.Body.Len())},
+	}
+	for _, tt := range tests {
+		if got := metric(t, scraped, tt.series); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.series, got, tt.want)
+		}
+	}
+
+	plain := newTestBridge(t, Config{GRPCAddr: be.addr})
+	rec = serve(plain.Handler(), httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if strings.Contains(rec.Body.String(), "bridge_request_size_bytes") {
+		t.Fatal("size histograms served without LogPayloadSizes")
+	}
+}
+
+func TestPayloadSizeSamplingKeepsLargeRequests(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, LogPayloadSizes: true, PayloadLogThreshold: 512, LogFormat: "clf"})
+	var out bytes.Buffer
+	b.logOut = &out
+	h := b.Handler()
+
+	tests := []struct {
+		name   string
+		body   string
+		logged bool
+	}{
+		{"small", `{"message": "hi"}`, false},
+		{"large", `{"message": "` + strings.Repeat("x", 1000) + `"}`, true},
+	}
+	for _, tt := range tests {
+		out.Reset()
+		if rec := post(h, "/test.v1.Echo/Echo", tt.body); rec.Code != 200 {
+			t.Fatalf("%s: got %d %s", tt.name, rec.Code, rec.Body)
+		}
+		line := out.String()
+		if line == "" {
+			t.Fatalf("%s request: no access log line", tt.name)
+		}
+		if got := strings.Contains(line, " request="); got != tt.logged {
+			t.Fatalf("%s request: sizes logged = %v, want %v in %q", tt.name, got, tt.logged, line)
+		}
+	}
+	if got := metric(t, h, "bridge_request_size_bytes_count"); got != 2 {
+		t.Fatalf("size histograms counted %v requests, want both, sampled or not", got)
+	}
+
+	if _, err := NewBridge(Config{GRPCAddr: be.addr, PayloadLogSampleRate: 2}); err == nil {
+		t.Fatal("a sample rate over 1 was accepted")
+	}
+}
+
+func TestPayloadSamplingAlwaysKeepsSlowRequests(t *testing.T) {
+	s := payloadSampling{threshold: 512, slow: time.Second}
+	if s.logged(10, 10, time.Millisecond) {
+		t.Fatal("a small fast request was logged at rate 0")
+	}
+	if !s.logged(10, 10, 2*time.Second) {
+		t.Fatal("a small slow request was sampled out")
+	}
+	if !s.logged(10, 600, time.Millisecond) {
+		t.Fatal("a large response was sampled out")
+	}
+	if !(payloadSampling{threshold: 512, rate: 1}).logged(10, 10, 0) {
+		t.Fatal("a small request was sampled out at rate 1")
+	}
+}
@@ -0,0 +1,40 @@
+package bridge
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// logSlowRequests logs the requests that take longer than threshold, with
+// the time the backend call took and the rest spent in the bridge, so
+// latency outliers surface without logging every request.
+func logSlowRequests(threshold time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r)
+
+			elapsed := time.Since(start)
+			if elapsed <= threshold {
+				return
+			}
+			// The handlers report the backend time for unary and client
+			// streaming calls
+			ms, err := strconv.ParseFloat(ww.Header().Get("X-Backend-Duration-Ms"), 64)
+			if err != nil {
+				log.Printf("⚠ Slow request: %s %s took %s (status %d)", r.Method, r.URL.Path, elapsed.Round(time.Millisecond), ww.Status())
+				return
+			}
+			backend := time.Duration(ms * float64(time.Millisecond))
+			log.Printf("⚠ Slow request: %s %s took %s: backend %s, bridge %s (status %d)",
+				r.Method, r.URL.Path, elapsed.Round(time.Millisecond),
+				backend.Round(time.Millisecond), (elapsed - backend).Round(time.Millisecond), ww.Status())
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package bridge
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestSlowRequestLog(t *testing.T) {
+	be := startBackend(t)
+	be.onUnary(func(ctx context.Context, req *dynamicpb.Message) (proto.Message, error) {
+		if req.Get(echoRequest.Fields().ByName("message")).String() == "slow" {
+			time.Sleep(100 * time.Millisecond)
+		}
+		return req, nil
+	})
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, SlowRequestThreshold: 50 * time.Millisecond})
+	h := b.Handler()
+
+	logged := captureLog(t)
+	post(h, "/test.v1.Echo/Echo", `{"message": "fast"}`)
+	if out := logged(); strings.Contains(out, "Slow request") {
+		t.Fatalf("a fast request was logged as slow:\n%s", out)
+	}
+
+	logged = captureLog(t)
+	post(h, "/test.v1.Echo/Echo", `{"message": "slow"}`)
+	want := regexp.MustCompile(`⚠ Slow request: POST /test.v1.Echo/Echo took \d+ms: backend \d+ms, bridge \d+m?s \(status 200\)`)
+	if out := logged(); !want.MatchString(out) {
+		t.Fatalf("no slow request entry with a timing breakdown in:\n%s", out)
+	}
+}
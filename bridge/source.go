@@ -0,0 +1,191 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// DescriptorSource resolves the descriptors of the methods the bridge
+// serves, e.g. from a schema registry. fullMethod is
+// "package.Service/Method". A source that doesn't have a method reports
+// it with a NotFound status, so that the next source is asked; any other
+// error fails the call.
+type DescriptorSource interface {
+	FindMethod(fullMethod string) (protoreflect.MethodDescriptor, error)
+	ListServices() ([]string, error)
+}
+
+// serviceFinder is implemented by sources that can describe a whole
+// service, so GET /services lists its methods. Services of sources that
+// can't are listed without methods.
+type serviceFinder interface {
+	FindService(service string) (protoreflect.ServiceDescriptor, error)
+}
+
+// WithDescriptorSource adds src to the sources methods are resolved from.
+// Sources are asked in the order they were added, after the descriptor
+// set and RegisterMethod and before reflection against the backend, and
+// their services are listed by GET /services. Calls still go to the
+// backend that serves the service.
+func WithDescriptorSource(src DescriptorSource) Option {
+	return func(o *options) {
+		o.sources = append(o.sources, src)
+	}
+}
+
+// sourceTimeout bounds each lookup a reflection source makes, since
+// DescriptorSource methods aren't given a context.
+const sourceTimeout = 10 * time.Second
+
+// reflectionSource resolves methods by server reflection against a
+// connection, caching what it fetches.
+type reflectionSource struct {
+	r *resolver
+}
+
+// NewReflectionSource returns a DescriptorSource that resolves methods by
+// gRPC server reflection against conn, such as a schema registry that
+// serves the reflection API for services it doesn't implement.
+func NewReflectionSource(conn grpc.ClientConnInterface) DescriptorSource {
+	return reflectionSource{newResolver(grpc_reflection_v1alpha.NewServerReflectionClient(conn))}
+}
+
+func (s reflectionSource) FindMethod(fullMethod string) (protoreflect.MethodDescriptor, error) {
+	service, method, err := splitFullMethod(fullMethod)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sourceTimeout)
+	defer cancel()
+	return s.r.FindMethod(ctx, service, method)
+}
+
+func (s reflectionSource) FindService(service string) (protoreflect.ServiceDescriptor, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), sourceTimeout)
+	defer cancel()
+	return s.r.FindService(ctx, service)
+}
+
+func (s reflectionSource) ListServices() ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), sourceTimeout)
+	defer cancel()
+	return s.r.ListServices(ctx)
+}
+
+// staticSource resolves methods from a fixed set of files.
+type staticSource struct {
+	files *protoregistry.Files
+}
+
+// NewStaticSource returns a DescriptorSource that resolves methods from
+// files, e.g. a descriptor set built with protoc.
+func NewStaticSource(files *protoregistry.Files) DescriptorSource {
+	return staticSource{files}
+}
+
+func (s staticSource) FindMethod(fullMethod string) (protoreflect.MethodDescriptor, error) {
+	service, method, err := splitFullMethod(fullMethod)
+	if err != nil {
+		return nil, err
+	}
+	sd, err := s.FindService(service)
+	if err != nil {
+		return nil, err
+	}
+	md := sd.Methods().ByName(protoreflect.Name(method))
+	if md == nil {
+		return nil, status.Errorf(codes.NotFound, "method %q not found in service %q", method, service)
+	}
+	return md, nil
+}
+
+func (s staticSource) FindService(service string) (protoreflect.ServiceDescriptor, error) {
+	d, err := s.files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "service %q not found", service)
+	}
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "%s is not a service", service)
+	}
+	return sd, nil
+}
+
+func (s staticSource) ListServices() ([]string, error) {
+	var names []string
+	s.files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		services := fd.Services()
+		for i := 0; i < services.Len(); i++ {
+			names = append(names, string(services.Get(i).FullName()))
+		}
+		return true
+	})
+	sort.Strings(names)
+	return names, nil
+}
+
+// splitFullMethod splits "package.Service/Method", with or without a
+// leading slash.
+func splitFullMethod(fullMethod string) (service, method string, err error) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok || service == "" || method == "" || strings.Contains(method, "/") {
+		return "", "", status.Errorf(codes.InvalidArgument, "invalid method name %q (want package.Service/Method)", fullMethod)
+	}
+	return service, method, nil
+}
+
+// findInSources resolves service/method from the sources added with
+// WithDescriptorSource, reporting whether one had it.
+func (b *Bridge) findInSources(service, method string) (protoreflect.MethodDescriptor, bool, error) {
+	for _, src := range b.sources {
+		md, err := src.FindMethod(service + "/" + method)
+		if status.Code(err) == codes.NotFound {
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		if md == nil {
+			return nil, false, status.Errorf(codes.Internal, "descriptor source returned no descriptor for %s/%s", service, method)
+		}
+		return md, true, nil
+	}
+	return nil, false, nil
+}
+
+// sourceServices describes the services of the sources added with
+// WithDescriptorSource that match and are exposed, unless services
+// already has them.
+func (b *Bridge) sourceServices(services map[string]serviceInfo, match func(string) bool) error {
+	for _, src := range b.sources {
+		names, err := src.ListServices()
+		if err != nil {
+			return fmt.Errorf("failed to list services of descriptor source: %w", err)
+		}
+		for _, name := range names {
+			if _, ok := services[name]; ok || strings.HasPrefix(name, "grpc.reflection.") || !match(name) || !b.exposed(name) {
+				continue
+			}
+			info := serviceInfo{Name: name, Methods: []methodInfo{}}
+			if finder, ok := src.(serviceFinder); ok {
+				sd, err := finder.FindService(name)
+				if err != nil {
+					return err
+				}
+				info = b.describeService(sd)
+			}
+			services[name] = info
+		}
+	}
+	return nil
+}
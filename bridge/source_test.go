@@ -0,0 +1,78 @@
+package bridge
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// memorySource is a DescriptorSource serving the methods of echoFile from
+// memory, counting its lookups.
+type memorySource struct {
+	methods map[string]protoreflect.MethodDescriptor
+	lookups atomic.Int64
+	err     error
+}
+
+func newMemorySource() *memorySource {
+	s := &memorySource{methods: make(map[string]protoreflect.MethodDescriptor)}
+	sd := echoFile.Services().Get(0)
+	for i := 0; i < sd.Methods().Len(); i++ {
+		md := sd.Methods().Get(i)
+		s.methods[string(sd.FullName())+"/"+string(md.Name())] = md
+	}
+	return s
+}
+
+func (s *memorySource) FindMethod(fullMethod string) (protoreflect.MethodDescriptor, error) {
+	s.lookups.Add(1)
+	if s.err != nil {
+		return nil, s.err
+	}
+	md, ok := s.methods[fullMethod]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "no method %s", fullMethod)
+	}
+	return md, nil
+}
+
+func (s *memorySource) ListServices() ([]string, error) {
+	return []string{string(echoFile.Services().Get(0).FullName())}, nil
+}
+
+func TestCustomDescriptorSourceDrivesInvocation(t *testing.T) {
+	counter, reflectionLookups := countLookups()
+	be := startBackend(t, counter)
+	src := newMemorySource()
+	b := newTestBridge(t, Config{GRPCAddr: be.addr}, WithDescriptorSource(src))
+	h := b.Handler()
+
+	before := reflectionLookups.Load()
+	if rec := post(h, "/test.v1.Echo/Echo", `{"message": "hi"}`); rec.Code != 200 {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+	if src.lookups.Load() == 0 || reflectionLookups.Load() != before || be.Calls() != 1 {
+		t.Fatalf("%d source lookups, %d reflection lookups, %d calls; want the source's method called without reflection",
+			src.lookups.Load(), reflectionLookups.Load()-before, be.Calls())
+	}
+
+	// Methods the source doesn't have fall back to reflection
+	delete(src.methods, "test.v1.Echo/OldEcho")
+	if rec := post(h, "/test.v1.Echo/OldEcho", `{}`); rec.Code != 200 || reflectionLookups.Load() == before {
+		t.Fatalf("method missing from the source: got %d %s after %d reflection lookups", rec.Code, rec.Body, reflectionLookups.Load()-before)
+	}
+}
+
+func TestDescriptorSourceErrorFailsCall(t *testing.T) {
+	be := startBackend(t)
+	src := newMemorySource()
+	src.err = status.Error(codes.Unavailable, "registry down")
+	b := newTestBridge(t, Config{GRPCAddr: be.addr}, WithDescriptorSource(src))
+
+	if rec := post(b.Handler(), "/test.v1.Echo/Echo", `{}`); rec.Code != 503 || be.Calls() != 0 {
+		t.Fatalf("got %d %s after %d calls, want 503 without calling the backend", rec.Code, rec.Body, be.Calls())
+	}
+}
@@ -0,0 +1,43 @@
+package bridge
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// weightedSelector picks one of several backends at random, in
+// proportion to their weights.
+type weightedSelector struct {
+	backends []*backend
+	// cumulative[i] is the sum of the weights of backends[0..i]
+	cumulative []int
+	total      int
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func newWeightedSelector(backends []*backend, weights []int) *weightedSelector {
+	s := &weightedSelector{
+		backends: backends,
+		rnd:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for _, w := range weights {
+		s.total += w
+		s.cumulative = append(s.cumulative, s.total)
+	}
+	return s
+}
+
+func (s *weightedSelector) pick() *backend {
+	s.mu.Lock()
+	n := s.rnd.Intn(s.total)
+	s.mu.Unlock()
+	for i, c := range s.cumulative {
+		if n < c {
+			return s.backends[i]
+		}
+	}
+	return s.backends[len(s.backends)-1]
+}
@@ -0,0 +1,32 @@
+package bridge
+
+import (
+	"testing"
+
+	"github.com/mizrahidaniel/grpc-http-bridge/bridgetest"
+)
+
+func TestWeightedSplitFollowsWeights(t *testing.T) {
+	stable, canary := bridgetest.Start(t), bridgetest.Start(t)
+	b := newTestBridge(t, Config{
+		GRPCAddr: stable.Addr,
+		Services: map[string]ServiceConfig{
+			bridgetest.Service: {Backends: []WeightedBackend{
+				{Address: stable.Addr, Weight: 90},
+				{Address: canary.Addr, Weight: 10},
+			}},
+		},
+	})
+	h := b.Handler()
+
+	const n = 1000
+	for i := 0; i < n; i++ {
+		if rec := post(h, "/bridgetest.v1.Greeter/SayHello", `{"name": "Ada"}`); rec.Code != 200 {
+			t.Fatalf("got %d %s", rec.Code, rec.Body)
+		}
+	}
+	// 100 expected, give or take five standard deviations
+	if got := canary.Calls(); stable.Calls()+got != n || got < 50 || got > 150 {
+		t.Fatalf("canary served %d of %d calls and stable %d, want about 10%%", got, n, stable.Calls())
+	}
+}
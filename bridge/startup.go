@@ -0,0 +1,68 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
+)
+
+// awaitStartup probes every backend until all of them are ready, up to
+// attempts times, interval apart. Serve runs it before binding the HTTP
+// port, so that orchestrators can't reach the bridge until its backends
+// can be.
+func (b *Bridge) awaitStartup(attempts int, interval time.Duration) error {
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		start := time.Now()
+		if err = b.startupProbe(interval); err == nil {
+			log.Printf("✓ Backends ready after %d startup probe(s)", attempt)
+			return nil
+		}
+		log.Printf("↻ Startup probe %d/%d: %v", attempt, attempts, err)
+		if attempt < attempts {
+			time.Sleep(interval - time.Since(start))
+		}
+	}
+	return fmt.Errorf("backends not ready after %d startup probes: %w", attempts, err)
+}
+
+// startupProbe checks that every backend is connected and answers the
+// health probe, or reflection if there is none, within timeout.
+func (b *Bridge) startupProbe(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	for _, be := range b.backendList() {
+		if err := awaitReady(ctx, be); err != nil {
+			return fmt.Errorf("%s: %w", be.addr, err)
+		}
+		if b.healthProbe != nil {
+			if !b.probe(ctx, be) {
+				return fmt.Errorf("%s: health probe failed", be.addr)
+			}
+			continue
+		}
+		if _, err := be.resolver.ListServices(ctx); err != nil {
+			return fmt.Errorf("%s: reflection: %w", be.addr, err)
+		}
+	}
+	return nil
+}
+
+// awaitReady waits for be's connection to become ready.
+func awaitReady(ctx context.Context, be *backend) error {
+	be.conn.Connect()
+	for {
+		state := be.conn.GetState()
+		if state == connectivity.Ready {
+			return nil
+		}
+		if !be.conn.WaitForStateChange(ctx, state) {
+			return status.Errorf(codes.Unavailable, "not connected (%s)", state)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package bridge
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// freeAddr returns a local address nothing is listening on.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	return lis.Addr().String()
+}
+
+func TestServeWaitsForStartupProbe(t *testing.T) {
+	backendAddr, httpAddr := freeAddr(t), freeAddr(t)
+	_, port, _ := net.SplitHostPort(httpAddr)
+	httpPort, _ := strconv.Atoi(port)
+
+	b := newTestBridge(t, Config{
+		GRPCAddr:             backendAddr,
+		HTTPPort:             httpPort,
+		StartupProbeAttempts: 100,
+		StartupProbeInterval: 50 * time.Millisecond,
+	})
+	served := make(chan error, 1)
+	go func() { served <- b.Serve() }()
+	t.Cleanup(func() {
+		b.Shutdown(context.Background())
+		<-served
+	})
+
+	time.Sleep(200 * time.Millisecond)
+	if c, err := net.Dial("tcp", httpAddr); err == nil {
+		c.Close()
+		t.Fatal("the HTTP port was bound before the backend was reachable")
+	}
+
+	lis, err := net.Listen("tcp", backendAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	reflection.Register(srv)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := http.Get("http://" + httpAddr + "/health")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode != 200 {
+				t.Fatalf("/health: got %d", resp.StatusCode)
+			}
+			break
+		}
+		select {
+		case err := <-served:
+			t.Fatalf("Serve returned early: %v", err)
+		default:
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the bridge never started serving after the backend came up")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServeFailsWhenStartupProbesRunOut(t *testing.T) {
+	b := newTestBridge(t, Config{
+		GRPCAddr:             freeAddr(t),
+		StartupProbeAttempts: 2,
+		StartupProbeInterval: 20 * time.Millisecond,
+	})
+	if err := b.Serve(); err == nil {
+		t.Fatal("Serve started without a reachable backend")
+	}
+}
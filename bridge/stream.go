@@ -0,0 +1,201 @@
+package bridge
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// messageReader yields successive JSON request messages from a body. It
+// returns io.EOF once the body is exhausted.
+type messageReader func() ([]byte, error)
+
+// handleClientStream serves a client-streaming method. The body is either a
+// JSON array of messages or, with Content-Type application/x-ndjson, one
+// message per line. Messages are sent to the backend as they are read.
+func (b *Bridge) handleClientStream(w http.ResponseWriter, r *http.Request, be *backend, md protoreflect.MethodDescriptor) {
+	markStream(r.Context())
+	var next messageReader
+	if isNDJSON(r.Header.Get("Content-Type")) {
+		next = ndjsonReader(r.Body)
+	} else {
+		next = jsonArrayReader(r.Body)
+	}
+	if b.streamIdle > 0 {
+		next = idleTimeoutReader(next, http.NewResponseController(w), b.streamIdle)
+	}
+	next = b.headerFieldReader(next, md, r.Header)
+
+	enc, err := b.jsonEncoding(r)
+	if err != nil {
+		b.writeError(w, err)
+		return
+	}
+	if b.debugHeaders {
+		enc.setHeader(w.Header())
+	}
+
+	var resp []byte
+	var timing callTiming
+	err = b.guard(md, func() (err error) {
+		resp, err = b.invokeClientStream(r.Context(), be, md, next, enc, &timing)
+		return err
+	})
+	timing.setHeaders(w.Header(), b.debugHeaders)
+	if err != nil {
+		b.writeError(w, err)
+		log.Printf("✗ RPC failed: %v", err)
+		return
+	}
+	b.writeJSON(w, http.StatusOK, resp)
+	log.Printf("✓ Response sent")
+}
+
+func (b *Bridge) invokeClientStream(ctx context.Context, be *backend, md protoreflect.MethodDescriptor, next messageReader, enc jsonEncoding, timing *callTiming) ([]byte, error) {
+	ctx, cancel := b.withCallTimeout(ctx, md)
+	defer cancel()
+
+	// The stream is in flight from open until the response arrives, which
+	// includes time spent reading the request body.
+	defer timing.addBackend(time.Now())
+
+	stream, err := b.newStream(ctx, be, &grpc.StreamDesc{ClientStreams: true}, md, timing.peerOption())
+	if err != nil {
+		be.observe(err)
+		return nil, err
+	}
+
+	for i := 0; ; i++ {
+		data, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// A read that timed out also ends the request's context
+			if st, ok := status.FromError(err); ok {
+				return nil, withCause(status.Errorf(st.Code(), "message %d: %s", i, st.Message()), err)
+			}
+			if ctx.Err() != nil {
+				return nil, clientGone(ctx, err)
+			}
+			return nil, withCause(status.Errorf(codes.InvalidArgument, "message %d: %v", i, err), err)
+		}
+		if err := b.checkRequestSchema(methodPath(md), data); err != nil {
+			st := status.Convert(err)
+			return nil, withCause(status.Errorf(st.Code(), "message %d: %s", i, st.Message()), err)
+		}
+		msg, err := b.decodeRequest(data, md.Input())
+		if err != nil {
+			st := status.Convert(err)
+			return nil, withCause(status.Errorf(st.Code(), "message %d: %s", i, st.Message()), err)
+		}
+		if err := stream.SendMsg(msg); err != nil {
+			if err == io.EOF {
+				// The backend ended the call; RecvMsg reports why
+				break
+			}
+			return nil, err
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+
+	resp := dynamicpb.NewMessage(md.Output())
+	if err := stream.RecvMsg(resp); err != nil {
+		be.observe(err)
+		return nil, err
+	}
+	return messageToJSON(resp, enc, b.types)
+}
+
+// idleTimeoutReader fails a read that waits longer than timeout for the
+// client's next message, which ends the call. Servers that can't set read
+// deadlines read without one.
+func idleTimeoutReader(next messageReader, rc *http.ResponseController, timeout time.Duration) messageReader {
+	return func() ([]byte, error) {
+		if err := rc.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+			return next()
+		}
+		data, err := next()
+		if isTimeout(err) {
+			log.Printf("✗ Client stream idle for %s, cancelling", timeout)
+			return nil, withCause(status.Errorf(codes.DeadlineExceeded, "no message received for %s", timeout), err)
+		}
+		return data, err
+	}
+}
+
+// isTimeout reports whether err is a read or write deadline expiring.
+func isTimeout(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+func isNDJSON(contentType string) bool {
+	mt, _, _ := mime.ParseMediaType(contentType)
+	return mt == "application/x-ndjson"
+}
+
+// ndjsonReader reads one message per line, skipping blank lines.
+func ndjsonReader(body io.Reader) messageReader {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	return func() ([]byte, error) {
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) > 0 {
+				return append([]byte(nil), line...), nil
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+}
+
+// jsonArrayReader decodes the elements of a top-level JSON array one at a
+// time. An empty body is treated as an empty array.
+func jsonArrayReader(body io.Reader) messageReader {
+	dec := json.NewDecoder(body)
+	started := false
+	return func() ([]byte, error) {
+		if !started {
+			started = true
+			tok, err := dec.Token()
+			if err == io.EOF {
+				return nil, io.EOF
+			}
+			if err != nil {
+				return nil, err
+			}
+			if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+				return nil, errors.New("request body must be a JSON array of messages")
+			}
+		}
+		if !dec.More() {
+			return nil, io.EOF
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		return raw, nil
+	}
+}
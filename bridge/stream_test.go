@@ -0,0 +1,77 @@
+package bridge
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+func TestClientStreamFromNDJSON(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr})
+
+	for _, tt := range []struct {
+		name, contentType, body string
+	}{
+		{"ndjson", "application/x-ndjson", "{\"message\": \"a\"}\n{\"message\": \"b\"}\n\n{\"message\": \"c\"}\n"},
+		{"array", "application/json", `[{"message": "a"}, {"message": "b"}, {"message": "c"}]`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/ClientStream", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := serve(b.Handler(), req)
+			var resp struct{ Count int }
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); rec.Code != 200 || err != nil {
+				t.Fatalf("got %d %s", rec.Code, rec.Body)
+			}
+			if resp.Count != 3 {
+				t.Fatalf("backend received %d messages, want 3", resp.Count)
+			}
+		})
+	}
+}
+
+func TestIdleClientStreamIsCancelled(t *testing.T) {
+	cancelled := make(chan struct{})
+	be := startBackend(t, grpc.ChainStreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		err := handler(srv, ss)
+		if info.FullMethod == "/test.v1.Echo/ClientStream" && ss.Context().Err() != nil {
+			close(cancelled)
+		}
+		return err
+	}))
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, StreamMessageTimeout: 100 * time.Millisecond})
+	// Read deadlines need a real connection
+	srv := httptest.NewServer(b.Handler())
+	t.Cleanup(srv.Close)
+
+	// The client sends one message and then neither sends nor ends the
+	// stream
+	pr, pw := io.Pipe()
+	t.Cleanup(func() { pw.Close() })
+	go pw.Write([]byte("{\"message\": \"a\"}\n"))
+	start := time.Now()
+	resp, err := http.Post(srv.URL+"/test.v1.Echo/ClientStream", "application/x-ndjson", pr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body struct{ Code, Error string }
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 504 || body.Code != "DeadlineExceeded" || !strings.Contains(body.Error, "no message received for 100ms") {
+		t.Fatalf("got %d %+v after %v, want 504 for the idle stream", resp.StatusCode, body, time.Since(start))
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the backend stream wasn't cancelled")
+	}
+}
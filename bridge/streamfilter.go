@@ -0,0 +1,201 @@
+package bridge
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// streamFilter selects the server stream messages forwarded to the client,
+// from ?where= predicates such as status==ACTIVE or stats.count>=10. A
+// message is forwarded if it matches every predicate.
+type streamFilter []fieldPredicate
+
+type fieldPredicate struct {
+	path  []protoreflect.FieldDescriptor
+	op    string
+	value protoreflect.Value
+}
+
+// predicateOps lists the comparison operators, two-character ones first so
+// that "<=" isn't read as "<".
+var predicateOps = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// parseStreamFilter compiles the where query parameters against the
+// stream's response message.
+func parseStreamFilter(wheres []string, desc protoreflect.MessageDescriptor) (streamFilter, error) {
+	var filter streamFilter
+	for _, where := range wheres {
+		p, err := parsePredicate(where, desc)
+		if err != nil {
+			var violations []*fieldViolation
+			addViolation(&violations, "where", err.Error())
+			return nil, badRequest(violations, "invalid where %q: %v", where, err)
+		}
+		filter = append(filter, p)
+	}
+	return filter, nil
+}
+
+func parsePredicate(where string, desc protoreflect.MessageDescriptor) (fieldPredicate, error) {
+	var p fieldPredicate
+	i := -1
+	for _, op := range predicateOps {
+		if j := strings.Index(where, op); j > 0 && (i < 0 || j < i) {
+			i, p.op = j, op
+		}
+	}
+	if i < 0 {
+		return p, fmt.Errorf("want {field}{op}{value} with op one of %s", strings.Join(predicateOps, " "))
+	}
+	field, raw := strings.TrimSpace(where[:i]), strings.TrimSpace(where[i+len(p.op):])
+
+	md := desc
+	for _, name := range strings.Split(field, ".") {
+		if md == nil {
+			return p, fmt.Errorf("%s is not a message field", joinFieldNames(p.path))
+		}
+		fd := md.Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			fd = md.Fields().ByJSONName(name)
+		}
+		if fd == nil {
+			return p, fmt.Errorf("%s has no field %q", md.FullName(), name)
+		}
+		if fd.IsList() || fd.IsMap() {
+			return p, fmt.Errorf("repeated field %s can't be compared", fd.Name())
+		}
+		p.path = append(p.path, fd)
+		md = fd.Message()
+	}
+	fd := p.path[len(p.path)-1]
+
+	var err error
+	p.value, err = predicateValue(fd, raw)
+	if err != nil {
+		return p, err
+	}
+	if p.op != "==" && p.op != "!=" && !isNumericKind(fd.Kind()) {
+		return p, fmt.Errorf("%s only applies to numeric fields", p.op)
+	}
+	return p, nil
+}
+
+// predicateValue parses raw as a value of fd.
+func predicateValue(fd protoreflect.FieldDescriptor, raw string) (protoreflect.Value, error) {
+	invalid := fmt.Errorf("%q is not a valid %s", raw, fd.Kind())
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(strings.Trim(raw, `"'`)), nil
+	case protoreflect.BoolKind:
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return protoreflect.Value{}, invalid
+		}
+		return protoreflect.ValueOfBool(v), nil
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(raw)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		n, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("%q is not a %s value", raw, fd.Enum().FullName())
+		}
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), nil
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return protoreflect.Value{}, invalid
+		}
+		return protoreflect.ValueOfInt64(n), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return protoreflect.Value{}, invalid
+		}
+		return protoreflect.ValueOfUint64(n), nil
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return protoreflect.Value{}, invalid
+		}
+		return protoreflect.ValueOfFloat64(f), nil
+	}
+	return protoreflect.Value{}, fmt.Errorf("%s fields can't be compared", fd.Kind())
+}
+
+func isNumericKind(k protoreflect.Kind) bool {
+	switch k {
+	case protoreflect.StringKind, protoreflect.BoolKind, protoreflect.EnumKind,
+		protoreflect.BytesKind, protoreflect.MessageKind, protoreflect.GroupKind:
+		return false
+	}
+	return true
+}
+
+// match reports whether msg satisfies every predicate.
+func (f streamFilter) match(msg proto.Message) bool {
+	for _, p := range f {
+		if !p.match(msg.ProtoReflect()) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p fieldPredicate) match(m protoreflect.Message) bool {
+	for _, fd := range p.path[:len(p.path)-1] {
+		m = m.Get(fd).Message()
+	}
+	fd := p.path[len(p.path)-1]
+	c := compareValues(fd.Kind(), m.Get(fd), p.value)
+	switch p.op {
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
+
+// compareValues compares the field value v with the predicate value want,
+// which predicateValue widened to 64 bits.
+func compareValues(kind protoreflect.Kind, v, want protoreflect.Value) int {
+	switch kind {
+	case protoreflect.StringKind:
+		return strings.Compare(v.String(), want.String())
+	case protoreflect.BoolKind:
+		if v.Bool() == want.Bool() {
+			return 0
+		}
+		return 1
+	case protoreflect.EnumKind:
+		return cmp.Compare(v.Enum(), want.Enum())
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return cmp.Compare(v.Uint(), want.Uint())
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return cmp.Compare(v.Float(), want.Float())
+	default:
+		return cmp.Compare(v.Int(), want.Int())
+	}
+}
+
+func joinFieldNames(path []protoreflect.FieldDescriptor) string {
+	names := make([]string, len(path))
+	for i, fd := range path {
+		names[i] = string(fd.Name())
+	}
+	return strings.Join(names, ".")
+}
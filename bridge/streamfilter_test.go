@@ -0,0 +1,28 @@
+package bridge
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStreamFilterForwardsMatchingMessages(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr})
+	h := b.Handler()
+
+	rec := post(h, "/test.v1.Echo/ServerStream?where=count>=3&where=count!=4", `{"message": "hi", "count": 5}`)
+	if rec.Code != 200 {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+	var counts []float64
+	for _, line := range ndjsonLines(t, rec.Body.String()) {
+		counts = append(counts, line["count"].(float64))
+	}
+	if want := []float64{3, 5}; !reflect.DeepEqual(counts, want) {
+		t.Fatalf("streamed counts %v, want %v", counts, want)
+	}
+
+	if rec := post(h, "/test.v1.Echo/ServerStream?where=colour==red", `{"count": 5}`); rec.Code != 400 || be.Calls() != 1 {
+		t.Fatalf("unknown field: got %d %s, want 400 without a backend call", rec.Code, rec.Body)
+	}
+}
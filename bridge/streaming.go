@@ -0,0 +1,72 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// streamingMode is how a method's responses are delivered, whatever the
+// method declares.
+type streamingMode string
+
+const (
+	// streamingDeclared streams server streams and buffers unary calls.
+	streamingDeclared streamingMode = ""
+	// streamingBuffered buffers server streams into one JSON array.
+	streamingBuffered streamingMode = "buffered"
+	// streamingStreamed also answers unary calls as NDJSON, a stream of
+	// one message.
+	streamingStreamed streamingMode = "streamed"
+)
+
+func parseStreamingMode(s string) (streamingMode, error) {
+	switch m := streamingMode(s); m {
+	case streamingDeclared, streamingBuffered, streamingStreamed:
+		return m, nil
+	}
+	return "", fmt.Errorf("invalid streaming %q (want buffered or streamed)", s)
+}
+
+// bufferedStream reports whether the server stream of method, as
+// "{service}/{method}", is answered with one JSON array rather than
+// NDJSON, and the field the array is wrapped in, if any. HTTP/1.0 clients
+// can't read a chunked response, so they always get the array.
+func (b *Bridge) bufferedStream(r *http.Request, method string) (field string, buffered bool) {
+	field, buffered = b.streamArrays[method]
+	if !r.ProtoAtLeast(1, 1) {
+		buffered = true
+	}
+	return field, buffered
+}
+
+// streamedUnary reports whether the successful unary responses of method
+// are answered as NDJSON. HTTP/1.0 clients get the plain response.
+func (b *Bridge) streamedUnary(r *http.Request, method string) bool {
+	return b.unaryStreams[method] && r.ProtoAtLeast(1, 1)
+}
+
+// writeStreamedUnary answers with resp, a JSON response message, as an
+// NDJSON stream of that one message, ended like a server stream.
+func (b *Bridge) writeStreamedUnary(w http.ResponseWriter, r *http.Request, resp []byte) {
+	var line bytes.Buffer
+	if err := json.Compact(&line, resp); err != nil {
+		line.Reset()
+		line.Write(bytes.TrimSpace(resp))
+	}
+	line.WriteByte('\n')
+	if b.streamEnds {
+		end, _ := json.Marshal(controlFrame{Control: controlEnd, RequestID: middleware.GetReqID(r.Context())})
+		line.Write(append(end, '\n'))
+	}
+	if b.bufferResponses {
+		w.Header().Set("Content-Length", strconv.Itoa(line.Len()))
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	w.Write(line.Bytes())
+}
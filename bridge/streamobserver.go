@@ -0,0 +1,116 @@
+package bridge
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// StreamObserver is told about the lifecycle of each streaming call the
+// bridge makes to a backend, as WithTracing records it in OpenTelemetry
+// spans. ctx is the request's context, so a span started from it is a
+// child of the request's span. A server stream that is resumed opens a
+// new stream per attempt.
+type StreamObserver interface {
+	StreamOpened(ctx context.Context, fullMethod string) StreamEvents
+}
+
+// StreamEvents receives the events of one stream. With bidirectional
+// streams its methods may be called from different goroutines.
+type StreamEvents interface {
+	// MessageSent is called for each message sent to the backend, and
+	// MessageReceived for each one received from it.
+	MessageSent(msg proto.Message)
+	MessageReceived(msg proto.Message)
+	// StreamClosed is called once, when the stream ends, with nil if it
+	// ended with OK or else the error it ended with.
+	StreamClosed(err error)
+}
+
+// WithStreamObserver adds an observer of streaming calls. Observers are
+// notified in the order they are added.
+func WithStreamObserver(observer StreamObserver) Option {
+	return func(o *options) {
+		o.streamObservers = append(o.streamObservers, observer)
+	}
+}
+
+// newStream opens a stream to md on be, reporting its lifecycle to the
+// stream observers.
+func (b *Bridge) newStream(ctx context.Context, be *backend, desc *grpc.StreamDesc, md protoreflect.MethodDescriptor, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	stream, err := be.conn.NewStream(ctx, desc, methodPath(md), opts...)
+	err = b.connRefused(be, err)
+	if len(b.streamObservers) == 0 {
+		return stream, err
+	}
+
+	o := &observedStream{ClientStream: stream, unary: !desc.ServerStreams}
+	for _, observer := range b.streamObservers {
+		o.events = append(o.events, observer.StreamOpened(ctx, methodPath(md)))
+	}
+	if err != nil {
+		o.close(err)
+		return nil, err
+	}
+	// Calls abandoned mid-stream, e.g. when the client goes away, end with
+	// their context
+	context.AfterFunc(ctx, func() {
+		o.close(status.FromContextError(ctx.Err()).Err())
+	})
+	return o, nil
+}
+
+// observedStream passes a stream's messages and end to its events.
+type observedStream struct {
+	grpc.ClientStream
+	events []StreamEvents
+	// unary is set for client streams, which end with their one response
+	unary bool
+
+	closed sync.Once
+}
+
+func (o *observedStream) SendMsg(m interface{}) error {
+	err := o.ClientStream.SendMsg(m)
+	switch {
+	case err == nil:
+		for _, ev := range o.events {
+			ev.MessageSent(m.(proto.Message))
+		}
+	case err != io.EOF:
+		// io.EOF means the backend ended the call; RecvMsg reports how
+		o.close(err)
+	}
+	return err
+}
+
+func (o *observedStream) RecvMsg(m interface{}) error {
+	err := o.ClientStream.RecvMsg(m)
+	switch {
+	case err == io.EOF:
+		o.close(nil)
+	case err != nil:
+		o.close(err)
+	default:
+		for _, ev := range o.events {
+			ev.MessageReceived(m.(proto.Message))
+		}
+		if o.unary {
+			o.close(nil)
+		}
+	}
+	return err
+}
+
+func (o *observedStream) close(err error) {
+	o.closed.Do(func() {
+		for _, ev := range o.events {
+			ev.StreamClosed(err)
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package bridge
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+type parentKey struct{}
+
+// recordingObserver records the events of every stream as strings.
+type recordingObserver struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (o *recordingObserver) StreamOpened(ctx context.Context, fullMethod string) StreamEvents {
+	parent, _ := ctx.Value(parentKey{}).(string)
+	o.add("open " + fullMethod + " under " + parent)
+	return o
+}
+
+func (o *recordingObserver) MessageSent(proto.Message)     { o.add("sent") }
+func (o *recordingObserver) MessageReceived(proto.Message) { o.add("received") }
+func (o *recordingObserver) StreamClosed(err error)        { o.add("close " + status.Code(err).String()) }
+
+func (o *recordingObserver) add(event string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events = append(o.events, event)
+}
+
+func TestStreamObserverSeesLifecycle(t *testing.T) {
+	be := startBackend(t)
+	observer := &recordingObserver{}
+	b := newTestBridge(t, Config{GRPCAddr: be.addr}, WithStreamObserver(observer))
+	h := b.Handler()
+
+	stream := func(body string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/ServerStream", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req = req.WithContext(context.WithValue(req.Context(), parentKey{}, "request span"))
+		if rec := serve(h, req); rec.Code != 200 {
+			t.Fatalf("got %d %s", rec.Code, rec.Body)
+		}
+	}
+	stream(`{"message": "hi", "count": 2}`)
+	want := []string{"open /test.v1.Echo/ServerStream under request span", "sent", "received", "received", "close OK"}
+	if !reflect.DeepEqual(observer.events, want) {
+		t.Fatalf("events %q, want %q", observer.events, want)
+	}
+
+	observer.events = nil
+	be.failStreams(status.Error(codes.Aborted, "gone"))
+	stream(`{"message": "hi", "count": 1}`)
+	want = []string{"open /test.v1.Echo/ServerStream under request span", "sent", "received", "close Aborted"}
+	if !reflect.DeepEqual(observer.events, want) {
+		t.Fatalf("failed stream: events %q, want %q", observer.events, want)
+	}
+}
@@ -0,0 +1,205 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// clientGone reports err, a failure to read the request or write the
+// response, as the cancellation it is if ctx has ended, e.g. because the
+// client reset its HTTP/2 stream. Otherwise the write error would count
+// against the backend as an Unknown failure.
+func clientGone(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	return err
+}
+
+// callTimeout returns the deadline for calls to md. A method's own timeout
+// takes precedence over its service's, then over the timeout the method
+// is annotated with, unless the client sent its own deadline, and then
+// over the global CallTimeout. Zero means no deadline beyond the
+// request's.
+func (b *Bridge) callTimeout(ctx context.Context, md protoreflect.MethodDescriptor) time.Duration {
+	if d, ok := b.methodTimeouts[strings.TrimPrefix(methodPath(md), "/")]; ok {
+		return d
+	}
+	if d, ok := b.serviceTimeouts[string(md.Parent().FullName())]; ok {
+		return d
+	}
+	if d, ok := b.annotatedTimeout(md); ok && ctx.Value(clientDeadlineKey{}) == nil {
+		return d
+	}
+	return b.callTimeoutDefault
+}
+
+// annotatedTimeout returns the timeout md declares with the method option
+// named by TimeoutOption, a google.protobuf.Duration or a duration string
+// like "5s". The option is looked up once per descriptor.
+func (b *Bridge) annotatedTimeout(md protoreflect.MethodDescriptor) (time.Duration, bool) {
+	if b.timeoutOption == "" {
+		return 0, false
+	}
+	if d, ok := b.optionTimeouts.Load(md); ok {
+		return d.(time.Duration), d.(time.Duration) > 0
+	}
+	d, err := methodOptionTimeout(md, b.timeoutOption)
+	if err != nil {
+		log.Printf("⚠ Ignoring %s on %s: %v", b.timeoutOption, md.FullName(), err)
+	}
+	b.optionTimeouts.Store(md, d)
+	return d, d > 0
+}
+
+// methodOptionTimeout reads the timeout option name from md's options.
+// Options of descriptors fetched by reflection hold custom options as
+// unknown fields unless the option is compiled in, so the option's field
+// number is found from its declaration among md's file and its imports
+// and read from the options' wire form.
+func methodOptionTimeout(md protoreflect.MethodDescriptor, name protoreflect.FullName) (time.Duration, error) {
+	xd := findExtension(md.ParentFile(), name, make(map[string]bool))
+	if xd == nil || xd.ContainingMessage().FullName() != "google.protobuf.MethodOptions" {
+		return 0, nil
+	}
+	isDuration := xd.Message() != nil && xd.Message().FullName() == "google.protobuf.Duration"
+	if xd.Kind() != protoreflect.StringKind && !isDuration {
+		return 0, fmt.Errorf("want a string or google.protobuf.Duration option")
+	}
+	opts, err := proto.Marshal(md.Options())
+	if err != nil {
+		return 0, err
+	}
+	var value []byte
+	for len(opts) > 0 {
+		num, typ, n := protowire.ConsumeTag(opts)
+		if n < 0 {
+			return 0, protowire.ParseError(n)
+		}
+		opts = opts[n:]
+		n = protowire.ConsumeFieldValue(num, typ, opts)
+		if n < 0 {
+			return 0, protowire.ParseError(n)
+		}
+		if num == xd.Number() && typ == protowire.BytesType {
+			value, _ = protowire.ConsumeBytes(opts[:n])
+		}
+		opts = opts[n:]
+	}
+	if value == nil {
+		return 0, nil
+	}
+
+	if !isDuration {
+		return time.ParseDuration(string(value))
+	}
+	var pd durationpb.Duration
+	if err := proto.Unmarshal(value, &pd); err != nil {
+		return 0, err
+	}
+	if err := pd.CheckValid(); err != nil {
+		return 0, err
+	}
+	return pd.AsDuration(), nil
+}
+
+// findExtension looks the extension name up in fd and the files it
+// imports.
+func findExtension(fd protoreflect.FileDescriptor, name protoreflect.FullName, seen map[string]bool) protoreflect.ExtensionDescriptor {
+	if seen[fd.Path()] {
+		return nil
+	}
+	seen[fd.Path()] = true
+	if xd := fd.Extensions().ByName(name.Name()); xd != nil && xd.FullName() == name {
+		return xd
+	}
+	// Extensions can also be declared inside a message
+	if md := fd.Messages().ByName(name.Parent().Name()); md != nil && md.FullName() == name.Parent() {
+		if xd := md.Extensions().ByName(name.Name()); xd != nil {
+			return xd
+		}
+	}
+	imports := fd.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		if xd := findExtension(imports.Get(i).FileDescriptor, name, seen); xd != nil {
+			return xd
+		}
+	}
+	return nil
+}
+
+// withCallTimeout derives the context for a call to md, bounded by
+// callTimeout. If the request has a deadline, the call's ends the
+// deadline margin before it, leaving time to write the response.
+func (b *Bridge) withCallTimeout(ctx context.Context, md protoreflect.MethodDescriptor) (context.Context, context.CancelFunc) {
+	timeout := b.callTimeout(ctx, md)
+	if dl, ok := ctx.Deadline(); ok && b.deadlineMargin > 0 {
+		if dl = dl.Add(-b.deadlineMargin); timeout <= 0 || time.Until(dl) < timeout {
+			return context.WithDeadline(ctx, dl)
+		}
+	}
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// clientDeadlineKey marks requests whose client set a Grpc-Timeout.
+type clientDeadlineKey struct{}
+
+// clientDeadline bounds the request context by the client's Grpc-Timeout
+// header, the same header gRPC and gRPC-Web clients send.
+func clientDeadline(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := r.Header.Get("Grpc-Timeout")
+		if h == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		d, err := parseGRPCTimeout(h)
+		if err != nil {
+			writeError(w, r, status.Errorf(codes.InvalidArgument, "invalid Grpc-Timeout %q: %v", h, err))
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.WithValue(r.Context(), clientDeadlineKey{}, d), d)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// parseGRPCTimeout parses a timeout in the gRPC wire format: up to eight
+// digits followed by a unit, H, M, S, m (milli), u (micro) or n (nano).
+func parseGRPCTimeout(s string) (time.Duration, error) {
+	if len(s) < 2 || len(s) > 9 {
+		return 0, fmt.Errorf("want 1 to 8 digits and a unit")
+	}
+	units := map[byte]time.Duration{
+		'H': time.Hour,
+		'M': time.Minute,
+		'S': time.Second,
+		'm': time.Millisecond,
+		'u': time.Microsecond,
+		'n': time.Nanosecond,
+	}
+	unit, ok := units[s[len(s)-1]]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q", s[len(s)-1:])
+	}
+	n, err := strconv.ParseUint(s[:len(s)-1], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(n) * unit, nil
+}
@@ -0,0 +1,192 @@
+package bridge
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestCallTimeoutPrecedence(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{
+		GRPCAddr:    be.addr,
+		CallTimeout: time.Second,
+		Services: map[string]ServiceConfig{
+			"test.v1.Echo": {Timeout: Duration(2 * time.Second)},
+		},
+		Methods: map[string]MethodConfig{
+			"test.v1.Echo/Echo": {Timeout: Duration(3 * time.Second)},
+		},
+	})
+	other := newTestBridge(t, Config{GRPCAddr: be.addr, CallTimeout: time.Second})
+
+	methods := echoFile.Services().Get(0).Methods()
+	for _, tt := range []struct {
+		name   string
+		b      *Bridge
+		method protoreflect.Name
+		want   time.Duration
+	}{
+		{"method", b, "Echo", 3 * time.Second},
+		{"service", b, "ServerStream", 2 * time.Second},
+		{"global", other, "Echo", time.Second},
+	} {
+		if got := tt.b.callTimeout(context.Background(), methods.ByName(tt.method)); got != tt.want {
+			t.Errorf("%s timeout = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAnnotatedMethodTimeout(t *testing.T) {
+	// slo.proto declares
+	//
+	//	extend google.protobuf.MethodOptions {
+	//	  google.protobuf.Duration timeout = 50001;
+	//	}
+	//
+	// and annotates Report with 30s. Options of descriptors fetched by
+	// reflection hold it as an unknown field.
+	s := proto.String
+	d, _ := proto.Marshal(durationpb.New(30 * time.Second))
+	opts := &descriptorpb.MethodOptions{}
+	opts.ProtoReflect().SetUnknown(protowire.AppendBytes(protowire.AppendTag(nil, 50001, protowire.BytesType), d))
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       s("slo.proto"),
+		Package:    s("slo.v1"),
+		Syntax:     s("proto3"),
+		Dependency: []string{"google/protobuf/descriptor.proto", "google/protobuf/duration.proto", "google/protobuf/empty.proto"},
+		Extension: []*descriptorpb.FieldDescriptorProto{{
+			Name: s("timeout"), JsonName: s("timeout"), Number: proto.Int32(50001),
+			Type: descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			TypeName: s(".google.protobuf.Duration"), Extendee: s(".google.protobuf.MethodOptions"),
+		}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: s("Reports"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{Name: s("Report"), InputType: s(".google.protobuf.Empty"), OutputType: s(".google.protobuf.Empty"), Options: opts},
+				{Name: s("Ping"), InputType: s(".google.protobuf.Empty"), OutputType: s(".google.protobuf.Empty")},
+			},
+		}},
+	}, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	methods := fd.Services().Get(0).Methods()
+
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, CallTimeout: time.Second, TimeoutOption: "slo.v1.timeout"})
+	clientDeadline := context.WithValue(context.Background(), clientDeadlineKey{}, 5*time.Second)
+	for _, tt := range []struct {
+		name   string
+		ctx    context.Context
+		method protoreflect.Name
+		want   time.Duration
+	}{
+		{"annotated", context.Background(), "Report", 30 * time.Second},
+		{"not annotated", context.Background(), "Ping", time.Second},
+		{"client deadline", clientDeadline, "Report", time.Second},
+	} {
+		if got := b.callTimeout(tt.ctx, methods.ByName(tt.method)); got != tt.want {
+			t.Errorf("%s timeout = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDeadlineMarginShortensBackendDeadline(t *testing.T) {
+	be := startBackend(t)
+	remaining := make(chan time.Duration, 1)
+	be.onUnary(func(ctx context.Context, req *dynamicpb.Message) (proto.Message, error) {
+		dl, ok := ctx.Deadline()
+		if !ok {
+			return nil, status.Error(codes.Internal, "no deadline")
+		}
+		remaining <- time.Until(dl)
+		return req, nil
+	})
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, DeadlineMargin: 500 * time.Millisecond})
+
+	req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/Echo", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Grpc-Timeout", "2S")
+	if rec := serve(b.Handler(), req); rec.Code != 200 {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+	if d := <-remaining; d > 1500*time.Millisecond || d < time.Second {
+		t.Fatalf("backend deadline %v away, want the 2s client deadline less the 500ms margin", d)
+	}
+}
+
+func TestClientResetCancelsBackendCall(t *testing.T) {
+	entered, canceled := make(chan string, 1), make(chan string, 1)
+	wait := func(ctx context.Context, method string) {
+		entered <- method
+		<-ctx.Done()
+		canceled <- method
+	}
+	be := startBackend(t, grpc.ChainStreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if info.FullMethod == "/test.v1.Echo/ServerStream" {
+			wait(ss.Context(), info.FullMethod)
+			return ss.Context().Err()
+		}
+		return handler(srv, ss)
+	}))
+	be.onUnary(func(ctx context.Context, req *dynamicpb.Message) (proto.Message, error) {
+		if method, _ := grpc.Method(ctx); method == "/test.v1.Echo/Echo" {
+			wait(ctx, method)
+			return nil, ctx.Err()
+		}
+		return req, nil
+	})
+	b := newTestBridge(t, Config{GRPCAddr: be.addr})
+	srv := httptest.NewUnstartedServer(b.Handler())
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	resp, err := srv.Client().Post(srv.URL+"/test.v1.Echo/OldEcho", "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 || resp.ProtoMajor != 2 {
+		t.Fatalf("got %d over HTTP/%d, want 200 over HTTP/2", resp.StatusCode, resp.ProtoMajor)
+	}
+
+	for _, method := range []string{"/test.v1.Echo/Echo", "/test.v1.Echo/ServerStream"} {
+		ctx, cancel := context.WithCancel(context.Background())
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL+method, strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			if resp, err := srv.Client().Do(req); err == nil {
+				resp.Body.Close()
+			}
+		}()
+		if got := <-entered; got != method {
+			t.Fatalf("backend entered %s, want %s", got, method)
+		}
+		// Cancelling a request makes the HTTP/2 client reset its stream
+		cancel()
+		select {
+		case <-canceled:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: backend call wasn't cancelled after the client reset its stream", method)
+		}
+		<-done
+	}
+}
@@ -0,0 +1,88 @@
+package bridge
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// callTiming records where time was spent serving a call, which backend
+// address served it and the headers the backend sent.
+type callTiming struct {
+	// resolve is the time spent finding the method's descriptor
+	resolve time.Duration
+	// backend is the time the gRPC call to the backend was in flight.
+	backend time.Duration
+	// marshal is the time spent rendering the response as JSON
+	marshal time.Duration
+	// peer is filled in by the grpc.Peer call option; with retries it is
+	// the address of the last attempt.
+	peer peer.Peer
+	// header and trailer are filled in by the grpc.Header and
+	// grpc.Trailer call options of unary calls
+	header  metadata.MD
+	trailer metadata.MD
+	// empty is whether the unary response had no populated fields, that
+	// is a zero-length encoding
+	empty bool
+}
+
+func (t *callTiming) addBackend(start time.Time) {
+	t.backend += time.Since(start)
+}
+
+func (t *callTiming) addMarshal(start time.Time) {
+	t.marshal += time.Since(start)
+}
+
+// timingBlock is the "_timing" an envelope reports with X-Bridge-Flags:
+// timing, in milliseconds.
+type timingBlock struct {
+	ResolveMs json.Number `json:"resolve_ms"`
+	BackendMs json.Number `json:"backend_ms"`
+	MarshalMs json.Number `json:"marshal_ms"`
+}
+
+func (t *callTiming) block() *timingBlock {
+	return &timingBlock{
+		ResolveMs: json.Number(formatMillis(t.resolve)),
+		BackendMs: json.Number(formatMillis(t.backend)),
+		MarshalMs: json.Number(formatMillis(t.marshal)),
+	}
+}
+
+// setHeaders reports the timing on the response, so clients can tell
+// bridge overhead from backend latency, and with debug the backend peer.
+func (t *callTiming) setHeaders(h http.Header, debug bool) {
+	if t.backend > 0 {
+		h.Set("X-Backend-Duration-Ms", formatMillis(t.backend))
+	}
+	if debug && t.peer.Addr != nil {
+		h.Set("X-Backend-Peer", t.peer.Addr.String())
+	}
+}
+
+// peerOption returns the call option that records the backend address.
+func (t *callTiming) peerOption() grpc.CallOption {
+	return grpc.Peer(&t.peer)
+}
+
+// headerOption returns the call option that records the backend headers.
+func (t *callTiming) headerOption() grpc.CallOption {
+	return grpc.Header(&t.header)
+}
+
+// trailerOption returns the call option that records the backend
+// trailers.
+func (t *callTiming) trailerOption() grpc.CallOption {
+	return grpc.Trailer(&t.trailer)
+}
+
+func formatMillis(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64)
+}
@@ -0,0 +1,61 @@
+package bridge
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mizrahidaniel/grpc-http-bridge/bridgetest"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestBackendDurationHeader(t *testing.T) {
+	be := startBackend(t)
+	be.onUnary(func(_ context.Context, req *dynamicpb.Message) (proto.Message, error) {
+		time.Sleep(50 * time.Millisecond)
+		return req, nil
+	})
+	b := newTestBridge(t, Config{GRPCAddr: be.addr})
+
+	start := time.Now()
+	rec := post(b.Handler(), "/test.v1.Echo/Echo", `{}`)
+	elapsed := time.Since(start)
+	if rec.Code != 200 {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+	ms, err := strconv.ParseFloat(rec.Header().Get("X-Backend-Duration-Ms"), 64)
+	if err != nil {
+		t.Fatalf("X-Backend-Duration-Ms %q: %v", rec.Header().Get("X-Backend-Duration-Ms"), err)
+	}
+	if ms < 50 || ms > float64(elapsed.Milliseconds())+1 {
+		t.Fatalf("X-Backend-Duration-Ms %v, want between 50 and the %v the request took", ms, elapsed)
+	}
+}
+
+func TestBackendPeerHeader(t *testing.T) {
+	v1, v2 := bridgetest.Start(t), bridgetest.Start(t)
+	cfg := Config{
+		GRPCAddr: v1.Addr,
+		Services: map[string]ServiceConfig{
+			bridgetest.Service: {Versions: map[string]string{"v1": v1.Addr, "v2": v2.Addr}},
+		},
+	}
+	h := newTestBridge(t, cfg).Handler()
+	if rec := post(h, "/v2/bridgetest.v1.Greeter/SayHello", `{}`); rec.Header().Get("X-Backend-Peer") != "" {
+		t.Fatal("X-Backend-Peer set without debug headers")
+	}
+
+	cfg.DebugHeaders = true
+	h = newTestBridge(t, cfg).Handler()
+	for path, want := range map[string]string{
+		"/v1/bridgetest.v1.Greeter/SayHello": v1.Addr,
+		"/v2/bridgetest.v1.Greeter/SayHello": v2.Addr,
+	} {
+		rec := post(h, path, `{}`)
+		if got := rec.Header().Get("X-Backend-Peer"); rec.Code != 200 || got != want {
+			t.Errorf("%s: got %d with X-Backend-Peer %q, want %s", path, rec.Code, got, want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package bridge
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// tracerName is the instrumentation name of the bridge's spans.
+const tracerName = "github.com/mizrahidaniel/grpc-http-bridge/bridge"
+
+// WithTracing records each streaming call to a backend as an OpenTelemetry
+// client span from tp, with a "message" event per message sent or
+// received and the call's gRPC status. A span is the child of the
+// request's span when the bridge's handler is wrapped by OpenTelemetry
+// instrumentation, and otherwise continues the trace of the request's W3C
+// traceparent header, if any.
+func WithTracing(tp trace.TracerProvider) Option {
+	return func(o *options) {
+		o.streamObservers = append(o.streamObservers, tracingObserver{tracer: tp.Tracer(tracerName)})
+		o.tracing = true
+	}
+}
+
+// extractTraceContext continues the trace of the request's traceparent
+// header, unless the request's context already has a span.
+func extractTraceContext(next http.Handler) http.Handler {
+	propagator := propagation.TraceContext{}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !trace.SpanContextFromContext(r.Context()).IsValid() {
+			r = r.WithContext(propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header)))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// tracingObserver is the StreamObserver of WithTracing. Spans follow the
+// OpenTelemetry semantic conventions for gRPC clients.
+type tracingObserver struct {
+	tracer trace.Tracer
+}
+
+func (o tracingObserver) StreamOpened(ctx context.Context, fullMethod string) StreamEvents {
+	name := strings.TrimPrefix(fullMethod, "/")
+	service, method, _ := strings.Cut(name, "/")
+	_, span := o.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.service", service),
+			attribute.String("rpc.method", method),
+		))
+	return &tracedStream{span: span}
+}
+
+// tracedStream records the events of one stream on its span.
+type tracedStream struct {
+	span           trace.Span
+	sent, received atomic.Int64
+}
+
+func (s *tracedStream) MessageSent(msg proto.Message) {
+	s.message("SENT", s.sent.Add(1), msg)
+}
+
+func (s *tracedStream) MessageReceived(msg proto.Message) {
+	s.message("RECEIVED", s.received.Add(1), msg)
+}
+
+func (s *tracedStream) message(typ string, id int64, msg proto.Message) {
+	s.span.AddEvent("message", trace.WithAttributes(
+		attribute.String("message.type", typ),
+		attribute.Int64("message.id", id),
+		attribute.Int("message.uncompressed_size", proto.Size(msg)),
+	))
+}
+
+func (s *tracedStream) StreamClosed(err error) {
+	st := status.Convert(err)
+	s.span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(st.Code())))
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(otelcodes.Error, st.Message())
+	}
+	s.span.End()
+}
@@ -0,0 +1,112 @@
+package bridge
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// spanAttr returns the value of the attribute key of span, if set.
+func spanAttr(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestTracingRecordsStreamSpans(t *testing.T) {
+	be := startBackend(t)
+	spans := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	b := newTestBridge(t, Config{GRPCAddr: be.addr}, WithTracing(tp))
+	h := b.Handler()
+
+	const traceID, parentID = "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+	req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/ServerStream", strings.NewReader(`{"message": "hi", "count": 2}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentID+"-01")
+	if rec := serve(h, req); rec.Code != 200 {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+
+	if started, ended := len(spans.Started()), len(spans.Ended()); started != 1 || ended != 1 {
+		t.Fatalf("%d spans started and %d ended, want 1 of each", started, ended)
+	}
+	span := spans.Ended()[0]
+	if span.Name() != "test.v1.Echo/ServerStream" || span.SpanKind() != trace.SpanKindClient {
+		t.Fatalf("span %q of kind %s, want a client span test.v1.Echo/ServerStream", span.Name(), span.SpanKind())
+	}
+	if got := span.Parent(); got.TraceID().String() != traceID || got.SpanID().String() != parentID || !got.IsRemote() {
+		t.Fatalf("span parent %s/%s, want the traceparent's %s/%s", got.TraceID(), got.SpanID(), traceID, parentID)
+	}
+	var events []string
+	for _, ev := range span.Events() {
+		var typ, id string
+		for _, kv := range ev.Attributes {
+			switch kv.Key {
+			case "message.type":
+				typ = kv.Value.AsString()
+			case "message.id":
+				id = kv.Value.Emit()
+			}
+		}
+		events = append(events, ev.Name+" "+typ+" "+id)
+	}
+	if want := []string{"message SENT 1", "message RECEIVED 1", "message RECEIVED 2"}; !reflect.DeepEqual(events, want) {
+		t.Fatalf("span events %q, want %q", events, want)
+	}
+	if code, _ := spanAttr(span, "rpc.grpc.status_code"); code.AsInt64() != 0 || span.Status().Code != otelcodes.Unset {
+		t.Fatalf("span ended with status code %v and status %v, want 0 and unset", code.Emit(), span.Status())
+	}
+
+	be.failStreams(status.Error(codes.Aborted, "gone"))
+	rec := post(h, "/test.v1.Echo/ServerStream", `{"message": "hi", "count": 1}`)
+	if rec.Code != 200 || len(spans.Ended()) != 2 {
+		t.Fatalf("failed stream: got %d with %d spans ended", rec.Code, len(spans.Ended()))
+	}
+	span = spans.Ended()[1]
+	if code, _ := spanAttr(span, "rpc.grpc.status_code"); code.AsInt64() != int64(codes.Aborted) || span.Status().Code != otelcodes.Error || span.Status().Description != "gone" {
+		t.Fatalf("failed stream: span ended with status code %v and status %v, want Aborted and an error", code.Emit(), span.Status())
+	}
+	if span.Parent().IsValid() {
+		t.Fatalf("a request without a trace got a span under %s", span.Parent().SpanID())
+	}
+}
+
+func TestTracingSpanIsChildOfRequestSpan(t *testing.T) {
+	be := startBackend(t)
+	spans := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	b := newTestBridge(t, Config{GRPCAddr: be.addr}, WithTracing(tp))
+
+	// As otelhttp would have started it, which wins over the header
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "POST")
+	req := httptest.NewRequest(http.MethodPost, "/test.v1.Echo/ServerStream", strings.NewReader(`{"count": 1}`)).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if rec := serve(b.Handler(), req); rec.Code != 200 {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+	parent.End()
+
+	ended := spans.Ended()
+	if len(ended) != 2 {
+		t.Fatalf("%d spans ended, want the stream's and the request's", len(ended))
+	}
+	if got, want := ended[0].Parent().SpanID(), parent.SpanContext().SpanID(); got != want {
+		t.Fatalf("stream span's parent %s, want the request span %s", got, want)
+	}
+}
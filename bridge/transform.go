@@ -0,0 +1,147 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/itchyny/gojq"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// transform is a compiled jq expression that reshapes a JSON payload.
+type transform struct {
+	src  string
+	code *gojq.Code
+}
+
+func compileTransform(src string) (*transform, error) {
+	q, err := gojq.Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	code, err := gojq.Compile(q)
+	if err != nil {
+		return nil, err
+	}
+	return &transform{src: src, code: code}, nil
+}
+
+// apply runs the expression on data, which must produce exactly one value.
+func (t *transform) apply(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var in interface{}
+	if err := dec.Decode(&in); err != nil {
+		return nil, err
+	}
+
+	iter := t.code.Run(in)
+	out, ok := iter.Next()
+	if !ok {
+		return nil, fmt.Errorf("%q produced no output", t.src)
+	}
+	if err, isErr := out.(error); isErr {
+		return nil, err
+	}
+	if _, more := iter.Next(); more {
+		return nil, fmt.Errorf("%q produced more than one output", t.src)
+	}
+	return json.Marshal(out)
+}
+
+// methodTransforms holds the transforms configured for one method.
+type methodTransforms struct {
+	request    *transform
+	response   *transform
+	envelope   *envelope
+	errorField *errorField
+}
+
+// compileTransforms compiles the transforms in methods, keyed by
+// "{service}/{method}".
+func compileTransforms(methods map[string]MethodConfig) (map[string]*methodTransforms, error) {
+	out := make(map[string]*methodTransforms)
+	for name, mc := range methods {
+		var mt methodTransforms
+		var err error
+		if mc.RequestTransform != "" {
+			if mt.request, err = compileTransform(mc.RequestTransform); err != nil {
+				return nil, fmt.Errorf("invalid request_transform for %s: %w", name, err)
+			}
+		}
+		if mc.ResponseTransform != "" {
+			if mt.response, err = compileTransform(mc.ResponseTransform); err != nil {
+				return nil, fmt.Errorf("invalid response_transform for %s: %w", name, err)
+			}
+		}
+		if mc.Envelope != nil {
+			if mt.envelope, err = newEnvelope(mc.Envelope); err != nil {
+				return nil, fmt.Errorf("invalid envelope for %s: %w", name, err)
+			}
+		}
+		if mc.ErrorField != nil {
+			if mt.errorField, err = newErrorField(mc.ErrorField); err != nil {
+				return nil, fmt.Errorf("invalid error_field for %s: %w", name, err)
+			}
+		}
+		if mt.request != nil || mt.response != nil || mt.envelope != nil || mt.errorField != nil {
+			out[name] = &mt
+		}
+	}
+	return out, nil
+}
+
+// transformRequest applies the request transform configured for fullMethod,
+// if any. Failures are InvalidArgument, since they're caused by the body.
+func (b *Bridge) transformRequest(fullMethod string, body []byte) ([]byte, error) {
+	mt := b.transforms[fullMethod[1:]]
+	if mt == nil || mt.request == nil {
+		return body, nil
+	}
+	if b.devMode {
+		body = relaxJSON(body)
+	}
+	out, err := mt.request.apply(body)
+	if err != nil {
+		return nil, withCause(status.Errorf(codes.InvalidArgument, "request transform failed: %v", err), err)
+	}
+	return out, nil
+}
+
+// transformResponse applies the error field check, envelope and response
+// transform configured for fullMethod, if any. Failures are Internal,
+// since the backend's response and the configuration are both outside the
+// client's control. If timing is set, the envelope reports it.
+func (b *Bridge) transformResponse(fullMethod string, resp []byte, timing *callTiming) ([]byte, error) {
+	mt := b.transforms[fullMethod[1:]]
+	if mt == nil {
+		return resp, nil
+	}
+	if mt.errorField != nil {
+		if err := mt.errorField.check(fullMethod[1:], resp); err != nil {
+			return nil, err
+		}
+	}
+	if mt.response == nil && mt.envelope == nil {
+		return resp, nil
+	}
+	out := resp
+	var err error
+	if mt.envelope != nil {
+		if out, err = mt.envelope.apply(out, timing); err != nil {
+			return nil, withCause(status.Errorf(codes.Internal, "response envelope failed: %v", err), err)
+		}
+	}
+	if mt.response != nil {
+		if out, err = mt.response.apply(out); err != nil {
+			return nil, withCause(status.Errorf(codes.Internal, "response transform failed: %v", err), err)
+		}
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, out, "", "  "); err != nil {
+		return nil, withCause(status.Errorf(codes.Internal, "response transform failed: %v", err), err)
+	}
+	return indented.Bytes(), nil
+}
@@ -0,0 +1,48 @@
+package bridge
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRequestTransformRenamesField(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{
+		GRPCAddr: be.addr,
+		Methods: map[string]MethodConfig{
+			"test.v1.Echo/Echo": {RequestTransform: `{message: .text}`},
+		},
+	})
+	h := b.Handler()
+
+	rec := post(h, "/test.v1.Echo/Echo", `{"text": "hi"}`)
+	var resp struct{ Message string }
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); rec.Code != 200 || err != nil || resp.Message != "hi" {
+		t.Fatalf("got %d %s, want text renamed to message", rec.Code, rec.Body)
+	}
+
+	calls := be.Calls()
+	if rec := post(h, "/test.v1.Echo/Echo", `{"text": "hi"`); rec.Code != 400 || be.Calls() != calls {
+		t.Fatalf("untransformable body: got %d, want 400 without a backend call", rec.Code)
+	}
+}
+
+func TestResponseTransformFlattensNestedField(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{
+		GRPCAddr: be.addr,
+		Methods: map[string]MethodConfig{
+			"test.v1.Echo/Echo": {ResponseTransform: `{message, city: .data.address.city}`},
+		},
+	})
+
+	rec := post(b.Handler(), "/test.v1.Echo/Echo", `{"message": "hi", "data": {"address": {"city": "London"}}}`)
+	var resp map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); rec.Code != 200 || err != nil {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+	if want := map[string]interface{}{"message": "hi", "city": "London"}; !reflect.DeepEqual(resp, want) {
+		t.Fatalf("got %v, want %v", resp, want)
+	}
+}
@@ -0,0 +1,135 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// typeLookupTimeout bounds the reflection request made to resolve a type
+// named by a google.protobuf.Any.
+const typeLookupTimeout = 5 * time.Second
+
+// typeResolver resolves the message types google.protobuf.Any values name,
+// for protojson. Types linked into the binary are found first, then types
+// in the descriptor set, then types the backends expose via reflection.
+type typeResolver struct {
+	b *Bridge
+}
+
+func (t *typeResolver) FindMessageByName(name protoreflect.FullName) (protoreflect.MessageType, error) {
+	if mt, err := protoregistry.GlobalTypes.FindMessageByName(name); err == nil {
+		return mt, nil
+	}
+	if files := t.b.descriptorFiles; files != nil {
+		if desc, err := files.FindDescriptorByName(name); err == nil {
+			if md, ok := desc.(protoreflect.MessageDescriptor); ok {
+				return dynamicpb.NewMessageType(md), nil
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), typeLookupTimeout)
+	defer cancel()
+	for _, be := range t.b.backendList() {
+		if md, err := be.resolver.FindMessage(ctx, string(name)); err == nil {
+			return dynamicpb.NewMessageType(md), nil
+		}
+	}
+	return nil, protoregistry.NotFound
+}
+
+func (t *typeResolver) FindMessageByURL(url string) (protoreflect.MessageType, error) {
+	return t.FindMessageByName(protoreflect.FullName(url[strings.LastIndexByte(url, '/')+1:]))
+}
+
+func (t *typeResolver) FindExtensionByName(field protoreflect.FullName) (protoreflect.ExtensionType, error) {
+	return protoregistry.GlobalTypes.FindExtensionByName(field)
+}
+
+func (t *typeResolver) FindExtensionByNumber(message protoreflect.FullName, field protoreflect.FieldNumber) (protoreflect.ExtensionType, error) {
+	return protoregistry.GlobalTypes.FindExtensionByNumber(message, field)
+}
+
+// marshalError explains a failure to render msg as JSON. protojson's own
+// message for an Any of an unknown type is cryptic, so that case names the
+// type and how to make it known.
+func (t *typeResolver) marshalError(msg protoreflect.Message, err error) error {
+	if url := t.unresolvedAny(msg); url != "" {
+		return status.Errorf(codes.Internal,
+			"response contains a google.protobuf.Any of type %q, which the bridge cannot resolve; enable reflection on a backend that defines it or load its schema with --descriptor-set", url)
+	}
+	return withCause(status.Errorf(codes.Internal, "failed to marshal response: %v", err), err)
+}
+
+// unresolvedAny returns the type URL of the first google.protobuf.Any in
+// msg whose type can't be resolved, or "" if there is none.
+func (t *typeResolver) unresolvedAny(msg protoreflect.Message) string {
+	if msg.Descriptor().FullName() == "google.protobuf.Any" {
+		fields := msg.Descriptor().Fields()
+		url := msg.Get(fields.ByName("type_url")).String()
+		if _, err := t.FindMessageByURL(url); err != nil {
+			return url
+		}
+		return ""
+	}
+
+	var found string
+	msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsMap():
+			if fd.MapValue().Message() != nil {
+				v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+					found = t.unresolvedAny(mv.Message())
+					return found == ""
+				})
+			}
+		case fd.IsList():
+			if fd.Message() != nil {
+				for i := 0; i < v.List().Len() && found == ""; i++ {
+					found = t.unresolvedAny(v.List().Get(i).Message())
+				}
+			}
+		case fd.Message() != nil:
+			found = t.unresolvedAny(v.Message())
+		}
+		return found == ""
+	})
+	return found
+}
+
+// FindMessage returns the descriptor for the message type name, asking the
+// backend for the file that defines it on a cache miss.
+func (r *resolver) FindMessage(ctx context.Context, name string) (protoreflect.MessageDescriptor, error) {
+	r.mu.RLock()
+	md, ok := r.messages[name]
+	r.mu.RUnlock()
+	if ok {
+		return md, nil
+	}
+
+	files, err := r.fileContainingSymbol(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "message %q not found", name)
+	}
+	md, ok = desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a message", name)
+	}
+
+	r.mu.Lock()
+	r.messages[name] = md
+	r.mu.Unlock()
+	return md, nil
+}
@@ -0,0 +1,28 @@
+package bridge
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestUnknownAnyTypeExplainsItself(t *testing.T) {
+	be := startBackend(t)
+	const url = "type.googleapis.com/unknown.v1.Thing"
+	be.onUnary(func(ctx context.Context, req *dynamicpb.Message) (proto.Message, error) {
+		extra := &anypb.Any{TypeUrl: url, Value: []byte{0x08, 0x01}}
+		req.Set(echoRequest.Fields().ByName("extra"), protoreflect.ValueOfMessage(extra.ProtoReflect()))
+		return req, nil
+	})
+	b := newTestBridge(t, Config{GRPCAddr: be.addr})
+
+	rec := post(b.Handler(), "/test.v1.Echo/Echo", `{}`)
+	if rec.Code != 500 || !strings.Contains(rec.Body.String(), url) || !strings.Contains(rec.Body.String(), "--descriptor-set") {
+		t.Fatalf("got %d %s, want a 500 naming %s and how to resolve it", rec.Code, rec.Body, url)
+	}
+}
@@ -0,0 +1,137 @@
+package bridge
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// wellKnownTS maps well-known types to the TypeScript types of their JSON
+// forms.
+var wellKnownTS = map[protoreflect.FullName]string{
+	"google.protobuf.Any":         `{ "@type": string; [key: string]: unknown }`,
+	"google.protobuf.Duration":    "string",
+	"google.protobuf.Empty":       "Record<string, never>",
+	"google.protobuf.FieldMask":   "string",
+	"google.protobuf.ListValue":   "unknown[]",
+	"google.protobuf.Struct":      "{ [key: string]: unknown }",
+	"google.protobuf.Timestamp":   "string",
+	"google.protobuf.Value":       "unknown",
+	"google.protobuf.BoolValue":   "boolean | null",
+	"google.protobuf.BytesValue":  "string | null",
+	"google.protobuf.DoubleValue": "number | null",
+	"google.protobuf.FloatValue":  "number | null",
+	"google.protobuf.Int32Value":  "number | null",
+	"google.protobuf.Int64Value":  "string | null",
+	"google.protobuf.StringValue": "string | null",
+	"google.protobuf.UInt32Value": "number | null",
+	"google.protobuf.UInt64Value": "string | null",
+}
+
+// handleMethodTypes serves GET /{service}/{method}/types, TypeScript
+// definitions of the JSON forms of a method's request and response and
+// every message and enum they use.
+func (b *Bridge) handleMethodTypes(w http.ResponseWriter, r *http.Request) {
+	md, ok := b.schemaMethod(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/typescript; charset=utf-8")
+	fmt.Fprintf(w, "// Types for %s, generated by grpc-http-bridge.\n", methodPath(md))
+	w.Write([]byte(typeScriptDefinitions(md.Input(), md.Output())))
+}
+
+// typeScriptDefinitions renders an interface for each of roots and the
+// messages they reference, and a union type for each enum. Fields are
+// optional since responses may leave out default values.
+func typeScriptDefinitions(roots ...protoreflect.MessageDescriptor) string {
+	var sb strings.Builder
+	seen := make(map[protoreflect.FullName]bool)
+	var queue []protoreflect.Descriptor
+	add := func(d protoreflect.Descriptor) {
+		if _, wkt := wellKnownTS[d.FullName()]; wkt || seen[d.FullName()] {
+			return
+		}
+		seen[d.FullName()] = true
+		queue = append(queue, d)
+	}
+	for _, root := range roots {
+		add(root)
+	}
+
+	for len(queue) > 0 {
+		d := queue[0]
+		queue = queue[1:]
+		sb.WriteByte('\n')
+
+		if ed, ok := d.(protoreflect.EnumDescriptor); ok {
+			values := ed.Values()
+			names := make([]string, values.Len())
+			for i := range names {
+				names[i] = fmt.Sprintf("%q", values.Get(i).Name())
+			}
+			fmt.Fprintf(&sb, "export type %s = %s;\n", tsName(ed), strings.Join(names, " | "))
+			continue
+		}
+
+		msg := d.(protoreflect.MessageDescriptor)
+		fmt.Fprintf(&sb, "export interface %s {\n", tsName(msg))
+		fields := msg.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			fd := fields.Get(i)
+			var typ string
+			if fd.IsMap() {
+				typ = fmt.Sprintf("{ [key: string]: %s }", tsFieldType(fd.MapValue(), add))
+			} else {
+				typ = tsFieldType(fd, add)
+				if fd.IsList() {
+					if strings.ContainsAny(typ, " |") {
+						typ = "(" + typ + ")"
+					}
+					typ += "[]"
+				}
+			}
+			fmt.Fprintf(&sb, "  %s?: %s;\n", fd.JSONName(), typ)
+		}
+		sb.WriteString("}\n")
+	}
+	return sb.String()
+}
+
+// tsFieldType returns the TypeScript type of one value of fd, passing any
+// message or enum it names to add.
+func tsFieldType(fd protoreflect.FieldDescriptor, add func(protoreflect.Descriptor)) string {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		if ts, ok := wellKnownTS[fd.Message().FullName()]; ok {
+			return ts
+		}
+		add(fd.Message())
+		return tsName(fd.Message())
+	case protoreflect.EnumKind:
+		if fd.Enum().FullName() == "google.protobuf.NullValue" {
+			return "null"
+		}
+		add(fd.Enum())
+		return tsName(fd.Enum())
+	case protoreflect.BoolKind:
+		return "boolean"
+	case protoreflect.StringKind, protoreflect.BytesKind:
+		return "string"
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		// protojson writes 64-bit integers as strings
+		return "string"
+	default:
+		return "number"
+	}
+}
+
+// tsName names d after its path within its package, e.g. Outer_Inner for
+// a message Inner nested in Outer.
+func tsName(d protoreflect.Descriptor) string {
+	name := strings.TrimPrefix(string(d.FullName()), string(d.ParentFile().Package())+".")
+	return strings.ReplaceAll(name, ".", "_")
+}
@@ -0,0 +1,94 @@
+package bridge
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestTypeScriptDefinitions(t *testing.T) {
+	s := proto.String
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label, typeName string) *descriptorpb.FieldDescriptorProto {
+		fd := &descriptorpb.FieldDescriptorProto{Name: s(name), JsonName: s(name), Number: proto.Int32(number), Type: typ.Enum(), Label: label.Enum()}
+		if typeName != "" {
+			fd.TypeName = s(typeName)
+		}
+		return fd
+	}
+	optional, repeated := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL, descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+	str, msg := descriptorpb.FieldDescriptorProto_TYPE_STRING, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    s("users/v1/users.proto"),
+		Package: s("users.v1"),
+		Syntax:  s("proto3"),
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: s("Role"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: s("ROLE_UNSPECIFIED"), Number: proto.Int32(0)},
+				{Name: s("ADMIN"), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: s("GetUserRequest"), Field: []*descriptorpb.FieldDescriptorProto{field("id", 1, str, optional, "")}},
+			{
+				Name:  s("GetUserResponse"),
+				Field: []*descriptorpb.FieldDescriptorProto{field("user", 1, msg, optional, ".users.v1.GetUserResponse.User")},
+				NestedType: []*descriptorpb.DescriptorProto{{
+					Name: s("User"),
+					Field: []*descriptorpb.FieldDescriptorProto{
+						field("name", 1, str, optional, ""),
+						field("roles", 2, descriptorpb.FieldDescriptorProto_TYPE_ENUM, repeated, ".users.v1.Role"),
+						field("scores", 3, msg, repeated, ".users.v1.GetUserResponse.User.ScoresEntry"),
+					},
+					NestedType: []*descriptorpb.DescriptorProto{{
+						Name: s("ScoresEntry"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							field("key", 1, str, optional, ""),
+							field("value", 2, descriptorpb.FieldDescriptorProto_TYPE_INT64, optional, ""),
+						},
+						Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+					}},
+				}},
+			},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := typeScriptDefinitions(file.Messages().ByName("GetUserRequest"), file.Messages().ByName("GetUserResponse"))
+	want := `
+export interface GetUserRequest {
+  id?: string;
+}
+
+export interface GetUserResponse {
+  user?: GetUserResponse_User;
+}
+
+export interface GetUserResponse_User {
+  name?: string;
+  roles?: Role[];
+  scores?: { [key: string]: string };
+}
+
+export type Role = "ROLE_UNSPECIFIED" | "ADMIN";
+`
+	if got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestMethodTypesEndpoint(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr})
+	rec := serve(b.Handler(), httptest.NewRequest(http.MethodGet, "/test.v1.Echo/Echo/types", nil))
+	if rec.Code != 200 || !strings.Contains(rec.Body.String(), "export interface EchoRequest {") {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,148 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+type fieldViolation = errdetails.BadRequest_FieldViolation
+
+// badRequest returns an InvalidArgument error carrying violations as a
+// google.rpc.BadRequest detail.
+func badRequest(violations []*fieldViolation, format string, args ...interface{}) error {
+	st := status.Newf(codes.InvalidArgument, format, args...)
+	if withDetails, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// fieldViolations checks a JSON request body against desc and reports every
+// field that protojson would reject: unknown names and values of the wrong
+// JSON type. protojson itself stops at the first problem, so this is run
+// after it fails to give clients the full list.
+func fieldViolations(body []byte, desc protoreflect.MessageDescriptor) []*fieldViolation {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil
+	}
+	var out []*fieldViolation
+	checkMessage(v, desc, "", &out)
+	return out
+}
+
+func checkMessage(v interface{}, desc protoreflect.MessageDescriptor, path string, out *[]*fieldViolation) {
+	// Well-known types have special JSON forms that protojson validates
+	if strings.HasPrefix(string(desc.FullName()), "google.protobuf.") {
+		return
+	}
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		addViolation(out, path, "must be an object")
+		return
+	}
+
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fieldPath := joinFieldPath(path, key)
+		fd := findJSONField(desc, key)
+		if fd == nil {
+			addViolation(out, fieldPath, "unknown field")
+			continue
+		}
+		val := obj[key]
+		if val == nil {
+			continue
+		}
+
+		switch {
+		case fd.IsMap():
+			m, ok := val.(map[string]interface{})
+			if !ok {
+				addViolation(out, fieldPath, "must be an object")
+				continue
+			}
+			mapKeys := make([]string, 0, len(m))
+			for k := range m {
+				mapKeys = append(mapKeys, k)
+			}
+			sort.Strings(mapKeys)
+			for _, k := range mapKeys {
+				checkValue(m[k], fd.MapValue(), fieldPath+"."+k, out)
+			}
+		case fd.IsList():
+			items, ok := val.([]interface{})
+			if !ok {
+				addViolation(out, fieldPath, "must be an array")
+				continue
+			}
+			for i, item := range items {
+				checkValue(item, fd, fmt.Sprintf("%s[%d]", fieldPath, i), out)
+			}
+		default:
+			checkValue(val, fd, fieldPath, out)
+		}
+	}
+}
+
+// checkValue checks a single (non-repeated) value of fd's kind.
+func checkValue(v interface{}, fd protoreflect.FieldDescriptor, path string, out *[]*fieldViolation) {
+	if v == nil {
+		return
+	}
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		checkMessage(v, fd.Message(), path, out)
+	case protoreflect.BoolKind:
+		if _, ok := v.(bool); !ok {
+			addViolation(out, path, "must be a boolean")
+		}
+	case protoreflect.StringKind:
+		if _, ok := v.(string); !ok {
+			addViolation(out, path, "must be a string")
+		}
+	case protoreflect.BytesKind:
+		if _, ok := v.(string); !ok {
+			addViolation(out, path, "must be a base64 string")
+		}
+	case protoreflect.EnumKind:
+		switch v.(type) {
+		case string, json.Number:
+		default:
+			addViolation(out, path, "must be an enum name or number")
+		}
+	default:
+		// Numbers may also be quoted, as protojson emits 64-bit integers
+		switch v.(type) {
+		case string, json.Number:
+		default:
+			addViolation(out, path, "must be a number")
+		}
+	}
+}
+
+func addViolation(out *[]*fieldViolation, field, description string) {
+	*out = append(*out, &fieldViolation{Field: field, Description: description})
+}
+
+func joinFieldPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
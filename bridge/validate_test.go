@@ -0,0 +1,41 @@
+package bridge
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestValidationErrorListsEveryFieldViolation(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr})
+
+	rec := post(b.Handler(), "/test.v1.Echo/Echo", `{"message": 5, "count": true, "colour": "red"}`)
+	if rec.Code != 400 {
+		t.Fatalf("got %d %s, want 400", rec.Code, rec.Body)
+	}
+	var resp struct {
+		Details []struct {
+			Type            string `json:"@type"`
+			FieldViolations []struct {
+				Field string
+			} `json:"field_violations"`
+		}
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Details) != 1 {
+		t.Fatalf("details %s, want one BadRequest", rec.Body)
+	}
+	var fields []string
+	for _, v := range resp.Details[0].FieldViolations {
+		fields = append(fields, v.Field)
+	}
+	if want := []string{"colour", "count", "message"}; !reflect.DeepEqual(fields, want) {
+		t.Fatalf("field_violations for %v, want %v", fields, want)
+	}
+	if be.Calls() != 0 {
+		t.Fatal("an invalid request reached the backend")
+	}
+}
@@ -0,0 +1,50 @@
+package bridge
+
+import (
+	"net/http"
+	"runtime"
+	"runtime/debug"
+
+	"google.golang.org/grpc"
+)
+
+// Build information, set at link time:
+//
+//	go build -ldflags "-X github.com/mizrahidaniel/grpc-http-bridge/bridge.Version=v1.2.0 \
+//	  -X github.com/mizrahidaniel/grpc-http-bridge/bridge.Commit=$(git rev-parse HEAD) \
+//	  -X github.com/mizrahidaniel/grpc-http-bridge/bridge.BuildDate=$(date -u +%FT%TZ)"
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildDate = ""
+)
+
+// handleVersion serves GET /version.
+func (b *Bridge) handleVersion(w http.ResponseWriter, r *http.Request) {
+	commit := Commit
+	protobufVersion := ""
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == "google.golang.org/protobuf" {
+				protobufVersion = dep.Version
+			}
+		}
+		// Fall back to the VCS stamp go build records for module checkouts
+		if commit == "" {
+			for _, s := range info.Settings {
+				if s.Key == "vcs.revision" {
+					commit = s.Value
+				}
+			}
+		}
+	}
+
+	b.encodeJSON(w, http.StatusOK, map[string]interface{}{
+		"version":    Version,
+		"commit":     commit,
+		"build_date": BuildDate,
+		"go":         runtime.Version(),
+		"grpc":       grpc.Version,
+		"protobuf":   protobufVersion,
+	})
+}
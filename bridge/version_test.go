@@ -0,0 +1,39 @@
+package bridge
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"google.golang.org/grpc"
+)
+
+func TestVersionReportsBuildInfo(t *testing.T) {
+	version, commit, buildDate := Version, Commit, BuildDate
+	t.Cleanup(func() { Version, Commit, BuildDate = version, commit, buildDate })
+	Version, Commit, BuildDate = "v1.2.0", "0123abc", "2024-05-01T12:00:00Z"
+
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr})
+	rec := serve(b.Handler(), httptest.NewRequest(http.MethodGet, "/version", nil))
+	var got map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); rec.Code != 200 || err != nil {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+	for field, want := range map[string]string{
+		"version":    "v1.2.0",
+		"commit":     "0123abc",
+		"build_date": "2024-05-01T12:00:00Z",
+		"go":         runtime.Version(),
+		"grpc":       grpc.Version,
+	} {
+		if got[field] != want {
+			t.Errorf("%s = %q, want %q", field, got[field], want)
+		}
+	}
+	if _, ok := got["protobuf"]; !ok {
+		t.Error("no protobuf version field")
+	}
+}
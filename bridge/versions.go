@@ -0,0 +1,55 @@
+package bridge
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// splitVersion strips a configured API version prefix, such as /v2, from
+// path, returning the version and the rest of the path.
+func (b *Bridge) splitVersion(path string) (version, rest string) {
+	first, remainder, ok := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if !ok || !b.versions[first] {
+		return "", path
+	}
+	return first, "/" + remainder
+}
+
+// backendForVersion returns the backend serving version of service, or
+// the usual backend for unversioned requests.
+func (b *Bridge) backendForVersion(service, version string) (*backend, error) {
+	if version == "" {
+		return b.backendFor(service), nil
+	}
+	if be, ok := b.routes[service].versions[version]; ok {
+		return be, nil
+	}
+	return nil, status.Errorf(codes.NotFound, "service %q has no version %q", service, version)
+}
+
+// backendForRequest returns the backend serving r's call to service: the
+// one mapped to r's routing header value, if the service routes by header
+// and r sends it, or else the one for version.
+func (b *Bridge) backendForRequest(r *http.Request, service, version string) (*backend, error) {
+	route := b.routes[service]
+	if route.header == "" || version != "" {
+		return b.backendForVersion(service, version)
+	}
+	value := r.Header.Get(route.header)
+	if value == "" {
+		return b.backendFor(service), nil
+	}
+	if be, ok := route.byHeader[value]; ok {
+		return be, nil
+	}
+	values := make([]string, 0, len(route.byHeader))
+	for v := range route.byHeader {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	return nil, status.Errorf(codes.InvalidArgument, "unknown %s %q (want %s)", route.header, value, strings.Join(values, ", "))
+}
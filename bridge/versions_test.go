@@ -0,0 +1,76 @@
+package bridge
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mizrahidaniel/grpc-http-bridge/bridgetest"
+)
+
+func TestVersionPrefixesRouteToTheirBackends(t *testing.T) {
+	v1, v2 := bridgetest.Start(t), bridgetest.Start(t)
+	b := newTestBridge(t, Config{
+		GRPCAddr: v1.Addr,
+		Services: map[string]ServiceConfig{
+			bridgetest.Service: {Versions: map[string]string{"v1": v1.Addr, "v2": v2.Addr}},
+		},
+	})
+	h := b.Handler()
+
+	for _, tt := range []struct {
+		path   string
+		v1, v2 int
+	}{
+		{"/v2/bridgetest.v1.Greeter/SayHello", 0, 1},
+		{"/v1/bridgetest.v1.Greeter/SayHello", 1, 1},
+		{"/v2/bridgetest.v1.Greeter/SayHello", 1, 2},
+	} {
+		if rec := post(h, tt.path, `{"name": "Ada"}`); rec.Code != 200 {
+			t.Fatalf("%s: got %d %s", tt.path, rec.Code, rec.Body)
+		}
+		if v1.Calls() != tt.v1 || v2.Calls() != tt.v2 {
+			t.Fatalf("after %s: calls v1 %d, v2 %d; want %d, %d", tt.path, v1.Calls(), v2.Calls(), tt.v1, tt.v2)
+		}
+	}
+}
+
+func TestHeaderValueRoutesToMappedBackend(t *testing.T) {
+	primary, eu, us := bridgetest.Start(t), bridgetest.Start(t), bridgetest.Start(t)
+	b := newTestBridge(t, Config{
+		GRPCAddr: primary.Addr,
+		Services: map[string]ServiceConfig{
+			bridgetest.Service: {
+				Primary:      primary.Addr,
+				RouteHeader:  "X-Region",
+				HeaderRoutes: map[string]string{"eu": eu.Addr, "us": us.Addr},
+			},
+		},
+	})
+	h := b.Handler()
+
+	backends := map[string]*bridgetest.Server{"": primary, "eu": eu, "us": us}
+	for _, region := range []string{"eu", "us", "", "eu"} {
+		req := httptest.NewRequest(http.MethodPost, "/bridgetest.v1.Greeter/SayHello", strings.NewReader(`{"name": "Ada"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if region != "" {
+			req.Header.Set("X-Region", region)
+		}
+		want := backends[region]
+		calls := want.Calls()
+		if rec := serve(h, req); rec.Code != 200 || want.Calls() != calls+1 {
+			t.Fatalf("X-Region %q: got %d %s, want it served by its backend", region, rec.Code, rec.Body)
+		}
+	}
+	if n := primary.Calls() + eu.Calls() + us.Calls(); n != 4 {
+		t.Fatalf("backends answered %d calls, want 4", n)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/bridgetest.v1.Greeter/SayHello", strings.NewReader(`{"name": "Ada"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Region", "ap")
+	if rec := serve(h, req); rec.Code != 400 || !strings.Contains(rec.Body.String(), "eu, us") {
+		t.Fatalf("unmapped value: got %d %s, want 400 listing eu, us", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,128 @@
+package bridge
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// warmupRequest is the optional body of POST /admin/warmup.
+type warmupRequest struct {
+	// Methods lists the methods to warm up as "{service}/{method}".
+	// Without it, every exposed method is.
+	Methods []string `json:"methods"`
+}
+
+// warmupResult is the POST /admin/warmup response.
+type warmupResult struct {
+	Warmed []string `json:"warmed"`
+	// Failed maps each method that couldn't be resolved to the reason
+	Failed map[string]string `json:"failed,omitempty"`
+}
+
+// handleWarmup serves POST /admin/warmup, which resolves the descriptors
+// of the methods listed in the body, or of every exposed method, on each
+// backend that may serve them, and builds their schemas and example
+// requests, so that the first calls after a deploy don't wait on
+// reflection. It answers 503 if any method failed to resolve.
+func (b *Bridge) handleWarmup(w http.ResponseWriter, r *http.Request) {
+	if b.replayer != nil {
+		b.writeError(w, status.Errorf(codes.FailedPrecondition, "there is nothing to warm up in replay mode"))
+		return
+	}
+	body, err := readBody(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req warmupRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		b.writeError(w, withCause(status.Errorf(codes.InvalidArgument, "invalid warmup request: %v", err), err))
+		return
+	}
+	methods := req.Methods
+	if len(methods) == 0 {
+		if methods, err = b.exposedMethods(r); err != nil {
+			b.writeError(w, err)
+			return
+		}
+	}
+
+	result := warmupResult{Warmed: []string{}, Failed: make(map[string]string)}
+	for _, name := range methods {
+		i := strings.LastIndex(name, "/")
+		if i <= 0 || i == len(name)-1 {
+			result.Failed[name] = "invalid method (want {service}/{method})"
+			continue
+		}
+		service, method := name[:i], name[i+1:]
+		err := func() error {
+			for _, be := range b.backendsFor(service) {
+				md, err := b.findMethod(r.Context(), be, service, method)
+				if err != nil {
+					return err
+				}
+				if _, err := b.docsFor(md); err != nil {
+					return err
+				}
+			}
+			return nil
+		}()
+		if err != nil {
+			result.Failed[name] = status.Convert(err).Message()
+			continue
+		}
+		result.Warmed = append(result.Warmed, name)
+	}
+	log.Printf("↻ Warmed up %d method(s), %d failed", len(result.Warmed), len(result.Failed))
+
+	code := http.StatusOK
+	if len(result.Failed) > 0 {
+		code = http.StatusServiceUnavailable
+	}
+	b.encodeJSON(w, code, result)
+}
+
+// exposedMethods lists every exposed method of every backend's services,
+// and those registered, as "{service}/{method}".
+func (b *Bridge) exposedMethods(r *http.Request) ([]string, error) {
+	seen := make(map[string]bool)
+	var methods []string
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			methods = append(methods, name)
+		}
+	}
+	for _, be := range b.backendList() {
+		names, err := be.resolver.ListServices(r.Context())
+		if err != nil {
+			be.observe(err)
+			return nil, err
+		}
+		for _, name := range names {
+			if strings.HasPrefix(name, "grpc.reflection.") || !b.exposed(name) {
+				continue
+			}
+			sd, err := be.resolver.FindService(r.Context(), name)
+			if err != nil {
+				return nil, err
+			}
+			for i := 0; i < sd.Methods().Len(); i++ {
+				add(name + "/" + string(sd.Methods().Get(i).Name()))
+			}
+		}
+	}
+	for _, md := range b.registeredMethods() {
+		if service := string(md.Parent().FullName()); b.exposed(service) {
+			add(service + "/" + string(md.Name()))
+		}
+	}
+	sort.Strings(methods)
+	return methods, nil
+}
@@ -0,0 +1,54 @@
+package bridge
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestWarmupPopulatesDescriptorCache(t *testing.T) {
+	counter, lookups := countLookups()
+	be := startBackend(t, counter)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, AdminToken: "s3cret"})
+	h := b.Handler()
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/warmup", strings.NewReader(`{"methods": ["test.v1.Echo/Echo", "test.v1.Echo/Missing"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec := serve(h, req)
+	var result warmupResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != 503 || !reflect.DeepEqual(result.Warmed, []string{"test.v1.Echo/Echo"}) || len(result.Failed) != 1 || result.Failed["test.v1.Echo/Missing"] == "" {
+		t.Fatalf("got %d %s, want 503 with Echo warmed and Missing failed", rec.Code, rec.Body)
+	}
+
+	b.backend.resolver.mu.RLock()
+	_, cached := b.backend.resolver.services["test.v1.Echo"]
+	b.backend.resolver.mu.RUnlock()
+	if !cached {
+		t.Fatal("warmup didn't cache test.v1.Echo's descriptors")
+	}
+	docs := false
+	b.methodDocs.Range(func(md, _ interface{}) bool {
+		docs = md.(protoreflect.MethodDescriptor).FullName() == "test.v1.Echo.Echo"
+		return !docs
+	})
+	if !docs {
+		t.Fatal("warmup didn't build Echo's schema and example")
+	}
+
+	before := lookups.Load()
+	if rec := post(h, "/test.v1.Echo/Echo", `{}`); rec.Code != 200 {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+	if n := lookups.Load() - before; n != 0 {
+		t.Fatalf("call after warmup made %d reflection lookups, want none", n)
+	}
+}
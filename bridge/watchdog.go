@@ -0,0 +1,132 @@
+package bridge
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// watchdogFailures is how many self-pings in a row must fail for the
+// bridge to be reported deadlocked, so that a single slow ping, during a
+// long GC pause say, doesn't get the process restarted.
+const watchdogFailures = 3
+
+// watchdog pings the bridge's own server periodically, through the
+// accept loop and the whole handler chain, and reports the bridge
+// deadlocked in /health once the pings keep failing, so an orchestrator
+// probing /health restarts it. A request served from a stuck process
+// still gets that answer whenever the part of it that's stuck isn't on
+// the way to /health, as with an exhausted connection limit or a wedged
+// middleware.
+type watchdog struct {
+	interval time.Duration
+	timeout  time.Duration
+	// ping makes one self-ping, set by Serve once the server's address is
+	// known
+	ping func(ctx context.Context) error
+
+	mu       sync.Mutex
+	failures int
+	lastErr  error
+}
+
+func newWatchdog(interval, timeout time.Duration) *watchdog {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &watchdog{interval: interval, timeout: timeout}
+}
+
+// start pings every interval until stop is called.
+func (d *watchdog) start() (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		t := time.NewTicker(d.interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				d.check(ctx)
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// check makes one self-ping and records its outcome.
+func (d *watchdog) check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+	err := d.ping(ctx)
+	if ctx.Err() == context.Canceled {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err == nil {
+		if d.failures >= watchdogFailures {
+			log.Printf("✓ Watchdog: self-ping answered again")
+		}
+		d.failures, d.lastErr = 0, nil
+		return
+	}
+	d.failures++
+	d.lastErr = err
+	if d.failures == watchdogFailures {
+		log.Printf("✗ Watchdog: %d self-pings in a row failed, reporting deadlocked: %v", d.failures, err)
+	}
+}
+
+// stuck reports whether the last watchdogFailures pings failed, with the
+// last error.
+func (d *watchdog) stuck() (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.failures >= watchdogFailures, d.lastErr
+}
+
+// httpPing returns a ping that requests GET /health from the server
+// listening on addr. Any response counts: it shows that connections are
+// accepted and requests served, while a 503 from a deadlocked bridge
+// mustn't keep it reported deadlocked.
+func httpPing(addr net.Addr, useTLS bool) func(ctx context.Context) error {
+	scheme := "http"
+	transport := &http.Transport{DisableKeepAlives: true}
+	if useTLS {
+		scheme = "https"
+		// The bridge's own certificate is for its public name, not the
+		// loopback address it's pinged on
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	host := addr.String()
+	if tcp, ok := addr.(*net.TCPAddr); ok && tcp.IP.IsUnspecified() {
+		host = net.JoinHostPort("localhost", fmt.Sprint(tcp.Port))
+	}
+	url := scheme + "://" + host + "/health"
+	client := &http.Client{Transport: transport}
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		io.Copy(io.Discard, resp.Body)
+		return resp.Body.Close()
+	}
+}
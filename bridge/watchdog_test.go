@@ -0,0 +1,71 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStuckSelfPingFlipsHealth(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr, WatchdogInterval: 10 * time.Millisecond, WatchdogTimeout: 20 * time.Millisecond})
+	h := b.Handler()
+
+	// The self-ping hangs until its timeout while stuck is set, as it would
+	// against a wedged accept loop
+	var stuck atomic.Bool
+	stuck.Store(true)
+	b.watchdog.ping = func(ctx context.Context) error {
+		if stuck.Load() {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	}
+	defer b.watchdog.start()()
+
+	health := func() (int, string) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+		var resp struct {
+			Status string `json:"status"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return rec.Code, resp.Status
+	}
+	waitFor := func(code int, status string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			gotCode, gotStatus := health()
+			if gotCode == code && gotStatus == status {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("/health: got %d %q, want %d %q", gotCode, gotStatus, code, status)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	waitFor(http.StatusServiceUnavailable, "deadlocked")
+	stuck.Store(false)
+	waitFor(http.StatusOK, "ok")
+}
+
+func TestWatchdogNeedsConsecutiveFailures(t *testing.T) {
+	d := newWatchdog(time.Minute, 0)
+	d.ping = func(ctx context.Context) error { return context.DeadlineExceeded }
+	for i := 1; i <= watchdogFailures; i++ {
+		d.check(context.Background())
+		if stuck, _ := d.stuck(); stuck != (i == watchdogFailures) {
+			t.Fatalf("after %d failed pings: stuck = %v", i, stuck)
+		}
+	}
+}
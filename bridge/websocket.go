@@ -0,0 +1,279 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"golang.org/x/net/websocket"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// controlFrame is a client message that steers the current call instead of
+// being sent to the backend, e.g. {"_control":"cancel"}. The bridge uses
+// the same shape to report how a call ended.
+type controlFrame struct {
+	Control   string `json:"_control"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+const (
+	// controlCancel cancels the current call; the socket stays open.
+	controlCancel = "cancel"
+	// controlCloseSend tells the backend the client has no more messages.
+	controlCloseSend = "close_send"
+	// controlEnd reports that the backend finished the call.
+	controlEnd = "end"
+	// controlCancelled acknowledges a cancel.
+	controlCancelled = "cancelled"
+)
+
+// isWebSocket reports whether r asks to upgrade to a WebSocket.
+func isWebSocket(r *http.Request) bool {
+	return r.Method == http.MethodGet && strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
+
+// handleBidiWebSocket serves a bidirectional streaming method over a
+// WebSocket. Each client message is one request message, and each
+// response is sent back as one text message. Control frames manage the
+// call: after a cancel, the next request message starts a new call on the
+// same socket.
+func (b *Bridge) handleBidiWebSocket(w http.ResponseWriter, r *http.Request, be *backend, md protoreflect.MethodDescriptor) {
+	enc, err := b.jsonEncoding(r)
+	if err != nil {
+		b.writeError(w, err)
+		return
+	}
+	service, method := string(md.Parent().FullName()), string(md.Name())
+
+	// The socket outlives the request timeout; calls are bounded by the
+	// method's call timeout instead
+	ctx, cancel := detachStream(r.Context())
+	defer cancel()
+	srv := websocket.Server{
+		Handshake: func(_ *websocket.Config, r *http.Request) error {
+			return b.checkWebSocketOrigin(r, service, method)
+		},
+		Handler: func(ws *websocket.Conn) {
+			b.serveBidi(ctx, ws, be, md, enc)
+		},
+	}
+	srv.ServeHTTP(w, r)
+}
+
+// checkWebSocketOrigin rejects browser origins other than the bridge's
+// own, unless the method's CORS policy allows them. Browsers don't apply
+// CORS to WebSockets, so without this any page could open a socket with
+// its visitor's cookies. Clients that send no Origin aren't browsers and
+// may connect.
+func (b *Bridge) checkWebSocketOrigin(r *http.Request, service, method string) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return nil
+	}
+	if p := b.corsPolicyFor(service + "/" + method); p != nil && p.allows(origin) {
+		return nil
+	}
+	return status.Errorf(codes.PermissionDenied, "origin %s is not allowed", origin)
+}
+
+// serveBidi relays messages between ws and calls to md until the client
+// closes the socket.
+func (b *Bridge) serveBidi(ctx context.Context, ws *websocket.Conn, be *backend, md protoreflect.MethodDescriptor, enc jsonEncoding) {
+	// Shutdown ends the socket along with its call
+	stop := context.AfterFunc(ctx, func() { ws.Close() })
+	defer stop()
+
+	var call *bidiCall
+	defer func() {
+		if call != nil {
+			call.stop()
+		}
+	}()
+
+	for {
+		if call != nil && call.finished() {
+			call = nil
+		}
+		// Only a call still waiting on the client is bounded by the
+		// message timeout; an idle socket may stay open
+		waiting := b.streamIdle > 0 && call != nil && !call.sendClosed
+		if waiting {
+			ws.SetReadDeadline(time.Now().Add(b.streamIdle))
+		} else {
+			ws.SetReadDeadline(time.Time{})
+		}
+		var data []byte
+		if err := websocket.Message.Receive(ws, &data); err != nil {
+			if waiting && isTimeout(err) {
+				// A frame may have been cut off midway, so the socket
+				// can't be read further
+				b.sendWebSocketError(ws, status.Errorf(codes.DeadlineExceeded, "no message received for %s", b.streamIdle))
+				log.Printf("✗ WebSocket call idle for %s, cancelling", b.streamIdle)
+				return
+			}
+			if err != io.EOF {
+				log.Printf("✗ WebSocket receive failed: %v", err)
+			}
+			return
+		}
+		if call != nil && call.finished() {
+			call = nil
+		}
+
+		var ctl controlFrame
+		if json.Unmarshal(data, &ctl) == nil && ctl.Control != "" {
+			switch ctl.Control {
+			case controlCancel:
+				if call != nil {
+					call.stop()
+					call = nil
+				}
+			case controlCloseSend:
+				if call != nil {
+					call.stream.CloseSend()
+					call.sendClosed = true
+				}
+			default:
+				b.sendWebSocketError(ws, status.Errorf(codes.InvalidArgument, "unknown control frame %q", ctl.Control))
+			}
+			continue
+		}
+
+		data, err := b.fillHeaderFields(methodPath(md), md.Input(), ws.Request().Header, data)
+		if err != nil {
+			b.sendWebSocketError(ws, err)
+			continue
+		}
+		if err := b.checkRequestSchema(methodPath(md), data); err != nil {
+			b.sendWebSocketError(ws, err)
+			continue
+		}
+		req, err := b.decodeRequest(data, md.Input())
+		if err != nil {
+			b.sendWebSocketError(ws, err)
+			continue
+		}
+		if call == nil {
+			call, err = b.startBidiCall(ctx, ws, be, md, enc)
+			if err != nil {
+				b.sendWebSocketError(ws, err)
+				continue
+			}
+		}
+		// A failed send ends the stream, which the call reports itself
+		if err := call.stream.SendMsg(req); err != nil && err != io.EOF {
+			b.sendWebSocketError(ws, err)
+		}
+	}
+}
+
+// bidiCall is one call to a bidirectional streaming method made over a
+// WebSocket.
+type bidiCall struct {
+	stream    grpc.ClientStream
+	cancel    context.CancelFunc
+	cancelled atomic.Bool
+	done      chan struct{}
+	// sendClosed is set once the client has sent its last message
+	sendClosed bool
+}
+
+// startBidiCall opens a stream to md and relays its responses to ws until
+// the call ends, which is reported with a final control frame or error.
+func (b *Bridge) startBidiCall(ctx context.Context, ws *websocket.Conn, be *backend, md protoreflect.MethodDescriptor, enc jsonEncoding) (*bidiCall, error) {
+	ctx, cancel := b.withCallTimeout(ctx, md)
+	stream, err := b.newStream(ctx, be, &grpc.StreamDesc{ServerStreams: true, ClientStreams: true}, md)
+	if err != nil {
+		cancel()
+		be.observe(err)
+		return nil, err
+	}
+	log.Printf("→ WebSocket call: %s", methodPath(md))
+
+	call := &bidiCall{stream: stream, cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(call.done)
+		defer cancel()
+
+		err := receiveAll(stream, md, func(resp *dynamicpb.Message) error {
+			line, err := enc.marshal(resp, "", b.types)
+			if err != nil {
+				return err
+			}
+			return websocket.Message.Send(ws, string(line))
+		})
+		switch {
+		case call.cancelled.Load():
+			sendControl(ws, controlCancelled)
+			log.Printf("✓ WebSocket call cancelled")
+		case err != nil:
+			be.observe(err)
+			b.sendWebSocketError(ws, err)
+			log.Printf("✗ RPC failed: %v", err)
+		default:
+			sendControl(ws, controlEnd)
+			log.Printf("✓ WebSocket call ended")
+		}
+	}()
+	return call, nil
+}
+
+// stop cancels the call and waits until its end has been reported.
+func (c *bidiCall) stop() {
+	c.cancelled.Store(true)
+	c.cancel()
+	<-c.done
+}
+
+// finished reports whether the call has ended.
+func (c *bidiCall) finished() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// receiveAll passes each message on stream to emit until the backend ends
+// the stream.
+func receiveAll(stream grpc.ClientStream, md protoreflect.MethodDescriptor, emit func(*dynamicpb.Message) error) error {
+	for {
+		resp := dynamicpb.NewMessage(md.Output())
+		if err := stream.RecvMsg(resp); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err := emit(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// sendControl reports how a call ended, along with the ID of the request
+// that opened the socket.
+func sendControl(ws *websocket.Conn, control string) {
+	frame, _ := json.Marshal(controlFrame{Control: control, RequestID: middleware.GetReqID(ws.Request().Context())})
+	websocket.Message.Send(ws, string(frame))
+}
+
+func (b *Bridge) sendWebSocketError(ws *websocket.Conn, err error) {
+	websocket.Message.Send(ws, string(b.streamError(ws.Request().Context(), err)))
+}
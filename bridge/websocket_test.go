@@ -0,0 +1,106 @@
+package bridge
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestWebSocketCancelControlFrame(t *testing.T) {
+	ended := make(chan error, 2)
+	be := startBackend(t, grpc.StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		err := handler(srv, ss)
+		if info.FullMethod == "/test.v1.Echo/Bidi" {
+			ended <- err
+		}
+		return err
+	}))
+	b := newTestBridge(t, Config{GRPCAddr: be.addr})
+	srv := httptest.NewServer(b.Handler())
+	defer srv.Close()
+
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/test.v1.Echo/Bidi", "", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	ws.SetDeadline(time.Now().Add(5 * time.Second))
+	exchange := func(send string) map[string]interface{} {
+		t.Helper()
+		if err := websocket.Message.Send(ws, send); err != nil {
+			t.Fatal(err)
+		}
+		var got string
+		if err := websocket.Message.Receive(ws, &got); err != nil {
+			t.Fatal(err)
+		}
+		var msg map[string]interface{}
+		if err := json.Unmarshal([]byte(got), &msg); err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+
+	if msg := exchange(`{"message": "one"}`); msg["message"] != "one" {
+		t.Fatalf("got %v, want the echo of one", msg)
+	}
+	if msg := exchange(`{"_control": "cancel"}`); msg["_control"] != "cancelled" {
+		t.Fatalf("got %v, want the cancel acknowledged", msg)
+	}
+	select {
+	case err := <-ended:
+		if status.Code(err) != codes.Canceled {
+			t.Fatalf("backend stream ended with %v, want Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("backend stream wasn't cancelled")
+	}
+
+	// The socket stays open for a new call
+	if msg := exchange(`{"message": "two"}`); msg["message"] != "two" {
+		t.Fatalf("got %v, want the echo of two", msg)
+	}
+	if n := be.Calls(); n != 2 {
+		t.Fatalf("backend saw %d streams, want 2", n)
+	}
+}
+
+func TestWebSocketRefusesForeignOrigins(t *testing.T) {
+	be := startBackend(t)
+	for _, tt := range []struct {
+		name    string
+		cors    []CORSPolicy
+		origin  string
+		allowed bool
+	}{
+		{"same origin", nil, "", true},
+		{"foreign origin", nil, "https://evil.example", false},
+		{"origin allowed by CORS", []CORSPolicy{{Pattern: "test.v1.Echo/", AllowedOrigins: []string{"https://app.example"}}}, "https://app.example", true},
+		{"origin not allowed by CORS", []CORSPolicy{{Pattern: "test.v1.Echo/", AllowedOrigins: []string{"https://app.example"}}}, "https://evil.example", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBridge(t, Config{GRPCAddr: be.addr, CORS: tt.cors})
+			srv := httptest.NewServer(b.Handler())
+			defer srv.Close()
+
+			origin := tt.origin
+			if origin == "" {
+				origin = srv.URL
+			}
+			ws, err := websocket.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/test.v1.Echo/Bidi", "", origin)
+			if err == nil {
+				ws.Close()
+			}
+			if got := err == nil; got != tt.allowed {
+				t.Fatalf("upgrade from %s: allowed = %v (%v), want %v", origin, got, err, tt.allowed)
+			}
+		})
+	}
+}
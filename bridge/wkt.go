@@ -0,0 +1,19 @@
+package bridge
+
+// The well-known types are linked in so their descriptors are in
+// protoregistry.GlobalFiles. Schemas from backends that leave them out of
+// reflection responses, and descriptor sets built with --exclude-imports,
+// resolve their imports from there; Struct, Value and ListValue in
+// particular need them to accept arbitrary JSON.
+import (
+	_ "google.golang.org/protobuf/types/known/anypb"
+	_ "google.golang.org/protobuf/types/known/apipb"
+	_ "google.golang.org/protobuf/types/known/durationpb"
+	_ "google.golang.org/protobuf/types/known/emptypb"
+	_ "google.golang.org/protobuf/types/known/fieldmaskpb"
+	_ "google.golang.org/protobuf/types/known/sourcecontextpb"
+	_ "google.golang.org/protobuf/types/known/structpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+	_ "google.golang.org/protobuf/types/known/typepb"
+	_ "google.golang.org/protobuf/types/known/wrapperspb"
+)
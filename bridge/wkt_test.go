@@ -0,0 +1,39 @@
+package bridge
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestStructFieldRoundTripsArbitraryJSON(t *testing.T) {
+	be := startBackend(t)
+	b := newTestBridge(t, Config{GRPCAddr: be.addr})
+
+	data := `{
+		"name": "Ada",
+		"age": 36,
+		"ratio": 1.5,
+		"admin": true,
+		"manager": null,
+		"tags": ["a", 2, false, null, {"nested": []}],
+		"address": {"city": "London", "lines": [{"n": 1}], "empty": {}}
+	}`
+	rec := post(b.Handler(), "/test.v1.Echo/Echo", `{"data": `+data+`}`)
+	if rec.Code != 200 {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		Data interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	var want interface{}
+	if err := json.Unmarshal([]byte(data), &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(resp.Data, want) {
+		t.Fatalf("data came back as %v, want %v", resp.Data, want)
+	}
+}
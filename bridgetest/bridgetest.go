@@ -0,0 +1,186 @@
+// Package bridgetest runs an in-process gRPC backend with server
+// reflection, so code embedding the bridge can be integration tested
+// without compiled protos or a separate service:
+//
+//	be := bridgetest.Start(t)
+//	b, err := bridge.NewBridge(bridge.Config{GRPCAddr: be.Addr})
+//	...
+//	req := httptest.NewRequest("POST", "/bridgetest.v1.Greeter/SayHello", strings.NewReader(`{"name":"Ada"}`))
+//	rec := httptest.NewRecorder()
+//	b.Handler().ServeHTTP(rec, req)
+//	// rec.Body: {"message":"Hello, Ada!"}
+package bridgetest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionv1alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Service is the full name of the service the backend serves:
+//
+//	service Greeter {
+//	  // SayHello answers {"name": "Ada"} with {"message": "Hello, Ada!"}
+//	  rpc SayHello(HelloRequest) returns (HelloReply);
+//	  // Fail fails with the gRPC code and message asked for, e.g.
+//	  // {"code": 5, "message": "no such user"} with NOT_FOUND
+//	  rpc Fail(FailRequest) returns (HelloReply);
+//	}
+const Service = "bridgetest.v1.Greeter"
+
+// files holds the backend's descriptors, kept out of the global registry
+// so that they can't clash with the protos of the code under test.
+var files = func() *protoregistry.Files {
+	s := proto.String
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     s(name),
+			JsonName: s(name),
+			Number:   proto.Int32(number),
+			Type:     typ.Enum(),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		}
+	}
+	fd := &descriptorpb.FileDescriptorProto{
+		Name:    s("bridgetest/v1/greeter.proto"),
+		Package: s("bridgetest.v1"),
+		Syntax:  s("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: s("HelloRequest"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+			}},
+			{Name: s("HelloReply"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("message", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+			}},
+			{Name: s("FailRequest"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("code", 1, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+				field("message", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+			}},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: s("Greeter"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{Name: s("SayHello"), InputType: s(".bridgetest.v1.HelloRequest"), OutputType: s(".bridgetest.v1.HelloReply")},
+				{Name: s("Fail"), InputType: s(".bridgetest.v1.FailRequest"), OutputType: s(".bridgetest.v1.HelloReply")},
+			},
+		}},
+	}
+	f, err := protodesc.NewFile(fd, nil)
+	if err != nil {
+		panic(err)
+	}
+	reg := new(protoregistry.Files)
+	if err := reg.RegisterFile(f); err != nil {
+		panic(err)
+	}
+	return reg
+}()
+
+// Server is a running test backend.
+type Server struct {
+	// Addr is the address the backend listens on, for Config.GRPCAddr.
+	Addr string
+
+	srv   *grpc.Server
+	calls atomic.Int64
+}
+
+// NewServer starts a backend on a free loopback port. Callers must Close
+// it.
+func NewServer() (*Server, error) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen: %w", err)
+	}
+	s := &Server{Addr: lis.Addr().String(), srv: grpc.NewServer()}
+	s.register()
+	go s.srv.Serve(lis)
+	return s, nil
+}
+
+// Start starts a backend for the duration of a test, failing tb if it
+// can't.
+func Start(tb testing.TB) *Server {
+	tb.Helper()
+	s, err := NewServer()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// Calls returns how many calls the backend has answered, for tests of
+// retries and caching.
+func (s *Server) Calls() int {
+	return int(s.calls.Load())
+}
+
+// Close stops the backend, cancelling calls in flight.
+func (s *Server) Close() {
+	s.srv.Stop()
+}
+
+func (s *Server) register() {
+	d, err := files.FindDescriptorByName(Service)
+	if err != nil {
+		panic(err)
+	}
+	sd := d.(protoreflect.ServiceDescriptor)
+	methods := sd.Methods()
+	handlers := map[protoreflect.Name]func(in, out *dynamicpb.Message) error{
+		"SayHello": func(in, out *dynamicpb.Message) error {
+			name := in.Get(in.Descriptor().Fields().ByName("name")).String()
+			out.Set(out.Descriptor().Fields().ByName("message"), protoreflect.ValueOfString("Hello, "+name+"!"))
+			return nil
+		},
+		"Fail": func(in, out *dynamicpb.Message) error {
+			code := codes.Code(in.Get(in.Descriptor().Fields().ByName("code")).Int())
+			if code == codes.OK {
+				code = codes.Unknown
+			}
+			return status.Error(code, in.Get(in.Descriptor().Fields().ByName("message")).String())
+		},
+	}
+
+	desc := &grpc.ServiceDesc{ServiceName: Service, HandlerType: (*interface{})(nil)}
+	for i := 0; i < methods.Len(); i++ {
+		md := methods.Get(i)
+		handle := handlers[md.Name()]
+		desc.Methods = append(desc.Methods, grpc.MethodDesc{
+			MethodName: string(md.Name()),
+			Handler: func(_ interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+				s.calls.Add(1)
+				in := dynamicpb.NewMessage(md.Input())
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				out := dynamicpb.NewMessage(md.Output())
+				if err := handle(in, out); err != nil {
+					return nil, err
+				}
+				return out, nil
+			},
+		})
+	}
+	s.srv.RegisterService(desc, struct{}{})
+
+	opts := reflection.ServerOptions{Services: s.srv, DescriptorResolver: files}
+	reflectionv1.RegisterServerReflectionServer(s.srv, reflection.NewServerV1(opts))
+	reflectionv1alpha.RegisterServerReflectionServer(s.srv, reflection.NewServer(opts))
+}
@@ -0,0 +1,66 @@
+package bridgetest_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mizrahidaniel/grpc-http-bridge/bridge"
+	"github.com/mizrahidaniel/grpc-http-bridge/bridgetest"
+)
+
+func TestUnaryCallThroughBridge(t *testing.T) {
+	be := bridgetest.Start(t)
+	b, err := bridge.NewBridge(bridge.Config{GRPCAddr: be.Addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/bridgetest.v1.Greeter/SayHello", strings.NewReader(`{"name": "Ada"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	b.Handler().ServeHTTP(rec, req)
+	if rec.Code != 200 {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Message != "Hello, Ada!" || be.Calls() != 1 {
+		t.Fatalf("got %q after %d calls, want Hello, Ada! after 1", resp.Message, be.Calls())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/bridgetest.v1.Greeter/Fail", strings.NewReader(`{"code": 5, "message": "no such user"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	b.Handler().ServeHTTP(rec, req)
+	if rec.Code != 404 || !strings.Contains(rec.Body.String(), "no such user") {
+		t.Fatalf("Fail: got %d %s, want 404 with the backend's message", rec.Code, rec.Body)
+	}
+}
+
+func TestNewServerClose(t *testing.T) {
+	s, err := bridgetest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := bridge.NewBridge(bridge.Config{GRPCAddr: s.Addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	s.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/bridgetest.v1.Greeter/SayHello", strings.NewReader(`{"name": "Ada"}`))
+	rec := httptest.NewRecorder()
+	b.Handler().ServeHTTP(rec, req)
+	if rec.Code == 200 {
+		t.Fatal("a closed server answered")
+	}
+}
@@ -2,195 +2,366 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/tls"
+	"crypto/x509"
 	"flag"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
-	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
-	"google.golang.org/protobuf/encoding/protojson"
-	"google.golang.org/protobuf/proto"
-	"google.golang.org/protobuf/reflect/protoreflect"
-	"google.golang.org/protobuf/types/dynamicpb"
-)
+	"google.golang.org/grpc/keepalive"
 
-type Bridge struct {
-	grpcConn   *grpc.ClientConn
-	grpcAddr   string
-	httpPort   int
-	reflClient grpc_reflection_v1alpha.ServerReflectionClient
-}
+	"github.com/mizrahidaniel/grpc-http-bridge/bridge"
+)
 
 func main() {
 	grpcAddr := flag.String("grpc-addr", "", "gRPC backend address (e.g., localhost:50051)")
 	httpPort := flag.Int("http-port", 8080, "HTTP server port")
+	configFile := flag.String("config", "", "JSON config file with per-service settings")
+	descriptorSet := flag.String("descriptor-set", "", "FileDescriptorSet or buf image to resolve services from instead of reflection")
+	recordFile := flag.String("record", "", "Append every request/response pair to this JSONL file")
+	auditLog := flag.String("audit-log", "", "JSONL file to record calls to methods with an audit policy in (- for stderr; defaults to the log)")
+	replayFile := flag.String("replay", "", "Serve responses recorded in this JSONL file without a backend")
+	diffRecording := flag.String("diff-recording", "", "Replay the requests recorded in this JSONL file against --grpc-addr, report the responses that changed and exit")
+	rootIndex := flag.Bool("root-index", true, "Serve a JSON index of the bridge's endpoints at GET /")
+	devMode := flag.Bool("dev-mode", false, "Accept comments and trailing commas in request JSON and report error chains in error responses (not for production)")
+	caseInsensitive := flag.Bool("case-insensitive", false, "Resolve service and method names in any case against the names the backend declares")
+	exposeServices := flag.String("expose-services", "", "Comma-separated services, or globs such as myapp.v1.*, to serve; others the backend reflects are hidden")
+	defaultService := flag.String("default-service", "", "Service that single-segment paths (/{method}) resolve against")
+	formPayloadField := flag.String("form-payload-field", "payload", "Field of form-encoded request bodies that holds the JSON request")
+	formRepeatedFields := flag.String("form-repeated-fields", "replace", "How form fields setting a repeated field combine with the payload's values: replace or append")
+	emitUnpopulated := flag.Bool("emit-unpopulated", true, "Include fields with default values in JSON responses (requests can override with ?emit_unpopulated=)")
+	omitEmptyRepeated := flag.Bool("omit-empty-repeated", false, "Leave empty repeated and map fields out of JSON responses instead of rendering [] and {}")
+	bufferResponses := flag.Bool("buffer-responses", false, "Send unary responses with Content-Length instead of chunked encoding")
+	compression := flag.String("compression", "", "Compress responses with these encodings, most preferred first, e.g. br,zstd,gzip (off by default)")
+	errorFormat := flag.String("error-format", "json", "Error response format: json, or problem for RFC 7807 application/problem+json")
+	jsonTrailingNewline := flag.Bool("json-trailing-newline", false, "End every JSON response body, success or error, with a newline")
+	defaultFormat := flag.String("default-format", "json", "Response format for requests without an Accept header or accepting */*: json, proto or yaml")
+	deterministicJSON := flag.Bool("deterministic-json", false, "Render the same response message as byte-identical JSON across runs and builds")
+	logFormat := flag.String("log-format", "text", "Access log format: text, clf (Common Log Format) or combined")
+	requestIDFormat := flag.String("request-id-format", "sequential", "Format of generated request IDs: sequential, uuid, ksuid or short")
+	callIDs := flag.Bool("call-ids", false, "Send each gRPC call a generated ID as x-call-id metadata, return it in X-Grpc-Call-Id and log it with the request ID")
+	debugHeaders := flag.Bool("debug-headers", false, "Report the backend address that served each call in X-Backend-Peer, the JSON options in X-Json-Options and the message types in X-Grpc-Input-Type and X-Grpc-Output-Type")
+	slowRequestThreshold := flag.Duration("slow-request-threshold", 0, "Log a warning for requests that take longer than this (0 disables)")
+	logPayloadSizes := flag.Bool("log-payload-sizes", false, "Add the request and response body sizes of every request to the access log, and histograms of them to /metrics")
+	payloadLogThreshold := flag.Int64("payload-log-threshold", 0, "With --log-payload-sizes, always log the sizes of requests with a request or response body of at least this many bytes, or slower than --slow-request-threshold, and sample the rest (0 logs all)")
+	payloadLogSampleRate := flag.Float64("payload-log-sample-rate", 0.01, "Fraction of requests under --payload-log-threshold whose payload sizes are logged")
+	duplicateKeys := flag.String("duplicate-keys", "last", "Handling of keys repeated in a request JSON object: last or error")
+	nullFields := flag.String("null-fields", "clear", "Handling of explicit JSON nulls in requests: clear, ignore or error")
+	callTimeout := flag.Duration("call-timeout", 0, "Default deadline for backend calls; services and methods can override it in --config (0 disables)")
+	deadlineMargin := flag.Duration("deadline-margin", 0, "Cut backend calls off this long before the request's deadline, leaving time to respond")
+	timeoutOption := flag.String("timeout-option", "", "Full name of a method option (Duration or string) giving annotated methods their default call timeout")
+	healthInterval := flag.Duration("health-interval", 5*time.Second, "How often to probe backends that have a failover secondary")
+	maxDescriptorAge := flag.Duration("max-descriptor-age", 0, "Fail /ready once cached descriptors are older than this (0 disables)")
+	breakerThreshold := flag.Int("breaker-threshold", 0, "Consecutive backend failures that open a method's circuit breaker (0 disables)")
+	breakerCooldown := flag.Duration("breaker-cooldown", 30*time.Second, "How long an open circuit breaker rejects calls")
+	rateLimit := flag.Int("rate-limit", 0, "RPC requests each client may make per --rate-limit-window (0 disables)")
+	rateLimitWindow := flag.Duration("rate-limit-window", time.Minute, "Window that --rate-limit applies to")
+	maxConcurrent := flag.Int("max-concurrent-requests", 0, "RPC requests served at once; beyond it requests queue or get 503 (0 disables)")
+	queueDepth := flag.Int("queue-depth", 0, "Requests over --max-concurrent-requests that wait for capacity instead of being shed")
+	queueWait := flag.Duration("queue-wait", time.Second, "How long a queued request waits for capacity before it is shed")
+	retryMax := flag.Int("retry-max", 0, "Times to retry unary calls that fail with UNAVAILABLE (0 disables)")
+	retryBackoff := flag.Duration("retry-backoff", 100*time.Millisecond, "Base delay between retries, doubled after each attempt")
+	retryMaxBackoff := flag.Duration("retry-max-backoff", 2*time.Second, "Upper bound on the delay between retries")
+	reflectionRetries := flag.Int("reflection-retries", 0, "Times to retry reflection lookups that fail with UNAVAILABLE (0 disables)")
+	reflectionRetryBackoff := flag.Duration("reflection-retry-backoff", 100*time.Millisecond, "Base delay between reflection retries, doubled after each attempt")
+	retryJitter := flag.String("retry-jitter", "full", "Retry delay jitter: none, full, equal or decorrelated")
+	retryMessages := flag.String("retry-messages", "", "Comma-separated phrases that make unary calls whose error message contains them retriable under --retry-max, matched as whole words ignoring case")
+	exhaustedStatus := flag.Int("resource-exhausted-status", 429, "HTTP status of calls failing with RESOURCE_EXHAUSTED, sent with Retry-After from the backend's pushback (e.g. 503 for overload)")
+	refusedMessage := flag.String("refused-message", "backend connection refused at {addr}", "Error message for calls whose backend refused the connection, which aren't retried; {addr} is the backend address")
+	streamResumes := flag.Int("stream-resumes", 0, "Times to restart a server stream interrupted with UNAVAILABLE by re-sending its request (0 disables)")
+	streamMessageTimeout := flag.Duration("stream-message-timeout", 0, "Cancel client and bidirectional streams whose client sends no message for this long (0 waits indefinitely)")
+	emptyResponse := flag.String("empty-response", "", "Answer unary responses without populated fields with object ({}), empty (empty body) or no-content (204); by default they are rendered as usual")
+	emptyStream := flag.String("empty-stream", "", "Answer server streams without messages with array ([]), ndjson (empty body) or no-content (204); by default the stream's own format")
+	streamEndFrames := flag.Bool("stream-end-frames", false, "End completed NDJSON server streams with a {\"_control\":\"end\"} line carrying the request ID")
+	grpcTLS := flag.Bool("grpc-tls", false, "Connect to gRPC backends over TLS")
+	grpcCAFile := flag.String("grpc-ca-file", "", "PEM file of CA certificates to verify backends with (implies --grpc-tls)")
+	keepaliveTime := flag.Duration("grpc-keepalive", 0, "Interval between keepalive pings to backends (0 disables)")
+	keepaliveTimeout := flag.Duration("grpc-keepalive-timeout", 20*time.Second, "How long to wait for a keepalive ping ack")
+	connectTimeout := flag.Duration("grpc-connect-timeout", 0, "Deadline for each backend connection attempt, including the TLS handshake (0 keeps retrying the first connection for 5s)")
+	idleTimeout := flag.Duration("grpc-idle-timeout", 30*time.Minute, "Close backend connections after this long without calls and reconnect on next use (0 disables)")
+	forwardBearer := flag.Bool("forward-bearer", false, "Forward Authorization bearer tokens to backends as call credentials (requires --grpc-tls)")
+	userAgent := flag.String("grpc-user-agent", "grpc-http-bridge/{version}", "User agent sent to backends, with {version} replaced by the bridge version")
+	serviceConfig := flag.String("grpc-service-config", "", "Default gRPC service config JSON for backend connections (e.g. retry policies)")
+	tlsCert := flag.String("tls-cert", "", "PEM certificate to serve HTTPS with")
+	tlsKey := flag.String("tls-key", "", "PEM private key for --tls-cert")
+	tlsClientCA := flag.String("tls-client-ca", "", "PEM file of CAs to verify client certificates with (enables mTLS)")
+	tlsClientAuth := flag.String("tls-client-auth", "require", "Whether client certificates are required or optional with --tls-client-ca")
+	publicMethods := flag.String("public-methods", "", "Comma-separated methods ({service}/{method}) callable without a client certificate when one is required")
+	startupProbeAttempts := flag.Int("startup-probe-attempts", 0, "Probe backends up to this many times before binding the HTTP port, exiting if they never become ready (0 disables)")
+	startupProbeInterval := flag.Duration("startup-probe-interval", 2*time.Second, "Delay between startup probes")
+	principalMetadata := flag.String("principal-metadata", "", "Forward the client certificate principal to backends under this metadata key")
+	maxForwardedMetadata := flag.Int("max-forwarded-metadata", 0, "Most bytes of metadata (principal and bearer token) forwarded to backends per request (0 is unlimited)")
+	forwardedMetadataPolicy := flag.String("forwarded-metadata-policy", "reject", "What to do with requests over --max-forwarded-metadata: reject (400) or drop the largest entries")
+	http2PingInterval := flag.Duration("http2-ping-interval", 0, "Ping HTTPS clients over HTTP/2 after this long without frames (0 disables)")
+	http2PingTimeout := flag.Duration("http2-ping-timeout", 15*time.Second, "How long to wait for an HTTP/2 ping ack before closing the connection")
+	reusePort := flag.Bool("reuse-port", false, "Set SO_REUSEPORT on the HTTP listener so a new bridge process can share the port during restarts")
+	maxConnections := flag.Int("max-connections", 0, "Most HTTP connections open at once; connections beyond it are closed on accept (0 is unlimited)")
+	maxPathLength := flag.Int("max-path-length", 0, "Longest request path in bytes; longer ones get 414 URI Too Long (0 is unlimited)")
+	metricsExemplars := flag.Bool("metrics-exemplars", false, "Attach the trace IDs of requests with a sampled traceparent header to reflection latency histograms as OpenMetrics exemplars")
+	tcpKeepAlive := flag.Duration("tcp-keepalive", 0, "TCP keepalive period for accepted connections (0 uses Go's default, negative disables)")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 10*time.Second, "On SIGINT or SIGTERM, how long unary calls in flight may finish before they are cancelled")
+	streamDrainTimeout := flag.Duration("stream-drain-timeout", 60*time.Second, "On shutdown, how long streams in flight may keep running before they are cancelled")
+	watchdogInterval := flag.Duration("watchdog-interval", 0, "Ping the bridge's own /health this often and report it deadlocked in /health after 3 failed pings (0 disables)")
+	watchdogTimeout := flag.Duration("watchdog-timeout", 5*time.Second, "How long a watchdog ping may take before it counts as failed")
+	adminToken := flag.String("admin-token", "", "Bearer token for the /admin endpoints, which are disabled without one (also read from BRIDGE_ADMIN_TOKEN)")
+	recentRequests := flag.Int("recent-requests", 100, "How many recent RPC requests GET /debug/requests reports (needs --admin-token; 0 disables)")
+	asyncOperations := flag.Int("async-operations", 0, "Accept unary requests with Prefer: respond-async and keep up to this many results for GET /operations/{id} (0 disables)")
+	asyncOperationTTL := flag.Duration("async-operation-ttl", 10*time.Minute, "How long an async operation may run and how long its result is kept")
+	maxBatchSize := flag.Int("max-batch-size", 0, "Serve POST /batch with up to this many unary calls per request, answered 207 Multi-Status unless all succeed (0 disables)")
 	flag.Parse()
 
-	if *grpcAddr == "" {
+	if *adminToken == "" {
+		*adminToken = os.Getenv("BRIDGE_ADMIN_TOKEN")
+	}
+
+	if *grpcAddr == "" && *replayFile == "" {
 		fmt.Fprintf(os.Stderr, "Error: --grpc-addr is required\n\n")
 		flag.Usage()
 		os.Exit(1)
 	}
 
-	bridge, err := NewBridge(*grpcAddr, *httpPort)
-	if err != nil {
-		log.Fatalf("Failed to create bridge: %v", err)
-	}
-	defer bridge.Close()
+	cfg := bridge.Config{
+		GRPCAddr:       *grpcAddr,
+		HTTPPort:       *httpPort,
+		DescriptorSet:  *descriptorSet,
+		RecordFile:     *recordFile,
+		AuditLog:       *auditLog,
+		ReplayFile:     *replayFile,
+		NullFields:     *nullFields,
+		DuplicateKeys:  *duplicateKeys,
+		CallTimeout:    *callTimeout,
+		DeadlineMargin: *deadlineMargin,
+		TimeoutOption:  *timeoutOption,
+		HealthInterval: *healthInterval,
+		DefaultService: *defaultService,
+		DevMode:        *devMode,
 
-	log.Printf("Starting gRPC-HTTP bridge...")
-	log.Printf("  gRPC backend: %s", *grpcAddr)
-	log.Printf("  HTTP server: http://localhost:%d", *httpPort)
+		FormPayloadField:   *formPayloadField,
+		FormRepeatedFields: *formRepeatedFields,
+		MaxDescriptorAge:   *maxDescriptorAge,
 
-	if err := bridge.Serve(); err != nil {
-		log.Fatalf("Server error: %v", err)
-	}
-}
+		CaseInsensitive: *caseInsensitive,
 
-func NewBridge(grpcAddr string, httpPort int) (*Bridge, error) {
-	// Connect to gRPC backend
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+		DisableRootIndex: !*rootIndex,
 
-	conn, err := grpc.DialContext(ctx, grpcAddr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithBlock(),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to gRPC backend: %w", err)
-	}
+		OmitUnpopulated:      !*emitUnpopulated,
+		OmitEmptyRepeated:    *omitEmptyRepeated,
+		DeterministicJSON:    *deterministicJSON,
+		DefaultFormat:        *defaultFormat,
+		BufferResponses:      *bufferResponses,
+		JSONTrailingNewline:  *jsonTrailingNewline,
+		ErrorFormat:          *errorFormat,
+		LogPayloadSizes:      *logPayloadSizes,
+		PayloadLogThreshold:  *payloadLogThreshold,
+		PayloadLogSampleRate: *payloadLogSampleRate,
+		LogFormat:            *logFormat,
+		RequestIDFormat:      *requestIDFormat,
+		CallIDs:              *callIDs,
+		DebugHeaders:         *debugHeaders,
 
-	// Create reflection client
-	reflClient := grpc_reflection_v1alpha.NewServerReflectionClient(conn)
+		SlowRequestThreshold: *slowRequestThreshold,
 
-	return &Bridge{
-		grpcConn:   conn,
-		grpcAddr:   grpcAddr,
-		httpPort:   httpPort,
-		reflClient: reflClient,
-	}, nil
-}
+		BreakerThreshold: *breakerThreshold,
+		BreakerCooldown:  *breakerCooldown,
 
-func (b *Bridge) Close() {
-	if b.grpcConn != nil {
-		b.grpcConn.Close()
-	}
-}
+		RateLimit:       *rateLimit,
+		RateLimitWindow: *rateLimitWindow,
 
-func (b *Bridge) Serve() error {
-	r := chi.NewRouter()
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
-	r.Use(middleware.RequestID)
-	r.Use(middleware.Timeout(60 * time.Second))
-
-	// Health check
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"status":      "ok",
-			"grpc_addr":   b.grpcAddr,
-			"reflection":  true,
-			"timestamp":   time.Now().Unix(),
-		})
-	})
-
-	// Main RPC handler: POST /{service}/{method}
-	r.Post("/*", b.handleRPC)
-
-	addr := fmt.Sprintf(":%d", b.httpPort)
-	log.Printf("✓ Bridge ready - listening on %s", addr)
-	log.Printf("  Example: curl http://localhost:%d/health", b.httpPort)
-	log.Printf("  RPC format: curl http://localhost:%d/{service}/{method} -d '{...}'", b.httpPort)
-
-	return http.ListenAndServe(addr, r)
-}
+		MaxConcurrentRequests: *maxConcurrent,
+		QueueDepth:            *queueDepth,
+		QueueWait:             *queueWait,
+
+		RetryMax:                *retryMax,
+		RetryBackoff:            *retryBackoff,
+		RetryMaxBackoff:         *retryMaxBackoff,
+		RetryJitter:             *retryJitter,
+		RefusedMessage:          *refusedMessage,
+		ResourceExhaustedStatus: *exhaustedStatus,
+
+		ReflectionRetries:      *reflectionRetries,
+		ReflectionRetryBackoff: *reflectionRetryBackoff,
+
+		StreamResumes:        *streamResumes,
+		StreamEndFrames:      *streamEndFrames,
+		EmptyStream:          *emptyStream,
+		EmptyResponse:        *emptyResponse,
+		StreamMessageTimeout: *streamMessageTimeout,
+
+		TLSCertFile:       *tlsCert,
+		TLSKeyFile:        *tlsKey,
+		TLSClientCAFile:   *tlsClientCA,
+		TLSClientAuth:     *tlsClientAuth,
+		PrincipalMetadata: *principalMetadata,
+
+		MaxForwardedMetadata:    *maxForwardedMetadata,
+		ForwardedMetadataPolicy: *forwardedMetadataPolicy,
+
+		StartupProbeAttempts: *startupProbeAttempts,
+		StartupProbeInterval: *startupProbeInterval,
 
-func (b *Bridge) handleRPC(w http.ResponseWriter, r *http.Request) {
-	// Extract service/method from URL
-	path := strings.TrimPrefix(r.URL.Path, "/")
-	parts := strings.Split(path, "/")
-	if len(parts) != 2 {
-		http.Error(w, "Invalid path format. Use: /{service}/{method}", http.StatusBadRequest)
-		return
+		HTTP2PingInterval: *http2PingInterval,
+		HTTP2PingTimeout:  *http2PingTimeout,
+
+		ReusePort:    *reusePort,
+		TCPKeepAlive: *tcpKeepAlive,
+
+		MaxConnections: *maxConnections,
+		MaxPathLength:  *maxPathLength,
+
+		MetricsExemplars: *metricsExemplars,
+
+		ShutdownTimeout:    *shutdownTimeout,
+		StreamDrainTimeout: *streamDrainTimeout,
+		WatchdogInterval:   *watchdogInterval,
+		WatchdogTimeout:    *watchdogTimeout,
+
+		AdminToken:     *adminToken,
+		RecentRequests: *recentRequests,
+
+		AsyncOperations:   *asyncOperations,
+		AsyncOperationTTL: *asyncOperationTTL,
+
+		MaxBatchSize: *maxBatchSize,
+	}
+	if *compression != "" {
+		cfg.Compression = strings.Split(*compression, ",")
+	}
+	if *retryMessages != "" {
+		cfg.RetryMessages = strings.Split(*retryMessages, ",")
+	}
+	if *publicMethods != "" {
+		cfg.PublicMethods = strings.Split(*publicMethods, ",")
+	}
+	if *exposeServices != "" {
+		cfg.ExposeServices = strings.Split(*exposeServices, ",")
+	}
+	if *configFile != "" {
+		if err := bridge.LoadConfigFile(*configFile, &cfg); err != nil {
+			log.Fatalf("Failed to load config: %v", err)
+		}
 	}
 
-	service := parts[0]
-	method := parts[1]
-	fullMethod := fmt.Sprintf("/%s/%s", service, method)
+	var opts []bridge.Option
+	if *grpcTLS || *grpcCAFile != "" {
+		tlsCfg, err := backendTLSConfig(*grpcCAFile)
+		if err != nil {
+			log.Fatalf("Failed to configure TLS: %v", err)
+		}
+		opts = append(opts, bridge.WithTLS(tlsCfg))
+	}
+	if *keepaliveTime > 0 {
+		opts = append(opts, bridge.WithKeepalive(keepalive.ClientParameters{
+			Time:    *keepaliveTime,
+			Timeout: *keepaliveTimeout,
+		}))
+	}
 
-	log.Printf("→ RPC call: %s", fullMethod)
+	if *connectTimeout > 0 {
+		opts = append(opts, bridge.WithConnectTimeout(*connectTimeout))
+	}
+	opts = append(opts, bridge.WithIdleTimeout(*idleTimeout))
+	if *forwardBearer {
+		opts = append(opts, bridge.WithBearerForwarding())
+	}
+	if *serviceConfig != "" {
+		opts = append(opts, bridge.WithServiceConfig(*serviceConfig))
+	}
+	opts = append(opts, bridge.WithUserAgent(*userAgent))
 
-	// Read request body
-	body, err := io.ReadAll(r.Body)
+	b, err := bridge.NewBridge(cfg, opts...)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to read body: %v", err), http.StatusBadRequest)
-		return
-	}
-
-	// For now, return a placeholder response with reflection info
-	// Full dynamic invocation requires method descriptor resolution via reflection
-	response := map[string]interface{}{
-		"status": "bridge_working",
-		"note":   "Full dynamic invocation coming in next iteration",
-		"request": map[string]interface{}{
-			"service": service,
-			"method":  method,
-			"body":    string(body),
-		},
-		"next_steps": []string{
-			"1. Resolve service descriptor via reflection",
-			"2. Parse method input/output types",
-			"3. Unmarshal JSON → protobuf",
-			"4. Invoke gRPC method dynamically",
-			"5. Marshal protobuf → JSON response",
-		},
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-
-	log.Printf("✓ Response sent (placeholder)")
-}
+		log.Fatalf("Failed to create bridge: %v", err)
+	}
+	defer b.Close()
 
-// invokeRPC performs dynamic gRPC invocation (next PR)
-func (b *Bridge) invokeRPC(ctx context.Context, fullMethod string, reqJSON []byte) ([]byte, error) {
-	// TODO: Implement dynamic invocation using grpc/reflection
-	// Steps:
-	// 1. Use b.reflClient.ServerReflectionInfo() to get service descriptors
-	// 2. Find method descriptor by name
-	// 3. Get input/output message types from descriptor
-	// 4. Create dynamic message: dynamicpb.NewMessage(inputDesc)
-	// 5. Unmarshal JSON into dynamic message: protojson.Unmarshal(reqJSON, dynamicMsg)
-	// 6. Invoke: grpc.Invoke(ctx, fullMethod, dynamicMsg, respMsg, b.grpcConn)
-	// 7. Marshal response: protojson.Marshal(respMsg)
-
-	return nil, fmt.Errorf("not implemented yet")
-}
+	if *diffRecording != "" {
+		os.Exit(runDiffRecording(b, *diffRecording))
+	}
 
-// Helper: convert protobuf Message to JSON
-func messageToJSON(msg proto.Message) ([]byte, error) {
-	marshaler := protojson.MarshalOptions{
-		Indent:          "  ",
-		EmitUnpopulated: true,
+	log.Printf("Starting gRPC-HTTP bridge...")
+	if *replayFile != "" {
+		log.Printf("  Replaying: %s", *replayFile)
+	} else {
+		log.Printf("  gRPC backend: %s", *grpcAddr)
+	}
+	if *recordFile != "" {
+		log.Printf("  Recording: %s", *recordFile)
+	}
+	if *tlsCert != "" {
+		log.Printf("  HTTPS server: https://localhost:%d", *httpPort)
+	} else {
+		log.Printf("  HTTP server: http://localhost:%d", *httpPort)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errc := make(chan error, 1)
+	go func() { errc <- b.Serve() }()
+	select {
+	case err := <-errc:
+		log.Fatalf("Server error: %v", err)
+	case <-ctx.Done():
+	}
+	stop()
+
+	log.Printf("Shutting down...")
+	drain := max(*shutdownTimeout, *streamDrainTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), drain+5*time.Second)
+	defer cancel()
+	if err := b.Shutdown(ctx); err != nil {
+		log.Printf("✗ Shutdown: %v", err)
 	}
-	return marshaler.Marshal(msg)
 }
 
-// Helper: convert JSON to protobuf Message
-func jsonToMessage(data []byte, msgDesc protoreflect.MessageDescriptor) (*dynamicpb.Message, error) {
-	msg := dynamicpb.NewMessage(msgDesc)
-	if err := protojson.Unmarshal(data, msg); err != nil {
+// backendTLSConfig returns the TLS config for backend connections, trusting
+// the CAs in caFile if given and the system roots otherwise.
+func backendTLSConfig(caFile string) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile == "" {
+		return cfg, nil
+	}
+
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
 		return nil, err
 	}
-	return msg, nil
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", caFile)
+	}
+	cfg.RootCAs = pool
+	return cfg, nil
+}
+
+// runDiffRecording replays a recording against the backend and prints
+// each call whose response changed, returning the exit status: 1 if any
+// did.
+func runDiffRecording(b *bridge.Bridge, path string) int {
+	defer b.Close()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	report, err := b.DiffRecording(ctx, path)
+	if err != nil {
+		log.Printf("✗ Failed to diff recording: %v", err)
+		return 2
+	}
+	for _, d := range report.Diffs {
+		fmt.Printf("✗ %s %s\n", d.Method, d.Request)
+		if d.OldStatus != d.NewStatus {
+			fmt.Printf("    status: %d → %d\n", d.OldStatus, d.NewStatus)
+		}
+		for _, c := range d.Changes {
+			fmt.Printf("    %s\n", c)
+		}
+	}
+	fmt.Printf("%d of %d responses changed\n", len(report.Diffs), report.Replayed)
+	if len(report.Diffs) > 0 {
+		return 1
+	}
+	return 0
 }